@@ -21,6 +21,8 @@ func main() {
 		appfx.MetricsModule,
 		appfx.CacheModule,
 		appfx.StorageModule,
+		appfx.SecurityModule,
+		appfx.PurgeModule,
 		appfx.ServiceModule,
 		appfx.ServerModule,
 
@@ -0,0 +1,211 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/vincent119/images-filters/internal/config"
+	"github.com/vincent119/images-filters/internal/security"
+	"github.com/vincent119/images-filters/internal/storage"
+)
+
+const keyUsage = `Usage:
+  signer key create -file <path> -name <name> -scopes <scopes> [-prefix <prefix>]
+  signer key revoke -file <path> -id <key-id>
+  signer key list   -file <path>
+
+Use -config <config.yaml> instead of -file to manage the key store configured
+in security.api_keys (file or storage backed).
+
+Scopes: upload, detect, purge, sign
+`
+
+func keyCmd(args []string, out io.Writer) int {
+	if len(args) < 1 {
+		fmt.Fprint(out, keyUsage)
+		return 1
+	}
+
+	switch args[0] {
+	case "create":
+		return keyCreateCmd(args[1:], out)
+	case "revoke":
+		return keyRevokeCmd(args[1:], out)
+	case "list":
+		return keyListCmd(args[1:], out)
+	case "help", "-h", "--help":
+		fmt.Fprint(out, keyUsage)
+		return 0
+	default:
+		fmt.Fprintf(out, "Unknown key command: %s\n\n", args[0])
+		fmt.Fprint(out, keyUsage)
+		return 1
+	}
+}
+
+// keyStoreFlags registers the flags shared by all key subcommands
+func keyStoreFlags(fs *flag.FlagSet) (filePath, configPath *string) {
+	filePath = fs.String("file", "", "API key file path")
+	configPath = fs.String("config", "", "Config file; uses security.api_keys store settings")
+	return filePath, configPath
+}
+
+// openKeyRing opens the key ring from -file or -config
+func openKeyRing(ctx context.Context, filePath, configPath string) (*security.KeyRing, error) {
+	if filePath != "" {
+		return security.NewKeyRing(security.NewFileKeyBackend(filePath), 0), nil
+	}
+	if configPath == "" {
+		return nil, fmt.Errorf("key store required (-file or -config)")
+	}
+
+	cfg, err := config.Load(configPath)
+	if err != nil {
+		return nil, err
+	}
+
+	keysCfg := cfg.Security.APIKeys
+	if keysCfg.Store != "storage" {
+		return security.NewKeyRing(security.NewFileKeyBackend(keysCfg.FilePath), 0), nil
+	}
+
+	if keysCfg.Storage == nil {
+		return nil, fmt.Errorf("security.api_keys.storage is required when store is storage")
+	}
+	store, err := storage.NewStorageFromConfig(ctx, *keysCfg.Storage)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create api key storage: %w", err)
+	}
+	return security.NewKeyRing(security.NewBlobKeyBackend(store, keysCfg.StorageKey), 0), nil
+}
+
+func keyCreateCmd(args []string, out io.Writer) int {
+	fs := flag.NewFlagSet("key create", flag.ContinueOnError)
+	fs.SetOutput(out)
+	filePath, configPath := keyStoreFlags(fs)
+	namePtr := fs.String("name", "", "Key name (e.g., tenant or client name)")
+	scopesPtr := fs.String("scopes", "", "Comma separated scopes: upload,detect,purge,sign")
+	prefixPtr := fs.String("prefix", "", "Storage prefix the key is restricted to (optional)")
+
+	if err := fs.Parse(args); err != nil {
+		return 1
+	}
+
+	if *namePtr == "" {
+		fmt.Fprintln(out, "Error: Key name required (-name)")
+		return 1
+	}
+
+	scopes, err := security.ParseScopes(*scopesPtr)
+	if err != nil {
+		fmt.Fprintln(out, "Error:", err)
+		return 1
+	}
+
+	ctx := context.Background()
+	ring, err := openKeyRing(ctx, *filePath, *configPath)
+	if err != nil {
+		fmt.Fprintln(out, "Error:", err)
+		return 1
+	}
+
+	rawKey, key, err := ring.Create(ctx, *namePtr, scopes, *prefixPtr)
+	if err != nil {
+		fmt.Fprintln(out, "Error:", err)
+		return 1
+	}
+
+	fmt.Fprintln(out, "ID:     ", key.ID)
+	fmt.Fprintln(out, "Name:   ", key.Name)
+	fmt.Fprintln(out, "Scopes: ", joinScopes(key.Scopes))
+	if key.Prefix != "" {
+		fmt.Fprintln(out, "Prefix: ", key.Prefix)
+	}
+	fmt.Fprintln(out, "API Key:", rawKey)
+	fmt.Fprintln(out, "\nStore the API key now; it cannot be shown again.")
+	return 0
+}
+
+func keyRevokeCmd(args []string, out io.Writer) int {
+	fs := flag.NewFlagSet("key revoke", flag.ContinueOnError)
+	fs.SetOutput(out)
+	filePath, configPath := keyStoreFlags(fs)
+	idPtr := fs.String("id", "", "Key ID to revoke")
+
+	if err := fs.Parse(args); err != nil {
+		return 1
+	}
+
+	if *idPtr == "" {
+		fmt.Fprintln(out, "Error: Key ID required (-id)")
+		return 1
+	}
+
+	ctx := context.Background()
+	ring, err := openKeyRing(ctx, *filePath, *configPath)
+	if err != nil {
+		fmt.Fprintln(out, "Error:", err)
+		return 1
+	}
+
+	if err := ring.Revoke(ctx, *idPtr); err != nil {
+		fmt.Fprintln(out, "Error:", err)
+		return 1
+	}
+
+	fmt.Fprintln(out, "✅ Key revoked:", *idPtr)
+	return 0
+}
+
+func keyListCmd(args []string, out io.Writer) int {
+	fs := flag.NewFlagSet("key list", flag.ContinueOnError)
+	fs.SetOutput(out)
+	filePath, configPath := keyStoreFlags(fs)
+
+	if err := fs.Parse(args); err != nil {
+		return 1
+	}
+
+	ctx := context.Background()
+	ring, err := openKeyRing(ctx, *filePath, *configPath)
+	if err != nil {
+		fmt.Fprintln(out, "Error:", err)
+		return 1
+	}
+
+	keys, err := ring.List(ctx)
+	if err != nil {
+		fmt.Fprintln(out, "Error:", err)
+		return 1
+	}
+
+	if len(keys) == 0 {
+		fmt.Fprintln(out, "No API keys")
+		return 0
+	}
+
+	for _, k := range keys {
+		status := "active"
+		if k.IsRevoked() {
+			status = "revoked"
+		}
+		prefix := k.Prefix
+		if prefix == "" {
+			prefix = "*"
+		}
+		fmt.Fprintf(out, "%s  %-16s  %-7s  scopes=%s  prefix=%s  created=%s\n",
+			k.ID, k.Name, status, joinScopes(k.Scopes), prefix, k.CreatedAt.Format("2006-01-02"))
+	}
+	return 0
+}
+
+func joinScopes(scopes []security.Scope) string {
+	parts := make([]string, len(scopes))
+	for i, s := range scopes {
+		parts[i] = string(s)
+	}
+	return strings.Join(parts, ",")
+}
@@ -19,6 +19,7 @@ Usage:
 Commands:
   sign      Generate signed URL
   verify    Verify signed URL
+  key       Manage API keys (create, revoke, list)
 
 Examples:
   # Generate signed URL
@@ -27,6 +28,12 @@ Examples:
   # Verify signature
   signer verify -key "your-secret-key" -url "/abc123.../300x200/test.jpg"
 
+  # Mint an API key limited to upload/sign under tenants/acme
+  signer key create -file config/api_keys.json -name acme -scopes upload,sign -prefix tenants/acme
+
+  # Revoke an API key
+  signer key revoke -file config/api_keys.json -id 3f2a9c1b7d4e
+
 Environment Variables:
   IMG_SECURITY_KEY    Security key (alternative to -key flag)
 `
@@ -48,6 +55,8 @@ func run(args []string, out io.Writer) int {
 		return signCmd(args[2:], out)
 	case "verify":
 		return verifyCmd(args[2:], out)
+	case "key":
+		return keyCmd(args[2:], out)
 	case "help", "-h", "--help":
 		fmt.Fprint(out, usage)
 		return 0
@@ -3,6 +3,7 @@ package main
 import (
 	"bytes"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
@@ -164,3 +165,82 @@ func TestVerifyCmd(t *testing.T) {
 		})
 	}
 }
+
+func TestKeyCmd(t *testing.T) {
+	keyFile := filepath.Join(t.TempDir(), "api_keys.json")
+
+	tests := []struct {
+		name     string
+		args     []string
+		wantExit int
+		wantOut  string
+	}{
+		{
+			name:     "No Subcommand",
+			args:     []string{"key"},
+			wantExit: 1,
+			wantOut:  "signer key create",
+		},
+		{
+			name:     "Missing Store",
+			args:     []string{"key", "list"},
+			wantExit: 1,
+			wantOut:  "key store required",
+		},
+		{
+			name:     "Missing Name",
+			args:     []string{"key", "create", "-file", keyFile, "-scopes", "upload"},
+			wantExit: 1,
+			wantOut:  "Key name required",
+		},
+		{
+			name:     "Unknown Scope",
+			args:     []string{"key", "create", "-file", keyFile, "-name", "acme", "-scopes", "admin"},
+			wantExit: 1,
+			wantOut:  "unknown scope",
+		},
+		{
+			name:     "Revoke Missing Key",
+			args:     []string{"key", "revoke", "-file", keyFile, "-id", "missing"},
+			wantExit: 1,
+			wantOut:  "api key not found",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := new(bytes.Buffer)
+			exitCode := run(append([]string{"signer"}, tt.args...), out)
+			assert.Equal(t, tt.wantExit, exitCode)
+			assert.Contains(t, out.String(), tt.wantOut)
+		})
+	}
+
+	t.Run("Create List Revoke", func(t *testing.T) {
+		out := new(bytes.Buffer)
+		exitCode := run([]string{"signer", "key", "create", "-file", keyFile, "-name", "acme", "-scopes", "upload,sign", "-prefix", "tenants/acme"}, out)
+		assert.Equal(t, 0, exitCode)
+		assert.Contains(t, out.String(), "API Key: imgf_")
+
+		var id string
+		for _, line := range strings.Split(out.String(), "\n") {
+			if strings.HasPrefix(line, "ID:") {
+				id = strings.TrimSpace(strings.TrimPrefix(line, "ID:"))
+			}
+		}
+		assert.NotEmpty(t, id)
+
+		out.Reset()
+		assert.Equal(t, 0, run([]string{"signer", "key", "list", "-file", keyFile}, out))
+		assert.Contains(t, out.String(), id)
+		assert.Contains(t, out.String(), "active")
+		assert.Contains(t, out.String(), "scopes=upload,sign")
+
+		out.Reset()
+		assert.Equal(t, 0, run([]string{"signer", "key", "revoke", "-file", keyFile, "-id", id}, out))
+
+		out.Reset()
+		assert.Equal(t, 0, run([]string{"signer", "key", "list", "-file", keyFile}, out))
+		assert.Contains(t, out.String(), "revoked")
+	})
+}
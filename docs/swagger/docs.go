@@ -109,6 +109,16 @@ const docTemplate = `{
                         "name": "file",
                         "in": "formData",
                         "required": true
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Presets or option strings to pre-render after upload (repeatable)",
+                        "name": "variants",
+                        "in": "formData"
                     }
                 ],
                 "responses": {
@@ -130,6 +140,18 @@ const docTemplate = `{
                             "$ref": "#/definitions/api.ErrorResponse"
                         }
                     },
+                    "413": {
+                        "description": "Image dimensions too large",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "415": {
+                        "description": "Unsupported image format",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
                     "500": {
                         "description": "Internal error",
                         "schema": {
@@ -210,9 +232,38 @@ const docTemplate = `{
         "api.UploadResponse": {
             "type": "object",
             "properties": {
+                "duplicate": {
+                    "description": "Duplicate 相同內容已存在（僅內容定址模式）",
+                    "type": "boolean"
+                },
+                "hash": {
+                    "description": "Hash 內容 SHA-256（僅內容定址模式）",
+                    "type": "string"
+                },
                 "path": {
                     "type": "string"
                 },
+                "url": {
+                    "type": "string"
+                },
+                "variants": {
+                    "description": "Variants 預先產生的處理結果（於背景產生）",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/api.UploadVariantResponse"
+                    }
+                }
+            }
+        },
+        "api.UploadVariantResponse": {
+            "type": "object",
+            "properties": {
+                "name": {
+                    "type": "string"
+                },
+                "options": {
+                    "type": "string"
+                },
                 "url": {
                     "type": "string"
                 }
@@ -227,10 +278,24 @@ const docTemplate = `{
                 "detected": {
                     "type": "boolean"
                 },
+                "recipient": {
+                    "$ref": "#/definitions/service.RecipientInfo"
+                },
                 "text": {
                     "type": "string"
                 }
             }
+        },
+        "service.RecipientInfo": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "string"
+                },
+                "rendered_at": {
+                    "type": "string"
+                }
+            }
         }
     }
 }`
@@ -0,0 +1,248 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"slices"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/vincent119/images-filters/internal/config"
+	"github.com/vincent119/images-filters/internal/service"
+	"github.com/vincent119/images-filters/pkg/logger"
+)
+
+// BatchUploadHandler 批次上傳處理器
+type BatchUploadHandler struct {
+	imageService service.ImageService
+	cfg          config.BatchUploadConfig
+}
+
+// NewBatchUploadHandler 建立新的批次上傳處理器
+func NewBatchUploadHandler(imageService service.ImageService, cfg config.BatchUploadConfig) *BatchUploadHandler {
+	return &BatchUploadHandler{
+		imageService: imageService,
+		cfg:          cfg,
+	}
+}
+
+// BatchUploadFileResult 單一檔案的上傳結果（成功時含 path/url，失敗時含 error/message）
+type BatchUploadFileResult struct {
+	// Name 表單檔名或封存檔內的路徑
+	Name      string `json:"name"`
+	Path      string `json:"path,omitempty"`
+	URL       string `json:"url,omitempty"`
+	Hash      string `json:"hash,omitempty"`
+	Duplicate bool   `json:"duplicate,omitempty"`
+	// Variants 預先產生的處理結果（於背景產生）
+	Variants []UploadVariantResponse `json:"variants,omitempty"`
+	Error    string                  `json:"error,omitempty"`
+	Message  string                  `json:"message,omitempty"`
+}
+
+// BatchUploadResponse 批次上傳回應
+type BatchUploadResponse struct {
+	Results   []BatchUploadFileResult `json:"results"`
+	Succeeded int                     `json:"succeeded"`
+	Failed    int                     `json:"failed"`
+}
+
+// HandleBatchUpload 處理批次上傳
+// @Summary Upload images in batch
+// @Description Upload many images in one request, as multiple "files" form fields (zip/tar/tar.gz files are expanded) or as a raw zip/tar/tar.gz request body. A failed file does not abort the batch; each file has its own result.
+// @Tags Image
+// @Accept multipart/form-data
+// @Accept application/zip
+// @Accept application/x-tar
+// @Accept application/gzip
+// @Produce json
+// @Param files formData file false "Image or archive files (repeatable)"
+// @Param variants formData []string false "Presets or option strings to pre-render for every file (repeatable)" collectionFormat(multi)
+// @Success 200 {object} BatchUploadResponse
+// @Failure 400 {object} ErrorResponse "Bad request"
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 413 {object} ErrorResponse "Request body too large"
+// @Security BearerAuth
+// @Router /upload/batch [post]
+func (h *BatchUploadHandler) HandleBatchUpload(c *gin.Context) {
+	var items iter.Seq[service.BatchUploadItem]
+	opts := uploadOptionsFromContext(c)
+
+	if limit := h.cfg.MaxArchiveSize; limit > 0 {
+		if c.Request.ContentLength > limit {
+			h.bodyTooLarge(c)
+			return
+		}
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, limit)
+	}
+
+	if c.ContentType() == "multipart/form-data" {
+		form, err := c.MultipartForm()
+		if isBodyTooLarge(err) {
+			h.bodyTooLarge(c)
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Error:   "INVALID_REQUEST",
+				Message: "Failed to parse multipart form: " + err.Error(),
+			})
+			return
+		}
+		defer form.RemoveAll()
+
+		headers := slices.Concat(form.File["files"], form.File["file"])
+		if len(headers) == 0 {
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Error:   "NO_FILES",
+				Message: "Field 'files' is required",
+			})
+			return
+		}
+
+		var opened []multipart.File
+		defer func() {
+			for _, f := range opened {
+				f.Close()
+			}
+		}()
+		items = h.formItems(headers, &opened)
+		if variants := form.Value["variants"]; len(variants) > 0 {
+			opts = append(opts, service.WithVariants(variants...))
+		}
+	} else {
+		archive, body := service.SniffArchive(c.Request.Body)
+		switch archive {
+		case service.ArchiveZip:
+			// zip 需要隨機存取，先寫入暫存檔
+			f, size, err := spoolBody(body)
+			if isBodyTooLarge(err) {
+				h.bodyTooLarge(c)
+				return
+			}
+			if err != nil {
+				c.JSON(http.StatusBadRequest, ErrorResponse{
+					Error:   "INVALID_REQUEST",
+					Message: err.Error(),
+				})
+				return
+			}
+			defer os.Remove(f.Name())
+			defer f.Close()
+			items = service.ZipEntries(f, size)
+		case service.ArchiveTar, service.ArchiveTarGz:
+			items = service.TarEntries(body, archive == service.ArchiveTarGz, h.cfg.MaxFileSize)
+		default:
+			c.JSON(http.StatusUnsupportedMediaType, ErrorResponse{
+				Error:   "UNSUPPORTED_MEDIA_TYPE",
+				Message: "Request body must be multipart/form-data or a zip, tar or tar.gz archive",
+			})
+			return
+		}
+	}
+
+	results := service.UploadBatch(c.Request.Context(), h.imageService, items, h.cfg, opts...)
+
+	resp := BatchUploadResponse{Results: make([]BatchUploadFileResult, 0, len(results))}
+	for _, r := range results {
+		item := BatchUploadFileResult{Name: r.Name}
+		if r.Err != nil {
+			_, item.Error = uploadErrorStatus(r.Err)
+			item.Message = r.Err.Error()
+			resp.Failed++
+		} else {
+			item.Path = r.Result.Path
+			item.URL = r.Result.SignedURL
+			item.Hash = r.Result.Hash
+			item.Duplicate = r.Result.Duplicate
+			item.Variants = newUploadVariantResponses(r.Result.Variants)
+			resp.Succeeded++
+		}
+		resp.Results = append(resp.Results, item)
+	}
+
+	logger.Info("batch upload completed",
+		logger.Int("succeeded", resp.Succeeded),
+		logger.Int("failed", resp.Failed),
+	)
+	c.JSON(http.StatusOK, resp)
+}
+
+// formItems 將表單檔案轉為上傳項目，封存檔展開為其中的檔案
+// 開啟的檔案加入 opened，由呼叫端於批次結束後關閉
+func (h *BatchUploadHandler) formItems(headers []*multipart.FileHeader, opened *[]multipart.File) iter.Seq[service.BatchUploadItem] {
+	return func(yield func(service.BatchUploadItem) bool) {
+		for _, header := range headers {
+			f, err := header.Open()
+			if err != nil {
+				if !yield(service.BatchUploadItem{Name: header.Filename, Err: err}) {
+					return
+				}
+				continue
+			}
+			*opened = append(*opened, f)
+
+			head := make([]byte, 512)
+			n, _ := io.ReadFull(f, head)
+			var items iter.Seq[service.BatchUploadItem]
+			switch service.DetectArchive(head[:n]) {
+			case service.ArchiveZip:
+				items = service.ZipEntries(f, header.Size)
+			case service.ArchiveTar:
+				items = service.TarEntries(io.NewSectionReader(f, 0, header.Size), false, h.cfg.MaxFileSize)
+			case service.ArchiveTarGz:
+				items = service.TarEntries(io.NewSectionReader(f, 0, header.Size), true, h.cfg.MaxFileSize)
+			default:
+				items = func(yield func(service.BatchUploadItem) bool) {
+					yield(service.BatchUploadItem{
+						Name:        header.Filename,
+						ContentType: header.Header.Get("Content-Type"),
+						Open:        func() (io.ReadCloser, error) { return header.Open() },
+					})
+				}
+			}
+
+			for item := range items {
+				if !yield(item) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// bodyTooLarge 回應請求內容超過 max_archive_size
+func (h *BatchUploadHandler) bodyTooLarge(c *gin.Context) {
+	c.JSON(http.StatusRequestEntityTooLarge, ErrorResponse{
+		Error:   "REQUEST_TOO_LARGE",
+		Message: fmt.Sprintf("Request body exceeds %d bytes", h.cfg.MaxArchiveSize),
+	})
+}
+
+// isBodyTooLarge 判斷錯誤是否來自 http.MaxBytesReader 的大小限制
+func isBodyTooLarge(err error) bool {
+	var maxErr *http.MaxBytesError
+	return errors.As(err, &maxErr)
+}
+
+// spoolBody 將請求內容寫入暫存檔
+// 寫入量由 HandleBatchUpload 以 http.MaxBytesReader 限制
+func spoolBody(body io.Reader) (*os.File, int64, error) {
+	f, err := os.CreateTemp("", "batch-upload-*.zip")
+	if err != nil {
+		return nil, 0, err
+	}
+
+	size, err := io.Copy(f, body)
+	if err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return nil, 0, err
+	}
+	return f, size, nil
+}
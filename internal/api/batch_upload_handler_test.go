@@ -0,0 +1,205 @@
+package api
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vincent119/images-filters/internal/config"
+	"github.com/vincent119/images-filters/internal/service"
+)
+
+func setupBatchUploadRouter(t *testing.T) (http.Handler, *sync.Map) {
+	t.Helper()
+	router, _, mockService := setupTestRouter()
+
+	uploaded := &sync.Map{}
+	mockService.uploadFunc = func(ctx context.Context, filename string, contentType string, reader io.Reader) (*service.UploadResult, error) {
+		data, err := io.ReadAll(reader)
+		if err != nil {
+			return nil, err
+		}
+		if strings.HasPrefix(filename, "bad") {
+			return nil, service.ErrUploadUnsupportedFormat
+		}
+		uploaded.Store(filename, string(data))
+		return &service.UploadResult{Path: "uploads/" + filename, SignedURL: "/signed/" + filename}, nil
+	}
+
+	handler := NewBatchUploadHandler(mockService, config.BatchUploadConfig{MaxFiles: 10, Concurrency: 2, MaxFileSize: 1024, MaxArchiveSize: 16384})
+	router.POST("/upload/batch", handler.HandleBatchUpload)
+	return router, uploaded
+}
+
+func doBatchUpload(t *testing.T, router http.Handler, body io.Reader, contentType string) (*httptest.ResponseRecorder, BatchUploadResponse) {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, "/upload/batch", body)
+	req.Header.Set("Content-Type", contentType)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	var resp BatchUploadResponse
+	if w.Code == http.StatusOK {
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	}
+	return w, resp
+}
+
+func TestBatchUploadHandler_Multipart(t *testing.T) {
+	router, uploaded := setupBatchUploadRouter(t)
+
+	var zipBuf bytes.Buffer
+	zw := zip.NewWriter(&zipBuf)
+	for _, name := range []string{"gallery/c.jpg", "gallery/d.jpg"} {
+		w, err := zw.Create(name)
+		require.NoError(t, err)
+		w.Write([]byte(name))
+	}
+	require.NoError(t, zw.Close())
+
+	body := new(bytes.Buffer)
+	writer := multipart.NewWriter(body)
+	for name, data := range map[string][]byte{"a.jpg": []byte("a"), "bad.jpg": []byte("x"), "gallery.zip": zipBuf.Bytes()} {
+		part, err := writer.CreateFormFile("files", name)
+		require.NoError(t, err)
+		part.Write(data)
+	}
+	require.NoError(t, writer.Close())
+
+	w, resp := doBatchUpload(t, router, body, writer.FormDataContentType())
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, 3, resp.Succeeded)
+	assert.Equal(t, 1, resp.Failed)
+
+	byName := make(map[string]BatchUploadFileResult)
+	for _, r := range resp.Results {
+		byName[r.Name] = r
+	}
+	assert.Equal(t, "UNSUPPORTED_FORMAT", byName["bad.jpg"].Error)
+	assert.Equal(t, "uploads/c.jpg", byName["gallery/c.jpg"].Path)
+	assert.Equal(t, "/signed/d.jpg", byName["gallery/d.jpg"].URL)
+
+	data, ok := uploaded.Load("c.jpg")
+	require.True(t, ok)
+	assert.Equal(t, "gallery/c.jpg", data)
+}
+
+func TestBatchUploadHandler_TarBody(t *testing.T) {
+	router, uploaded := setupBatchUploadRouter(t)
+
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for name, data := range map[string]string{"a.jpg": "a", "big.jpg": strings.Repeat("x", 2048)} {
+		require.NoError(t, tw.WriteHeader(&tar.Header{Name: name, Mode: 0o644, Size: int64(len(data))}))
+		tw.Write([]byte(data))
+	}
+	require.NoError(t, tw.Close())
+
+	w, resp := doBatchUpload(t, router, &buf, "application/x-tar")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, 1, resp.Succeeded)
+	assert.Equal(t, 1, resp.Failed)
+	for _, r := range resp.Results {
+		if r.Name == "big.jpg" {
+			assert.Equal(t, "FILE_TOO_LARGE", r.Error)
+		}
+	}
+	_, ok := uploaded.Load("a.jpg")
+	assert.True(t, ok)
+}
+
+func TestBatchUploadHandler_ZipBody(t *testing.T) {
+	router, _ := setupBatchUploadRouter(t)
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	w, err := zw.Create("a.jpg")
+	require.NoError(t, err)
+	w.Write([]byte("a"))
+	require.NoError(t, zw.Close())
+
+	rec, resp := doBatchUpload(t, router, &buf, "application/zip")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	require.Len(t, resp.Results, 1)
+	assert.Equal(t, "uploads/a.jpg", resp.Results[0].Path)
+}
+
+func TestBatchUploadHandler_BadRequests(t *testing.T) {
+	router, _ := setupBatchUploadRouter(t)
+
+	body := new(bytes.Buffer)
+	writer := multipart.NewWriter(body)
+	writer.WriteField("name", "value")
+	require.NoError(t, writer.Close())
+	w, _ := doBatchUpload(t, router, body, writer.FormDataContentType())
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "NO_FILES")
+
+	w, _ = doBatchUpload(t, router, strings.NewReader("not an archive"), "application/octet-stream")
+	assert.Equal(t, http.StatusUnsupportedMediaType, w.Code)
+}
+
+func TestBatchUploadHandler_BodyTooLarge(t *testing.T) {
+	router, uploaded := setupBatchUploadRouter(t)
+
+	// 每個檔案都在 max_file_size 內，但整體超過 max_archive_size
+	var zipBuf bytes.Buffer
+	zw := zip.NewWriter(&zipBuf)
+	for i := range 20 {
+		w, err := zw.CreateHeader(&zip.FileHeader{Name: fmt.Sprintf("%d.jpg", i), Method: zip.Store})
+		require.NoError(t, err)
+		w.Write(bytes.Repeat([]byte{byte(i)}, 1000))
+	}
+	require.NoError(t, zw.Close())
+
+	form := new(bytes.Buffer)
+	writer := multipart.NewWriter(form)
+	part, err := writer.CreateFormFile("files", "gallery.zip")
+	require.NoError(t, err)
+	part.Write(zipBuf.Bytes())
+	require.NoError(t, writer.Close())
+
+	tests := []struct {
+		name        string
+		body        []byte
+		contentType string
+	}{
+		{"zip", zipBuf.Bytes(), "application/zip"},
+		{"multipart", form.Bytes(), writer.FormDataContentType()},
+	}
+	for _, tt := range tests {
+		for _, chunked := range []bool{false, true} {
+			t.Run(fmt.Sprintf("%s/chunked=%v", tt.name, chunked), func(t *testing.T) {
+				req := httptest.NewRequest(http.MethodPost, "/upload/batch", bytes.NewReader(tt.body))
+				req.Header.Set("Content-Type", tt.contentType)
+				if chunked {
+					// 未知長度時由 MaxBytesReader 在讀取中途中止
+					req.ContentLength = -1
+				}
+				w := httptest.NewRecorder()
+				router.ServeHTTP(w, req)
+
+				assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+				assert.Contains(t, w.Body.String(), "REQUEST_TOO_LARGE")
+			})
+		}
+	}
+
+	uploaded.Range(func(key, _ any) bool {
+		t.Errorf("unexpected upload %v", key)
+		return true
+	})
+}
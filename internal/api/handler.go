@@ -2,10 +2,12 @@
 package api
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
 
+	"github.com/vincent119/images-filters/internal/loader"
 	"github.com/vincent119/images-filters/internal/parser"
 	"github.com/vincent119/images-filters/internal/service"
 )
@@ -52,8 +54,11 @@ func (h *Handler) HandleImage(c *gin.Context) {
 	// 設定 Accept Header 用於內容協商
 	parsedURL.AcceptHeader = c.Request.Header.Get("Accept")
 
+	// 原始請求標頭供來源 Profile 的 forward_headers 使用
+	ctx := loader.ContextWithRequestHeaders(c.Request.Context(), c.Request.Header)
+
 	// 處理圖片
-	imageData, contentType, err := h.imageService.ProcessImage(c.Request.Context(), parsedURL)
+	imageData, contentType, err := h.imageService.ProcessImage(ctx, parsedURL)
 	if err != nil {
 		// 根據錯誤類型返回不同的狀態碼
 		statusCode := http.StatusInternalServerError
@@ -128,6 +133,42 @@ func containsHelper(s, substr string) bool {
 type UploadResponse struct {
 	Path string `json:"path"`
 	URL  string `json:"url"`
+	// Hash 內容 SHA-256（僅內容定址模式）
+	Hash string `json:"hash,omitempty"`
+	// Duplicate 相同內容已存在（僅內容定址模式）
+	Duplicate bool `json:"duplicate,omitempty"`
+	// Variants 預先產生的處理結果（於背景產生）
+	Variants []UploadVariantResponse `json:"variants,omitempty"`
+}
+
+// UploadVariantResponse 預先產生的處理結果
+type UploadVariantResponse struct {
+	Name    string `json:"name"`
+	Options string `json:"options"`
+	URL     string `json:"url"`
+}
+
+// newUploadResponse 建立上傳回應
+func newUploadResponse(result *service.UploadResult) UploadResponse {
+	return UploadResponse{
+		Path:      result.Path,
+		URL:       result.SignedURL,
+		Hash:      result.Hash,
+		Duplicate: result.Duplicate,
+		Variants:  newUploadVariantResponses(result.Variants),
+	}
+}
+
+// newUploadVariantResponses 轉換預先產生的處理結果
+func newUploadVariantResponses(variants []service.VariantResult) []UploadVariantResponse {
+	if len(variants) == 0 {
+		return nil
+	}
+	resp := make([]UploadVariantResponse, 0, len(variants))
+	for _, v := range variants {
+		resp = append(resp, UploadVariantResponse{Name: v.Name, Options: v.Options, URL: v.SignedURL})
+	}
+	return resp
 }
 
 // HandleUpload 處理圖片上傳
@@ -137,9 +178,12 @@ type UploadResponse struct {
 // @Accept multipart/form-data
 // @Produce json
 // @Param file formData file true "Image file to upload"
+// @Param variants formData []string false "Presets or option strings to pre-render after upload (repeatable)" collectionFormat(multi)
 // @Success 200 {object} UploadResponse
 // @Failure 400 {object} ErrorResponse "Bad request"
 // @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 413 {object} ErrorResponse "Image dimensions too large"
+// @Failure 415 {object} ErrorResponse "Unsupported image format"
 // @Failure 500 {object} ErrorResponse "Internal error"
 // @Security BearerAuth
 // @Router /upload [post]
@@ -162,8 +206,8 @@ func (h *Handler) HandleUpload(c *gin.Context) {
 		contentType = "application/octet-stream"
 	}
 
-	// 驗證檔案類型
-	if !isValidImageType(contentType) {
+	// 驗證宣告的檔案類型（未宣告具體類型時由服務層依內容判斷）
+	if contentType != "application/octet-stream" && !isValidImageType(contentType) {
 		c.JSON(http.StatusBadRequest, ErrorResponse{
 			Error:   "INVALID_FILE_TYPE",
 			Message: "Only image files are allowed",
@@ -172,20 +216,59 @@ func (h *Handler) HandleUpload(c *gin.Context) {
 	}
 
 	// 呼叫 Service 進行上傳
-	result, err := h.imageService.UploadImage(c.Request.Context(), filename, contentType, file)
+	opts := uploadOptionsFromContext(c)
+	if variants := c.PostFormArray("variants"); len(variants) > 0 {
+		opts = append(opts, service.WithVariants(variants...))
+	}
+	result, err := h.imageService.UploadImage(c.Request.Context(), filename, contentType, file, opts...)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, ErrorResponse{
-			Error:   "UPLOAD_ERROR",
+		statusCode, errorCode := uploadErrorStatus(err)
+		c.JSON(statusCode, ErrorResponse{
+			Error:   errorCode,
 			Message: err.Error(),
 		})
 		return
 	}
 
 	// 回傳結果
-	c.JSON(http.StatusOK, UploadResponse{
-		Path: result.Path,
-		URL:  result.SignedURL,
-	})
+	c.JSON(http.StatusOK, newUploadResponse(result))
+}
+
+// uploadOptionsFromContext 取得上傳選項
+// 使用 API 金鑰時，上傳至金鑰所屬的路徑前綴
+func uploadOptionsFromContext(c *gin.Context) []service.UploadOption {
+	if key := apiKeyFromContext(c); key != nil && key.Prefix != "" {
+		return []service.UploadOption{service.WithUploadPrefix(key.Prefix)}
+	}
+	return nil
+}
+
+// uploadErrorStatus 依上傳驗證錯誤取得狀態碼與錯誤碼
+func uploadErrorStatus(err error) (int, string) {
+	switch {
+	case errors.Is(err, service.ErrUploadUnsupportedFormat):
+		return http.StatusUnsupportedMediaType, "UNSUPPORTED_FORMAT"
+	case errors.Is(err, service.ErrUploadFormatMismatch):
+		return http.StatusBadRequest, "FORMAT_MISMATCH"
+	case errors.Is(err, service.ErrUploadInvalidImage):
+		return http.StatusBadRequest, "INVALID_IMAGE"
+	case errors.Is(err, service.ErrUploadActiveContent):
+		return http.StatusBadRequest, "ACTIVE_CONTENT"
+	case errors.Is(err, service.ErrUploadDimensionsExceeded):
+		return http.StatusRequestEntityTooLarge, "DIMENSIONS_TOO_LARGE"
+	case errors.Is(err, service.ErrUploadPixelsExceeded):
+		return http.StatusRequestEntityTooLarge, "TOO_MANY_PIXELS"
+	case errors.Is(err, service.ErrBatchFileTooLarge):
+		return http.StatusRequestEntityTooLarge, "FILE_TOO_LARGE"
+	case errors.Is(err, service.ErrBatchTooManyFiles):
+		return http.StatusRequestEntityTooLarge, "TOO_MANY_FILES"
+	case errors.Is(err, service.ErrBatchInvalidArchive):
+		return http.StatusBadRequest, "INVALID_ARCHIVE"
+	case errors.Is(err, service.ErrInvalidVariant):
+		return http.StatusBadRequest, "INVALID_VARIANT"
+	default:
+		return http.StatusInternalServerError, "UPLOAD_ERROR"
+	}
 }
 
 // isValidImageType 檢查是否為有效的圖片類型
@@ -3,17 +3,27 @@ package api
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"image"
+	"image/jpeg"
 	"io"
 	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vincent119/images-filters/internal/cache"
+	"github.com/vincent119/images-filters/internal/config"
 	"github.com/vincent119/images-filters/internal/parser"
 	"github.com/vincent119/images-filters/internal/service"
+	"github.com/vincent119/images-filters/internal/storage"
 )
 
 // mockImageService is a mock implementation of service.ImageService
@@ -29,7 +39,7 @@ func (m *mockImageService) ProcessImage(ctx context.Context, parsedURL *parser.P
 	return nil, "", nil
 }
 
-func (m *mockImageService) UploadImage(ctx context.Context, filename string, contentType string, reader io.Reader) (*service.UploadResult, error) {
+func (m *mockImageService) UploadImage(ctx context.Context, filename string, contentType string, reader io.Reader, opts ...service.UploadOption) (*service.UploadResult, error) {
 	if m.uploadFunc != nil {
 		return m.uploadFunc(ctx, filename, contentType, reader)
 	}
@@ -225,3 +235,87 @@ func TestHandler_HandleUpload(t *testing.T) {
 		})
 	}
 }
+
+func TestHandler_HandleUpload_Variants(t *testing.T) {
+	root := t.TempDir()
+	store, err := storage.NewLocalStorage(root)
+	require.NoError(t, err)
+	svc := service.NewImageService(&config.Config{
+		Processing: config.ProcessingConfig{DefaultQuality: 80, Workers: 1},
+		Upload: config.UploadConfig{Variants: config.UploadVariantsConfig{
+			Presets: map[string]string{"thumb": "fit-in/8x8"},
+		}},
+	}, store, cache.NewNoOpCache())
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/upload", NewHandler(svc).HandleUpload)
+
+	var img bytes.Buffer
+	require.NoError(t, jpeg.Encode(&img, image.NewRGBA(image.Rect(0, 0, 16, 16)), nil))
+
+	upload := func(variants ...string) *httptest.ResponseRecorder {
+		body := new(bytes.Buffer)
+		writer := multipart.NewWriter(body)
+		part, err := writer.CreateFormFile("file", "a.jpg")
+		require.NoError(t, err)
+		part.Write(img.Bytes())
+		for _, v := range variants {
+			writer.WriteField("variants", v)
+		}
+		require.NoError(t, writer.Close())
+
+		req := httptest.NewRequest(http.MethodPost, "/upload", body)
+		req.Header.Set("Content-Type", writer.FormDataContentType())
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := upload("thumb", "4x4/filters:format(png)")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var resp UploadResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, []UploadVariantResponse{
+		{Name: "thumb", Options: "fit-in/8x8", URL: "/unsafe/fit-in/8x8/" + resp.Path},
+		{Name: "4x4/filters:format(png)", Options: "4x4/filters:format(png)", URL: "/unsafe/4x4/filters:format(png)/" + resp.Path},
+	}, resp.Variants)
+
+	// 等待背景產生完成
+	assert.Eventually(t, func() bool {
+		files, _ := filepath.Glob(filepath.Join(root, "cache", "*", "*", "*"))
+		return len(files) == 2
+	}, 5*time.Second, 10*time.Millisecond)
+
+	w = upload("medium")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "INVALID_VARIANT")
+}
+
+func TestUploadErrorStatus(t *testing.T) {
+	tests := []struct {
+		err        error
+		wantStatus int
+		wantCode   string
+	}{
+		{service.ErrUploadUnsupportedFormat, http.StatusUnsupportedMediaType, "UNSUPPORTED_FORMAT"},
+		{fmt.Errorf("%w: extension .jpg, detected png", service.ErrUploadFormatMismatch), http.StatusBadRequest, "FORMAT_MISMATCH"},
+		{service.ErrUploadInvalidImage, http.StatusBadRequest, "INVALID_IMAGE"},
+		{service.ErrUploadActiveContent, http.StatusBadRequest, "ACTIVE_CONTENT"},
+		{service.ErrUploadDimensionsExceeded, http.StatusRequestEntityTooLarge, "DIMENSIONS_TOO_LARGE"},
+		{service.ErrUploadPixelsExceeded, http.StatusRequestEntityTooLarge, "TOO_MANY_PIXELS"},
+		{service.ErrBatchFileTooLarge, http.StatusRequestEntityTooLarge, "FILE_TOO_LARGE"},
+		{service.ErrBatchTooManyFiles, http.StatusRequestEntityTooLarge, "TOO_MANY_FILES"},
+		{service.ErrBatchInvalidArchive, http.StatusBadRequest, "INVALID_ARCHIVE"},
+		{fmt.Errorf("%w: %q", service.ErrInvalidVariant, "medium"), http.StatusBadRequest, "INVALID_VARIANT"},
+		{errors.New("storage down"), http.StatusInternalServerError, "UPLOAD_ERROR"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.wantCode, func(t *testing.T) {
+			status, code := uploadErrorStatus(tt.err)
+			assert.Equal(t, tt.wantStatus, status)
+			assert.Equal(t, tt.wantCode, code)
+		})
+	}
+}
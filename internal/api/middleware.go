@@ -1,6 +1,8 @@
 package api
 
 import (
+	"crypto/subtle"
+	"errors"
 	"net/http"
 	"strconv"
 	"strings"
@@ -11,6 +13,7 @@ import (
 	"github.com/vincent119/images-filters/internal/config"
 	"github.com/vincent119/images-filters/internal/metrics"
 	"github.com/vincent119/images-filters/internal/security"
+	"github.com/vincent119/images-filters/pkg/logger"
 )
 
 // CORSMiddleware CORS 中介層
@@ -71,7 +74,7 @@ func SecurityMiddleware(cfg *config.SecurityConfig, m metrics.Metrics) gin.Handl
 		path := c.Request.URL.Path
 
 		// 跳過非圖片處理路徑
-		if isSkippedPath(path) {
+		if isSkippedPath(c.Request.Method, path) {
 			c.Next()
 			return
 		}
@@ -151,58 +154,134 @@ func SecurityMiddleware(cfg *config.SecurityConfig, m metrics.Metrics) gin.Handl
 
 // UploadAuthMiddleware 上傳驗證中介層
 // 驗證 Authorization: Bearer <SecurityKey> 標頭
+// 未設定 API 金鑰時的相容模式，建議改用 APIKeyAuthMiddleware
 func UploadAuthMiddleware(securityKey string, m metrics.Metrics) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		// 取得 Authorization 標頭
-		authHeader := c.Request.Header.Get("Authorization")
-		if authHeader == "" {
+		token, ok := extractBearerToken(c, m)
+		if !ok {
+			return
+		}
+
+		// 驗證 Token（使用 Security Key 作為 Token，常數時間比較防止時序攻擊）
+		if subtle.ConstantTimeCompare([]byte(token), []byte(securityKey)) != 1 {
 			if m != nil {
-				m.RecordRejectedRequest("missing_auth")
+				m.RecordRejectedRequest("invalid_token")
 			}
 			c.JSON(http.StatusUnauthorized, gin.H{
 				"error":   "UNAUTHORIZED",
-				"message": "Missing Authorization header",
+				"message": "Invalid token",
 			})
 			c.Abort()
 			return
 		}
 
-		// 驗證 Bearer Token 格式
-		const bearerPrefix = "Bearer "
-		if len(authHeader) <= len(bearerPrefix) || authHeader[:len(bearerPrefix)] != bearerPrefix {
+		c.Next()
+	}
+}
+
+// APIKeyAuthMiddleware API 金鑰驗證中介層
+// 驗證 Authorization: Bearer <api_key> 標頭並檢查金鑰是否具備指定權限
+func APIKeyAuthMiddleware(store security.APIKeyStore, scope security.Scope, m metrics.Metrics) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token, ok := extractBearerToken(c, m)
+		if !ok {
+			return
+		}
+
+		key, err := store.Authenticate(c.Request.Context(), token)
+		if err != nil {
+			reason := "invalid_token"
+			message := "Invalid token"
+			if errors.Is(err, security.ErrAPIKeyRevoked) {
+				reason = "revoked_token"
+				message = "API key has been revoked"
+			} else if !errors.Is(err, security.ErrInvalidAPIKey) {
+				logger.Error("api key authentication failed", logger.Err(err))
+				c.JSON(http.StatusInternalServerError, gin.H{
+					"error":   "AUTH_ERROR",
+					"message": "Failed to verify API key",
+				})
+				c.Abort()
+				return
+			}
 			if m != nil {
-				m.RecordRejectedRequest("invalid_auth_format")
+				m.RecordRejectedRequest(reason)
 			}
 			c.JSON(http.StatusUnauthorized, gin.H{
 				"error":   "UNAUTHORIZED",
-				"message": "Invalid Authorization format, expected 'Bearer <token>'",
+				"message": message,
 			})
 			c.Abort()
 			return
 		}
 
-		// 取得 Token
-		token := authHeader[len(bearerPrefix):]
-
-		// 驗證 Token（使用 Security Key 作為 Token）
-		if token != securityKey {
+		if !key.HasScope(scope) {
 			if m != nil {
-				m.RecordRejectedRequest("invalid_token")
+				m.RecordRejectedRequest("insufficient_scope")
 			}
-			c.JSON(http.StatusUnauthorized, gin.H{
-				"error":   "UNAUTHORIZED",
-				"message": "Invalid token",
+			c.JSON(http.StatusForbidden, gin.H{
+				"error":   "FORBIDDEN",
+				"message": "API key does not have the '" + string(scope) + "' scope",
 			})
 			c.Abort()
 			return
 		}
 
+		c.Set(apiKeyContextKey, key)
 		c.Next()
 	}
 }
 
+// apiKeyContextKey 已驗證 API 金鑰在 gin.Context 中的鍵值
+const apiKeyContextKey = "api_key"
+
+// apiKeyFromContext 取得已驗證的 API 金鑰（未使用 API 金鑰驗證時回傳 nil）
+func apiKeyFromContext(c *gin.Context) *security.APIKey {
+	v, ok := c.Get(apiKeyContextKey)
+	if !ok {
+		return nil
+	}
+	key, _ := v.(*security.APIKey)
+	return key
+}
+
+// extractBearerToken 取得 Bearer Token，失敗時直接回應 401
+func extractBearerToken(c *gin.Context, m metrics.Metrics) (string, bool) {
+	// 取得 Authorization 標頭
+	authHeader := c.Request.Header.Get("Authorization")
+	if authHeader == "" {
+		if m != nil {
+			m.RecordRejectedRequest("missing_auth")
+		}
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error":   "UNAUTHORIZED",
+			"message": "Missing Authorization header",
+		})
+		c.Abort()
+		return "", false
+	}
+
+	// 驗證 Bearer Token 格式
+	const bearerPrefix = "Bearer "
+	if len(authHeader) <= len(bearerPrefix) || authHeader[:len(bearerPrefix)] != bearerPrefix {
+		if m != nil {
+			m.RecordRejectedRequest("invalid_auth_format")
+		}
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error":   "UNAUTHORIZED",
+			"message": "Invalid Authorization format, expected 'Bearer <token>'",
+		})
+		c.Abort()
+		return "", false
+	}
+
+	return authHeader[len(bearerPrefix):], true
+}
+
 // isSkippedPath 檢查是否為不需要安全驗證的路徑
-func isSkippedPath(path string) bool {
+// 前綴路徑同時略過其子路徑；僅限 POST 的端點只略過完全相同的路徑，
+// 避免 GET /sign/...、GET /purge/... 等請求落入 NoRoute 的圖片處理而繞過簽名驗證
+func isSkippedPath(method, path string) bool {
 	skippedPaths := []string{
 		"/healthz",
 		"/metrics",
@@ -210,12 +289,23 @@ func isSkippedPath(path string) bool {
 		"/upload",
 		"/detect",
 	}
+	postOnlyPaths := []string{
+		"/sign",
+		"/purge",
+	}
 
 	for _, p := range skippedPaths {
 		if path == p || len(path) > len(p) && path[:len(p)+1] == p+"/" {
 			return true
 		}
 	}
+	if method == http.MethodPost {
+		for _, p := range postOnlyPaths {
+			if path == p {
+				return true
+			}
+		}
+	}
 
 	return false
 }
@@ -234,7 +324,7 @@ func SourceValidatorMiddleware(allowedSources []string) gin.HandlerFunc {
 
 		// 跳過非圖片處理路徑
 		path := c.Request.URL.Path
-		if isSkippedPath(path) {
+		if isSkippedPath(c.Request.Method, path) {
 			c.Next()
 			return
 		}
@@ -2,15 +2,18 @@ package api
 
 import (
 	"bytes"
+	"context"
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"path/filepath"
 	"testing"
 
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
 	"github.com/vincent119/images-filters/internal/config"
 	"github.com/vincent119/images-filters/internal/metrics"
+	"github.com/vincent119/images-filters/internal/security"
 )
 
 type MockMetrics struct {
@@ -192,6 +195,31 @@ func TestSecurityMiddleware(t *testing.T) {
 		assert.Equal(t, http.StatusOK, w.Code)
 	})
 
+	t.Run("Enabled - Endpoint Prefix Requires Signature", func(t *testing.T) {
+		cfg := &config.SecurityConfig{Enabled: true, SecurityKey: "secret", AllowUnsafe: false}
+		r := gin.New()
+		r.Use(SecurityMiddleware(cfg, nil))
+		r.POST("/sign", func(c *gin.Context) { c.Status(200) })
+		r.POST("/purge", func(c *gin.Context) { c.Status(200) })
+		r.NoRoute(func(c *gin.Context) { c.Status(200) })
+
+		// POST /sign 與 POST /purge 本身不需要簽名
+		for _, path := range []string{"/sign", "/purge"} {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("POST", path, nil)
+			r.ServeHTTP(w, req)
+			assert.Equal(t, http.StatusOK, w.Code, path)
+		}
+
+		// 以端點路徑為前綴的圖片請求仍需驗證簽名
+		for _, path := range []string{"/sign/300x200/uploads/a.jpg", "/sign", "/purge/300x200/uploads/a.jpg", "/purge"} {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", path, nil)
+			r.ServeHTTP(w, req)
+			assert.Equal(t, http.StatusForbidden, w.Code, path)
+		}
+	})
+
 	// Valid signature test would require generating signature.
 	// We can use internal/security if needed or rely on integration tests.
 	// But let's skip for now or duplicate logic.
@@ -237,6 +265,52 @@ func TestUploadAuthMiddleware(t *testing.T) {
 	})
 }
 
+func TestAPIKeyAuthMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	ctx := context.Background()
+
+	ring := security.NewKeyRing(security.NewFileKeyBackend(filepath.Join(t.TempDir(), "api_keys.json")), 0)
+	uploadKey, _, err := ring.Create(ctx, "uploader", []security.Scope{security.ScopeUpload}, "tenants/acme")
+	assert.NoError(t, err)
+	signKey, _, err := ring.Create(ctx, "signer", []security.Scope{security.ScopeSign}, "")
+	assert.NoError(t, err)
+	revokedKey, revoked, err := ring.Create(ctx, "old", []security.Scope{security.ScopeUpload}, "")
+	assert.NoError(t, err)
+	assert.NoError(t, ring.Revoke(ctx, revoked.ID))
+
+	r := gin.New()
+	r.Use(APIKeyAuthMiddleware(ring, security.ScopeUpload, nil))
+	r.POST("/upload", func(c *gin.Context) {
+		c.String(http.StatusOK, apiKeyFromContext(c).Prefix)
+	})
+
+	tests := []struct {
+		name       string
+		header     string
+		wantStatus int
+		wantBody   string
+	}{
+		{"Success", "Bearer " + uploadKey, http.StatusOK, "tenants/acme"},
+		{"Missing Header", "", http.StatusUnauthorized, "UNAUTHORIZED"},
+		{"Invalid Token", "Bearer imgf_abc_wrong", http.StatusUnauthorized, "Invalid token"},
+		{"Revoked Token", "Bearer " + revokedKey, http.StatusUnauthorized, "revoked"},
+		{"Insufficient Scope", "Bearer " + signKey, http.StatusForbidden, "FORBIDDEN"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("POST", "/upload", nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			r.ServeHTTP(w, req)
+			assert.Equal(t, tt.wantStatus, w.Code)
+			assert.Contains(t, w.Body.String(), tt.wantBody)
+		})
+	}
+}
+
 func TestSourceValidatorMiddleware(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 	allowed := []string{"example.com"}
@@ -259,6 +333,13 @@ func TestSourceValidatorMiddleware(t *testing.T) {
 		assert.Equal(t, http.StatusForbidden, w.Code)
 	})
 
+	t.Run("Endpoint Prefix Still Validated", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/purge/http://evil.com/image.jpg", nil)
+		r.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+
 	t.Run("Local File (Ignored)", func(t *testing.T) {
 		w := httptest.NewRecorder()
 		req, _ := http.NewRequest("GET", "/local/image.jpg", nil)
@@ -0,0 +1,113 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/vincent119/images-filters/internal/purge"
+	"github.com/vincent119/images-filters/internal/service"
+	"github.com/vincent119/images-filters/pkg/logger"
+)
+
+// PurgeHandler 快取與儲存清除處理器
+type PurgeHandler struct {
+	purgeService service.PurgeService
+}
+
+// NewPurgeHandler 建立新的清除處理器
+func NewPurgeHandler(purgeService service.PurgeService) *PurgeHandler {
+	return &PurgeHandler{
+		purgeService: purgeService,
+	}
+}
+
+// PurgeRequest 清除請求
+type PurgeRequest struct {
+	Path   string `json:"path" binding:"required"`
+	Prefix bool   `json:"prefix"`
+}
+
+// PurgeResponse 清除回應
+type PurgeResponse struct {
+	Path     string `json:"path"`
+	Prefix   bool   `json:"prefix"`
+	Sources  int    `json:"sources"`
+	Variants int    `json:"variants"`
+}
+
+// HandlePurge 清除來源圖片的所有處理結果
+// @Summary Purge image variants
+// @Description Delete every cached and stored variant derived from a source image path (or every source under a prefix)
+// @Tags Cache
+// @Accept json
+// @Produce json
+// @Param request body PurgeRequest true "Source image path (e.g. uploads/2025/01/01/image.jpg)"
+// @Success 200 {object} PurgeResponse
+// @Failure 400 {object} ErrorResponse "Bad request"
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 403 {object} ErrorResponse "Path outside the API key prefix"
+// @Failure 500 {object} ErrorResponse "Purge failed"
+// @Security BearerAuth
+// @Router /purge [post]
+func (h *PurgeHandler) HandlePurge(c *gin.Context) {
+	var req PurgeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "INVALID_REQUEST",
+			Message: "Field 'path' is required",
+		})
+		return
+	}
+
+	path := req.Path
+	if !strings.HasPrefix(path, "http://") && !strings.HasPrefix(path, "https://") {
+		path = strings.TrimPrefix(path, "/")
+	}
+	if path == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "INVALID_REQUEST",
+			Message: "Field 'path' is required",
+		})
+		return
+	}
+
+	// 前綴清除以目錄為單位，避免 "a/b" 同時清除 "a/bc/..."
+	if req.Prefix && !strings.HasSuffix(path, "/") {
+		path += "/"
+	}
+
+	if key := apiKeyFromContext(c); key != nil && !key.AllowsPath(path) {
+		c.JSON(http.StatusForbidden, ErrorResponse{
+			Error:   "PATH_NOT_ALLOWED",
+			Message: "Image path is outside the API key's allowed prefix",
+		})
+		return
+	}
+
+	result, err := h.purgeService.Purge(c.Request.Context(), path, req.Prefix)
+	if err != nil {
+		if errors.Is(err, purge.ErrPrefixUnsupported) {
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Error:   "PREFIX_PURGE_UNSUPPORTED",
+				Message: err.Error(),
+			})
+			return
+		}
+		logger.Error("purge failed", logger.String("path", path), logger.Err(err))
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "PURGE_FAILED",
+			Message: "Failed to purge image variants",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, PurgeResponse{
+		Path:     path,
+		Prefix:   req.Prefix,
+		Sources:  result.Sources,
+		Variants: result.Variants,
+	})
+}
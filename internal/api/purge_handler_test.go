@@ -0,0 +1,96 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/vincent119/images-filters/internal/purge"
+	"github.com/vincent119/images-filters/internal/security"
+	"github.com/vincent119/images-filters/internal/service"
+)
+
+// MockPurgeService 模擬清除服務
+type MockPurgeService struct {
+	path   string
+	prefix bool
+	err    error
+}
+
+func (m *MockPurgeService) Purge(ctx context.Context, path string, prefix bool) (*service.PurgeResult, error) {
+	m.path, m.prefix = path, prefix
+	if m.err != nil {
+		return nil, m.err
+	}
+	return &service.PurgeResult{Sources: 1, Variants: 3}, nil
+}
+
+func setupPurgeRouter(svc service.PurgeService, key *security.APIKey) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	h := NewPurgeHandler(svc)
+	r := gin.New()
+	r.POST("/purge", func(c *gin.Context) {
+		if key != nil {
+			c.Set(apiKeyContextKey, key)
+		}
+		h.HandlePurge(c)
+	})
+	return r
+}
+
+func postPurge(r *gin.Engine, body string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/purge", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestPurgeHandler_HandlePurge(t *testing.T) {
+	t.Run("Purge Source", func(t *testing.T) {
+		svc := &MockPurgeService{}
+		w := postPurge(setupPurgeRouter(svc, nil), `{"path":"/uploads/a.jpg"}`)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var resp PurgeResponse
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Equal(t, PurgeResponse{Path: "uploads/a.jpg", Sources: 1, Variants: 3}, resp)
+		assert.Equal(t, "uploads/a.jpg", svc.path)
+	})
+
+	t.Run("Prefix Is Directory Scoped", func(t *testing.T) {
+		svc := &MockPurgeService{}
+		w := postPurge(setupPurgeRouter(svc, nil), `{"path":"uploads/2025","prefix":true}`)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "uploads/2025/", svc.path)
+		assert.True(t, svc.prefix)
+	})
+
+	t.Run("Path Outside Prefix", func(t *testing.T) {
+		svc := &MockPurgeService{}
+		w := postPurge(setupPurgeRouter(svc, &security.APIKey{Prefix: "tenants/acme"}), `{"path":"tenants/other/a.jpg"}`)
+
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		assert.Empty(t, svc.path)
+	})
+
+	t.Run("Prefix Unsupported", func(t *testing.T) {
+		svc := &MockPurgeService{err: purge.ErrPrefixUnsupported}
+		w := postPurge(setupPurgeRouter(svc, nil), `{"path":"uploads/","prefix":true}`)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "PREFIX_PURGE_UNSUPPORTED")
+	})
+
+	t.Run("Missing Path", func(t *testing.T) {
+		w := postPurge(setupPurgeRouter(&MockPurgeService{}, nil), `{}`)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
@@ -0,0 +1,220 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/vincent119/images-filters/internal/service"
+)
+
+// 可續傳上傳使用的標頭
+const (
+	headerUploadOffset  = "Upload-Offset"
+	headerUploadLength  = "Upload-Length"
+	headerUploadExpires = "Upload-Expires"
+)
+
+// ResumableUploadHandler 可續傳分段上傳處理器
+type ResumableUploadHandler struct {
+	uploadService service.ResumableUploadService
+}
+
+// NewResumableUploadHandler 建立新的可續傳上傳處理器
+func NewResumableUploadHandler(uploadService service.ResumableUploadService) *ResumableUploadHandler {
+	return &ResumableUploadHandler{
+		uploadService: uploadService,
+	}
+}
+
+// ResumableCreateRequest 建立可續傳上傳請求
+type ResumableCreateRequest struct {
+	Filename    string `json:"filename" binding:"required"`
+	ContentType string `json:"content_type"`
+	Length      int64  `json:"length" binding:"required,gt=0"`
+}
+
+// ResumableUploadResponse 可續傳上傳狀態回應
+type ResumableUploadResponse struct {
+	ID        string    `json:"id"`
+	Offset    int64     `json:"offset"`
+	Length    int64     `json:"length"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// HandleCreate 建立可續傳上傳
+// @Summary Create resumable upload
+// @Description Start a resumable upload. Send the content with PATCH requests, then finalize with POST /upload/resumable/{id}/complete.
+// @Tags Image
+// @Accept json
+// @Produce json
+// @Param request body ResumableCreateRequest true "File name, content type and total length in bytes"
+// @Success 201 {object} ResumableUploadResponse
+// @Failure 400 {object} ErrorResponse "Bad request"
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 413 {object} ErrorResponse "Upload too large"
+// @Security BearerAuth
+// @Router /upload/resumable [post]
+func (h *ResumableUploadHandler) HandleCreate(c *gin.Context) {
+	var req ResumableCreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "INVALID_REQUEST",
+			Message: "Fields 'filename' and 'length' are required",
+		})
+		return
+	}
+
+	upload, err := h.uploadService.Create(c.Request.Context(), req.Filename, req.ContentType, req.Length, uploadOptionsFromContext(c)...)
+	if err != nil {
+		respondResumableError(c, err)
+		return
+	}
+
+	c.Header("Location", c.Request.URL.Path+"/"+upload.ID)
+	setResumableHeaders(c, upload)
+	c.JSON(http.StatusCreated, newResumableUploadResponse(upload))
+}
+
+// HandleStatus 取得可續傳上傳的位移
+// @Summary Get resumable upload offset
+// @Description Returns the number of bytes received in the Upload-Offset header, to resume after a failed PATCH
+// @Tags Image
+// @Param id path string true "Upload ID"
+// @Success 200 "Upload-Offset and Upload-Length headers"
+// @Failure 404 {object} ErrorResponse "Upload not found or expired"
+// @Security BearerAuth
+// @Router /upload/resumable/{id} [head]
+func (h *ResumableUploadHandler) HandleStatus(c *gin.Context) {
+	upload, err := h.uploadService.Status(c.Request.Context(), c.Param("id"), uploadOptionsFromContext(c)...)
+	if err != nil {
+		statusCode, _ := resumableErrorStatus(err)
+		c.Status(statusCode)
+		return
+	}
+
+	setResumableHeaders(c, upload)
+	c.Status(http.StatusOK)
+}
+
+// HandlePatch 寫入分段
+// @Summary Upload a chunk
+// @Description Append the request body at Upload-Offset. The offset must equal the bytes received so far.
+// @Tags Image
+// @Accept application/offset+octet-stream
+// @Param id path string true "Upload ID"
+// @Param Upload-Offset header int true "Offset of this chunk"
+// @Success 204 "New offset in the Upload-Offset header"
+// @Failure 400 {object} ErrorResponse "Bad request"
+// @Failure 404 {object} ErrorResponse "Upload not found or expired"
+// @Failure 409 {object} ErrorResponse "Offset mismatch"
+// @Failure 413 {object} ErrorResponse "Chunk too large"
+// @Security BearerAuth
+// @Router /upload/resumable/{id} [patch]
+func (h *ResumableUploadHandler) HandlePatch(c *gin.Context) {
+	offset, err := strconv.ParseInt(c.GetHeader(headerUploadOffset), 10, 64)
+	if err != nil || offset < 0 {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "INVALID_OFFSET",
+			Message: "Header 'Upload-Offset' must be a non-negative integer",
+		})
+		return
+	}
+
+	upload, err := h.uploadService.WriteChunk(c.Request.Context(), c.Param("id"), offset, c.Request.Body, uploadOptionsFromContext(c)...)
+	if upload != nil {
+		setResumableHeaders(c, upload)
+	}
+	if err != nil {
+		respondResumableError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// HandleComplete 完成可續傳上傳
+// @Summary Complete resumable upload
+// @Description Assemble the received chunks and run the regular upload pipeline (validation, blind watermark, storage)
+// @Tags Image
+// @Produce json
+// @Param id path string true "Upload ID"
+// @Success 200 {object} UploadResponse
+// @Failure 400 {object} ErrorResponse "Invalid image"
+// @Failure 404 {object} ErrorResponse "Upload not found or expired"
+// @Failure 409 {object} ErrorResponse "Upload incomplete"
+// @Security BearerAuth
+// @Router /upload/resumable/{id}/complete [post]
+func (h *ResumableUploadHandler) HandleComplete(c *gin.Context) {
+	result, err := h.uploadService.Complete(c.Request.Context(), c.Param("id"), uploadOptionsFromContext(c)...)
+	if err != nil {
+		respondResumableError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, newUploadResponse(result))
+}
+
+// HandleAbort 放棄可續傳上傳
+// @Summary Abort resumable upload
+// @Description Delete the staged chunks of an upload
+// @Tags Image
+// @Param id path string true "Upload ID"
+// @Success 204
+// @Failure 404 {object} ErrorResponse "Upload not found or expired"
+// @Security BearerAuth
+// @Router /upload/resumable/{id} [delete]
+func (h *ResumableUploadHandler) HandleAbort(c *gin.Context) {
+	if err := h.uploadService.Abort(c.Request.Context(), c.Param("id"), uploadOptionsFromContext(c)...); err != nil {
+		respondResumableError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// newResumableUploadResponse 建立狀態回應
+func newResumableUploadResponse(upload *service.ResumableUpload) ResumableUploadResponse {
+	return ResumableUploadResponse{
+		ID:        upload.ID,
+		Offset:    upload.Offset,
+		Length:    upload.Length,
+		ExpiresAt: upload.ExpiresAt,
+	}
+}
+
+// setResumableHeaders 設定位移、總長度與過期時間標頭
+func setResumableHeaders(c *gin.Context, upload *service.ResumableUpload) {
+	c.Header(headerUploadOffset, strconv.FormatInt(upload.Offset, 10))
+	c.Header(headerUploadLength, strconv.FormatInt(upload.Length, 10))
+	c.Header(headerUploadExpires, upload.ExpiresAt.UTC().Format(http.TimeFormat))
+	c.Header("Cache-Control", "no-store")
+}
+
+// respondResumableError 回傳可續傳上傳錯誤
+func respondResumableError(c *gin.Context, err error) {
+	statusCode, errorCode := resumableErrorStatus(err)
+	c.JSON(statusCode, ErrorResponse{
+		Error:   errorCode,
+		Message: err.Error(),
+	})
+}
+
+// resumableErrorStatus 依可續傳上傳錯誤取得狀態碼與錯誤碼，其餘錯誤依上傳錯誤處理
+func resumableErrorStatus(err error) (int, string) {
+	switch {
+	case errors.Is(err, service.ErrResumableNotFound):
+		return http.StatusNotFound, "UPLOAD_NOT_FOUND"
+	case errors.Is(err, service.ErrResumableOffsetMismatch):
+		return http.StatusConflict, "OFFSET_MISMATCH"
+	case errors.Is(err, service.ErrResumableIncomplete):
+		return http.StatusConflict, "UPLOAD_INCOMPLETE"
+	case errors.Is(err, service.ErrResumableTooLarge):
+		return http.StatusRequestEntityTooLarge, "UPLOAD_TOO_LARGE"
+	case errors.Is(err, service.ErrResumableChunkTooLarge):
+		return http.StatusRequestEntityTooLarge, "CHUNK_TOO_LARGE"
+	default:
+		return uploadErrorStatus(err)
+	}
+}
@@ -0,0 +1,129 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vincent119/images-filters/internal/config"
+	"github.com/vincent119/images-filters/internal/service"
+	"github.com/vincent119/images-filters/internal/storage"
+)
+
+func setupResumableRouter(t *testing.T) (http.Handler, *string) {
+	t.Helper()
+	router, _, mockService := setupTestRouter()
+
+	uploaded := new(string)
+	mockService.uploadFunc = func(ctx context.Context, filename string, contentType string, reader io.Reader) (*service.UploadResult, error) {
+		data, err := io.ReadAll(reader)
+		if err != nil {
+			return nil, err
+		}
+		*uploaded = string(data)
+		return &service.UploadResult{Path: "uploads/" + filename, SignedURL: "/signed/" + filename}, nil
+	}
+
+	store, err := storage.NewLocalStorage(t.TempDir())
+	require.NoError(t, err)
+	svc := service.NewResumableUploadService(config.ResumableUploadConfig{MaxChunkSize: 4}, store, mockService)
+
+	h := NewResumableUploadHandler(svc)
+	g := router.Group("/upload/resumable")
+	g.POST("", h.HandleCreate)
+	g.HEAD("/:id", h.HandleStatus)
+	g.PATCH("/:id", h.HandlePatch)
+	g.DELETE("/:id", h.HandleAbort)
+	g.POST("/:id/complete", h.HandleComplete)
+	return router, uploaded
+}
+
+func resumableRequest(router http.Handler, method, path, body string, headers map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestResumableUploadHandler_Flow(t *testing.T) {
+	router, uploaded := setupResumableRouter(t)
+
+	w := resumableRequest(router, http.MethodPost, "/upload/resumable", `{"filename":"big.jpg","content_type":"image/jpeg","length":6}`, map[string]string{"Content-Type": "application/json"})
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+	var created ResumableUploadResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+	location := w.Header().Get("Location")
+	assert.Equal(t, "/upload/resumable/"+created.ID, location)
+	assert.Equal(t, "0", w.Header().Get(headerUploadOffset))
+
+	// 缺少或不合法的位移
+	w = resumableRequest(router, http.MethodPatch, location, "abcd", nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = resumableRequest(router, http.MethodPatch, location, "abcd", map[string]string{headerUploadOffset: "0"})
+	require.Equal(t, http.StatusNoContent, w.Code, w.Body.String())
+	assert.Equal(t, "4", w.Header().Get(headerUploadOffset))
+
+	// 重送已接收的分段時回傳目前位移
+	w = resumableRequest(router, http.MethodPatch, location, "abcd", map[string]string{headerUploadOffset: "0"})
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Equal(t, "4", w.Header().Get(headerUploadOffset))
+
+	w = resumableRequest(router, http.MethodPost, location+"/complete", "", nil)
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Contains(t, w.Body.String(), "UPLOAD_INCOMPLETE")
+
+	w = resumableRequest(router, http.MethodHead, location, "", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "4", w.Header().Get(headerUploadOffset))
+	assert.Equal(t, "6", w.Header().Get(headerUploadLength))
+	assert.NotEmpty(t, w.Header().Get(headerUploadExpires))
+
+	w = resumableRequest(router, http.MethodPatch, location, "ef", map[string]string{headerUploadOffset: "4"})
+	require.Equal(t, http.StatusNoContent, w.Code, w.Body.String())
+
+	w = resumableRequest(router, http.MethodPost, location+"/complete", "", nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var resp UploadResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, "uploads/big.jpg", resp.Path)
+	assert.Equal(t, "abcdef", *uploaded)
+
+	w = resumableRequest(router, http.MethodHead, location, "", nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestResumableUploadHandler_Errors(t *testing.T) {
+	router, _ := setupResumableRouter(t)
+
+	w := resumableRequest(router, http.MethodPost, "/upload/resumable", `{"filename":"a.jpg"}`, map[string]string{"Content-Type": "application/json"})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = resumableRequest(router, http.MethodPatch, "/upload/resumable/0123456789abcdef0123456789abcdef", "x", map[string]string{headerUploadOffset: "0"})
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Contains(t, w.Body.String(), "UPLOAD_NOT_FOUND")
+
+	w = resumableRequest(router, http.MethodPost, "/upload/resumable", `{"filename":"a.jpg","length":2}`, map[string]string{"Content-Type": "application/json"})
+	require.Equal(t, http.StatusCreated, w.Code)
+	location := w.Header().Get("Location")
+
+	w = resumableRequest(router, http.MethodPatch, location, "abc", map[string]string{headerUploadOffset: "0"})
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+	assert.Contains(t, w.Body.String(), "CHUNK_TOO_LARGE")
+
+	w = resumableRequest(router, http.MethodDelete, location, "", nil)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	w = resumableRequest(router, http.MethodDelete, location, "", nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
@@ -0,0 +1,95 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/vincent119/images-filters/internal/parser"
+	"github.com/vincent119/images-filters/internal/security"
+)
+
+// SignHandler URL 簽名處理器
+// 讓持有 sign 權限的 API 金鑰取得簽名 URL，而不需取得伺服器的 security_key
+type SignHandler struct {
+	signer    *security.Signer
+	urlParser *parser.URLParser
+}
+
+// NewSignHandler 建立新的簽名處理器
+// securityKey 為空時回傳 unsafe 路徑
+func NewSignHandler(securityKey string) *SignHandler {
+	h := &SignHandler{
+		urlParser: parser.NewURLParser(),
+	}
+	if securityKey != "" {
+		h.signer = security.NewSigner(securityKey)
+	}
+	return h
+}
+
+// SignRequest 簽名請求
+type SignRequest struct {
+	Path string `json:"path" binding:"required"`
+}
+
+// SignResponse 簽名回應
+type SignResponse struct {
+	Path string `json:"path"`
+	URL  string `json:"url"`
+}
+
+// HandleSign 產生簽名 URL
+// @Summary Sign image URL
+// @Description Generate a signed processing URL for an image path within the API key's prefix
+// @Tags Security
+// @Accept json
+// @Produce json
+// @Param request body SignRequest true "Path to sign (e.g. 300x200/uploads/2025/01/01/image.jpg)"
+// @Success 200 {object} SignResponse
+// @Failure 400 {object} ErrorResponse "Bad request"
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 403 {object} ErrorResponse "Path outside the API key prefix"
+// @Security BearerAuth
+// @Router /sign [post]
+func (h *SignHandler) HandleSign(c *gin.Context) {
+	var req SignRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "INVALID_REQUEST",
+			Message: "Field 'path' is required",
+		})
+		return
+	}
+
+	path := strings.TrimPrefix(req.Path, "/")
+
+	// 解析路徑以取得實際的圖片路徑（使用 unsafe 前綴讓解析器略過簽名段）
+	parsed, err := h.urlParser.Parse("unsafe/" + path)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "INVALID_URL",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	if key := apiKeyFromContext(c); key != nil && !key.AllowsPath(parsed.ImagePath) {
+		c.JSON(http.StatusForbidden, ErrorResponse{
+			Error:   "PATH_NOT_ALLOWED",
+			Message: "Image path is outside the API key's allowed prefix",
+		})
+		return
+	}
+
+	url := "/unsafe/" + path
+	if h.signer != nil {
+		url = h.signer.SignURL(path)
+	}
+
+	c.JSON(http.StatusOK, SignResponse{
+		Path: path,
+		URL:  url,
+	})
+}
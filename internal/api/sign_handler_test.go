@@ -0,0 +1,70 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/vincent119/images-filters/internal/security"
+)
+
+func setupSignRouter(securityKey string, key *security.APIKey) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	h := NewSignHandler(securityKey)
+	r := gin.New()
+	r.POST("/sign", func(c *gin.Context) {
+		if key != nil {
+			c.Set(apiKeyContextKey, key)
+		}
+		h.HandleSign(c)
+	})
+	return r
+}
+
+func postSign(r *gin.Engine, body string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/sign", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestSignHandler_HandleSign(t *testing.T) {
+	t.Run("Signed URL", func(t *testing.T) {
+		r := setupSignRouter("secret", &security.APIKey{Prefix: "tenants/acme"})
+		w := postSign(r, `{"path":"/300x200/tenants/acme/uploads/a.jpg"}`)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var resp SignResponse
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Equal(t, "300x200/tenants/acme/uploads/a.jpg", resp.Path)
+		assert.Equal(t, security.NewSigner("secret").SignURL(resp.Path), resp.URL)
+	})
+
+	t.Run("Path Outside Prefix", func(t *testing.T) {
+		r := setupSignRouter("secret", &security.APIKey{Prefix: "tenants/acme"})
+		w := postSign(r, `{"path":"300x200/tenants/other/a.jpg"}`)
+
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		assert.Contains(t, w.Body.String(), "PATH_NOT_ALLOWED")
+	})
+
+	t.Run("Unsafe Without Security Key", func(t *testing.T) {
+		r := setupSignRouter("", nil)
+		w := postSign(r, `{"path":"300x200/a.jpg"}`)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `"url":"/unsafe/300x200/a.jpg"`)
+	})
+
+	t.Run("Missing Path", func(t *testing.T) {
+		r := setupSignRouter("secret", nil)
+		w := postSign(r, `{}`)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
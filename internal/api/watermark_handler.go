@@ -29,6 +29,7 @@ func NewWatermarkHandler(svc service.WatermarkService) *WatermarkHandler {
 // @Param path formData string false "Storage path of existing image (e.g. uploads/2025/12/26/image.jpg)"
 // @Success 200 {object} service.DetectionResult
 // @Failure 400 {object} ErrorResponse "Bad request - neither file nor path provided"
+// @Failure 403 {object} ErrorResponse "Path outside the API key prefix"
 // @Failure 500 {object} ErrorResponse "Internal error"
 // @Router /detect [post]
 func (h *WatermarkHandler) HandleDetect(c *gin.Context) {
@@ -52,6 +53,14 @@ func (h *WatermarkHandler) HandleDetect(c *gin.Context) {
 	// 2. 如果沒有檔案，檢查 path 參數
 	path := c.PostForm("path")
 	if path != "" {
+		// 使用 API 金鑰時，僅允許檢測金鑰路徑前綴內的圖片
+		if key := apiKeyFromContext(c); key != nil && !key.AllowsPath(path) {
+			c.JSON(http.StatusForbidden, ErrorResponse{
+				Error:   "PATH_NOT_ALLOWED",
+				Message: "Path is outside the API key's allowed prefix",
+			})
+			return
+		}
 		result, err := h.service.DetectWatermarkFromPath(c.Request.Context(), path)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, ErrorResponse{
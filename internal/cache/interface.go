@@ -9,6 +9,14 @@ import (
 // ErrCacheMiss 當快取 Key 不存在時回傳此錯誤
 var ErrCacheMiss = errors.New("cache: key not found")
 
+// 快取類型名稱（同時作為指標的 cacheType 標籤）
+const (
+	TypeMemory = "memory"
+	TypeRedis  = "redis"
+	TypeNoOp   = "noop"
+	TypeTiered = "tiered"
+)
+
 // Cache 定義通用快取介面
 // 支援不同的快取實作（如 Redis, Memory 等）
 type Cache interface {
@@ -26,3 +34,16 @@ type Cache interface {
 	// Exists 檢查 Key 是否存在
 	Exists(ctx context.Context, key string) (bool, error)
 }
+
+// Named 可回報自身類型的快取
+type Named interface {
+	Name() string
+}
+
+// TypeOf 取得快取類型名稱（未實作 Named 時回傳 "unknown"）
+func TypeOf(c Cache) string {
+	if n, ok := c.(Named); ok {
+		return n.Name()
+	}
+	return "unknown"
+}
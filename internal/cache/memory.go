@@ -63,6 +63,11 @@ func NewMemoryCache(cfg config.MemoryCacheConfig) (*MemoryCache, error) {
 	}, nil
 }
 
+// Name 回傳快取類型
+func (m *MemoryCache) Name() string {
+	return TypeMemory
+}
+
 // Get 取得快取值
 func (m *MemoryCache) Get(ctx context.Context, key string) ([]byte, error) {
 	val, found := m.cache.Get(key)
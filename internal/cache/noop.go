@@ -14,6 +14,11 @@ func NewNoOpCache() *NoOpCache {
 	return &NoOpCache{}
 }
 
+// Name 回傳快取類型
+func (c *NoOpCache) Name() string {
+	return TypeNoOp
+}
+
 func (c *NoOpCache) Get(ctx context.Context, key string) ([]byte, error) {
 	return nil, ErrCacheMiss
 }
@@ -19,9 +19,9 @@ type RedisCache struct {
 	defaultTTL time.Duration
 }
 
-// NewRedisCache 建立新的 Redis 快取實例
-// NewRedisCache 建立新的 Redis 快取實例
-func NewRedisCache(cfg config.RedisCacheConfig) (*RedisCache, error) {
+// NewRedisClient 依設定建立 Redis 連線並測試連線
+// 供快取以外需要共用 Redis 的元件（例如清除索引）使用
+func NewRedisClient(cfg config.RedisCacheConfig) (*redis.Client, error) {
 	opts, err := getRedisOptions(cfg)
 	if err != nil {
 		return nil, err
@@ -34,8 +34,19 @@ func NewRedisCache(cfg config.RedisCacheConfig) (*RedisCache, error) {
 	defer cancel()
 
 	if err := client.Ping(ctx).Err(); err != nil {
+		client.Close()
 		return nil, fmt.Errorf("failed to connect to redis: %w", err)
 	}
+	return client, nil
+}
+
+// NewRedisCache 建立新的 Redis 快取實例
+func NewRedisCache(cfg config.RedisCacheConfig) (*RedisCache, error) {
+	client, err := NewRedisClient(cfg)
+	if err != nil {
+		return nil, err
+	}
+	opts := client.Options()
 
 	ttl := time.Duration(cfg.TTL) * time.Second
 	if ttl == 0 {
@@ -56,6 +67,11 @@ func NewRedisCache(cfg config.RedisCacheConfig) (*RedisCache, error) {
 	}, nil
 }
 
+// Name 回傳快取類型
+func (r *RedisCache) Name() string {
+	return TypeRedis
+}
+
 // Get 取得快取值
 func (r *RedisCache) Get(ctx context.Context, key string) ([]byte, error) {
 	val, err := r.client.Get(ctx, key).Bytes()
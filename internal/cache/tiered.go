@@ -0,0 +1,125 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/vincent119/images-filters/internal/metrics"
+	"github.com/vincent119/images-filters/pkg/logger"
+)
+
+// TieredCache 兩層快取：行程內 L1（Memory）在前、共用 L2（Redis）在後
+// 讀取先查 L1，L2 命中時回填 L1；寫入與刪除同時作用於兩層
+type TieredCache struct {
+	l1      Cache
+	l2      Cache
+	l1TTL   time.Duration
+	metrics metrics.Metrics
+}
+
+// TieredOption TieredCache 選項
+type TieredOption func(*TieredCache)
+
+// WithL1TTL 設定 L1 的最長存活時間
+// 其他副本更新或刪除 L2 時無法通知本機 L1，因此 L1 的存活時間應遠短於 L2
+func WithL1TTL(ttl time.Duration) TieredOption {
+	return func(c *TieredCache) {
+		c.l1TTL = ttl
+	}
+}
+
+// WithTierMetrics 設定指標收集器，依層級（l1/l2 的快取類型）記錄命中與未命中
+func WithTierMetrics(m metrics.Metrics) TieredOption {
+	return func(c *TieredCache) {
+		c.metrics = m
+	}
+}
+
+// NewTieredCache 建立兩層快取
+func NewTieredCache(l1, l2 Cache, opts ...TieredOption) *TieredCache {
+	c := &TieredCache{
+		l1:    l1,
+		l2:    l2,
+		l1TTL: time.Minute,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	logger.Info("tiered cache initialized",
+		logger.String("l1", TypeOf(l1)),
+		logger.String("l2", TypeOf(l2)),
+		logger.String("l1_ttl", c.l1TTL.String()),
+	)
+
+	return c
+}
+
+// Name 回傳快取類型
+func (c *TieredCache) Name() string {
+	return TypeTiered
+}
+
+// Get 取得快取值（L1 → L2，L2 命中時回填 L1）
+func (c *TieredCache) Get(ctx context.Context, key string) ([]byte, error) {
+	if data, err := c.l1.Get(ctx, key); err == nil {
+		c.recordHit(c.l1)
+		return data, nil
+	}
+	c.recordMiss(c.l1)
+
+	data, err := c.l2.Get(ctx, key)
+	if err != nil {
+		if errors.Is(err, ErrCacheMiss) {
+			c.recordMiss(c.l2)
+		}
+		return nil, err
+	}
+	c.recordHit(c.l2)
+
+	if err := c.l1.Set(ctx, key, data, c.l1TTL); err != nil {
+		logger.Debug("failed to promote cache entry to l1", logger.String("key", key), logger.Err(err))
+	}
+	return data, nil
+}
+
+// Set 同時寫入 L1 與 L2
+// L1 的存活時間不超過 l1TTL；L2 寫入失敗時回傳錯誤
+func (c *TieredCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	l1TTL := c.l1TTL
+	if ttl > 0 && ttl < l1TTL {
+		l1TTL = ttl
+	}
+	if err := c.l1.Set(ctx, key, value, l1TTL); err != nil {
+		logger.Debug("failed to set l1 cache", logger.String("key", key), logger.Err(err))
+	}
+	return c.l2.Set(ctx, key, value, ttl)
+}
+
+// Delete 同時刪除 L1 與 L2
+func (c *TieredCache) Delete(ctx context.Context, key string) error {
+	l1Err := c.l1.Delete(ctx, key)
+	l2Err := c.l2.Delete(ctx, key)
+	return errors.Join(l1Err, l2Err)
+}
+
+// Exists 檢查 Key 是否存在於任一層
+func (c *TieredCache) Exists(ctx context.Context, key string) (bool, error) {
+	if found, err := c.l1.Exists(ctx, key); err == nil && found {
+		return true, nil
+	}
+	return c.l2.Exists(ctx, key)
+}
+
+func (c *TieredCache) recordHit(tier Cache) {
+	if c.metrics != nil {
+		c.metrics.RecordCacheHit(TypeOf(tier))
+	}
+}
+
+func (c *TieredCache) recordMiss(tier Cache) {
+	if c.metrics != nil {
+		c.metrics.RecordCacheMiss(TypeOf(tier))
+	}
+}
@@ -0,0 +1,96 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vincent119/images-filters/internal/config"
+	"github.com/vincent119/images-filters/internal/metrics"
+)
+
+// tierMetrics 記錄各層命中與未命中次數
+type tierMetrics struct {
+	metrics.Metrics
+	hits   map[string]int
+	misses map[string]int
+}
+
+func newTierMetrics() *tierMetrics {
+	return &tierMetrics{hits: map[string]int{}, misses: map[string]int{}}
+}
+
+func (m *tierMetrics) RecordCacheHit(cacheType string)  { m.hits[cacheType]++ }
+func (m *tierMetrics) RecordCacheMiss(cacheType string) { m.misses[cacheType]++ }
+
+func setupTieredCache(t *testing.T, m metrics.Metrics) (*TieredCache, *MemoryCache, *RedisCache) {
+	mr, l2 := setupTestRedis(t)
+	t.Cleanup(mr.Close)
+
+	l1, err := NewMemoryCache(config.MemoryCacheConfig{MaxSize: 10 * 1024 * 1024, TTL: 3600})
+	if err != nil {
+		t.Fatalf("Failed to create memory cache: %v", err)
+	}
+	t.Cleanup(l1.Close)
+
+	return NewTieredCache(l1, l2, WithL1TTL(time.Minute), WithTierMetrics(m)), l1, l2
+}
+
+func TestTieredCache_WriteThrough(t *testing.T) {
+	c, l1, l2 := setupTieredCache(t, nil)
+	ctx := context.Background()
+
+	assert.NoError(t, c.Set(ctx, "key", []byte("value"), 0))
+	time.Sleep(50 * time.Millisecond) // Ristretto Set is async
+
+	got, err := l1.Get(ctx, "key")
+	assert.NoError(t, err)
+	assert.Equal(t, "value", string(got))
+
+	got, err = l2.Get(ctx, "key")
+	assert.NoError(t, err)
+	assert.Equal(t, "value", string(got))
+
+	assert.NoError(t, c.Delete(ctx, "key"))
+	time.Sleep(50 * time.Millisecond)
+
+	exists, err := c.Exists(ctx, "key")
+	assert.NoError(t, err)
+	assert.False(t, exists)
+}
+
+func TestTieredCache_PromotesL2Hits(t *testing.T) {
+	m := newTierMetrics()
+	c, l1, l2 := setupTieredCache(t, m)
+	ctx := context.Background()
+
+	// 其他副本寫入的值只存在於 L2
+	assert.NoError(t, l2.Set(ctx, "key", []byte("value"), 0))
+
+	got, err := c.Get(ctx, "key")
+	assert.NoError(t, err)
+	assert.Equal(t, "value", string(got))
+	assert.Equal(t, 1, m.misses[TypeMemory])
+	assert.Equal(t, 1, m.hits[TypeRedis])
+
+	time.Sleep(50 * time.Millisecond)
+	got, err = l1.Get(ctx, "key")
+	assert.NoError(t, err)
+	assert.Equal(t, "value", string(got))
+
+	_, err = c.Get(ctx, "key")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, m.hits[TypeMemory])
+	assert.Equal(t, 1, m.hits[TypeRedis])
+
+	_, err = c.Get(ctx, "missing")
+	assert.ErrorIs(t, err, ErrCacheMiss)
+	assert.Equal(t, 2, m.misses[TypeMemory])
+	assert.Equal(t, 1, m.misses[TypeRedis])
+}
+
+func TestTypeOf(t *testing.T) {
+	assert.Equal(t, TypeNoOp, TypeOf(NewNoOpCache()))
+	assert.Equal(t, TypeTiered, TypeOf(&TieredCache{}))
+}
@@ -8,6 +8,7 @@ import (
 	"os"
 	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/go-playground/validator/v10"
 	"github.com/spf13/viper"
@@ -24,15 +25,189 @@ type Config struct {
 	Metrics        MetricsConfig        `mapstructure:"metrics"`
 	Swagger        SwaggerConfig        `mapstructure:"swagger"`
 	BlindWatermark BlindWatermarkConfig `mapstructure:"blind_watermark"`
+	Loader         LoaderConfig         `mapstructure:"loader"`
+	Resilience     ResilienceConfig     `mapstructure:"resilience"`
+	Purge          PurgeConfig          `mapstructure:"purge"`
+	Upload         UploadConfig         `mapstructure:"upload"`
+	Filters        FiltersConfig        `mapstructure:"filters"`
+}
+
+// FiltersConfig URL 濾鏡設定
+type FiltersConfig struct {
+	Text      TextFilterConfig      `mapstructure:"text"`
+	Watermark WatermarkFilterConfig `mapstructure:"watermark"`
+}
+
+// TextFilterConfig 文字浮水印濾鏡 text(...) 設定
+// 內嵌 Go 字型（go、go-bold、go-italic、go-mono、go-mono-bold）僅涵蓋拉丁字母，CJK 需於 font_dir 提供字型
+type TextFilterConfig struct {
+	FontDir       string   `mapstructure:"font_dir"`       // 額外字型目錄（.ttf/.otf/.ttc），以小寫檔名與字型家族名稱註冊
+	DefaultFont   string   `mapstructure:"default_font"`   // 未指定字型時使用的字型
+	FallbackFonts []string `mapstructure:"fallback_fonts"` // 缺字時依序嘗試的字型，未設定時使用 font_dir 中的所有字型
+}
+
+// WatermarkFilterConfig 圖片浮水印濾鏡 watermark(...) 設定
+// 素材經由與來源圖片相同的載入器與 Storage 載入，遠端 URL 需通過 AllowedSources 檢查
+type WatermarkFilterConfig struct {
+	CacheMaxSize int64         `mapstructure:"cache_max_size" validate:"gte=0"` // 解碼後素材快取上限（bytes，以寬 x 高 x 4 計算，0 表示停用）
+	CacheTTL     time.Duration `mapstructure:"cache_ttl"`                       // 快取存活時間，逾時後重新載入（0 表示不過期）
+	Timeout      time.Duration `mapstructure:"timeout"`                         // 素材載入逾時
+	FailOpen     bool          `mapstructure:"fail_open"`                       // 載入失敗時回傳未加浮水印的圖片；false 時請求失敗
+}
+
+// UploadConfig 上傳設定
+type UploadConfig struct {
+	// ContentAddressable 依內容 SHA-256 儲存上傳檔案（cas/ab/cdef...），重複上傳回傳既有路徑
+	ContentAddressable bool `mapstructure:"content_addressable"`
+	// 尺寸上限（0 表示不限制），僅解析檔頭判斷，避免解壓縮炸彈
+	MaxWidth  int   `mapstructure:"max_width" validate:"min=0"`
+	MaxHeight int   `mapstructure:"max_height" validate:"min=0"`
+	MaxPixels int64 `mapstructure:"max_pixels" validate:"min=0"`
+	// Reencode 解碼後重新編碼，移除中繼資料與附加在圖片後的內容
+	Reencode bool `mapstructure:"reencode"`
+	// Batch 批次上傳（POST /upload/batch）設定
+	Batch BatchUploadConfig `mapstructure:"batch"`
+	// Resumable 可續傳分段上傳（/upload/resumable）設定
+	Resumable ResumableUploadConfig `mapstructure:"resumable"`
+	// Variants 上傳後預先產生的處理結果（eager variants）
+	Variants UploadVariantsConfig `mapstructure:"variants"`
+}
+
+// UploadVariantsConfig 上傳後預先產生處理結果的設定
+// 每個項目為預設名稱或處理選項字串（即 URL 中簽名與圖片路徑之間的部分，如 "fit-in/300x300/filters:format(webp)"）
+type UploadVariantsConfig struct {
+	Presets      map[string]string            `mapstructure:"presets"`                         // 預設名稱對應處理選項（名稱不分大小寫）
+	Default      []string                     `mapstructure:"default"`                         // 未符合任何前綴時預先產生的項目
+	Prefixes     []UploadVariantsPrefixConfig `mapstructure:"prefixes" validate:"dive"`        // 依上傳路徑前綴（API 金鑰前綴）指定，取最長相符者
+	MaxPerUpload int                          `mapstructure:"max_per_upload" validate:"min=0"` // 單次上傳最多預先產生的數量（0 表示不限制）
+	MaxPending   int                          `mapstructure:"max_pending" validate:"min=0"`    // 等待或正在產生的項目上限，超過時略過（0 表示使用預設值 64）
+}
+
+// UploadVariantsPrefixConfig 特定上傳路徑前綴預先產生的項目
+type UploadVariantsPrefixConfig struct {
+	Prefix   string   `mapstructure:"prefix" validate:"required"`
+	Variants []string `mapstructure:"variants"`
+}
+
+// ResumableUploadConfig 可續傳分段上傳設定
+// 分段暫存於儲存層的 staging_prefix 下；同一上傳的寫入僅以行程內的鎖排序，
+// 多副本部署時同一上傳的所有請求須由同一副本處理（例如依上傳 ID 做 sticky routing）
+type ResumableUploadConfig struct {
+	Enabled         bool          `mapstructure:"enabled"`
+	MaxSize         int64         `mapstructure:"max_size" validate:"min=0"`       // 單一上傳總大小上限（bytes，0 表示不限制）
+	MaxChunkSize    int64         `mapstructure:"max_chunk_size" validate:"min=0"` // 單次 PATCH 大小上限（bytes，0 表示不限制）
+	Expiry          time.Duration `mapstructure:"expiry"`                          // 最後一次寫入後多久未完成即過期
+	CleanupInterval time.Duration `mapstructure:"cleanup_interval"`                // 清除過期上傳的間隔（0 表示僅於存取時清除）
+	StagingPrefix   string        `mapstructure:"staging_prefix"`                  // 暫存路徑前綴
+}
+
+// BatchUploadConfig 批次上傳設定
+type BatchUploadConfig struct {
+	MaxFiles       int   `mapstructure:"max_files" validate:"min=1"`        // 單次請求最多檔案數（含封存檔內的檔案）
+	Concurrency    int   `mapstructure:"concurrency" validate:"min=1"`      // 同時上傳的檔案數
+	MaxFileSize    int64 `mapstructure:"max_file_size" validate:"min=1"`    // 單一檔案大小上限（bytes），封存檔內的檔案解壓後計算
+	MaxArchiveSize int64 `mapstructure:"max_archive_size" validate:"min=1"` // 請求內容大小上限（bytes），以壓縮後的封存檔或 multipart 內容計算
+}
+
+// PurgeConfig 依來源清除處理結果的設定
+type PurgeConfig struct {
+	Enabled bool   `mapstructure:"enabled"`
+	Index   string `mapstructure:"index" validate:"required_if=Enabled true,omitempty,oneof=storage redis"` // 索引保存位置（redis 使用 cache.redis 連線設定）
+}
+
+// ResilienceConfig 遠端來源與儲存讀取的容錯設定
+type ResilienceConfig struct {
+	Loader  LoaderResilienceConfig  `mapstructure:"loader"`
+	Storage StorageResilienceConfig `mapstructure:"storage"`
+}
+
+// LoaderResilienceConfig 遠端來源容錯設定
+type LoaderResilienceConfig struct {
+	Retry          RetryConfig          `mapstructure:"retry"`
+	CircuitBreaker CircuitBreakerConfig `mapstructure:"circuit_breaker"`
+	Hedge          HedgeConfig          `mapstructure:"hedge"`
+}
+
+// StorageResilienceConfig 儲存讀取容錯設定
+type StorageResilienceConfig struct {
+	Retry RetryConfig `mapstructure:"retry"`
+}
+
+// RetryConfig 重試設定（指數退避 + jitter）
+type RetryConfig struct {
+	MaxAttempts int           `mapstructure:"max_attempts" validate:"gte=0"` // 含第一次的最大嘗試次數（0/1 表示不重試）
+	BaseDelay   time.Duration `mapstructure:"base_delay" validate:"gte=0"`
+	MaxDelay    time.Duration `mapstructure:"max_delay" validate:"gte=0"`
+}
+
+// CircuitBreakerConfig 每個來源主機的斷路器設定
+type CircuitBreakerConfig struct {
+	Enabled          bool          `mapstructure:"enabled"`
+	FailureThreshold int           `mapstructure:"failure_threshold" validate:"gte=0"` // 連續失敗幾次後開啟
+	Cooldown         time.Duration `mapstructure:"cooldown" validate:"gte=0"`          // 開啟後多久進入半開狀態
+}
+
+// HedgeConfig 對沖請求設定
+// 第一個請求超過 Delay 未回應時，再送出一個相同請求並採用先回應者
+type HedgeConfig struct {
+	Enabled bool          `mapstructure:"enabled"`
+	Delay   time.Duration `mapstructure:"delay" validate:"gte=0"`
+}
+
+// LoaderConfig 來源載入器設定
+type LoaderConfig struct {
+	Profiles    []LoaderProfileConfig `mapstructure:"profiles" validate:"dive"`
+	SourceCache SourceCacheConfig     `mapstructure:"source_cache"`
+}
+
+// SourceCacheConfig 遠端來源快取設定
+// 將來源圖片與 ETag/Last-Modified 保存於 Storage，過期後以條件式請求重新驗證
+type SourceCacheConfig struct {
+	Enabled           bool          `mapstructure:"enabled"`
+	DefaultTTL        time.Duration `mapstructure:"default_ttl" validate:"gte=0"` // 來源未提供 Cache-Control/Expires 時的有效期限
+	MaxTTL            time.Duration `mapstructure:"max_ttl" validate:"gte=0"`     // 有效期限上限（0 表示不限制）
+	ServeStaleOnError bool          `mapstructure:"serve_stale_on_error"`         // 重新驗證失敗時回傳過期內容
+}
+
+// LoaderProfileConfig 來源主機 Profile 設定
+// 依 Hosts（支援 *.example.com）比對來源 URL，第一個符合的 Profile 生效
+type LoaderProfileConfig struct {
+	Name           string            `mapstructure:"name" validate:"required"`
+	Hosts          []string          `mapstructure:"hosts" validate:"required,min=1"`
+	UserAgent      string            `mapstructure:"user_agent"`
+	Headers        map[string]string `mapstructure:"headers"`
+	Auth           LoaderAuthConfig  `mapstructure:"auth"`
+	ForwardHeaders []string          `mapstructure:"forward_headers"` // 從原始請求轉發的標頭（例如 Cookie）
+	TLS            LoaderTLSConfig   `mapstructure:"tls"`
+	Timeout        time.Duration     `mapstructure:"timeout"`
+	MaxSize        int64             `mapstructure:"max_size" validate:"gte=0"`
+}
+
+// LoaderAuthConfig 來源驗證設定
+type LoaderAuthConfig struct {
+	Type     string `mapstructure:"type" validate:"omitempty,oneof=bearer basic"`
+	Token    string `mapstructure:"token" validate:"required_if=Type bearer"`
+	Username string `mapstructure:"username" validate:"required_if=Type basic"`
+	Password string `mapstructure:"password"`
+}
+
+// LoaderTLSConfig 來源 mTLS 設定
+type LoaderTLSConfig struct {
+	CertFile string `mapstructure:"cert_file" validate:"required_with=KeyFile"`
+	KeyFile  string `mapstructure:"key_file" validate:"required_with=CertFile"`
+	CAFile   string `mapstructure:"ca_file"`
 }
 
 // BlindWatermarkConfig 隱形浮水印設定
 type BlindWatermarkConfig struct {
 	Enabled     bool   `mapstructure:"enabled"`
-	SecurityKey string `mapstructure:"security_key"`
-	Text        string `mapstructure:"text"`
+	SecurityKey string `mapstructure:"security_key"` // 浮水印圖樣金鑰，未設定時使用 security.security_key
+	Text        string `mapstructure:"text"`         // 超過 BlindWatermarkMaxTextBytes 時於載入時截斷
 }
 
+// BlindWatermarkMaxTextBytes 隱形浮水印文字的最大長度（bytes），與浮水印 payload 的容量一致
+const BlindWatermarkMaxTextBytes = 13
+
 // SwaggerConfig Swagger UI 設定
 type SwaggerConfig struct {
 	Enabled  bool   `mapstructure:"enabled"`
@@ -74,16 +249,38 @@ type SecurityConfig struct {
 	SecurityKey    string   `mapstructure:"security_key" validate:"required_if=Enabled true,omitempty,min=16"`
 	AllowUnsafe    bool     `mapstructure:"allow_unsafe"`
 	AllowedSources []string `mapstructure:"allowed_sources"`
-	MaxWidth       int      `mapstructure:"max_width" validate:"omitempty,min=1,max=16384"`
-	MaxHeight      int      `mapstructure:"max_height" validate:"omitempty,min=1,max=16384"`
+	// AllowPrivateNetworks 允許 HTTP 載入器連線至私有、回環與鏈路本地位址（僅供開發使用）
+	AllowPrivateNetworks bool `mapstructure:"allow_private_networks"`
+	// MaxRedirects HTTP 載入器最大重導向次數，每次重導向皆重新驗證 AllowedSources
+	MaxRedirects int           `mapstructure:"max_redirects" validate:"gte=0,lte=20"`
+	MaxWidth     int           `mapstructure:"max_width" validate:"omitempty,min=1,max=16384"`
+	MaxHeight    int           `mapstructure:"max_height" validate:"omitempty,min=1,max=16384"`
+	APIKeys      APIKeysConfig `mapstructure:"api_keys"`
+}
+
+// APIKeysConfig API 金鑰設定
+// 啟用後上傳、檢測、清除與簽名端點改用具權限範圍的 API 金鑰驗證
+type APIKeysConfig struct {
+	Enabled    bool   `mapstructure:"enabled"`
+	Store      string `mapstructure:"store" validate:"required_if=Enabled true,omitempty,oneof=file storage"`
+	FilePath   string `mapstructure:"file_path" validate:"required_if=Store file"`
+	StorageKey string `mapstructure:"storage_key" validate:"required_if=Store storage"`
+	// Storage 保存金鑰清單的專用儲存（store 為 storage 時必填），與圖片儲存分開，避免金鑰清單可經由圖片路徑存取
+	Storage         *StorageConfig `mapstructure:"storage" validate:"required_if=Store storage,omitempty"`
+	RefreshInterval time.Duration  `mapstructure:"refresh_interval"` // 金鑰清單重新載入間隔（0 或負值於載入時改為 DefaultAPIKeyRefreshInterval）
 }
 
+// DefaultAPIKeyRefreshInterval 金鑰清單預設的重新載入間隔
+const DefaultAPIKeyRefreshInterval = 30 * time.Second
+
 // StorageConfig 儲存設定
 type StorageConfig struct {
-	Type  string             `mapstructure:"type" validate:"required,oneof=local s3 no_storage mixed"`
-	Local LocalStorageConfig `mapstructure:"local"`
-	S3    S3StorageConfig    `mapstructure:"s3"`
-	Mixed MixedStorageConfig `mapstructure:"mixed"`
+	Type   string                 `mapstructure:"type" validate:"required,oneof=local s3 gcs azblob no_storage mixed"`
+	Local  LocalStorageConfig     `mapstructure:"local"`
+	S3     S3StorageConfig        `mapstructure:"s3"`
+	GCS    GCSStorageConfig       `mapstructure:"gcs"`
+	AzBlob AzureBlobStorageConfig `mapstructure:"azblob"`
+	Mixed  MixedStorageConfig     `mapstructure:"mixed"`
 }
 
 // LocalStorageConfig 本地儲存設定
@@ -100,18 +297,50 @@ type S3StorageConfig struct {
 	Endpoint  string `mapstructure:"endpoint"`
 }
 
+// GCSStorageConfig Google Cloud Storage 儲存設定
+type GCSStorageConfig struct {
+	Bucket          string `mapstructure:"bucket" validate:"required_if=Type gcs"`
+	CredentialsFile string `mapstructure:"credentials_file"` // 服務帳戶金鑰檔，空值使用 Application Default Credentials
+	Endpoint        string `mapstructure:"endpoint"`         // 自訂端點（例如 fake-gcs-server），未設定金鑰檔時以匿名存取
+}
+
+// AzureBlobStorageConfig Azure Blob Storage 儲存設定
+type AzureBlobStorageConfig struct {
+	Container        string `mapstructure:"container" validate:"required_if=Type azblob"`
+	AccountName      string `mapstructure:"account_name"`
+	AccountKey       string `mapstructure:"account_key"`
+	ConnectionString string `mapstructure:"connection_string"` // 優先於帳戶名稱與金鑰（例如 Azurite）
+	Endpoint         string `mapstructure:"endpoint"`          // 服務 URL，預設 https://{account_name}.blob.core.windows.net/
+}
+
 // MixedStorageConfig 混合儲存設定
 type MixedStorageConfig struct {
-	SourceStorage string `mapstructure:"source_storage" validate:"required_if=Type mixed,omitempty,oneof=local s3"`
-	ResultStorage string `mapstructure:"result_storage" validate:"required_if=Type mixed,omitempty,oneof=local s3"`
+	SourceStorage string `mapstructure:"source_storage" validate:"required_if=Type mixed,omitempty,oneof=local s3 gcs azblob"`
+	ResultStorage string `mapstructure:"result_storage" validate:"required_if=Type mixed,omitempty,oneof=local s3 gcs azblob"`
 }
 
 // CacheConfig 快取設定
 type CacheConfig struct {
 	Enabled bool              `mapstructure:"enabled"`
-	Type    string            `mapstructure:"type" validate:"required_if=Enabled true,omitempty,oneof=redis memory"`
+	Type    string            `mapstructure:"type" validate:"required_if=Enabled true,omitempty,oneof=redis memory tiered"`
 	Redis   RedisCacheConfig  `mapstructure:"redis"`
 	Memory  MemoryCacheConfig `mapstructure:"memory"`
+	Tiered  TieredCacheConfig `mapstructure:"tiered"`
+
+	SourceVersion SourceVersionConfig `mapstructure:"source_version"`
+}
+
+// SourceVersionConfig 來源版本感知的快取鍵設定
+// 啟用後快取鍵包含來源版本（S3 VersionId/ETag、本地檔案修改時間與大小、HTTP ETag），
+// 來源被覆寫時快取與儲存層結果自動失效
+type SourceVersionConfig struct {
+	Enabled       bool          `mapstructure:"enabled"`
+	CheckInterval time.Duration `mapstructure:"check_interval" validate:"gte=0"` // 同一來源版本的重新檢查間隔（0 表示每次請求都檢查）
+}
+
+// TieredCacheConfig 兩層快取設定（L1 使用 memory 設定，L2 使用 redis 設定）
+type TieredCacheConfig struct {
+	L1TTL int `mapstructure:"l1_ttl" validate:"omitempty,min=1"` // L1 最長存活時間（秒），應遠短於 L2
 }
 
 // RedisCacheConfig Redis 快取設定
@@ -207,6 +436,9 @@ func Load(configPath string) (*Config, error) {
 		return nil, fmt.Errorf("failed to parse config: %w", err)
 	}
 
+	truncateBlindWatermarkText(&cfg)
+	defaultAPIKeyRefreshInterval(&cfg)
+
 	// 使用 validator 驗證設定結構體
 	if err := ValidateConfig(&cfg); err != nil {
 		return nil, err
@@ -226,6 +458,36 @@ func Load(configPath string) (*Config, error) {
 	return &cfg, nil
 }
 
+// truncateBlindWatermarkText 將過長的浮水印文字截斷至 BlindWatermarkMaxTextBytes（不切斷 UTF-8 字元）
+// 舊版允許 16 bytes，截斷並警告而非驗證失敗，避免既有設定無法啟動
+// Note: This runs before zlogger is initialized, so the warning is output to stderr
+func truncateBlindWatermarkText(cfg *Config) {
+	text := cfg.BlindWatermark.Text
+	if len(text) <= BlindWatermarkMaxTextBytes {
+		return
+	}
+	n := BlindWatermarkMaxTextBytes
+	for n > 0 && !utf8.RuneStart(text[n]) {
+		n--
+	}
+	cfg.BlindWatermark.Text = text[:n]
+	fmt.Fprintf(os.Stderr, "[WARN] blind_watermark.text exceeds %d bytes, truncated to %q\n", BlindWatermarkMaxTextBytes, cfg.BlindWatermark.Text)
+}
+
+// defaultAPIKeyRefreshInterval 將非正值的 refresh_interval 改為 DefaultAPIKeyRefreshInterval
+// 間隔為 0 時 KeyRing 會在每次驗證時同步重新載入，每個請求都讀取一次後端
+// Note: This runs before zlogger is initialized, so the warning is output to stderr
+func defaultAPIKeyRefreshInterval(cfg *Config) {
+	keys := &cfg.Security.APIKeys
+	if keys.RefreshInterval > 0 {
+		return
+	}
+	if keys.Enabled {
+		fmt.Fprintf(os.Stderr, "[WARN] security.api_keys.refresh_interval %s is not positive, using %s\n", keys.RefreshInterval, DefaultAPIKeyRefreshInterval)
+	}
+	keys.RefreshInterval = DefaultAPIKeyRefreshInterval
+}
+
 // ValidateConfig validates config struct using validator
 // Note: This runs before zlogger is initialized, so errors are output to stderr
 func ValidateConfig(cfg *Config) error {
@@ -296,8 +558,15 @@ func setDefaults(v *viper.Viper) {
 	v.SetDefault("security.allow_unsafe", true)
 	v.SetDefault("security.security_key", "")
 	v.SetDefault("security.allowed_sources", []string{})
+	v.SetDefault("security.allow_private_networks", false)
+	v.SetDefault("security.max_redirects", 5)
 	v.SetDefault("security.max_width", 4096)
 	v.SetDefault("security.max_height", 4096)
+	v.SetDefault("security.api_keys.enabled", false)
+	v.SetDefault("security.api_keys.store", "file")
+	v.SetDefault("security.api_keys.file_path", "./config/api_keys.json")
+	v.SetDefault("security.api_keys.storage_key", "security/api_keys.json")
+	v.SetDefault("security.api_keys.refresh_interval", DefaultAPIKeyRefreshInterval.String())
 
 	// Storage 預設值
 	v.SetDefault("storage.type", "local")
@@ -322,6 +591,9 @@ func setDefaults(v *viper.Viper) {
 	v.SetDefault("cache.redis.tls.enabled", false)
 	v.SetDefault("cache.memory.max_size", 536870912) // 512MB
 	v.SetDefault("cache.memory.ttl", 3600)
+	v.SetDefault("cache.tiered.l1_ttl", 60)
+	v.SetDefault("cache.source_version.enabled", false)
+	v.SetDefault("cache.source_version.check_interval", "10s")
 
 	// Logging 預設值
 	v.SetDefault("logging.level", "info")
@@ -345,6 +617,56 @@ func setDefaults(v *viper.Viper) {
 	v.SetDefault("blind_watermark.enabled", true)
 	v.SetDefault("blind_watermark.security_key", "")
 	v.SetDefault("blind_watermark.text", "")
+
+	// Loader 預設值
+	v.SetDefault("loader.source_cache.enabled", false)
+	v.SetDefault("loader.source_cache.default_ttl", "1h")
+	v.SetDefault("loader.source_cache.max_ttl", "24h")
+	v.SetDefault("loader.source_cache.serve_stale_on_error", true)
+
+	// Purge 預設值
+	v.SetDefault("purge.enabled", false)
+	v.SetDefault("purge.index", "storage")
+
+	// Upload 預設值
+	v.SetDefault("upload.content_addressable", false)
+	v.SetDefault("upload.max_width", 0)
+	v.SetDefault("upload.max_height", 0)
+	v.SetDefault("upload.max_pixels", 50000000)
+	v.SetDefault("upload.reencode", false)
+	v.SetDefault("upload.batch.max_files", 200)
+	v.SetDefault("upload.batch.concurrency", 4)
+	v.SetDefault("upload.batch.max_file_size", 52428800)
+	v.SetDefault("upload.batch.max_archive_size", 524288000)
+	v.SetDefault("upload.resumable.enabled", false)
+	v.SetDefault("upload.resumable.max_size", 1073741824)
+	v.SetDefault("upload.resumable.max_chunk_size", 8388608)
+	v.SetDefault("upload.resumable.expiry", "24h")
+	v.SetDefault("upload.resumable.cleanup_interval", "1h")
+	v.SetDefault("upload.resumable.staging_prefix", "staging/resumable")
+	v.SetDefault("upload.variants.max_per_upload", 10)
+	v.SetDefault("upload.variants.max_pending", 64)
+
+	// Filters 預設值
+	v.SetDefault("filters.text.font_dir", "")
+	v.SetDefault("filters.text.default_font", "go")
+	v.SetDefault("filters.watermark.cache_max_size", 67108864) // 64MB
+	v.SetDefault("filters.watermark.cache_ttl", "10m")
+	v.SetDefault("filters.watermark.timeout", "10s")
+	v.SetDefault("filters.watermark.fail_open", true)
+
+	// Resilience 預設值
+	v.SetDefault("resilience.loader.retry.max_attempts", 3)
+	v.SetDefault("resilience.loader.retry.base_delay", "100ms")
+	v.SetDefault("resilience.loader.retry.max_delay", "2s")
+	v.SetDefault("resilience.loader.circuit_breaker.enabled", true)
+	v.SetDefault("resilience.loader.circuit_breaker.failure_threshold", 5)
+	v.SetDefault("resilience.loader.circuit_breaker.cooldown", "30s")
+	v.SetDefault("resilience.loader.hedge.enabled", false)
+	v.SetDefault("resilience.loader.hedge.delay", "200ms")
+	v.SetDefault("resilience.storage.retry.max_attempts", 3)
+	v.SetDefault("resilience.storage.retry.base_delay", "50ms")
+	v.SetDefault("resilience.storage.retry.max_delay", "1s")
 }
 
 // GetAddress 取得服務器監聽地址
@@ -4,6 +4,7 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 )
 
 // TestLoad 測試設定載入
@@ -59,6 +60,63 @@ logging:
 	}
 }
 
+// TestLoad_TruncatesBlindWatermarkText 測試過長的浮水印文字於載入時截斷而非驗證失敗
+func TestLoad_TruncatesBlindWatermarkText(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"COPYRIGHT", "COPYRIGHT"},
+		{"COPYRIGHT-2024-ACME", "COPYRIGHT-202"},
+		{"版權所有不得轉載", "版權所有"}, // 不切斷 UTF-8 字元
+	}
+
+	for _, tt := range tests {
+		configPath := filepath.Join(t.TempDir(), "config.yaml")
+		content := "blind_watermark:\n  text: \"" + tt.text + "\"\n"
+		if err := os.WriteFile(configPath, []byte(content), 0644); err != nil {
+			t.Fatalf("無法建立測試設定檔: %v", err)
+		}
+
+		cfg, err := Load(configPath)
+		if err != nil {
+			t.Fatalf("載入設定失敗: %v", err)
+		}
+		if cfg.BlindWatermark.Text != tt.want {
+			t.Errorf("BlindWatermark.Text = %q; want %q", cfg.BlindWatermark.Text, tt.want)
+		}
+	}
+}
+
+// TestLoad_DefaultsAPIKeyRefreshInterval 測試非正值的金鑰重新載入間隔改為預設值
+func TestLoad_DefaultsAPIKeyRefreshInterval(t *testing.T) {
+	tests := []struct {
+		interval string
+		want     time.Duration
+	}{
+		{"0", DefaultAPIKeyRefreshInterval},
+		{"0s", DefaultAPIKeyRefreshInterval},
+		{"-5s", DefaultAPIKeyRefreshInterval},
+		{"5m", 5 * time.Minute},
+	}
+
+	for _, tt := range tests {
+		configPath := filepath.Join(t.TempDir(), "config.yaml")
+		content := "security:\n  api_keys:\n    enabled: true\n    store: file\n    file_path: keys.json\n    refresh_interval: \"" + tt.interval + "\"\n"
+		if err := os.WriteFile(configPath, []byte(content), 0644); err != nil {
+			t.Fatalf("無法建立測試設定檔: %v", err)
+		}
+
+		cfg, err := Load(configPath)
+		if err != nil {
+			t.Fatalf("載入設定失敗: %v", err)
+		}
+		if got := cfg.Security.APIKeys.RefreshInterval; got != tt.want {
+			t.Errorf("refresh_interval %q = %v; want %v", tt.interval, got, tt.want)
+		}
+	}
+}
+
 // TestLoadDefaults 測試預設值載入
 func TestLoadDefaults(t *testing.T) {
 	// 建立空的臨時設定檔
@@ -137,6 +195,64 @@ logging:
 			config: `
 storage:
   type: "invalid"
+`,
+			wantError: true,
+		},
+		{
+			name: "valid loader profile",
+			config: `
+loader:
+  profiles:
+    - name: "origin"
+      hosts: ["assets.example.com"]
+      auth:
+        type: "bearer"
+        token: "abc"
+`,
+			wantError: false,
+		},
+		{
+			name: "loader profile without hosts",
+			config: `
+loader:
+  profiles:
+    - name: "origin"
+`,
+			wantError: true,
+		},
+		{
+			name: "loader profile bearer without token",
+			config: `
+loader:
+  profiles:
+    - name: "origin"
+      hosts: ["assets.example.com"]
+      auth:
+        type: "bearer"
+`,
+			wantError: true,
+		},
+		{
+			name: "api keys in dedicated storage",
+			config: `
+security:
+  api_keys:
+    enabled: true
+    store: "storage"
+    storage:
+      type: "local"
+      local:
+        root_path: "./data/keys"
+`,
+			wantError: false,
+		},
+		{
+			name: "api keys storage without dedicated storage",
+			config: `
+security:
+  api_keys:
+    enabled: true
+    store: "storage"
 `,
 			wantError: true,
 		},
@@ -0,0 +1,469 @@
+package filter
+
+import (
+	"fmt"
+	"image"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/disintegration/imaging"
+)
+
+// channelLUT 各色版（R、G、B）的查表
+type channelLUT [3][256]uint8
+
+// identityLUT 建立不改變顏色的查表
+func identityLUT() *channelLUT {
+	var lut channelLUT
+	for c := range lut {
+		for i := range lut[c] {
+			lut[c][i] = uint8(i)
+		}
+	}
+	return &lut
+}
+
+// applyLUT 以查表轉換圖片的 RGB 色版，保留透明度
+func applyLUT(img image.Image, lut *channelLUT) *image.NRGBA {
+	result := imaging.Clone(img)
+	applyLUTInPlace(result, lut)
+	return result
+}
+
+// applyLUTInPlace 以查表直接轉換圖片
+func applyLUTInPlace(img *image.NRGBA, lut *channelLUT) {
+	for y := range img.Rect.Dy() {
+		row := img.Pix[y*img.Stride : y*img.Stride+img.Rect.Dx()*4]
+		for i := 0; i < len(row); i += 4 {
+			row[i] = lut[0][row[i]]
+			row[i+1] = lut[1][row[i+1]]
+			row[i+2] = lut[2][row[i+2]]
+		}
+	}
+}
+
+// levelsCurve 將 [black, white] 線性映射至 [0, 255] 後套用 gamma
+func levelsCurve(black, white, gamma float64) [256]uint8 {
+	var curve [256]uint8
+	for i := range curve {
+		v := clamp((float64(i)-black)/math.Max(white-black, 1), 0, 1)
+		curve[i] = clip(255 * math.Pow(v, 1/gamma))
+	}
+	return curve
+}
+
+// LevelsFilter 色階濾鏡
+type LevelsFilter struct{}
+
+// NewLevelsFilter 建立色階濾鏡
+func NewLevelsFilter() *LevelsFilter {
+	return &LevelsFilter{}
+}
+
+// Name 返回濾鏡名稱
+func (f *LevelsFilter) Name() string {
+	return "levels"
+}
+
+// Apply 應用色階調整
+// params[0]: black point (0 ~ 254, 預設 0)
+// params[1]: white point (1 ~ 255, 預設 255)
+// params[2]: gamma (0.1 ~ 10, 預設 1.0，大於 1 時中間調變亮)
+func (f *LevelsFilter) Apply(img image.Image, params []string) (image.Image, error) {
+	black, white, gamma := 0.0, 255.0, 1.0
+	if len(params) > 0 {
+		if v, err := strconv.ParseFloat(params[0], 64); err == nil {
+			black = clamp(v, 0, 254)
+		}
+	}
+	if len(params) > 1 {
+		if v, err := strconv.ParseFloat(params[1], 64); err == nil {
+			white = clamp(v, 1, 255)
+		}
+	}
+	if len(params) > 2 {
+		if v, err := strconv.ParseFloat(params[2], 64); err == nil && v > 0 {
+			gamma = clamp(v, 0.1, 10)
+		}
+	}
+	if white <= black {
+		return nil, fmt.Errorf("levels white point must be greater than black point")
+	}
+
+	curve := levelsCurve(black, white, gamma)
+	return applyLUT(img, &channelLUT{curve, curve, curve}), nil
+}
+
+// CurvesFilter 曲線濾鏡
+type CurvesFilter struct{}
+
+// NewCurvesFilter 建立曲線濾鏡
+func NewCurvesFilter() *CurvesFilter {
+	return &CurvesFilter{}
+}
+
+// Name 返回濾鏡名稱
+func (f *CurvesFilter) Name() string {
+	return "curves"
+}
+
+// Apply 應用曲線調整
+// params[0]: channel (rgb、r、g、b，可省略，預設 rgb)
+// params[1:]: 控制點 x:y（0 ~ 255），至少兩個，以單調三次曲線插值
+// 例如 curves(r,0:0,128:150,255:255)
+func (f *CurvesFilter) Apply(img image.Image, params []string) (image.Image, error) {
+	channels := []int{0, 1, 2}
+	if len(params) > 0 && !strings.Contains(params[0], ":") {
+		switch strings.ToLower(params[0]) {
+		case "rgb":
+		case "r", "red":
+			channels = []int{0}
+		case "g", "green":
+			channels = []int{1}
+		case "b", "blue":
+			channels = []int{2}
+		default:
+			return nil, fmt.Errorf("invalid curves channel: %s", params[0])
+		}
+		params = params[1:]
+	}
+
+	points, err := parseCurvePoints(params)
+	if err != nil {
+		return nil, err
+	}
+	curve := monotoneCurve(points)
+
+	lut := identityLUT()
+	for _, c := range channels {
+		lut[c] = curve
+	}
+	return applyLUT(img, lut), nil
+}
+
+// curvePoint 曲線控制點
+type curvePoint struct {
+	x, y float64
+}
+
+// parseCurvePoints 解析 x:y 控制點，依 x 排序，x 重複時後者取代前者
+func parseCurvePoints(params []string) ([]curvePoint, error) {
+	byX := make(map[float64]float64)
+	for _, p := range params {
+		xs, ys, ok := strings.Cut(p, ":")
+		if !ok {
+			return nil, fmt.Errorf("invalid curve point: %s", p)
+		}
+		x, errX := strconv.ParseFloat(xs, 64)
+		y, errY := strconv.ParseFloat(ys, 64)
+		if errX != nil || errY != nil {
+			return nil, fmt.Errorf("invalid curve point: %s", p)
+		}
+		byX[clamp(x, 0, 255)] = clamp(y, 0, 255)
+	}
+	if len(byX) < 2 {
+		return nil, fmt.Errorf("curves requires at least two control points")
+	}
+
+	points := make([]curvePoint, 0, len(byX))
+	for x, y := range byX {
+		points = append(points, curvePoint{x, y})
+	}
+	sort.Slice(points, func(i, j int) bool { return points[i].x < points[j].x })
+	return points, nil
+}
+
+// monotoneCurve 以單調三次 Hermite 插值（Fritsch-Carlson）產生曲線
+// 控制點之間不會超出相鄰點的範圍，第一點之前與最後一點之後維持端點值
+func monotoneCurve(points []curvePoint) [256]uint8 {
+	n := len(points)
+	// 各區間斜率
+	delta := make([]float64, n-1)
+	for i := range delta {
+		delta[i] = (points[i+1].y - points[i].y) / (points[i+1].x - points[i].x)
+	}
+	// 各點切線
+	m := make([]float64, n)
+	m[0], m[n-1] = delta[0], delta[n-2]
+	for i := 1; i < n-1; i++ {
+		if delta[i-1]*delta[i] <= 0 {
+			m[i] = 0
+		} else {
+			m[i] = (delta[i-1] + delta[i]) / 2
+		}
+	}
+	for i, d := range delta {
+		if d == 0 {
+			m[i], m[i+1] = 0, 0
+			continue
+		}
+		a, b := m[i]/d, m[i+1]/d
+		if s := a*a + b*b; s > 9 {
+			t := 3 / math.Sqrt(s)
+			m[i], m[i+1] = t*a*d, t*b*d
+		}
+	}
+
+	var curve [256]uint8
+	seg := 0
+	for i := range curve {
+		x := float64(i)
+		switch {
+		case x <= points[0].x:
+			curve[i] = clip(points[0].y)
+			continue
+		case x >= points[n-1].x:
+			curve[i] = clip(points[n-1].y)
+			continue
+		}
+		for x > points[seg+1].x {
+			seg++
+		}
+		p0, p1 := points[seg], points[seg+1]
+		h := p1.x - p0.x
+		t := (x - p0.x) / h
+		t2, t3 := t*t, t*t*t
+		y := (2*t3-3*t2+1)*p0.y + (t3-2*t2+t)*h*m[seg] + (-2*t3+3*t2)*p1.y + (t3-t2)*h*m[seg+1]
+		curve[i] = clip(y)
+	}
+	return curve
+}
+
+// VibranceFilter 自然飽和度濾鏡
+type VibranceFilter struct{}
+
+// NewVibranceFilter 建立自然飽和度濾鏡
+func NewVibranceFilter() *VibranceFilter {
+	return &VibranceFilter{}
+}
+
+// Name 返回濾鏡名稱
+func (f *VibranceFilter) Name() string {
+	return "vibrance"
+}
+
+// Apply 應用自然飽和度調整
+// 與 saturation 不同，飽和度越低的像素調整越多，已飽和的顏色幾乎不變
+// params[0]: amount (-100 ~ 100)
+func (f *VibranceFilter) Apply(img image.Image, params []string) (image.Image, error) {
+	amount := 0.0
+	if len(params) > 0 {
+		if v, err := strconv.ParseFloat(params[0], 64); err == nil {
+			amount = clamp(v, -100, 100) / 100
+		}
+	}
+	if amount == 0 {
+		return img, nil
+	}
+
+	result := imaging.Clone(img)
+	for y := range result.Rect.Dy() {
+		row := result.Pix[y*result.Stride : y*result.Stride+result.Rect.Dx()*4]
+		for i := 0; i < len(row); i += 4 {
+			r, g, b := float64(row[i]), float64(row[i+1]), float64(row[i+2])
+			hi := math.Max(r, math.Max(g, b))
+			if hi == 0 {
+				continue
+			}
+			sat := (hi - math.Min(r, math.Min(g, b))) / hi
+			scale := 1 + amount*(1-sat)
+			luma := 0.299*r + 0.587*g + 0.114*b
+			row[i] = clip(luma + (r-luma)*scale)
+			row[i+1] = clip(luma + (g-luma)*scale)
+			row[i+2] = clip(luma + (b-luma)*scale)
+		}
+	}
+	return result, nil
+}
+
+// whiteBalanceLUT 依色溫與色調（-1 ~ 1）建立查表
+// 各色版增益經正規化後維持亮度（BT.601 權重）不變
+func whiteBalanceLUT(temperature, tint float64) *channelLUT {
+	gain := [3]float64{1 + 0.2*temperature, 1, 1 - 0.2*temperature}
+	gain[0] *= 1 + 0.1*tint
+	gain[1] *= 1 - 0.2*tint
+	gain[2] *= 1 + 0.1*tint
+
+	norm := 0.299*gain[0] + 0.587*gain[1] + 0.114*gain[2]
+	var lut channelLUT
+	for c := range lut {
+		for i := range lut[c] {
+			lut[c][i] = clip(float64(i) * gain[c] / norm)
+		}
+	}
+	return &lut
+}
+
+// TemperatureFilter 色溫濾鏡
+type TemperatureFilter struct{}
+
+// NewTemperatureFilter 建立色溫濾鏡
+func NewTemperatureFilter() *TemperatureFilter {
+	return &TemperatureFilter{}
+}
+
+// Name 返回濾鏡名稱
+func (f *TemperatureFilter) Name() string {
+	return "temperature"
+}
+
+// Apply 應用色溫調整
+// params[0]: amount (-100 ~ 100，正值偏暖（黃），負值偏冷（藍）)
+func (f *TemperatureFilter) Apply(img image.Image, params []string) (image.Image, error) {
+	amount := 0.0
+	if len(params) > 0 {
+		if v, err := strconv.ParseFloat(params[0], 64); err == nil {
+			amount = clamp(v, -100, 100) / 100
+		}
+	}
+	if amount == 0 {
+		return img, nil
+	}
+	return applyLUT(img, whiteBalanceLUT(amount, 0)), nil
+}
+
+// TintFilter 色調濾鏡
+type TintFilter struct{}
+
+// NewTintFilter 建立色調濾鏡
+func NewTintFilter() *TintFilter {
+	return &TintFilter{}
+}
+
+// Name 返回濾鏡名稱
+func (f *TintFilter) Name() string {
+	return "tint"
+}
+
+// Apply 應用色調調整
+// params[0]: amount (-100 ~ 100，正值偏洋紅，負值偏綠)
+func (f *TintFilter) Apply(img image.Image, params []string) (image.Image, error) {
+	amount := 0.0
+	if len(params) > 0 {
+		if v, err := strconv.ParseFloat(params[0], 64); err == nil {
+			amount = clamp(v, -100, 100) / 100
+		}
+	}
+	if amount == 0 {
+		return img, nil
+	}
+	return applyLUT(img, whiteBalanceLUT(0, amount)), nil
+}
+
+// defaultClipPercent 自動調整時每端忽略的像素比例（%）
+const defaultClipPercent = 0.5
+
+// parseClipPercent 解析自動調整的裁切比例（0 ~ 10%）
+func parseClipPercent(params []string) float64 {
+	if len(params) > 0 {
+		if v, err := strconv.ParseFloat(params[0], 64); err == nil {
+			return clamp(v, 0, 10)
+		}
+	}
+	return defaultClipPercent
+}
+
+// histogramRange 取得直方圖忽略兩端 clip% 像素後的最小與最大值
+func histogramRange(hist *[256]int, total int, clip float64) (lo, hi int) {
+	limit := int(float64(total) * clip / 100)
+	for sum := 0; lo < 255; lo++ {
+		if sum += hist[lo]; sum > limit {
+			break
+		}
+	}
+	hi = 255
+	for sum := 0; hi > 0; hi-- {
+		if sum += hist[hi]; sum > limit {
+			break
+		}
+	}
+	return lo, hi
+}
+
+// stretchCurve 將 [lo, hi] 線性拉伸至 [0, 255]，範圍無效時不調整
+func stretchCurve(lo, hi int) [256]uint8 {
+	if hi <= lo {
+		return identityLUT()[0]
+	}
+	return levelsCurve(float64(lo), float64(hi), 1)
+}
+
+// histograms 計算不透明像素的亮度與各色版直方圖
+func histograms(img *image.NRGBA) (luma *[256]int, channels *[3][256]int, total int) {
+	luma, channels = new([256]int), new([3][256]int)
+	for y := range img.Rect.Dy() {
+		row := img.Pix[y*img.Stride : y*img.Stride+img.Rect.Dx()*4]
+		for i := 0; i < len(row); i += 4 {
+			if row[i+3] == 0 {
+				continue
+			}
+			r, g, b := row[i], row[i+1], row[i+2]
+			luma[clip(0.299*float64(r)+0.587*float64(g)+0.114*float64(b))]++
+			channels[0][r]++
+			channels[1][g]++
+			channels[2][b]++
+			total++
+		}
+	}
+	return luma, channels, total
+}
+
+// AutoContrastFilter 自動對比濾鏡
+type AutoContrastFilter struct{}
+
+// NewAutoContrastFilter 建立自動對比濾鏡
+func NewAutoContrastFilter() *AutoContrastFilter {
+	return &AutoContrastFilter{}
+}
+
+// Name 返回濾鏡名稱
+func (f *AutoContrastFilter) Name() string {
+	return "auto_contrast"
+}
+
+// Apply 依亮度直方圖拉伸對比，所有色版使用相同曲線，不改變色彩平衡
+// params[0]: clip (每端忽略的像素比例 0 ~ 10%，預設 0.5)
+func (f *AutoContrastFilter) Apply(img image.Image, params []string) (image.Image, error) {
+	src := imaging.Clone(img)
+	luma, _, total := histograms(src)
+	if total == 0 {
+		return src, nil
+	}
+	lo, hi := histogramRange(luma, total, parseClipPercent(params))
+	curve := stretchCurve(lo, hi)
+	applyLUTInPlace(src, &channelLUT{curve, curve, curve})
+	return src, nil
+}
+
+// AutoLevelFilter 自動色階濾鏡
+type AutoLevelFilter struct{}
+
+// NewAutoLevelFilter 建立自動色階濾鏡
+func NewAutoLevelFilter() *AutoLevelFilter {
+	return &AutoLevelFilter{}
+}
+
+// Name 返回濾鏡名稱
+func (f *AutoLevelFilter) Name() string {
+	return "auto_level"
+}
+
+// Apply 依各色版直方圖分別拉伸，同時修正色偏
+// params[0]: clip (每端忽略的像素比例 0 ~ 10%，預設 0.5)
+func (f *AutoLevelFilter) Apply(img image.Image, params []string) (image.Image, error) {
+	src := imaging.Clone(img)
+	_, channels, total := histograms(src)
+	if total == 0 {
+		return src, nil
+	}
+	clipPercent := parseClipPercent(params)
+	var lut channelLUT
+	for c := range lut {
+		lo, hi := histogramRange(&channels[c], total, clipPercent)
+		lut[c] = stretchCurve(lo, hi)
+	}
+	applyLUTInPlace(src, &lut)
+	return src, nil
+}
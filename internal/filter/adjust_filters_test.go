@@ -0,0 +1,217 @@
+package filter
+
+import (
+	"flag"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var updateGolden = flag.Bool("update", false, "update golden images in testdata/golden")
+
+// newAdjustTestImage 建立色相（水平）、飽和度與亮度（垂直）漸層的測試圖片
+// 亮度限制在 40-200 之間並略為偏藍，供自動調整濾鏡測試
+func newAdjustTestImage() *image.NRGBA {
+	const w, h = 64, 48
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for y := range h {
+		for x := range w {
+			hue := float64(x) / w
+			sat := 1 - float64(y%24)/24
+			light := 0.25 + 0.5*float64(y)/h
+			r, g, b := hslToRGB(hue, sat, light)
+			img.SetNRGBA(x, y, color.NRGBA{
+				R: uint8(40 + int(r)*150/255),
+				G: uint8(40 + int(g)*150/255),
+				B: uint8(50 + int(b)*150/255),
+				A: 255,
+			})
+		}
+	}
+	return img
+}
+
+// assertGolden 比對 testdata/golden/<name>.png（允許 ±1 的誤差），-update 時重新產生
+func assertGolden(t *testing.T, name string, img image.Image) {
+	t.Helper()
+	path := filepath.Join("testdata", "golden", name+".png")
+
+	if *updateGolden {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		out, err := os.Create(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer out.Close()
+		if err := png.Encode(out, img); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	in, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("missing golden image (run go test -update): %v", err)
+	}
+	defer in.Close()
+	golden, err := png.Decode(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if golden.Bounds() != img.Bounds() {
+		t.Fatalf("bounds = %v, want %v", img.Bounds(), golden.Bounds())
+	}
+	b := img.Bounds()
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			got := color.NRGBAModel.Convert(img.At(x, y)).(color.NRGBA)
+			want := color.NRGBAModel.Convert(golden.At(x, y)).(color.NRGBA)
+			if absDiff(got.R, want.R) > 1 || absDiff(got.G, want.G) > 1 || absDiff(got.B, want.B) > 1 || got.A != want.A {
+				t.Fatalf("pixel (%d, %d) = %v, want %v", x, y, got, want)
+			}
+		}
+	}
+}
+
+func absDiff(a, b uint8) uint8 {
+	if a > b {
+		return a - b
+	}
+	return b - a
+}
+
+func TestAdjustFilters_Golden(t *testing.T) {
+	src := newAdjustTestImage()
+
+	tests := []struct {
+		golden string
+		filter Filter
+		params []string
+	}{
+		{"levels", NewLevelsFilter(), []string{"30", "220", "1.4"}},
+		{"curves_rgb", NewCurvesFilter(), []string{"0:0", "64:48", "192:216", "255:255"}},
+		{"curves_red", NewCurvesFilter(), []string{"r", "0:20", "128:160", "255:255"}},
+		{"vibrance", NewVibranceFilter(), []string{"60"}},
+		{"vibrance_negative", NewVibranceFilter(), []string{"-60"}},
+		{"temperature_warm", NewTemperatureFilter(), []string{"50"}},
+		{"temperature_cool", NewTemperatureFilter(), []string{"-50"}},
+		{"tint", NewTintFilter(), []string{"40"}},
+		{"auto_contrast", NewAutoContrastFilter(), nil},
+		{"auto_level", NewAutoLevelFilter(), nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.golden, func(t *testing.T) {
+			result, err := tt.filter.Apply(src, tt.params)
+			if err != nil {
+				t.Fatalf("Apply failed: %v", err)
+			}
+			assertGolden(t, "adjust_"+tt.golden, result)
+		})
+	}
+}
+
+func TestLevelsFilter(t *testing.T) {
+	f := NewLevelsFilter()
+	if f.Name() != "levels" {
+		t.Fatalf("Expected name 'levels', got '%s'", f.Name())
+	}
+
+	curve := levelsCurve(50, 200, 1)
+	if curve[50] != 0 || curve[200] != 255 || curve[0] != 0 || curve[255] != 255 {
+		t.Errorf("unexpected end points: %d %d %d %d", curve[0], curve[50], curve[200], curve[255])
+	}
+	// gamma 大於 1 時中間調變亮
+	if bright := levelsCurve(0, 255, 2); bright[128] <= 128 {
+		t.Errorf("gamma 2 midtone = %d, want > 128", bright[128])
+	}
+
+	if _, err := f.Apply(createTestImage(4, 4), []string{"200", "100"}); err == nil {
+		t.Error("expected error when white point is below black point")
+	}
+}
+
+func TestCurvesFilter(t *testing.T) {
+	f := NewCurvesFilter()
+	if f.Name() != "curves" {
+		t.Fatalf("Expected name 'curves', got '%s'", f.Name())
+	}
+
+	// 對角線為恆等曲線
+	curve := monotoneCurve([]curvePoint{{0, 0}, {128, 128}, {255, 255}})
+	for i, v := range curve {
+		if int(v) != i {
+			t.Fatalf("identity curve[%d] = %d", i, v)
+		}
+	}
+
+	// 單調遞增的控制點產生單調遞增的曲線
+	curve = monotoneCurve([]curvePoint{{0, 0}, {30, 120}, {60, 130}, {255, 255}})
+	for i := 1; i < len(curve); i++ {
+		if curve[i] < curve[i-1] {
+			t.Fatalf("curve not monotone at %d: %d < %d", i, curve[i], curve[i-1])
+		}
+	}
+
+	for _, params := range [][]string{
+		nil,
+		{"0:0"},
+		{"x", "0:0", "255:255"},
+		{"0-0", "255:255"},
+	} {
+		if _, err := f.Apply(createTestImage(4, 4), params); err == nil {
+			t.Errorf("expected error for params %v", params)
+		}
+	}
+}
+
+func TestWhiteBalanceFilters(t *testing.T) {
+	gray := image.NewNRGBA(image.Rect(0, 0, 1, 1))
+	gray.SetNRGBA(0, 0, color.NRGBA{R: 128, G: 128, B: 128, A: 255})
+
+	warm, _ := NewTemperatureFilter().Apply(gray, []string{"60"})
+	if c := warm.(*image.NRGBA).NRGBAAt(0, 0); c.R <= c.B {
+		t.Errorf("warm result %v, want red above blue", c)
+	}
+	magenta, _ := NewTintFilter().Apply(gray, []string{"60"})
+	if c := magenta.(*image.NRGBA).NRGBAAt(0, 0); c.G >= c.R || c.G >= c.B {
+		t.Errorf("tint result %v, want green below red and blue", c)
+	}
+
+	// 數值為 0 時不處理
+	if out, _ := NewTemperatureFilter().Apply(gray, nil); out != image.Image(gray) {
+		t.Error("expected unchanged image for zero temperature")
+	}
+}
+
+func TestAutoFilters(t *testing.T) {
+	src := newAdjustTestImage()
+	for _, f := range []Filter{NewAutoContrastFilter(), NewAutoLevelFilter()} {
+		result, err := f.Apply(src, nil)
+		if err != nil {
+			t.Fatalf("%s: Apply failed: %v", f.Name(), err)
+		}
+		// 拉伸後涵蓋完整範圍
+		lo, hi := uint8(255), uint8(0)
+		out := result.(*image.NRGBA)
+		for i := 0; i < len(out.Pix); i += 4 {
+			lo = min(lo, out.Pix[i], out.Pix[i+1], out.Pix[i+2])
+			hi = max(hi, out.Pix[i], out.Pix[i+1], out.Pix[i+2])
+		}
+		if lo > 5 || hi < 250 {
+			t.Errorf("%s: range = [%d, %d], want full range", f.Name(), lo, hi)
+		}
+	}
+
+	// 透明圖片不處理
+	empty := image.NewNRGBA(image.Rect(0, 0, 4, 4))
+	if _, err := NewAutoLevelFilter().Apply(empty, nil); err != nil {
+		t.Errorf("unexpected error for transparent image: %v", err)
+	}
+}
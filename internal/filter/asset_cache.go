@@ -0,0 +1,112 @@
+package filter
+
+import (
+	"container/list"
+	"image"
+	"sync"
+	"time"
+)
+
+// assetCache 解碼後浮水印圖片的 LRU 快取
+// 以解碼後像素大小（寬 x 高 x 4 bytes）限制總量，項目超過存活時間後重新載入
+type assetCache struct {
+	mu       sync.Mutex
+	maxBytes int64
+	ttl      time.Duration
+	size     int64
+	ll       *list.List
+	items    map[string]*list.Element
+
+	now     func() time.Time
+	onEvict func()
+}
+
+// assetEntry 快取項目
+type assetEntry struct {
+	key       string
+	img       image.Image
+	size      int64
+	expiresAt time.Time
+}
+
+// newAssetCache 建立快取（ttl <= 0 表示不過期）
+func newAssetCache(maxBytes int64, ttl time.Duration) *assetCache {
+	return &assetCache{
+		maxBytes: maxBytes,
+		ttl:      ttl,
+		ll:       list.New(),
+		items:    make(map[string]*list.Element),
+		now:      time.Now,
+	}
+}
+
+// get 取得快取圖片，過期項目會被移除
+func (c *assetCache) get(key string) (image.Image, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.items[key]
+	if !ok {
+		return nil, false
+	}
+	entry := elem.Value.(*assetEntry)
+	if !entry.expiresAt.IsZero() && !c.now().Before(entry.expiresAt) {
+		c.remove(elem)
+		return nil, false
+	}
+	c.ll.MoveToFront(elem)
+	return entry.img, true
+}
+
+// add 加入快取，超過上限時淘汰最久未使用的項目
+// 單一圖片大於快取上限時不快取
+func (c *assetCache) add(key string, img image.Image) {
+	size := imageBytes(img)
+	if size > c.maxBytes {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.items[key]; ok {
+		c.remove(elem)
+	}
+	entry := &assetEntry{key: key, img: img, size: size}
+	if c.ttl > 0 {
+		entry.expiresAt = c.now().Add(c.ttl)
+	}
+	c.items[key] = c.ll.PushFront(entry)
+	c.size += size
+
+	for c.size > c.maxBytes {
+		oldest := c.ll.Back()
+		if oldest == nil {
+			break
+		}
+		c.remove(oldest)
+		if c.onEvict != nil {
+			c.onEvict()
+		}
+	}
+}
+
+// remove 移除項目（呼叫者需持有鎖）
+func (c *assetCache) remove(elem *list.Element) {
+	entry := c.ll.Remove(elem).(*assetEntry)
+	delete(c.items, entry.key)
+	c.size -= entry.size
+}
+
+// count 取得快取項目數量
+func (c *assetCache) count() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.ll.Len()
+}
+
+// imageBytes 估算解碼後圖片佔用的記憶體
+func imageBytes(img image.Image) int64 {
+	b := img.Bounds()
+	return int64(b.Dx()) * int64(b.Dy()) * 4
+}
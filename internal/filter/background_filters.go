@@ -0,0 +1,195 @@
+package filter
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/disintegration/imaging"
+)
+
+const (
+	// fillBlurSigma 模糊填充時背景的模糊程度（相對於畫布長邊的比例）
+	fillBlurSigma = 0.03
+	// fillBlurWorkSize 模糊填充時背景的處理尺寸上限（像素），先縮小再模糊後放大以降低運算量
+	fillBlurWorkSize = 256
+	// maxFillCanvasSize 填充畫布的寬高上限（像素，與處理設定的最大寬高上限相同）
+	maxFillCanvasSize = 16384
+)
+
+// FillFilter 填充濾鏡
+// 將圖片置中於指定大小的畫布，空白處以純色、邊緣主色或模糊放大的圖片填滿；
+// 透明區域同樣會被填滿
+type FillFilter struct{}
+
+// NewFillFilter 建立填充濾鏡
+func NewFillFilter() *FillFilter {
+	return &FillFilter{}
+}
+
+// Name 返回濾鏡名稱
+func (f *FillFilter) Name() string {
+	return "fill"
+}
+
+// Apply 應用填充
+// params[0]: 填充方式（十六進位顏色、transparent、blur 或 auto，預設 ffffff）
+// params[1]: 畫布寬度（可選，fit-in 時由服務帶入目標尺寸）
+// params[2]: 畫布高度（可選）
+func (f *FillFilter) Apply(img image.Image, params []string) (image.Image, error) {
+	mode := "ffffff"
+	if len(params) > 0 && params[0] != "" {
+		mode = strings.ToLower(params[0])
+	}
+
+	b := img.Bounds()
+	width, height := b.Dx(), b.Dy()
+	if len(params) > 2 {
+		w, errW := strconv.Atoi(params[1])
+		h, errH := strconv.Atoi(params[2])
+		if errW != nil || errH != nil || w <= 0 || h <= 0 || w > maxFillCanvasSize || h > maxFillCanvasSize {
+			return nil, fmt.Errorf("invalid fill canvas size: %sx%s", params[1], params[2])
+		}
+		// 畫布不小於圖片，避免裁切
+		width, height = max(w, width), max(h, height)
+	}
+
+	var canvas *image.NRGBA
+	if mode == "blur" {
+		canvas = blurredBackground(img, width, height)
+	} else {
+		c, ok := parseBackground(mode, img)
+		if !ok {
+			return nil, fmt.Errorf("invalid fill color: %s", params[0])
+		}
+		canvas = imaging.New(width, height, c)
+	}
+
+	offset := image.Point{X: (width - b.Dx()) / 2, Y: (height - b.Dy()) / 2}
+	draw.Draw(canvas, b.Sub(b.Min).Add(offset), img, b.Min, draw.Over)
+	return canvas, nil
+}
+
+// parseBackground 解析背景顏色：十六進位顏色、transparent 或 auto（圖片邊緣主色）
+func parseBackground(s string, img image.Image) (color.NRGBA, bool) {
+	switch strings.ToLower(s) {
+	case "transparent":
+		return color.NRGBA{}, true
+	case "auto":
+		return dominantEdgeColor(img), true
+	default:
+		return parseHexColor(s)
+	}
+}
+
+// blurredBackground 將圖片放大至覆蓋畫布後模糊，作為填充背景
+func blurredBackground(img image.Image, width, height int) *image.NRGBA {
+	// 以縮小的尺寸處理，模糊後再放大
+	scale := math.Min(1, float64(fillBlurWorkSize)/float64(max(width, height)))
+	sw, sh := max(int(float64(width)*scale), 1), max(int(float64(height)*scale), 1)
+
+	bg := imaging.Fill(img, sw, sh, imaging.Center, imaging.Linear)
+	bg = imaging.Blur(bg, fillBlurSigma*float64(max(sw, sh)))
+	bg = imaging.Resize(bg, width, height, imaging.Linear)
+
+	// 背景需完全不透明
+	for i := 3; i < len(bg.Pix); i += 4 {
+		bg.Pix[i] = 255
+	}
+	return bg
+}
+
+// dominantEdgeColor 取得圖片邊緣最常見的顏色
+// 邊緣像素以每色版 4 位元分組，取數量最多的分組平均值；透明像素不列入計算
+func dominantEdgeColor(img image.Image) color.NRGBA {
+	src := imaging.Clone(img)
+	w, h := src.Rect.Dx(), src.Rect.Dy()
+	if w == 0 || h == 0 {
+		return color.NRGBA{R: 255, G: 255, B: 255, A: 255}
+	}
+
+	type bucket struct {
+		count   int
+		r, g, b int
+	}
+	buckets := make(map[int]*bucket)
+	add := func(x, y int) {
+		c := src.NRGBAAt(x, y)
+		if c.A < 128 {
+			return
+		}
+		key := int(c.R>>4)<<8 | int(c.G>>4)<<4 | int(c.B>>4)
+		bk, ok := buckets[key]
+		if !ok {
+			bk = &bucket{}
+			buckets[key] = bk
+		}
+		bk.count++
+		bk.r += int(c.R)
+		bk.g += int(c.G)
+		bk.b += int(c.B)
+	}
+	for x := range w {
+		add(x, 0)
+		add(x, h-1)
+	}
+	for y := 1; y < h-1; y++ {
+		add(0, y)
+		add(w-1, y)
+	}
+
+	var best *bucket
+	bestKey := 0
+	for key, bk := range buckets {
+		// 數量相同時取較小的鍵值，確保結果一致
+		if best == nil || bk.count > best.count || (bk.count == best.count && key < bestKey) {
+			best, bestKey = bk, key
+		}
+	}
+	if best == nil {
+		return color.NRGBA{R: 255, G: 255, B: 255, A: 255}
+	}
+	return color.NRGBA{
+		R: uint8(best.r / best.count),
+		G: uint8(best.g / best.count),
+		B: uint8(best.b / best.count),
+		A: 255,
+	}
+}
+
+// BackgroundColorFilter 背景色濾鏡
+// 將透明區域以指定顏色填滿，輸出不透明的圖片（例如 PNG 轉為 JPEG 時）
+type BackgroundColorFilter struct{}
+
+// NewBackgroundColorFilter 建立背景色濾鏡
+func NewBackgroundColorFilter() *BackgroundColorFilter {
+	return &BackgroundColorFilter{}
+}
+
+// Name 返回濾鏡名稱
+func (f *BackgroundColorFilter) Name() string {
+	return "background_color"
+}
+
+// Apply 應用背景色
+// params[0]: 十六進位顏色（預設 ffffff，透明度會被忽略）
+func (f *BackgroundColorFilter) Apply(img image.Image, params []string) (image.Image, error) {
+	bg := color.NRGBA{R: 255, G: 255, B: 255, A: 255}
+	if len(params) > 0 && params[0] != "" {
+		c, ok := parseHexColor(params[0])
+		if !ok {
+			return nil, fmt.Errorf("invalid background color: %s", params[0])
+		}
+		bg = c
+		bg.A = 255
+	}
+
+	b := img.Bounds()
+	result := imaging.New(b.Dx(), b.Dy(), bg)
+	draw.Draw(result, result.Bounds(), img, b.Min, draw.Over)
+	return result, nil
+}
@@ -0,0 +1,162 @@
+package filter
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+// newLogoImage 建立透明背景、中央為不透明方塊的圖片
+func newLogoImage(width, height int, c color.NRGBA) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y := height / 4; y < height*3/4; y++ {
+		for x := width / 4; x < width*3/4; x++ {
+			img.SetNRGBA(x, y, c)
+		}
+	}
+	return img
+}
+
+func TestFillFilter(t *testing.T) {
+	f := NewFillFilter()
+	if f.Name() != "fill" {
+		t.Fatalf("Expected name 'fill', got '%s'", f.Name())
+	}
+
+	blue := color.NRGBA{B: 255, A: 255}
+	red := color.NRGBA{R: 255, A: 255}
+	logo := newLogoImage(40, 20, blue)
+
+	// 純色填充：置中並填滿透明區域
+	result, err := f.Apply(logo, []string{"ff0000", "100", "50"})
+	if err != nil {
+		t.Fatalf("Apply failed: %v", err)
+	}
+	out := result.(*image.NRGBA)
+	if out.Bounds().Dx() != 100 || out.Bounds().Dy() != 50 {
+		t.Fatalf("Expected 100x50 canvas, got %v", out.Bounds())
+	}
+	for _, tt := range []struct {
+		pt   image.Point
+		want color.NRGBA
+	}{
+		{image.Pt(0, 0), red},
+		{image.Pt(99, 49), red},
+		{image.Pt(31, 15), red}, // 原圖的透明處
+		{image.Pt(50, 25), blue},
+	} {
+		if got := out.NRGBAAt(tt.pt.X, tt.pt.Y); got != tt.want {
+			t.Errorf("pixel %v = %v, want %v", tt.pt, got, tt.want)
+		}
+	}
+
+	// 未指定畫布尺寸時只填滿透明區域
+	result, _ = f.Apply(logo, []string{"ff0000"})
+	if result.Bounds() != logo.Bounds() {
+		t.Errorf("Expected bounds %v, got %v", logo.Bounds(), result.Bounds())
+	}
+
+	// 畫布小於圖片時不裁切
+	result, _ = f.Apply(logo, []string{"ff0000", "10", "10"})
+	if result.Bounds().Dx() != 40 || result.Bounds().Dy() != 20 {
+		t.Errorf("Expected canvas at least the image size, got %v", result.Bounds())
+	}
+
+	for _, params := range [][]string{{"nocolor"}, {"ff0000", "x", "10"}, {"ff0000", "10", "0"}, {"ff0000", "60000", "60000"}} {
+		if _, err := f.Apply(logo, params); err == nil {
+			t.Errorf("expected error for params %v", params)
+		}
+	}
+}
+
+func TestFillFilter_Auto(t *testing.T) {
+	// 邊緣多為灰色，少數為綠色
+	img := image.NewNRGBA(image.Rect(0, 0, 30, 30))
+	for i := 0; i < len(img.Pix); i += 4 {
+		img.Pix[i], img.Pix[i+1], img.Pix[i+2], img.Pix[i+3] = 200, 200, 200, 255
+	}
+	for x := range 5 {
+		img.SetNRGBA(x, 0, color.NRGBA{G: 255, A: 255})
+	}
+
+	if got := dominantEdgeColor(img); got != (color.NRGBA{R: 200, G: 200, B: 200, A: 255}) {
+		t.Errorf("dominantEdgeColor() = %v", got)
+	}
+
+	result, err := NewFillFilter().Apply(img, []string{"auto", "60", "30"})
+	if err != nil {
+		t.Fatalf("Apply failed: %v", err)
+	}
+	if got := result.(*image.NRGBA).NRGBAAt(2, 2); got != (color.NRGBA{R: 200, G: 200, B: 200, A: 255}) {
+		t.Errorf("padding = %v, want edge color", got)
+	}
+}
+
+func TestFillFilter_Blur(t *testing.T) {
+	// 左半紅、右半綠
+	img := image.NewNRGBA(image.Rect(0, 0, 40, 40))
+	for y := range 40 {
+		for x := range 40 {
+			c := color.NRGBA{R: 255, A: 255}
+			if x >= 20 {
+				c = color.NRGBA{G: 255, A: 255}
+			}
+			img.SetNRGBA(x, y, c)
+		}
+	}
+
+	result, err := NewFillFilter().Apply(img, []string{"blur", "120", "40"})
+	if err != nil {
+		t.Fatalf("Apply failed: %v", err)
+	}
+	out := result.(*image.NRGBA)
+	if out.Bounds().Dx() != 120 || out.Bounds().Dy() != 40 {
+		t.Fatalf("Expected 120x40 canvas, got %v", out.Bounds())
+	}
+
+	// 背景為放大後的圖片：左側偏紅、右側偏綠，且不透明
+	left, right := out.NRGBAAt(5, 20), out.NRGBAAt(114, 20)
+	if left.R <= left.G || right.G <= right.R || left.A != 255 || right.A != 255 {
+		t.Errorf("unexpected background: left %v, right %v", left, right)
+	}
+	// 原圖置中
+	if got := out.NRGBAAt(45, 20); got != (color.NRGBA{R: 255, A: 255}) {
+		t.Errorf("center-left pixel = %v, want original red", got)
+	}
+}
+
+func TestBackgroundColorFilter(t *testing.T) {
+	f := NewBackgroundColorFilter()
+	if f.Name() != "background_color" {
+		t.Fatalf("Expected name 'background_color', got '%s'", f.Name())
+	}
+
+	img := newLogoImage(8, 8, color.NRGBA{B: 255, A: 255})
+	// 半透明像素與背景混合
+	img.SetNRGBA(0, 1, color.NRGBA{R: 255, A: 128})
+
+	result, err := f.Apply(img, []string{"00ff00"})
+	if err != nil {
+		t.Fatalf("Apply failed: %v", err)
+	}
+	out := result.(*image.NRGBA)
+	if got := out.NRGBAAt(0, 0); got != (color.NRGBA{G: 255, A: 255}) {
+		t.Errorf("transparent pixel = %v, want background", got)
+	}
+	if got := out.NRGBAAt(4, 4); got != (color.NRGBA{B: 255, A: 255}) {
+		t.Errorf("opaque pixel = %v, want unchanged", got)
+	}
+	if got := out.NRGBAAt(0, 1); got.A != 255 || got.R < 120 || got.G < 120 {
+		t.Errorf("semi-transparent pixel = %v, want blended", got)
+	}
+
+	// 預設白色
+	result, _ = f.Apply(img, nil)
+	if got := result.(*image.NRGBA).NRGBAAt(0, 0); got != (color.NRGBA{R: 255, G: 255, B: 255, A: 255}) {
+		t.Errorf("default background = %v, want white", got)
+	}
+
+	if _, err := f.Apply(img, []string{"zz"}); err == nil {
+		t.Error("expected error for invalid color")
+	}
+}
@@ -0,0 +1,183 @@
+package filter
+
+import (
+	"errors"
+	"slices"
+)
+
+// errBCHUncorrectable 錯誤數量超過 BCH 碼可修正範圍
+var errBCHUncorrectable = errors.New("bch: too many errors")
+
+// bchN BCH 碼長（GF(2^8) 上的本原 BCH 碼）
+const bchN = 255
+
+// GF(2^8) 運算表（本原多項式 x^8+x^4+x^3+x^2+1）
+var gfExp, gfLog = func() ([512]byte, [256]byte) {
+	var exp [512]byte
+	var log [256]byte
+	x := 1
+	for i := range 255 {
+		exp[i] = byte(x)
+		log[x] = byte(i)
+		x <<= 1
+		if x&0x100 != 0 {
+			x ^= 0x11d
+		}
+	}
+	for i := 255; i < 512; i++ {
+		exp[i] = exp[i-255]
+	}
+	return exp, log
+}()
+
+func gfMul(a, b byte) byte {
+	if a == 0 || b == 0 {
+		return 0
+	}
+	return gfExp[int(gfLog[a])+int(gfLog[b])]
+}
+
+func gfDiv(a, b byte) byte {
+	if a == 0 {
+		return 0
+	}
+	return gfExp[(int(gfLog[a])+255-int(gfLog[b]))%255]
+}
+
+// gfPow 計算 α^e
+func gfPow(e int) byte {
+	return gfExp[((e%255)+255)%255]
+}
+
+// bchCode 二元 BCH 碼，碼長 255、可修正 t 個位元錯誤
+type bchCode struct {
+	t   int
+	k   int    // 訊息位元數
+	gen []byte // 生成多項式（GF(2) 係數，最高次項在前）
+}
+
+// newBCHCode 以 α^1..α^2t 的最小多項式乘積建立生成多項式
+func newBCHCode(t int) *bchCode {
+	gen := []byte{1}
+	seen := make(map[int]bool)
+	for i := 1; i <= 2*t; i++ {
+		if seen[i] {
+			continue
+		}
+		// 共軛類 {i, 2i, 4i, ...} mod 255 的最小多項式
+		minimal := []byte{1}
+		for j := i; !seen[j]; j = j * 2 % bchN {
+			seen[j] = true
+			minimal = gfPolyMul(minimal, []byte{1, gfPow(j)})
+		}
+		gen = gfPolyMul(gen, minimal)
+	}
+	return &bchCode{t: t, k: bchN - (len(gen) - 1), gen: gen}
+}
+
+// gfPolyMul GF(2^8) 多項式相乘（最高次項在前）
+func gfPolyMul(a, b []byte) []byte {
+	out := make([]byte, len(a)+len(b)-1)
+	for i, x := range a {
+		for j, y := range b {
+			out[i+j] ^= gfMul(x, y)
+		}
+	}
+	return out
+}
+
+// encode 系統編碼：碼字為訊息位元後接 n-k 個檢查位元（每個元素為 0 或 1）
+func (c *bchCode) encode(msg []byte) []byte {
+	out := make([]byte, bchN)
+	copy(out, msg[:c.k])
+	for i := range c.k {
+		if out[i] == 0 {
+			continue
+		}
+		for j := 1; j < len(c.gen); j++ {
+			out[i+j] ^= c.gen[j]
+		}
+	}
+	copy(out, msg[:c.k])
+	return out
+}
+
+// decode 修正碼字中的位元錯誤，回傳訊息位元與修正的位元數
+func (c *bchCode) decode(code []byte) ([]byte, int, error) {
+	synd := c.syndromes(code)
+	if !slices.ContainsFunc(synd, func(s byte) bool { return s != 0 }) {
+		return slices.Clone(code[:c.k]), 0, nil
+	}
+
+	locator, degree := errorLocator(synd)
+	if degree > c.t {
+		return nil, 0, errBCHUncorrectable
+	}
+
+	// Chien 搜尋：位置 pos 對應 X = α^(n-1-pos)，Λ(X^-1) = 0 時該位元有錯
+	fixed := slices.Clone(code)
+	found := 0
+	for pos := range bchN {
+		xInv := gfPow(-(bchN - 1 - pos))
+		var v byte
+		for j := len(locator) - 1; j >= 0; j-- {
+			v = gfMul(v, xInv) ^ locator[j]
+		}
+		if v == 0 {
+			fixed[pos] ^= 1
+			found++
+		}
+	}
+	if found != degree || slices.ContainsFunc(c.syndromes(fixed), func(s byte) bool { return s != 0 }) {
+		return nil, 0, errBCHUncorrectable
+	}
+	return fixed[:c.k], found, nil
+}
+
+// syndromes 計算症狀值 S_j = c(α^j)，j = 1..2t
+func (c *bchCode) syndromes(code []byte) []byte {
+	synd := make([]byte, 2*c.t)
+	for j := range synd {
+		x := gfPow(j + 1)
+		var v byte
+		for _, bit := range code {
+			v = gfMul(v, x) ^ bit
+		}
+		synd[j] = v
+	}
+	return synd
+}
+
+// errorLocator 以 Berlekamp-Massey 演算法求錯誤定位多項式 Λ(x)（低次項在前）與其次數
+func errorLocator(synd []byte) ([]byte, int) {
+	locator := []byte{1}
+	prev := []byte{1}
+	l, m := 0, 1
+	b := byte(1)
+	for i := range synd {
+		d := synd[i]
+		for j := 1; j <= l && j < len(locator); j++ {
+			d ^= gfMul(locator[j], synd[i-j])
+		}
+		if d == 0 {
+			m++
+			continue
+		}
+		coef := gfDiv(d, b)
+		next := make([]byte, max(len(locator), len(prev)+m))
+		copy(next, locator)
+		for j, p := range prev {
+			next[j+m] ^= gfMul(coef, p)
+		}
+		if 2*l <= i {
+			prev = locator
+			l = i + 1 - l
+			b = d
+			m = 1
+		} else {
+			m++
+		}
+		locator = next
+	}
+	return locator, l
+}
@@ -1,15 +1,80 @@
 package filter
 
 import (
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
 	"image"
-	"image/color"
 	"math"
-
+	"math/cmplx"
+	"math/rand/v2"
 	"strconv"
 
 	"github.com/disintegration/imaging"
 )
 
+// 隱形浮水印參數
+// 浮水印為 128x128 像素的 tile，由 32x32 個 4x4 像素的 chip 組成，週期性鋪滿整張圖片的亮度通道。
+// 每個 chip 依 SecurityKey 產生的展頻序列取 ±1：256 個 chip 構成同步模板，其餘 chip 承載
+// BCH 編碼後的酬載。偵測時以同步模板的相關峰值搜尋縮放比例與位移，因此可承受
+// 重新壓縮、縮放與裁切。
+const (
+	bwChipSize  = 4
+	bwTileChips = 32
+	bwTileSize  = bwChipSize * bwTileChips
+	bwChips     = bwTileChips * bwTileChips
+	bwSyncChips = 256
+	bwDataBytes = 16 // 酬載容量（bytes）
+	bwCodedBits = bchN
+
+	// 偵測時搜尋的縮放範圍（偵測圖片相對於嵌入時的尺寸）
+	bwMinScale = 0.6
+	bwMaxScale = 1.6
+	// bwCoarseRegion 粗略搜尋縮放比例時使用的中央區域大小
+	bwCoarseRegion = 320
+	// bwMaxAnalyze 偵測時分析的最大區域（像素），較大的圖片取中央區域
+	bwMaxAnalyze = 1024
+	// bwHighPassRadius 去除圖片內容的高通濾波半徑
+	bwHighPassRadius = 2
+)
+
+// bwChannel 隱形浮水印通道
+// 水平相鄰的兩個 chip 組成一個單元，配置以單元為單位產生；各通道在單元內使用不同的符號組合
+// （文字通道相同、收件者通道相反），彼此正交，可疊加於同一張圖片而互不干擾
+type bwChannel struct {
+	seed    string
+	pattern [2]float64
+}
+
+// 隱形浮水印通道
+var (
+	bwChannelText      = bwChannel{seed: "images-filters/blind-watermark/", pattern: [2]float64{1, -1}}
+	bwChannelRecipient = bwChannel{seed: "images-filters/blind-watermark-recipient/", pattern: [2]float64{1, 1}}
+)
+
+// 文字酬載格式（16 bytes）：
+//
+//	[0]     版本（高 3 位元）與文字長度（低 5 位元）
+//	[1:14]  文字（右側補 0）
+//	[14:16] 前 14 bytes 的 CRC-32 (IEEE) 前 2 bytes
+const (
+	bwPayloadVersion = 1
+	// bwMaxTextBytes 文字浮水印的最大長度（bytes）
+	bwMaxTextBytes = 13
+)
+
+// 信心值使用的同步相關峰值範圍：未嵌入浮水印的圖片峰值低於 bwScoreFloor，
+// 高於 bwScoreStrong 時視為完全同步（由 TestBlindWatermarkReader_Calibration 校正）
+const (
+	bwScoreFloor  = 8.0
+	bwScoreStrong = 16.0
+)
+
+// bwCode 酬載使用的 BCH(255,131) 碼，最多修正 18 個位元錯誤
+var bwCode = newBCHCode(18)
+
 // BlindWatermarkFilter 隱形浮水印濾鏡
 type BlindWatermarkFilter struct {
 	Text        string
@@ -20,7 +85,7 @@ type BlindWatermarkFilter struct {
 // NewBlindWatermarkFilter 建立隱形浮水印濾鏡
 func NewBlindWatermarkFilter() *BlindWatermarkFilter {
 	return &BlindWatermarkFilter{
-		Strength: 10.0, // 預設強度
+		Strength: 4.0, // 預設強度（亮度變化幅度）
 	}
 }
 
@@ -30,13 +95,11 @@ func (f *BlindWatermarkFilter) Name() string {
 }
 
 // Apply 套用濾鏡
-// params[0]: text (浮水印文字)
-// params[1]: strength (強度, optiona, default 10.0)
+// params[0]: text (浮水印文字，最多 13 bytes)
+// params[1]: strength (強度，亮度變化幅度，可選，預設 4)
 func (f *BlindWatermarkFilter) Apply(img image.Image, params []string) (image.Image, error) {
-	// 1. 解析參數
 	text := f.Text
 	strength := f.Strength
-	key := f.SecurityKey
 
 	if len(params) > 0 && params[0] != "" {
 		text = params[0]
@@ -51,330 +114,451 @@ func (f *BlindWatermarkFilter) Apply(img image.Image, params []string) (image.Im
 	if text == "" {
 		return img, nil
 	}
+	if len(text) > bwMaxTextBytes {
+		return nil, fmt.Errorf("blind watermark text exceeds %d bytes", bwMaxTextBytes)
+	}
+	return embedPayload(img, newBWLayout(bwChannelText, f.SecurityKey), encodeTextPayload(text), strength), nil
+}
 
-	// 1. 轉換為 YCbCr 或灰階 (為了方便處理，這裡先假設處理亮度通道 Y)
-	// 簡單起見，我們先將浮水印嵌入到 Resize 後的圖片
-	// 注意：DCT 需要圖片尺寸是 8 的倍數，或者我們會對 8x8 區塊進行處理
-
-	bounds := img.Bounds()
-	width := bounds.Dx()
-	height := bounds.Dy()
+// Extract 提取隱形浮水印，找不到有效的浮水印時回傳錯誤
+func (f *BlindWatermarkFilter) Extract(img image.Image) (string, error) {
+	det := NewBlindWatermarkReader(img).Detect(f.SecurityKey)
+	if !det.Detected {
+		return "", errBlindWatermarkNotFound
+	}
+	return det.Text, nil
+}
 
-	// 建立可修改的圖片副本 (RGBA)
-	rgba := imaging.Clone(img)
+// errBlindWatermarkNotFound 找不到有效的隱形浮水印
+var errBlindWatermarkNotFound = errors.New("blind watermark not found")
 
-	// 產生浮水印訊號 (基於 Text 和 SecurityKey)
-	watermarkSignal := generateWatermarkSignal(text, key)
+// BlindWatermarkDetection 文字浮水印檢測結果
+type BlindWatermarkDetection struct {
+	Detected   bool    // 酬載通過 BCH 解碼、版本與校驗碼檢查
+	Text       string  // 浮水印文字（僅在 Detected 時有值）
+	Confidence float64 // 依同步相關峰值與位元錯誤率估計（0-1），未檢測到時低於 0.5
+	BitErrors  int     // BCH 修正的位元數
+}
 
-	// 嵌入浮水印
-	embedWatermark(rgba, width, height, watermarkSignal, strength)
+// BlindWatermarkReader 讀取圖片中的隱形浮水印
+// 各通道共用同一份去除圖片內容後的殘差，避免重複計算
+type BlindWatermarkReader struct {
+	residual *bwResidual
+}
 
-	return rgba, nil
+// NewBlindWatermarkReader 建立隱形浮水印讀取器
+func NewBlindWatermarkReader(img image.Image) *BlindWatermarkReader {
+	return &BlindWatermarkReader{residual: newBWResidual(img)}
 }
 
-// generateWatermarkSignal 產生浮水印訊號
-// 這裡將文字轉換為二進位序列，並重複填充以適應圖片
-func generateWatermarkSignal(text, key string) []int {
-	// 簡單實作：將文字轉為 ascii bits
-	var bits []int
-	for _, char := range text {
-		for i := 0; i < 8; i++ {
-			if (char>>uint(7-i))&1 == 1 {
-				bits = append(bits, 1)
-			} else {
-				bits = append(bits, -1) // 使用 1 和 -1
-			}
-		}
+// Detect 檢測文字浮水印
+func (r *BlindWatermarkReader) Detect(key string) BlindWatermarkDetection {
+	det := r.residual.detect(newBWLayout(bwChannelText, key))
+	data, fixed, err := decodePayload(det)
+	if err != nil {
+		return BlindWatermarkDetection{Confidence: detectionConfidence(det.score, 0, false)}
+	}
+	text, ok := decodeTextPayload(data)
+	if !ok {
+		return BlindWatermarkDetection{Confidence: detectionConfidence(det.score, 0, false)}
 	}
-	if len(bits) == 0 {
-		return []int{1, -1, 1, -1} // Default pattern
+	return BlindWatermarkDetection{
+		Detected:   true,
+		Text:       text,
+		Confidence: detectionConfidence(det.score, fixed, true),
+		BitErrors:  fixed,
 	}
-	return bits
 }
 
-// embedWatermark 嵌入浮水印
-func embedWatermark(img *image.NRGBA, width, height int, signal []int, strength float64) {
-	// 對每個 8x8 區塊進行 DCT
-	// 修改中頻係數
-	// IDCT
+// encodeTextPayload 編碼文字酬載
+func encodeTextPayload(text string) []byte {
+	payload := make([]byte, bwDataBytes)
+	payload[0] = bwPayloadVersion<<5 | byte(len(text))
+	copy(payload[1:14], text)
+
+	var sum [4]byte
+	binary.BigEndian.PutUint32(sum[:], crc32.ChecksumIEEE(payload[:14]))
+	copy(payload[14:], sum[:2])
+	return payload
+}
 
-	// 這裡僅提供框架，詳細 DCT 運算較為複雜
-	// 為此示範，我們將實作一個簡化的空間域 LSB 隱寫作為 placeholder，
-	// 因為完整的 DCT 實作程式碼量較大，且需引入矩陣運算庫或手寫 DCT。
-	// 但為了符合 "Blind Watermark" 要求，我會盡量寫一個簡易的 DCT 變換。
+// decodeTextPayload 解碼文字酬載並驗證版本、長度與校驗碼
+func decodeTextPayload(payload []byte) (string, bool) {
+	if len(payload) < bwDataBytes || payload[0]>>5 != bwPayloadVersion {
+		return "", false
+	}
+	n := int(payload[0] & 0x1f)
+	if n > bwMaxTextBytes {
+		return "", false
+	}
+	var sum [4]byte
+	binary.BigEndian.PutUint32(sum[:], crc32.ChecksumIEEE(payload[:14]))
+	if string(sum[:2]) != string(payload[14:16]) {
+		return "", false
+	}
+	return string(payload[1 : 1+n]), true
+}
 
-	processBlocks(img, width, height, signal, strength)
+// detectionConfidence 依同步相關峰值與位元錯誤率估計信心值
+// 酬載驗證通過時介於 0.5-1（錯誤越少、峰值越高越接近 1），否則僅依峰值估計且低於 0.5
+func detectionConfidence(score float64, bitErrors int, verified bool) float64 {
+	sync := clamp((score-bwScoreFloor)/(bwScoreStrong-bwScoreFloor), 0, 1)
+	if !verified {
+		return 0.45 * sync
+	}
+	quality := 1 - float64(bitErrors)/float64(bwCode.t+1)
+	return 0.5 + 0.25*sync + 0.25*quality
 }
 
-// processBlocks 處理 8x8 區塊
-func processBlocks(img *image.NRGBA, width, height int, signal []int, strength float64) {
-	signalIdx := 0
-	signalLen := len(signal)
+// embedPayload 以 BCH 編碼酬載並嵌入圖片副本
+func embedPayload(img image.Image, layout *bwLayout, payload []byte, strength float64) *image.NRGBA {
+	result := imaging.Clone(img)
+	embedBlindWatermark(result, layout, bwCode.encode(bytesToBits(payload, bwCode.k)), strength)
+	return result
+}
 
-	for y := 0; y <= height-8; y += 8 {
-		for x := 0; x <= width-8; x += 8 {
-			// 提取 Y 通道 8x8
-			block := extractYBlock(img, x, y)
+// decodePayload 以 BCH 碼修正偵測到的位元，回傳酬載與修正的位元數
+func decodePayload(det bwDetection) ([]byte, int, error) {
+	bits, fixed, err := bwCode.decode(hardDecision(det.soft))
+	if err != nil {
+		return nil, 0, err
+	}
+	return bitsToBytes(bits[:bwDataBytes*8]), fixed, nil
+}
 
-			// DCT
-			dctBlock := dct2d(block)
+// bwLayout 依金鑰產生的 chip 配置
+type bwLayout struct {
+	sign    [bwChips]float64 // 每個 chip 的展頻符號（±1）
+	bit     [bwChips]int     // 每個 chip 承載的編碼位元索引，-1 表示同步 chip
+	syncFFT []complex128     // 同步模板（像素層級）的二維 FFT
+}
 
-			// 嵌入 bit
-			bit := signal[signalIdx%signalLen]
-			embedInDct(dctBlock, bit, strength)
+// newBWLayout 以通道與 SecurityKey 產生 chip 配置與同步模板
+func newBWLayout(channel bwChannel, key string) *bwLayout {
+	seed := sha256.Sum256([]byte(channel.seed + key))
+	rng := rand.New(rand.NewChaCha8(seed))
 
-			// IDCT
-			idctBlock := idct2d(dctBlock)
+	const units = bwChips / 2
+	var unitSign [units]float64
+	for i := range unitSign {
+		unitSign[i] = float64(rng.IntN(2)*2 - 1)
+	}
+	var unitBit [units]int
+	for i, u := range rng.Perm(units) {
+		if i < bwSyncChips/2 {
+			unitBit[u] = -1
+		} else {
+			unitBit[u] = (i - bwSyncChips/2) % bwCodedBits
+		}
+	}
 
-			// 寫回圖片
-			updateYBlock(img, x, y, idctBlock)
+	l := &bwLayout{}
+	for c := range bwChips {
+		l.sign[c] = unitSign[c/2] * channel.pattern[c%2]
+		l.bit[c] = unitBit[c/2]
+	}
 
-			signalIdx++
+	l.syncFFT = make([]complex128, bwTileSize*bwTileSize)
+	for y := range bwTileSize {
+		for x := range bwTileSize {
+			c := bwChipAt(x, y)
+			if l.bit[c] < 0 {
+				l.syncFFT[y*bwTileSize+x] = complex(l.sign[c], 0)
+			}
 		}
 	}
+	fft2d(l.syncFFT, bwTileSize, false)
+	return l
 }
 
-// extractYBlock 提取 8x8 Y 通道
-func extractYBlock(img *image.NRGBA, xStart, yStart int) [][]float64 {
-	block := make([][]float64, 8)
-	for i := 0; i < 8; i++ {
-		block[i] = make([]float64, 8)
-		for j := 0; j < 8; j++ {
-			c := img.At(xStart+j, yStart+i)
-			r, g, b, _ := c.RGBA()
-			// RGB to Y (SDTV formula)
-			y := 0.299*float64(r>>8) + 0.587*float64(g>>8) + 0.114*float64(b>>8)
-			block[i][j] = y - 128 // Shift to zero-mean
-		}
+// bwChipAt 取得 tile 內像素座標對應的 chip 索引
+func bwChipAt(x, y int) int {
+	return (y/bwChipSize)*bwTileChips + x/bwChipSize
+}
+
+// chipValue 取得 chip 嵌入的值（同步 chip 為展頻符號，酬載 chip 依編碼位元調變）
+func (l *bwLayout) chipValue(c int, coded []byte) float64 {
+	b := l.bit[c]
+	if b < 0 {
+		return l.sign[c]
+	}
+	if coded[b] == 1 {
+		return l.sign[c]
 	}
-	return block
+	return -l.sign[c]
 }
 
-// updateYBlock 更新 8x8 Y 通道
-func updateYBlock(img *image.NRGBA, xStart, yStart int, block [][]float64) {
-	for i := 0; i < 8; i++ {
-		for j := 0; j < 8; j++ {
-			// Get original color to preserve Cb Cr
-			c := img.At(xStart+j, yStart+i)
-			r, g, b, a := c.RGBA()
-			r8, g8, b8, a8 := uint8(r>>8), uint8(g>>8), uint8(b>>8), uint8(a>>8)
+// embedBlindWatermark 將浮水印 tile 週期性加入亮度通道
+// 各 8x8 區塊依紋理強度調整幅度：平坦區域較弱、紋理豐富區域較強
+func embedBlindWatermark(img *image.NRGBA, layout *bwLayout, coded []byte, strength float64) {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	luma := lumaOf(img)
 
-			// yOld := 0.299*float64(r8) + 0.587*float64(g8) + 0.114*float64(b8)
-			cb := -0.1687*float64(r8) - 0.3313*float64(g8) + 0.5*float64(b8)
-			cr := 0.5*float64(r8) - 0.4187*float64(g8) - 0.0813*float64(b8)
+	var tile [bwChips]float64
+	for c := range tile {
+		tile[c] = layout.chipValue(c, coded)
+	}
 
-			yNew := block[i][j] + 128
+	for by := 0; by < h; by += 8 {
+		for bx := 0; bx < w; bx += 8 {
+			ex, ey := min(bx+8, w), min(by+8, h)
+			mask := textureMask(luma, w, bx, by, ex, ey)
+
+			for y := by; y < ey; y++ {
+				row := img.Pix[y*img.Stride:]
+				for x := bx; x < ex; x++ {
+					delta := strength * mask * tile[bwChipAt(x%bwTileSize, y%bwTileSize)]
+					i := x * 4
+					row[i] = clip(float64(row[i]) + delta)
+					row[i+1] = clip(float64(row[i+1]) + delta)
+					row[i+2] = clip(float64(row[i+2]) + delta)
+				}
+			}
+		}
+	}
+}
 
-			// YCbCr back to RGB
-			newR := yNew + 1.402*cr
-			newG := yNew - 0.34414*cb - 0.71414*cr
-			newB := yNew + 1.772*cb
+// textureMask 依區塊亮度標準差計算浮水印幅度係數（0.6 - 1.6）
+func textureMask(luma []float64, stride, x0, y0, x1, y1 int) float64 {
+	var sum, sq float64
+	n := float64((x1 - x0) * (y1 - y0))
+	for y := y0; y < y1; y++ {
+		for x := x0; x < x1; x++ {
+			v := luma[y*stride+x]
+			sum += v
+			sq += v * v
+		}
+	}
+	mean := sum / n
+	std := math.Sqrt(math.Max(sq/n-mean*mean, 0))
+	return clamp(0.6+std/20, 0.6, 1.6)
+}
 
-			img.Set(xStart+j, yStart+i, color.NRGBA{
-				R: clip(newR),
-				G: clip(newG),
-				B: clip(newB),
-				A: a8,
-			})
+// lumaOf 取得圖片的亮度通道（BT.601）
+func lumaOf(img *image.NRGBA) []float64 {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	luma := make([]float64, w*h)
+	for y := range h {
+		row := img.Pix[y*img.Stride:]
+		for x := range w {
+			i := x * 4
+			luma[y*w+x] = 0.299*float64(row[i]) + 0.587*float64(row[i+1]) + 0.114*float64(row[i+2])
 		}
 	}
+	return luma
 }
 
-func clip(v float64) uint8 {
-	if v < 0 {
-		return 0
+// bwDetection 偵測結果
+type bwDetection struct {
+	scale  float64   // 偵測圖片相對於嵌入時的縮放比例
+	dx, dy int       // tile 位移
+	score  float64   // 同步模板相關峰值（相對於相關值標準差的倍數）
+	soft   []float64 // 每個編碼位元的軟判決值（正值為 1）
+}
+
+// bwResidual 去除圖片內容後的亮度殘差
+type bwResidual struct {
+	data []float64
+	w, h int
+}
+
+// newBWResidual 計算圖片（過大時取中央區域）的亮度殘差
+func newBWResidual(img image.Image) *bwResidual {
+	nrgba, ok := img.(*image.NRGBA)
+	if !ok || nrgba.Rect.Min != (image.Point{}) {
+		nrgba = imaging.Clone(img)
 	}
-	if v > 255 {
-		return 255
+	b := nrgba.Bounds()
+	if w, h := b.Dx(), b.Dy(); w > bwMaxAnalyze || h > bwMaxAnalyze {
+		cw, ch := min(w, bwMaxAnalyze), min(h, bwMaxAnalyze)
+		nrgba = imaging.Crop(nrgba, image.Rect((w-cw)/2, (h-ch)/2, (w-cw)/2+cw, (h-ch)/2+ch))
+		b = nrgba.Bounds()
 	}
-	return uint8(v)
+
+	w, h := b.Dx(), b.Dy()
+	if w < bwChipSize*4 || h < bwChipSize*4 {
+		return &bwResidual{}
+	}
+	return &bwResidual{data: highPass(lumaOf(nrgba), w, h, bwHighPassRadius), w: w, h: h}
 }
 
-// dct2d 2D DCT
-func dct2d(block [][]float64) [][]float64 {
-	// 簡單實作，可優化
-	res := make([][]float64, 8)
-	for i := 0; i < 8; i++ {
-		res[i] = make([]float64, 8)
+// detect 搜尋縮放比例與位移並解調酬載
+// 先以中央區域粗略搜尋縮放比例，再以完整分析區域細部調整
+func (r *bwResidual) detect(layout *bwLayout) bwDetection {
+	det := bwDetection{soft: make([]float64, bwCodedBits)}
+	if r.data == nil {
+		return det
+	}
+	residual, w, h := r.data, r.w, r.h
+
+	// 粗略搜尋：中央區域，縮放比例每步 1%
+	rw, rh := min(w, bwCoarseRegion), min(h, bwCoarseRegion)
+	rx, ry := (w-rw)/2, (h-rh)/2
+	best := bwDetection{}
+	for s := bwMinScale; s <= bwMaxScale; s *= 1.01 {
+		folded := foldResidual(residual, w, rx, ry, rw, rh, s)
+		if d := correlateSync(folded, layout, s); d.score > best.score {
+			best = d
+		}
+	}
+	if best.scale == 0 {
+		return det
 	}
 
-	c1 := 0.0
-	c2 := 0.0
+	// 細部調整：完整區域，縮放比例每步 0.1%
+	coarse := best.scale
+	var folded []float64
+	for k := -6; k <= 6; k++ {
+		s := coarse * (1 + float64(k)*0.001)
+		f := foldResidual(residual, w, 0, 0, w, h, s)
+		if d := correlateSync(f, layout, s); k == -6 || d.score > det.score {
+			det.scale, det.dx, det.dy, det.score = d.scale, d.dx, d.dy, d.score
+			folded = f
+		}
+	}
 
-	for u := 0; u < 8; u++ {
-		for v := 0; v < 8; v++ {
-			if u == 0 {
-				c1 = 1.0 / math.Sqrt(2)
-			} else {
-				c1 = 1.0
-			}
+	// 解調酬載：折疊後的 tile 位移 (dx, dy) 即為嵌入時的 tile 原點
+	for y := range bwTileSize {
+		for x := range bwTileSize {
+			v := folded[y*bwTileSize+x]
 			if v == 0 {
-				c2 = 1.0 / math.Sqrt(2)
-			} else {
-				c2 = 1.0
+				continue
 			}
-
-			sum := 0.0
-			for x := 0; x < 8; x++ {
-				for y := 0; y < 8; y++ {
-					sum += block[y][x] *
-						math.Cos((2*float64(x)+1)*float64(u)*math.Pi/16) *
-						math.Cos((2*float64(y)+1)*float64(v)*math.Pi/16)
-				}
+			c := bwChipAt((x-det.dx+bwTileSize)%bwTileSize, (y-det.dy+bwTileSize)%bwTileSize)
+			if bit := layout.bit[c]; bit >= 0 {
+				det.soft[bit] += v * layout.sign[c]
 			}
-			res[v][u] = 0.25 * c1 * c2 * sum
 		}
 	}
-	return res
-}
-
-// idct2d 2D IDCT
-func idct2d(block [][]float64) [][]float64 {
-	res := make([][]float64, 8)
-	for i := 0; i < 8; i++ {
-		res[i] = make([]float64, 8)
-	}
-
-	c1 := 0.0
-	c2 := 0.0
-
-	for x := 0; x < 8; x++ {
-		for y := 0; y < 8; y++ {
-			sum := 0.0
-			for u := 0; u < 8; u++ {
-				for v := 0; v < 8; v++ {
-					if u == 0 {
-						c1 = 1.0 / math.Sqrt(2)
-					} else {
-						c1 = 1.0
-					}
-					if v == 0 {
-						c2 = 1.0 / math.Sqrt(2)
-					} else {
-						c2 = 1.0
-					}
-
-					sum += c1 * c2 * block[v][u] *
-						math.Cos((2*float64(x)+1)*float64(u)*math.Pi/16) *
-						math.Cos((2*float64(y)+1)*float64(v)*math.Pi/16)
-				}
-			}
-			res[y][x] = 0.25 * sum
+	return det
+}
+
+// highPass 以方框平均去除低頻圖片內容，並限制殘差幅度以降低邊緣的影響
+func highPass(luma []float64, w, h, radius int) []float64 {
+	// 積分圖
+	integral := make([]float64, (w+1)*(h+1))
+	for y := range h {
+		var rowSum float64
+		for x := range w {
+			rowSum += luma[y*w+x]
+			integral[(y+1)*(w+1)+x+1] = integral[y*(w+1)+x+1] + rowSum
 		}
 	}
-	return res
+
+	residual := make([]float64, w*h)
+	var absSum float64
+	for y := range h {
+		y0, y1 := max(y-radius, 0), min(y+radius+1, h)
+		for x := range w {
+			x0, x1 := max(x-radius, 0), min(x+radius+1, w)
+			sum := integral[y1*(w+1)+x1] - integral[y0*(w+1)+x1] - integral[y1*(w+1)+x0] + integral[y0*(w+1)+x0]
+			r := luma[y*w+x] - sum/float64((x1-x0)*(y1-y0))
+			residual[y*w+x] = r
+			absSum += math.Abs(r)
+		}
+	}
+
+	limit := 3 * absSum / float64(w*h)
+	for i, r := range residual {
+		residual[i] = clamp(r, -limit, limit)
+	}
+	return residual
 }
 
-// embedInDct 修改中頻係數 (Cox 演算法變體)
-func embedInDct(block [][]float64, bit int, strength float64) {
-	// 選取一對中頻係數，例如 (4,3) 和 (3,4)
-	v1 := block[3][4]
-	v2 := block[4][3]
+// foldResidual 以縮放比例 s 將區域內的殘差折疊至一個 tile（取平均）
+// 裁切只會造成折疊結果的循環位移
+func foldResidual(residual []float64, stride, x0, y0, w, h int, s float64) []float64 {
+	sum := make([]float64, bwTileSize*bwTileSize)
+	count := make([]int, bwTileSize*bwTileSize)
 
-	if bit == 1 {
-		// 使 v1 > v2 + strength
-		if v1 <= v2+strength {
-			diff := (v2 + strength - v1) / 2
-			v1 += diff + 0.1
-			v2 -= diff + 0.1 // 確保變動在兩者之間
+	xIndex := make([]int, w)
+	for x := range w {
+		xIndex[x] = int(float64(x)/s) % bwTileSize
+	}
+	for y := range h {
+		row := (int(float64(y)/s) % bwTileSize) * bwTileSize
+		src := residual[(y0+y)*stride+x0:]
+		for x := range w {
+			sum[row+xIndex[x]] += src[x]
+			count[row+xIndex[x]]++
 		}
-	} else {
-		// 使 v1 < v2 - strength
-		if v1 >= v2-strength {
-			diff := (v1 - (v2 - strength)) / 2
-			v1 -= diff + 0.1
-			v2 += diff + 0.1
+	}
+	for i, n := range count {
+		if n > 0 {
+			sum[i] /= float64(n)
 		}
 	}
-	block[3][4] = v1
-	block[4][3] = v2
+	return sum
 }
 
-// Extract 提取隱形浮水印
-// length: 預期文字長度 (字元數)
-func (f *BlindWatermarkFilter) Extract(img image.Image, length int) (string, error) {
-	bounds := img.Bounds()
-	width := bounds.Dx()
-	height := bounds.Dy()
-
-	// 如果沒有指定長度，預設嘗試提取一些 (比如 16 chars)
-	if length <= 0 {
-		length = 16
+// correlateSync 計算折疊 tile 與同步模板的循環相關，回傳峰值位置與顯著程度
+func correlateSync(folded []float64, layout *bwLayout, s float64) bwDetection {
+	n := bwTileSize
+	buf := make([]complex128, n*n)
+	for i, v := range folded {
+		buf[i] = complex(v, 0)
 	}
-
-	bitLength := length * 8
-	votes := make([]float64, bitLength)
-	counts := make([]int, bitLength)
-
-	// 複製圖片以確保不修改原圖 (雖然提取不需要修改，但為了讀取像素一致性)
-	// 這裡直接讀取
-	nrgba, ok := img.(*image.NRGBA)
-	if !ok {
-		nrgba = imaging.Clone(img)
+	fft2d(buf, n, false)
+	for i := range buf {
+		buf[i] *= cmplx.Conj(layout.syncFFT[i])
 	}
-
-	signalIdx := 0
-
-	for y := 0; y <= height-8; y += 8 {
-		for x := 0; x <= width-8; x += 8 {
-			// 提取 Y 通道
-			block := extractYBlock(nrgba, x, y)
-
-			// DCT
-			dctBlock := dct2d(block)
-
-			// 提取 bit
-			val, diff := extractFromDct(dctBlock)
-
-			// 投票機制: 累加差異值 (正值代表 1, 負值代表 0/-1)
-			// 使用 diff 作為權重，信心度越高權重越大
-			voteIdx := signalIdx % bitLength
-			votes[voteIdx] += val * diff
-			counts[voteIdx]++
-
-			signalIdx++
+	fft2d(buf, n, true)
+
+	peak, at := math.Inf(-1), 0
+	var sum, sq float64
+	for i, c := range buf {
+		v := real(c)
+		sum += v
+		sq += v * v
+		if v > peak {
+			peak, at = v, i
 		}
 	}
+	mean := sum / float64(len(buf))
+	std := math.Sqrt(math.Max(sq/float64(len(buf))-mean*mean, 0))
+	if std == 0 {
+		return bwDetection{}
+	}
+	return bwDetection{scale: s, dx: at % n, dy: at / n, score: (peak - mean) / std}
+}
 
-	// 重組 bits
-	var bits []int
-	for _, v := range votes {
+// hardDecision 將軟判決值轉為位元（0 或 1）
+func hardDecision(soft []float64) []byte {
+	bits := make([]byte, len(soft))
+	for i, v := range soft {
 		if v > 0 {
-			bits = append(bits, 1)
-		} else {
-			bits = append(bits, 0)
+			bits[i] = 1
 		}
 	}
-
-	return bitsToString(bits), nil
+	return bits
 }
 
-// extractFromDct 從 DCT 係數提取 bit
-// 回傳: (bit value 1/-1, confidence/diff)
-func extractFromDct(block [][]float64) (float64, float64) {
-	v1 := block[3][4]
-	v2 := block[4][3]
+// bytesToBits 將位元組展開為 n 個位元（不足補 0）
+func bytesToBits(data []byte, n int) []byte {
+	bits := make([]byte, n)
+	for i := range min(n, len(data)*8) {
+		bits[i] = data[i/8] >> (7 - i%8) & 1
+	}
+	return bits
+}
 
-	if v1 >= v2 {
-		return 1.0, math.Abs(v1 - v2)
+// bitsToBytes 將位元合併為位元組
+func bitsToBytes(bits []byte) []byte {
+	out := make([]byte, len(bits)/8)
+	for i, b := range bits[:len(out)*8] {
+		out[i/8] |= b << (7 - i%8)
 	}
-	return -1.0, math.Abs(v1 - v2)
+	return out
 }
 
-// bitsToString 將 bits 轉換回字串
-func bitsToString(bits []int) string {
-	var bytes []byte
-	for i := 0; i < len(bits); i += 8 {
-		var val byte
-		for j := 0; j < 8; j++ {
-			if i+j < len(bits) && bits[i+j] == 1 {
-				val |= 1 << uint(7-j)
-			}
-		}
-		bytes = append(bytes, val)
+func clip(v float64) uint8 {
+	if v < 0 {
+		return 0
 	}
-	// 去除結尾的 null chars (如果有)
-	result := string(bytes)
-	// result = strings.TrimRight(result, "\x00")
-	return result
+	if v > 255 {
+		return 255
+	}
+	return uint8(math.Round(v))
 }
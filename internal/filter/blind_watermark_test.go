@@ -1,11 +1,57 @@
 package filter
 
 import (
+	"bytes"
 	"image"
 	"image/color"
+	"image/jpeg"
+	"math"
+	"math/cmplx"
+	"math/rand/v2"
 	"testing"
+
+	"github.com/disintegration/imaging"
 )
 
+// newPhotoLikeImage 建立具有漸層、邊緣與紋理的測試圖片（模擬一般照片的內容）
+func newPhotoLikeImage(width, height int, seed uint64) *image.NRGBA {
+	rng := rand.New(rand.NewPCG(seed, seed^0x9e3779b97f4a7c15))
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	fx, fy := 0.01+rng.Float64()*0.03, 0.01+rng.Float64()*0.03
+	for y := range height {
+		for x := range width {
+			base := 110 + 60*math.Sin(float64(x)*fx)*math.Cos(float64(y)*fy)
+			if (x/97+y/61)%2 == 0 {
+				base += 30
+			}
+			texture := 12 * math.Sin(float64(x*x+y*y)*0.0007)
+			noise := rng.NormFloat64() * 3
+			v := base + texture + noise
+			img.SetNRGBA(x, y, color.NRGBA{
+				R: clip(v + 20),
+				G: clip(v),
+				B: clip(v - 25),
+				A: 255,
+			})
+		}
+	}
+	return img
+}
+
+// reencodeJPEG 以指定品質重新壓縮
+func reencodeJPEG(t *testing.T, img image.Image, quality int) image.Image {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: quality}); err != nil {
+		t.Fatalf("jpeg encode failed: %v", err)
+	}
+	out, err := jpeg.Decode(&buf)
+	if err != nil {
+		t.Fatalf("jpeg decode failed: %v", err)
+	}
+	return out
+}
+
 func TestBlindWatermarkFilter_Apply(t *testing.T) {
 	// 建立測試圖片
 	width, height := 64, 64
@@ -66,7 +112,6 @@ func TestBlindWatermarkFilter_Apply(t *testing.T) {
 			}
 
 			// 檢查像素是否發生變化 (浮水印應該會修改像素)
-			// 注意：如果強度為 0 或圖片太小可能不變，但這裡設定應該會變
 			changed := false
 			for y := 0; y < height; y++ {
 				for x := 0; x < width; x++ {
@@ -87,31 +132,73 @@ func TestBlindWatermarkFilter_Apply(t *testing.T) {
 			}
 		})
 	}
+
+	// 超過容量的文字
+	if _, err := NewBlindWatermarkFilter().Apply(img, []string{"fourteen-bytes"}); err == nil {
+		t.Error("expected error for text longer than the payload capacity")
+	}
 }
 
-func TestDct2d(t *testing.T) {
-	// 簡單測試 DCT/IDCT 可逆性 (在一定誤差範圍內)
-	block := make([][]float64, 8)
-	for i := 0; i < 8; i++ {
-		block[i] = make([]float64, 8)
-		for j := 0; j < 8; j++ {
-			block[i][j] = float64(i + j)
+func TestFFT2D(t *testing.T) {
+	// FFT/IFFT 可逆性
+	n := 16
+	data := make([]complex128, n*n)
+	for i := range data {
+		data[i] = complex(float64(i%7)-3, 0)
+	}
+	orig := append([]complex128(nil), data...)
+
+	fft2d(data, n, false)
+	// 直流分量為所有值的總和
+	var sum complex128
+	for _, v := range orig {
+		sum += v
+	}
+	if cmplx.Abs(data[0]-sum) > 1e-9 {
+		t.Errorf("DC component = %v, want %v", data[0], sum)
+	}
+
+	fft2d(data, n, true)
+	for i := range data {
+		if cmplx.Abs(data[i]-orig[i]) > 1e-9 {
+			t.Fatalf("FFT/IFFT mismatch at %d: got %v, want %v", i, data[i], orig[i])
 		}
 	}
+}
 
-	dct := dct2d(block)
-	idct := idct2d(dct)
+func TestBCHCode(t *testing.T) {
+	code := newBCHCode(18)
+	if code.k != 131 {
+		t.Fatalf("k = %d, want 131", code.k)
+	}
 
-	for i := 0; i < 8; i++ {
-		for j := 0; j < 8; j++ {
-			diff := block[i][j] - idct[i][j]
-			if diff < 0 {
-				diff = -diff
-			}
-			if diff > 0.0001 {
-				t.Errorf("DCT/IDCT mismatch at [%d][%d]: got %f, want %f", i, j, idct[i][j], block[i][j])
-			}
+	msg := bytesToBits([]byte("Copyright 2025!!"), code.k)
+	encoded := code.encode(msg)
+	if len(encoded) != bchN {
+		t.Fatalf("code length = %d, want %d", len(encoded), bchN)
+	}
+
+	// 最多修正 18 個位元錯誤
+	for _, nerr := range []int{0, 1, 9, 18} {
+		corrupted := append([]byte(nil), encoded...)
+		for i := range nerr {
+			corrupted[i*13] ^= 1
 		}
+		got, fixed, err := code.decode(corrupted)
+		if err != nil {
+			t.Fatalf("%d errors: decode failed: %v", nerr, err)
+		}
+		if string(bitsToBytes(got[:128])) != "Copyright 2025!!" || fixed != nerr {
+			t.Errorf("%d errors: got %q (fixed %d)", nerr, bitsToBytes(got[:128]), fixed)
+		}
+	}
+
+	corrupted := append([]byte(nil), encoded...)
+	for i := range 30 {
+		corrupted[i*7] ^= 1
+	}
+	if _, _, err := code.decode(corrupted); err == nil {
+		t.Error("expected decode failure with 30 errors")
 	}
 }
 
@@ -119,7 +206,7 @@ func TestBlindWatermarkFilter_Extract(t *testing.T) {
 	// 1. 準備圖片
 	width, height := 128, 128
 	img := image.NewNRGBA(image.Rect(0, 0, width, height))
-	// 填充隨機雜訊/圖案，避免純色導致 DCT 係數全零
+	// 填充圖案，避免純色圖片
 	for y := 0; y < height; y++ {
 		for x := 0; x < width; x++ {
 			img.Set(x, y, color.NRGBA{
@@ -144,19 +231,149 @@ func TestBlindWatermarkFilter_Extract(t *testing.T) {
 	}
 
 	// 3. 提取浮水印
-	// 預期提取同樣長度的文字
-	extracted, err := f.Extract(watermarkedImg, len(text))
+	extracted, err := f.Extract(watermarkedImg)
 	if err != nil {
 		t.Fatalf("Extract failed: %v", err)
 	}
 
 	// 4. 驗證
-	t.Logf("Original: %s", text)
-	t.Logf("Extracted: %s", extracted)
-
-	// 注意：DCT 隱寫可能會有誤碼，這裡檢查是否完全匹配
-	// 如果有誤碼，可以改用相似度檢查
 	if extracted != text {
 		t.Errorf("Extracted text mismatch: got %q, want %q", extracted, text)
 	}
+
+	// 金鑰不同時無法取得正確內容
+	other := NewBlindWatermarkFilter()
+	other.SecurityKey = "another_key"
+	if got, err := other.Extract(watermarkedImg); err == nil || got == text {
+		t.Errorf("extracted %q with a different key", got)
+	}
+}
+
+func TestBlindWatermarkFilter_Robustness(t *testing.T) {
+	const text = "ACME-2025"
+	f := NewBlindWatermarkFilter()
+	f.Text = text
+	f.SecurityKey = "robustness-key"
+
+	original := newPhotoLikeImage(512, 384, 1)
+	marked, err := f.Apply(original, nil)
+	if err != nil {
+		t.Fatalf("Apply failed: %v", err)
+	}
+
+	crop := func(img image.Image, ratio float64) image.Image {
+		b := img.Bounds()
+		dx, dy := int(float64(b.Dx())*ratio/2), int(float64(b.Dy())*ratio/2)
+		// 非對稱裁切，避免剛好對齊 tile
+		return imaging.Crop(img, image.Rect(dx+3, dy+1, b.Dx()-dx+3, b.Dy()-dy+1))
+	}
+	resize := func(img image.Image, scale float64) image.Image {
+		b := img.Bounds()
+		return imaging.Resize(img, int(float64(b.Dx())*scale), 0, imaging.Lanczos)
+	}
+
+	tests := []struct {
+		name   string
+		attack func(image.Image) image.Image
+	}{
+		{"jpeg q90", func(img image.Image) image.Image { return reencodeJPEG(t, img, 90) }},
+		{"jpeg q70", func(img image.Image) image.Image { return reencodeJPEG(t, img, 70) }},
+		{"jpeg q50", func(img image.Image) image.Image { return reencodeJPEG(t, img, 50) }},
+		{"resize 70%", func(img image.Image) image.Image { return reencodeJPEG(t, resize(img, 0.7), 85) }},
+		{"resize 130%", func(img image.Image) image.Image { return reencodeJPEG(t, resize(img, 1.3), 85) }},
+		{"crop 10%", func(img image.Image) image.Image { return reencodeJPEG(t, crop(img, 0.1), 85) }},
+		{"resize 75% + crop 10% + jpeg q60", func(img image.Image) image.Image {
+			return reencodeJPEG(t, crop(resize(img, 0.75), 0.1), 60)
+		}},
+		{"resize 70% + crop 10% + jpeg q50", func(img image.Image) image.Image {
+			return reencodeJPEG(t, crop(resize(img, 0.7), 0.1), 50)
+		}},
+		{"resize 125% + crop 10% + jpeg q50", func(img image.Image) image.Image {
+			return reencodeJPEG(t, crop(resize(img, 1.25), 0.1), 50)
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			det := NewBlindWatermarkReader(tt.attack(marked)).Detect(f.SecurityKey)
+			if !det.Detected || det.Text != text {
+				t.Fatalf("detected %+v, want %q", det, text)
+			}
+			if det.Confidence < 0.5 || det.Confidence > 1 {
+				t.Errorf("confidence = %.2f, want within [0.5, 1]", det.Confidence)
+			}
+		})
+	}
+}
+
+func TestBlindWatermarkFilter_Invisible(t *testing.T) {
+	original := newPhotoLikeImage(256, 256, 2)
+	f := NewBlindWatermarkFilter()
+	f.Text = "ACME"
+
+	marked, err := f.Apply(original, nil)
+	if err != nil {
+		t.Fatalf("Apply failed: %v", err)
+	}
+
+	// 預設強度下 PSNR 應高於 36dB
+	var mse float64
+	m := marked.(*image.NRGBA)
+	for i := range original.Pix {
+		d := float64(original.Pix[i]) - float64(m.Pix[i])
+		mse += d * d
+	}
+	mse /= float64(len(original.Pix))
+	if psnr := 10 * math.Log10(255*255/mse); psnr < 36 {
+		t.Errorf("PSNR = %.1f dB, want >= 36", psnr)
+	}
+}
+
+func TestTextPayload(t *testing.T) {
+	for _, text := range []string{"", "A", "ACME-2025", "thirteen-byte"} {
+		got, ok := decodeTextPayload(encodeTextPayload(text))
+		if !ok || got != text {
+			t.Errorf("round trip %q: got %q (%v)", text, got, ok)
+		}
+	}
+
+	payload := encodeTextPayload("ACME")
+	payload[0] = 2<<5 | 4
+	if _, ok := decodeTextPayload(payload); ok {
+		t.Error("expected unknown version to be rejected")
+	}
+	payload = encodeTextPayload("ACME")
+	payload[2] ^= 1
+	if _, ok := decodeTextPayload(payload); ok {
+		t.Error("expected checksum mismatch to be rejected")
+	}
+}
+
+// TestBlindWatermarkReader_Calibration 以未嵌入浮水印的圖片限制誤判
+func TestBlindWatermarkReader_Calibration(t *testing.T) {
+	var fixtures []image.Image
+	for seed := uint64(1); seed <= 8; seed++ {
+		img := newPhotoLikeImage(200+int(seed)*23, 180+int(seed)*17, seed+100)
+		fixtures = append(fixtures, img, reencodeJPEG(t, img, 60))
+	}
+
+	rng := rand.New(rand.NewPCG(1, 2))
+	noise := image.NewNRGBA(image.Rect(0, 0, 256, 256))
+	for i := range noise.Pix {
+		noise.Pix[i] = byte(rng.IntN(256))
+	}
+	gradient := image.NewNRGBA(image.Rect(0, 0, 256, 256))
+	for y := range 256 {
+		for x := range 256 {
+			gradient.SetNRGBA(x, y, color.NRGBA{R: uint8(x), G: uint8(y), B: 128, A: 255})
+		}
+	}
+	fixtures = append(fixtures, noise, gradient, imaging.New(256, 256, color.White))
+
+	// 同步峰值低於 bwScoreFloor 時信心值為 0
+	for i, img := range fixtures {
+		if det := NewBlindWatermarkReader(img).Detect("calibration-key"); det.Detected || det.Confidence != 0 {
+			t.Errorf("fixture %d: false positive %+v", i, det)
+		}
+	}
 }
@@ -0,0 +1,534 @@
+package filter
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/disintegration/imaging"
+)
+
+const (
+	// maxBorderWidth 邊框寬度上限（像素）
+	maxBorderWidth = 1000
+	// maxShadowOffset 陰影偏移量上限（像素，正負值皆同）
+	maxShadowOffset = 1000
+)
+
+// alphaMask 遮罩函式，回傳像素 (x, y)（相對於圖片左上角）的覆蓋率 0-1
+type alphaMask func(x, y int) float64
+
+// applyMask 將遮罩乘上圖片的透明度
+func applyMask(img image.Image, mask alphaMask) *image.NRGBA {
+	result := imaging.Clone(img)
+	w, h := result.Rect.Dx(), result.Rect.Dy()
+	for y := range h {
+		row := result.Pix[y*result.Stride:]
+		for x := range w {
+			cov := mask(x, y)
+			if cov >= 1 {
+				continue
+			}
+			row[x*4+3] = uint8(float64(row[x*4+3])*cov + 0.5)
+		}
+	}
+	return result
+}
+
+// edgeCoverage 依像素中心到邊緣的距離（內側為正）計算覆蓋率，邊緣 1 像素內反鋸齒
+func edgeCoverage(dist float64) float64 {
+	return clamp(dist+0.5, 0, 1)
+}
+
+// roundedRectMask 圓角矩形遮罩
+// radii 依序為左上、右上、右下、左下的半徑，超過短邊一半時自動縮小
+func roundedRectMask(width, height int, radii [4]float64) alphaMask {
+	w, h := float64(width), float64(height)
+	limit := math.Min(w, h) / 2
+	for i := range radii {
+		radii[i] = clamp(radii[i], 0, limit)
+	}
+	return func(x, y int) float64 {
+		px, py := float64(x)+0.5, float64(y)+0.5
+		left, top := px < w/2, py < h/2
+
+		var r float64
+		switch {
+		case left && top:
+			r = radii[0]
+		case !left && top:
+			r = radii[1]
+		case !left && !top:
+			r = radii[2]
+		default:
+			r = radii[3]
+		}
+		if r <= 0 {
+			return 1
+		}
+
+		// 圓角圓心
+		cx, cy := r, r
+		if !left {
+			cx = w - r
+		}
+		if !top {
+			cy = h - r
+		}
+		if (left && px >= cx) || (!left && px <= cx) || (top && py >= cy) || (!top && py <= cy) {
+			return 1
+		}
+		return edgeCoverage(r - math.Hypot(px-cx, py-cy))
+	}
+}
+
+// ellipseMask 內切橢圓遮罩（寬高相同時為圓形）
+func ellipseMask(width, height int) alphaMask {
+	a, b := float64(width)/2, float64(height)/2
+	return func(x, y int) float64 {
+		dx, dy := float64(x)+0.5-a, float64(y)+0.5-b
+		k := math.Hypot(dx/a, dy/b)
+		if k < 0.5 {
+			return 1
+		}
+		// 以梯度近似像素到橢圓邊緣的距離
+		grad := math.Hypot(dx/(a*a), dy/(b*b)) / k
+		return edgeCoverage((1 - k) / grad)
+	}
+}
+
+// circleMask 置中內切圓遮罩（直徑為短邊）
+func circleMask(width, height int) alphaMask {
+	r := math.Min(float64(width), float64(height)) / 2
+	cx, cy := float64(width)/2, float64(height)/2
+	return func(x, y int) float64 {
+		return edgeCoverage(r - math.Hypot(float64(x)+0.5-cx, float64(y)+0.5-cy))
+	}
+}
+
+// imageMask 以遮罩圖片作為遮罩，縮放至圖片大小
+// 遮罩圖片含透明像素時使用透明度，否則使用亮度（白色保留、黑色移除）
+func imageMask(mask image.Image, width, height int) alphaMask {
+	m := imaging.Resize(mask, width, height, imaging.Linear)
+	useAlpha := false
+	for i := 3; i < len(m.Pix); i += 4 {
+		if m.Pix[i] < 255 {
+			useAlpha = true
+			break
+		}
+	}
+	return func(x, y int) float64 {
+		p := m.Pix[y*m.Stride+x*4:]
+		if useAlpha {
+			return float64(p[3]) / 255
+		}
+		return (0.299*float64(p[0]) + 0.587*float64(p[1]) + 0.114*float64(p[2])) / 255
+	}
+}
+
+// parseCornerRadii 解析圓角半徑，與 CSS border-radius 相同：
+// 1 個值套用四角；2 個值為左上/右下、右上/左下；3 個值為左上、右上/左下、右下；4 個值依序為左上、右上、右下、左下
+func parseCornerRadii(params []string, def float64) ([4]float64, error) {
+	if len(params) == 0 {
+		return [4]float64{def, def, def, def}, nil
+	}
+	if len(params) > 4 {
+		return [4]float64{}, fmt.Errorf("too many corner radii: %d", len(params))
+	}
+	v := make([]float64, len(params))
+	for i, p := range params {
+		r, err := strconv.ParseFloat(p, 64)
+		if err != nil || r < 0 || math.IsInf(r, 0) {
+			return [4]float64{}, fmt.Errorf("invalid corner radius: %s", p)
+		}
+		v[i] = r
+	}
+	switch len(v) {
+	case 1:
+		return [4]float64{v[0], v[0], v[0], v[0]}, nil
+	case 2:
+		return [4]float64{v[0], v[1], v[0], v[1]}, nil
+	case 3:
+		return [4]float64{v[0], v[1], v[2], v[1]}, nil
+	default:
+		return [4]float64{v[0], v[1], v[2], v[3]}, nil
+	}
+}
+
+// MaskFilter 遮罩濾鏡
+// 以形狀或遮罩圖片裁出圖片輪廓，輪廓外變為透明
+type MaskFilter struct {
+	assets *WatermarkFilter
+}
+
+// NewMaskFilter 建立遮罩濾鏡
+// 遮罩圖片透過浮水印濾鏡的素材載入器（來源白名單、快取與失敗處理策略）載入；assets 為 nil 時僅能讀取本機檔案
+func NewMaskFilter(assets *WatermarkFilter) *MaskFilter {
+	if assets == nil {
+		assets = NewWatermarkFilter()
+	}
+	return &MaskFilter{assets: assets}
+}
+
+// Name 返回濾鏡名稱
+func (f *MaskFilter) Name() string {
+	return "mask"
+}
+
+// Apply 應用遮罩
+// params[0]: circle、ellipse、rounded 或遮罩圖片 URL／路徑
+// params[1:]: rounded 時為圓角半徑（規則同 round_corner）
+func (f *MaskFilter) Apply(img image.Image, params []string) (image.Image, error) {
+	if len(params) == 0 || params[0] == "" {
+		return nil, fmt.Errorf("mask filter requires a shape or mask image")
+	}
+	w, h := img.Bounds().Dx(), img.Bounds().Dy()
+
+	switch strings.ToLower(params[0]) {
+	case "circle":
+		return applyMask(img, circleMask(w, h)), nil
+	case "ellipse":
+		return applyMask(img, ellipseMask(w, h)), nil
+	case "rounded":
+		radii, err := parseCornerRadii(params[1:], 10)
+		if err != nil {
+			return nil, err
+		}
+		return applyMask(img, roundedRectMask(w, h, radii)), nil
+	}
+
+	mask, err := f.assets.loadOrSkip("mask", params[0])
+	if err != nil {
+		return nil, err
+	}
+	if mask == nil {
+		return img, nil
+	}
+	return applyMask(img, imageMask(mask, w, h)), nil
+}
+
+// BorderFilter 邊框濾鏡
+// 於圖片外側加上指定寬度的邊框，畫布隨之擴大
+type BorderFilter struct{}
+
+// NewBorderFilter 建立邊框濾鏡
+func NewBorderFilter() *BorderFilter {
+	return &BorderFilter{}
+}
+
+// Name 返回濾鏡名稱
+func (f *BorderFilter) Name() string {
+	return "border"
+}
+
+// Apply 應用邊框
+// params[0]: width (邊框寬度，像素，預設 10，上限 1000)
+// params[1]: color (十六進位顏色，預設 000000)
+// params[2]: radius (外框圓角半徑，可選；圖片四角同時裁為同心圓角)
+func (f *BorderFilter) Apply(img image.Image, params []string) (image.Image, error) {
+	width := 10
+	if len(params) > 0 && params[0] != "" {
+		v, err := strconv.Atoi(params[0])
+		if err != nil || v < 0 || v > maxBorderWidth {
+			return nil, fmt.Errorf("invalid border width: %s (max %d)", params[0], maxBorderWidth)
+		}
+		width = v
+	}
+	c := color.NRGBA{A: 255}
+	if len(params) > 1 && params[1] != "" {
+		v, ok := parseHexColor(params[1])
+		if !ok {
+			return nil, fmt.Errorf("invalid border color: %s", params[1])
+		}
+		c = v
+	}
+	radius := 0.0
+	if len(params) > 2 && params[2] != "" {
+		v, err := strconv.ParseFloat(params[2], 64)
+		if err != nil || v < 0 || math.IsInf(v, 0) {
+			return nil, fmt.Errorf("invalid border radius: %s", params[2])
+		}
+		radius = v
+	}
+	if width == 0 && radius == 0 {
+		return img, nil
+	}
+
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	outerW, outerH := w+2*width, h+2*width
+	outer := roundedRectMask(outerW, outerH, [4]float64{radius, radius, radius, radius})
+	innerR := math.Max(radius-float64(width), 0)
+	inner := roundedRectMask(w, h, [4]float64{innerR, innerR, innerR, innerR})
+
+	// 邊框只繪製於外框與圖片輪廓之間，圖片本身的透明區域維持透明
+	result := image.NewNRGBA(image.Rect(0, 0, outerW, outerH))
+	for y := range outerH {
+		row := result.Pix[y*result.Stride:]
+		for x := range outerW {
+			cov := outer(x, y)
+			if ix, iy := x-width, y-width; ix >= 0 && iy >= 0 && ix < w && iy < h {
+				cov *= 1 - inner(ix, iy)
+			}
+			if cov <= 0 {
+				continue
+			}
+			row[x*4], row[x*4+1], row[x*4+2] = c.R, c.G, c.B
+			row[x*4+3] = uint8(float64(c.A)*cov + 0.5)
+		}
+	}
+
+	var content image.Image = img
+	if innerR > 0 {
+		content = applyMask(img, inner)
+	}
+	draw.Draw(result, image.Rect(width, width, width+w, width+h), content, content.Bounds().Min, draw.Over)
+	return result, nil
+}
+
+// ShadowFilter 陰影濾鏡
+// 依圖片輪廓（透明度）產生模糊的投影，畫布擴大以容納陰影
+type ShadowFilter struct{}
+
+// NewShadowFilter 建立陰影濾鏡
+func NewShadowFilter() *ShadowFilter {
+	return &ShadowFilter{}
+}
+
+// Name 返回濾鏡名稱
+func (f *ShadowFilter) Name() string {
+	return "shadow"
+}
+
+// Apply 應用陰影
+// params[0]: x offset (像素，預設 5，範圍 ±1000)
+// params[1]: y offset (像素，預設 5，範圍 ±1000)
+// params[2]: color (十六進位顏色，預設 00000080)
+// params[3]: blur (模糊半徑，預設 5)
+func (f *ShadowFilter) Apply(img image.Image, params []string) (image.Image, error) {
+	offsetX, offsetY := 5, 5
+	c := color.NRGBA{A: 128}
+	blur := 5.0
+
+	for i, dst := range []*int{&offsetX, &offsetY} {
+		if len(params) > i && params[i] != "" {
+			v, err := strconv.Atoi(params[i])
+			if err != nil || v < -maxShadowOffset || v > maxShadowOffset {
+				return nil, fmt.Errorf("invalid shadow offset: %s (max %d)", params[i], maxShadowOffset)
+			}
+			*dst = v
+		}
+	}
+	if len(params) > 2 && params[2] != "" {
+		v, ok := parseHexColor(params[2])
+		if !ok {
+			return nil, fmt.Errorf("invalid shadow color: %s", params[2])
+		}
+		c = v
+	}
+	if len(params) > 3 && params[3] != "" {
+		v, err := strconv.ParseFloat(params[3], 64)
+		if err != nil || v < 0 || v > 100 {
+			return nil, fmt.Errorf("invalid shadow blur: %s", params[3])
+		}
+		blur = v
+	}
+
+	src := imaging.Clone(img)
+	w, h := src.Rect.Dx(), src.Rect.Dy()
+
+	// 陰影圖層預留模糊擴散的範圍
+	spread := int(math.Ceil(blur * 2))
+	alpha := image.NewAlpha(image.Rect(0, 0, w+2*spread, h+2*spread))
+	for y := range h {
+		for x := range w {
+			alpha.Pix[(y+spread)*alpha.Stride+x+spread] = src.Pix[y*src.Stride+x*4+3]
+		}
+	}
+	shadow := colorizeAlpha(alpha, c)
+	if blur > 0 {
+		shadow = imaging.Blur(shadow, blur)
+	}
+
+	// 畫布向陰影方向擴大，圖片保持完整
+	left, right := max(spread-offsetX, 0), max(spread+offsetX, 0)
+	top, bottom := max(spread-offsetY, 0), max(spread+offsetY, 0)
+	result := image.NewNRGBA(image.Rect(0, 0, w+left+right, h+top+bottom))
+	draw.Draw(result, shadow.Bounds().Add(image.Pt(left+offsetX-spread, top+offsetY-spread)), shadow, image.Point{}, draw.Over)
+	draw.Draw(result, src.Bounds().Add(image.Pt(left, top)), src, image.Point{}, draw.Over)
+	return result, nil
+}
+
+// blendFunc 混合模式函式，輸入底色 cb 與疊加色 cs（0-1），回傳混合後的顏色
+type blendFunc func(cb, cs float64) float64
+
+// blendModes 支援的混合模式（W3C Compositing and Blending 定義）
+var blendModes = map[string]blendFunc{
+	"normal":   func(_, cs float64) float64 { return cs },
+	"multiply": func(cb, cs float64) float64 { return cb * cs },
+	"screen":   blendScreen,
+	"overlay": func(cb, cs float64) float64 {
+		return blendHardLight(cs, cb)
+	},
+	"soft-light": blendSoftLight,
+}
+
+// blendScreen 濾色
+func blendScreen(cb, cs float64) float64 {
+	return cb + cs - cb*cs
+}
+
+// blendHardLight 實光（overlay 為底色與疊加色互換的實光）
+func blendHardLight(cb, cs float64) float64 {
+	if cs <= 0.5 {
+		return cb * 2 * cs
+	}
+	return blendScreen(cb, 2*cs-1)
+}
+
+// blendSoftLight 柔光
+func blendSoftLight(cb, cs float64) float64 {
+	if cs <= 0.5 {
+		return cb - (1-2*cs)*cb*(1-cb)
+	}
+	var d float64
+	if cb <= 0.25 {
+		d = ((16*cb-12)*cb + 4) * cb
+	} else {
+		d = math.Sqrt(cb)
+	}
+	return cb + (2*cs-1)*(d-cb)
+}
+
+// parseBlendMode 解析混合模式名稱（可使用 - 或 _，或省略分隔符號）
+func parseBlendMode(s string) (blendFunc, bool) {
+	name := strings.ToLower(strings.NewReplacer("_", "-").Replace(s))
+	if name == "softlight" {
+		name = "soft-light"
+	}
+	fn, ok := blendModes[name]
+	return fn, ok
+}
+
+// OverlayFilter 疊加濾鏡
+// 以混合模式將另一張圖片疊加於圖片上，例如紋理、漸層或光暈
+type OverlayFilter struct {
+	assets *WatermarkFilter
+}
+
+// NewOverlayFilter 建立疊加濾鏡
+// 疊加圖片透過浮水印濾鏡的素材載入器（來源白名單、快取與失敗處理策略）載入；assets 為 nil 時僅能讀取本機檔案
+func NewOverlayFilter(assets *WatermarkFilter) *OverlayFilter {
+	if assets == nil {
+		assets = NewWatermarkFilter()
+	}
+	return &OverlayFilter{assets: assets}
+}
+
+// Name 返回濾鏡名稱
+func (f *OverlayFilter) Name() string {
+	return "overlay"
+}
+
+// Apply 應用疊加
+// params[0]: overlay image URL or path
+// params[1]: blend mode (normal, multiply, screen, overlay, soft-light，預設 normal)
+// params[2]: opacity (0-100，預設 100)
+// params[3]: position (fill 縮放覆蓋整張圖片（預設）、tile 平鋪，或與 watermark 相同的九個錨點)
+// params[4]: x offset (可選，錨點定位時使用，預設 0)
+// params[5]: y offset (可選)
+func (f *OverlayFilter) Apply(img image.Image, params []string) (image.Image, error) {
+	if len(params) == 0 || params[0] == "" {
+		return nil, fmt.Errorf("overlay filter requires an image")
+	}
+	blend := blendModes["normal"]
+	if len(params) > 1 && params[1] != "" {
+		fn, ok := parseBlendMode(params[1])
+		if !ok {
+			return nil, fmt.Errorf("unsupported blend mode: %s", params[1])
+		}
+		blend = fn
+	}
+	opacity := 1.0
+	if len(params) > 2 {
+		if a, err := strconv.Atoi(params[2]); err == nil {
+			opacity = float64(clampInt(a, 0, 100)) / 100
+		}
+	}
+	position := "fill"
+	if len(params) > 3 && params[3] != "" {
+		position = strings.ToLower(params[3])
+	}
+	xOffset, yOffset := 0, 0
+	if len(params) > 4 {
+		if x, err := strconv.Atoi(params[4]); err == nil {
+			xOffset = x
+		}
+	}
+	if len(params) > 5 {
+		if y, err := strconv.Atoi(params[5]); err == nil {
+			yOffset = y
+		}
+	}
+
+	overlay, err := f.assets.loadOrSkip("overlay", params[0])
+	if err != nil {
+		return nil, err
+	}
+	if overlay == nil {
+		return img, nil
+	}
+
+	base := imaging.Clone(img)
+	w, h := base.Rect.Dx(), base.Rect.Dy()
+
+	// 建立與圖片同尺寸的疊加圖層
+	var layer *image.NRGBA
+	switch position {
+	case "fill", "stretch":
+		layer = imaging.Fill(overlay, w, h, imaging.Center, imaging.Lanczos)
+	default:
+		layer = image.NewNRGBA(base.Rect)
+		ob := overlay.Bounds()
+		if pos := parsePosition(position); pos == PositionTile {
+			tile := repeatTile(overlay, w, h)
+			tb := tile.Bounds()
+			for y := 0; y < h; y += tb.Dy() {
+				for x := 0; x < w; x += tb.Dx() {
+					draw.Draw(layer, tb.Sub(tb.Min).Add(image.Pt(x, y)), tile, tb.Min, draw.Src)
+				}
+			}
+		} else {
+			x, y := calculatePosition(base.Rect, ob, pos, xOffset, yOffset)
+			draw.Draw(layer, ob.Sub(ob.Min).Add(image.Pt(x, y)), overlay, ob.Min, draw.Src)
+		}
+	}
+
+	blendLayer(base, layer, blend, opacity)
+	return base, nil
+}
+
+// blendLayer 以混合模式將同尺寸圖層合成至底圖（非預乘色彩）
+// 疊加色先依底圖透明度與混合結果混合，再以 source-over 合成
+func blendLayer(base, layer *image.NRGBA, blend blendFunc, opacity float64) {
+	for i := 0; i+3 < len(base.Pix) && i+3 < len(layer.Pix); i += 4 {
+		as := float64(layer.Pix[i+3]) / 255 * opacity
+		if as <= 0 {
+			continue
+		}
+		ab := float64(base.Pix[i+3]) / 255
+		ao := as + ab*(1-as)
+		for c := range 3 {
+			cb := float64(base.Pix[i+c]) / 255
+			cs := float64(layer.Pix[i+c]) / 255
+			mixed := (1-ab)*cs + ab*blend(cb, cs)
+			co := (as*mixed + ab*(1-as)*cb) / ao
+			base.Pix[i+c] = uint8(clamp(co*255+0.5, 0, 255))
+		}
+		base.Pix[i+3] = uint8(clamp(ao*255+0.5, 0, 255))
+	}
+}
@@ -0,0 +1,311 @@
+package filter
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+
+	"github.com/disintegration/imaging"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// pngLoader 回傳指定圖片的素材載入器
+func pngLoader(t *testing.T, img image.Image) *stubAssetLoader {
+	t.Helper()
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, img))
+	return &stubAssetLoader{data: buf.Bytes()}
+}
+
+func TestParseCornerRadii(t *testing.T) {
+	tests := []struct {
+		params []string
+		want   [4]float64
+	}{
+		{nil, [4]float64{10, 10, 10, 10}},
+		{[]string{"5"}, [4]float64{5, 5, 5, 5}},
+		{[]string{"5", "0"}, [4]float64{5, 0, 5, 0}},
+		{[]string{"1", "2", "3"}, [4]float64{1, 2, 3, 2}},
+		{[]string{"1", "2", "3", "4"}, [4]float64{1, 2, 3, 4}},
+	}
+	for _, tt := range tests {
+		got, err := parseCornerRadii(tt.params, 10)
+		require.NoError(t, err)
+		assert.Equal(t, tt.want, got, "params %v", tt.params)
+	}
+
+	for _, params := range [][]string{{"-1"}, {"x"}, {"1", "2", "3", "4", "5"}} {
+		_, err := parseCornerRadii(params, 10)
+		assert.Error(t, err, "params %v", params)
+	}
+}
+
+func TestRoundCornersFilter_PerCorner(t *testing.T) {
+	img := imaging.New(40, 40, color.NRGBA{R: 255, A: 255})
+
+	// 左上與右下為圓角，右上與左下為直角
+	result, err := NewRoundCornersFilter().Apply(img, []string{"10", "0", "10", "0"})
+	require.NoError(t, err)
+	out := result.(*image.NRGBA)
+
+	assert.Equal(t, uint8(0), out.NRGBAAt(0, 0).A)
+	assert.Equal(t, uint8(0), out.NRGBAAt(39, 39).A)
+	assert.Equal(t, uint8(255), out.NRGBAAt(39, 0).A)
+	assert.Equal(t, uint8(255), out.NRGBAAt(0, 39).A)
+	assert.Equal(t, uint8(255), out.NRGBAAt(20, 20).A)
+	assert.Equal(t, uint8(255), out.NRGBAAt(10, 0).A, "edge past the corner stays opaque")
+
+	// 圓弧邊緣反鋸齒
+	edge := out.NRGBAAt(2, 3).A
+	assert.True(t, edge > 0 && edge < 255, "expected partial coverage on the arc, got %d", edge)
+
+	// 預設半徑 10
+	result, err = NewRoundCornersFilter().Apply(img, nil)
+	require.NoError(t, err)
+	assert.Equal(t, uint8(0), result.(*image.NRGBA).NRGBAAt(39, 0).A)
+
+	_, err = NewRoundCornersFilter().Apply(img, []string{"abc"})
+	assert.Error(t, err)
+}
+
+func TestMaskFilter_Shapes(t *testing.T) {
+	f := NewMaskFilter(nil)
+	assert.Equal(t, "mask", f.Name())
+
+	img := imaging.New(60, 40, color.NRGBA{G: 255, A: 255})
+
+	// 圓形：直徑為短邊，置中
+	result, err := f.Apply(img, []string{"circle"})
+	require.NoError(t, err)
+	out := result.(*image.NRGBA)
+	assert.Equal(t, img.Bounds(), out.Bounds())
+	assert.Equal(t, uint8(255), out.NRGBAAt(30, 20).A)
+	assert.Equal(t, uint8(255), out.NRGBAAt(30, 1).A)
+	assert.Equal(t, uint8(0), out.NRGBAAt(5, 20).A)
+	assert.Equal(t, uint8(0), out.NRGBAAt(0, 0).A)
+
+	// 橢圓：左右邊緣中點保留
+	result, err = f.Apply(img, []string{"ellipse"})
+	require.NoError(t, err)
+	out = result.(*image.NRGBA)
+	assert.Equal(t, uint8(255), out.NRGBAAt(1, 20).A)
+	assert.Equal(t, uint8(0), out.NRGBAAt(0, 0).A)
+
+	// 圓角矩形
+	result, err = f.Apply(img, []string{"rounded", "15"})
+	require.NoError(t, err)
+	out = result.(*image.NRGBA)
+	assert.Equal(t, uint8(0), out.NRGBAAt(1, 1).A)
+	assert.Equal(t, uint8(255), out.NRGBAAt(30, 0).A)
+
+	_, err = f.Apply(img, nil)
+	assert.Error(t, err)
+}
+
+func TestMaskFilter_Image(t *testing.T) {
+	img := imaging.New(20, 20, color.NRGBA{R: 255, A: 255})
+
+	// 不透明遮罩使用亮度：左半白色保留、右半黑色移除
+	lum := imaging.New(10, 10, color.NRGBA{A: 255})
+	for y := range 10 {
+		for x := range 5 {
+			lum.SetNRGBA(x, y, color.NRGBA{R: 255, G: 255, B: 255, A: 255})
+		}
+	}
+	f := NewMaskFilter(NewWatermarkFilter(WithAssetLoader(pngLoader(t, lum))))
+	result, err := f.Apply(img, []string{"mask.png"})
+	require.NoError(t, err)
+	out := result.(*image.NRGBA)
+	assert.Equal(t, uint8(255), out.NRGBAAt(2, 10).A)
+	assert.Equal(t, uint8(0), out.NRGBAAt(17, 10).A)
+
+	// 含透明度的遮罩使用透明度（黑色不透明處保留）
+	shape := image.NewNRGBA(image.Rect(0, 0, 10, 10))
+	for y := range 5 {
+		for x := range 10 {
+			shape.SetNRGBA(x, y, color.NRGBA{A: 255})
+		}
+	}
+	f = NewMaskFilter(NewWatermarkFilter(WithAssetLoader(pngLoader(t, shape))))
+	result, err = f.Apply(img, []string{"shape.png"})
+	require.NoError(t, err)
+	out = result.(*image.NRGBA)
+	assert.Equal(t, uint8(255), out.NRGBAAt(10, 2).A)
+	assert.Equal(t, uint8(0), out.NRGBAAt(10, 17).A)
+
+	// 載入失敗依浮水印的失敗處理策略
+	failing := &stubAssetLoader{err: fmt.Errorf("source not allowed")}
+	result, err = NewMaskFilter(NewWatermarkFilter(WithAssetLoader(failing))).Apply(img, []string{"mask.png"})
+	require.NoError(t, err)
+	assert.Same(t, image.Image(img), result)
+
+	_, err = NewMaskFilter(NewWatermarkFilter(WithAssetLoader(failing), WithFailOpen(false))).Apply(img, []string{"mask.png"})
+	assert.ErrorContains(t, err, "failed to load mask")
+}
+
+func TestBorderFilter(t *testing.T) {
+	f := NewBorderFilter()
+	assert.Equal(t, "border", f.Name())
+
+	red := color.NRGBA{R: 255, A: 255}
+	img := imaging.New(20, 10, color.NRGBA{B: 255, A: 255})
+	// 原圖的透明像素
+	img.SetNRGBA(10, 5, color.NRGBA{})
+
+	result, err := f.Apply(img, []string{"3", "ff0000"})
+	require.NoError(t, err)
+	out := result.(*image.NRGBA)
+	assert.Equal(t, image.Rect(0, 0, 26, 16), out.Bounds())
+	assert.Equal(t, red, out.NRGBAAt(0, 0))
+	assert.Equal(t, red, out.NRGBAAt(25, 15))
+	assert.Equal(t, color.NRGBA{B: 255, A: 255}, out.NRGBAAt(3, 3))
+	assert.Equal(t, uint8(0), out.NRGBAAt(13, 8).A, "transparent areas of the image stay transparent")
+
+	// 圓角邊框：外框角落透明，內側同心圓角
+	result, err = f.Apply(imaging.New(40, 40, color.NRGBA{B: 255, A: 255}), []string{"4", "ff0000", "12"})
+	require.NoError(t, err)
+	out = result.(*image.NRGBA)
+	assert.Equal(t, image.Rect(0, 0, 48, 48), out.Bounds())
+	assert.Equal(t, uint8(0), out.NRGBAAt(0, 0).A)
+	assert.Equal(t, red, out.NRGBAAt(24, 1))
+	assert.Equal(t, red, out.NRGBAAt(5, 5), "ring fills the gap outside the inner corner")
+	assert.Equal(t, color.NRGBA{B: 255, A: 255}, out.NRGBAAt(24, 24))
+
+	// 寬度 0 且無圓角時不變
+	result, err = f.Apply(img, []string{"0"})
+	require.NoError(t, err)
+	assert.Same(t, image.Image(img), result)
+
+	for _, params := range [][]string{{"-1"}, {"1001"}, {"60000"}, {"9223372036854775807"}, {"3", "nope"}, {"3", "000000", "x"}} {
+		_, err := f.Apply(img, params)
+		assert.Error(t, err, "params %v", params)
+	}
+}
+
+func TestShadowFilter(t *testing.T) {
+	f := NewShadowFilter()
+	assert.Equal(t, "shadow", f.Name())
+
+	white := color.NRGBA{R: 255, G: 255, B: 255, A: 255}
+	img := imaging.New(20, 20, white)
+
+	// 無模糊時畫布僅向陰影方向擴大
+	result, err := f.Apply(img, []string{"4", "6", "000000ff", "0"})
+	require.NoError(t, err)
+	out := result.(*image.NRGBA)
+	assert.Equal(t, image.Rect(0, 0, 24, 26), out.Bounds())
+	assert.Equal(t, white, out.NRGBAAt(0, 0))
+	assert.Equal(t, color.NRGBA{A: 255}, out.NRGBAAt(22, 24))
+	assert.Equal(t, uint8(0), out.NRGBAAt(1, 24).A)
+
+	// 負位移：圖片移至右下
+	result, err = f.Apply(img, []string{"-4", "-4", "000000ff", "0"})
+	require.NoError(t, err)
+	out = result.(*image.NRGBA)
+	assert.Equal(t, image.Rect(0, 0, 24, 24), out.Bounds())
+	assert.Equal(t, color.NRGBA{A: 255}, out.NRGBAAt(1, 1))
+	assert.Equal(t, white, out.NRGBAAt(23, 23))
+
+	// 模糊陰影向外漸淡，圖片完整保留
+	result, err = f.Apply(img, nil)
+	require.NoError(t, err)
+	out = result.(*image.NRGBA)
+	assert.Equal(t, image.Rect(0, 0, 40, 40), out.Bounds())
+	assert.Equal(t, white, out.NRGBAAt(5, 5))
+	near, far := out.NRGBAAt(27, 27).A, out.NRGBAAt(37, 37).A
+	assert.True(t, near > far, "shadow should fade out: near %d, far %d", near, far)
+	assert.True(t, near <= 128)
+
+	for _, params := range [][]string{{"x"}, {"60000", "0"}, {"0", "-1001"}, {"1", "1", "nope"}, {"1", "1", "000000", "-1"}} {
+		_, err := f.Apply(img, params)
+		assert.Error(t, err, "params %v", params)
+	}
+}
+
+func TestBlendModes(t *testing.T) {
+	tests := []struct {
+		mode   string
+		cb, cs float64
+		want   float64
+	}{
+		{"normal", 0.2, 0.6, 0.6},
+		{"multiply", 0.5, 0.5, 0.25},
+		{"screen", 0.5, 0.5, 0.75},
+		{"overlay", 0.25, 0.5, 0.25},
+		{"overlay", 0.75, 0.5, 0.75},
+		{"overlay", 0.25, 1, 0.5},
+		{"soft-light", 0.5, 0.5, 0.5},
+		{"soft-light", 0.5, 0, 0.25},
+		{"soft_light", 1, 1, 1},
+		{"softlight", 0, 1, 0},
+	}
+	for _, tt := range tests {
+		fn, ok := parseBlendMode(tt.mode)
+		require.True(t, ok, tt.mode)
+		assert.InDelta(t, tt.want, fn(tt.cb, tt.cs), 1e-9, "%s(%v, %v)", tt.mode, tt.cb, tt.cs)
+	}
+
+	_, ok := parseBlendMode("dissolve")
+	assert.False(t, ok)
+}
+
+func TestOverlayFilter(t *testing.T) {
+	gray := color.NRGBA{R: 128, G: 128, B: 128, A: 255}
+	base := imaging.New(20, 20, gray)
+	texture := imaging.New(4, 4, color.NRGBA{R: 255, G: 128, B: 0, A: 255})
+
+	f := NewOverlayFilter(NewWatermarkFilter(WithAssetLoader(pngLoader(t, texture))))
+	assert.Equal(t, "overlay", f.Name())
+
+	// multiply 預設覆蓋整張圖片
+	result, err := f.Apply(base, []string{"texture.png", "multiply"})
+	require.NoError(t, err)
+	out := result.(*image.NRGBA)
+	assert.Equal(t, base.Bounds(), out.Bounds())
+	for _, pt := range []image.Point{{0, 0}, {19, 19}} {
+		assert.Equal(t, color.NRGBA{R: 128, G: 64, B: 0, A: 255}, out.NRGBAAt(pt.X, pt.Y))
+	}
+
+	// screen 搭配 50% 不透明度
+	result, err = f.Apply(base, []string{"texture.png", "screen", "50"})
+	require.NoError(t, err)
+	c := result.(*image.NRGBA).NRGBAAt(10, 10)
+	assert.InDelta(t, 192, int(c.R), 1)
+	assert.InDelta(t, 160, int(c.G), 1)
+	assert.InDelta(t, 128, int(c.B), 1)
+
+	// 錨點定位時只影響疊加圖片範圍
+	result, err = f.Apply(base, []string{"texture.png", "normal", "100", "top-left", "2", "3"})
+	require.NoError(t, err)
+	out = result.(*image.NRGBA)
+	assert.Equal(t, color.NRGBA{R: 255, G: 128, B: 0, A: 255}, out.NRGBAAt(2, 3))
+	assert.Equal(t, gray, out.NRGBAAt(6, 3))
+	assert.Equal(t, gray, out.NRGBAAt(1, 3))
+
+	// 平鋪
+	result, err = f.Apply(base, []string{"texture.png", "multiply", "100", "tile"})
+	require.NoError(t, err)
+	assert.Equal(t, color.NRGBA{R: 128, G: 64, B: 0, A: 255}, result.(*image.NRGBA).NRGBAAt(19, 0))
+
+	// 透明底圖上的疊加色直接顯示
+	result, err = f.Apply(image.NewNRGBA(image.Rect(0, 0, 4, 4)), []string{"texture.png", "multiply"})
+	require.NoError(t, err)
+	assert.Equal(t, color.NRGBA{R: 255, G: 128, B: 0, A: 255}, result.(*image.NRGBA).NRGBAAt(1, 1))
+
+	_, err = f.Apply(base, []string{"texture.png", "dissolve"})
+	assert.ErrorContains(t, err, "unsupported blend mode")
+	_, err = f.Apply(base, nil)
+	assert.Error(t, err)
+
+	// 載入失敗依浮水印的失敗處理策略
+	failing := &stubAssetLoader{err: fmt.Errorf("source not allowed")}
+	result, err = NewOverlayFilter(NewWatermarkFilter(WithAssetLoader(failing))).Apply(base, []string{"texture.png"})
+	require.NoError(t, err)
+	assert.Same(t, image.Image(base), result)
+	_, err = NewOverlayFilter(NewWatermarkFilter(WithAssetLoader(failing), WithFailOpen(false))).Apply(base, []string{"texture.png"})
+	assert.ErrorContains(t, err, "failed to load overlay")
+}
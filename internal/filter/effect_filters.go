@@ -10,36 +10,6 @@ import (
 	"github.com/disintegration/imaging"
 )
 
-// RotateFilter 旋轉濾鏡
-type RotateFilter struct{}
-
-// NewRotateFilter 建立旋轉濾鏡
-func NewRotateFilter() *RotateFilter {
-	return &RotateFilter{}
-}
-
-// Name 返回濾鏡名稱
-func (f *RotateFilter) Name() string {
-	return "rotate"
-}
-
-// Apply 應用旋轉
-// params[0]: degree (角度，可為負數)
-func (f *RotateFilter) Apply(img image.Image, params []string) (image.Image, error) {
-	degree := 0.0
-	if len(params) > 0 {
-		if d, err := strconv.ParseFloat(params[0], 64); err == nil {
-			degree = d
-		}
-	}
-
-	if degree == 0 {
-		return img, nil
-	}
-
-	return imaging.Rotate(img, degree, color.Transparent), nil
-}
-
 // RoundCornersFilter 圓角濾鏡
 type RoundCornersFilter struct{}
 
@@ -54,66 +24,14 @@ func (f *RoundCornersFilter) Name() string {
 }
 
 // Apply 應用圓角效果
-// params[0]: radius (圓角半徑)
+// params[0]: radius (圓角半徑，預設 10)
+// params[0-3]: 分別指定左上、右上、右下、左下的半徑（與 CSS border-radius 順序相同，0 表示直角）
 func (f *RoundCornersFilter) Apply(img image.Image, params []string) (image.Image, error) {
-	radius := 10
-	if len(params) > 0 {
-		if r, err := strconv.Atoi(params[0]); err == nil && r > 0 {
-			radius = r
-		}
+	radii, err := parseCornerRadii(params, 10)
+	if err != nil {
+		return nil, err
 	}
-
-	bounds := img.Bounds()
-	width := bounds.Dx()
-	height := bounds.Dy()
-	result := image.NewRGBA(bounds)
-
-	// 確保半徑不超過圖片尺寸的一半
-	maxRadius := minInt(width, height) / 2
-	if radius > maxRadius {
-		radius = maxRadius
-	}
-
-	for y := 0; y < height; y++ {
-		for x := 0; x < width; x++ {
-			// 判斷是否在圓角區域外
-			if isOutsideRoundedRect(x, y, width, height, radius) {
-				result.Set(x+bounds.Min.X, y+bounds.Min.Y, color.Transparent)
-			} else {
-				result.Set(x+bounds.Min.X, y+bounds.Min.Y, img.At(x+bounds.Min.X, y+bounds.Min.Y))
-			}
-		}
-	}
-
-	return result, nil
-}
-
-// isOutsideRoundedRect 判斷點是否在圓角矩形外
-func isOutsideRoundedRect(x, y, width, height, radius int) bool {
-	// 左上角
-	if x < radius && y < radius {
-		return !isInsideCircle(x, y, radius, radius, radius)
-	}
-	// 右上角
-	if x >= width-radius && y < radius {
-		return !isInsideCircle(x, y, width-radius-1, radius, radius)
-	}
-	// 左下角
-	if x < radius && y >= height-radius {
-		return !isInsideCircle(x, y, radius, height-radius-1, radius)
-	}
-	// 右下角
-	if x >= width-radius && y >= height-radius {
-		return !isInsideCircle(x, y, width-radius-1, height-radius-1, radius)
-	}
-	return false
-}
-
-// isInsideCircle 判斷點是否在圓內
-func isInsideCircle(x, y, cx, cy, r int) bool {
-	dx := x - cx
-	dy := y - cy
-	return dx*dx+dy*dy <= r*r
+	return applyMask(img, roundedRectMask(img.Bounds().Dx(), img.Bounds().Dy(), radii)), nil
 }
 
 // NoiseFilter 雜訊濾鏡
@@ -232,11 +150,3 @@ func (f *PixelateFilter) Apply(img image.Image, params []string) (image.Image, e
 	small := imaging.Resize(img, newWidth, newHeight, imaging.NearestNeighbor)
 	return imaging.Resize(small, width, height, imaging.NearestNeighbor), nil
 }
-
-// minInt 返回較小的整數
-func minInt(a, b int) int {
-	if a < b {
-		return a
-	}
-	return b
-}
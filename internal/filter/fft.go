@@ -0,0 +1,68 @@
+package filter
+
+import (
+	"math"
+	"math/bits"
+	"math/cmplx"
+)
+
+// fft 原地計算一維複數 FFT（長度需為 2 的次方）
+// inverse 為 true 時計算反轉換並除以長度
+func fft(a []complex128, inverse bool) {
+	n := len(a)
+	if n <= 1 {
+		return
+	}
+
+	// 位元反轉排列
+	shift := 64 - bits.Len(uint(n-1))
+	for i := range n {
+		j := int(bits.Reverse64(uint64(i)) >> shift)
+		if i < j {
+			a[i], a[j] = a[j], a[i]
+		}
+	}
+
+	sign := -1.0
+	if inverse {
+		sign = 1.0
+	}
+	for size := 2; size <= n; size <<= 1 {
+		w := cmplx.Rect(1, sign*2*math.Pi/float64(size))
+		half := size / 2
+		for start := 0; start < n; start += size {
+			t := complex(1, 0)
+			for k := range half {
+				u := a[start+k]
+				v := a[start+k+half] * t
+				a[start+k] = u + v
+				a[start+k+half] = u - v
+				t *= w
+			}
+		}
+	}
+
+	if inverse {
+		scale := complex(1/float64(n), 0)
+		for i := range a {
+			a[i] *= scale
+		}
+	}
+}
+
+// fft2d 對 n x n 矩陣（列優先）原地計算二維 FFT
+func fft2d(a []complex128, n int, inverse bool) {
+	for y := range n {
+		fft(a[y*n:(y+1)*n], inverse)
+	}
+	col := make([]complex128, n)
+	for x := range n {
+		for y := range n {
+			col[y] = a[y*n+x]
+		}
+		fft(col, inverse)
+		for y := range n {
+			a[y*n+x] = col[y]
+		}
+	}
+}
@@ -186,6 +186,11 @@ func TestDefaultFiltersRegistered(t *testing.T) {
 		"blur", "grayscale", "brightness",
 		"contrast", "saturation", "sharpen",
 		"invert", "noop",
+		"levels", "curves", "vibrance", "temperature",
+		"tint", "auto_contrast", "auto_level",
+		"fill", "background_color",
+		"mask", "border", "shadow", "overlay",
+		"rotate", "shear", "perspective", "deskew",
 	}
 
 	for _, name := range expectedFilters {
@@ -0,0 +1,243 @@
+package filter
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+
+	"golang.org/x/image/font/gofont/gobold"
+	"golang.org/x/image/font/gofont/goitalic"
+	"golang.org/x/image/font/gofont/gomono"
+	"golang.org/x/image/font/gofont/gomonobold"
+	"golang.org/x/image/font/gofont/goregular"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/font/sfnt"
+
+	"github.com/vincent119/images-filters/pkg/logger"
+)
+
+// DefaultFontName 內嵌的預設字型
+const DefaultFontName = "go"
+
+// embeddedFonts 內嵌字型（Go 字型，僅涵蓋拉丁、希臘與西里爾字母）
+var embeddedFonts = map[string][]byte{
+	"go":           goregular.TTF,
+	"go-bold":      gobold.TTF,
+	"go-italic":    goitalic.TTF,
+	"go-mono":      gomono.TTF,
+	"go-mono-bold": gomonobold.TTF,
+}
+
+// FontSet 文字濾鏡使用的字型集合
+// 字型本身可並行使用；缺字時依序改用 fallback 字型（例如 CJK 字型）
+type FontSet struct {
+	fonts       map[string]*sfnt.Font
+	fallback    []*sfnt.Font
+	defaultFont *sfnt.Font
+}
+
+// FontSetOption 字型集合選項
+type FontSetOption func(*fontSetOptions)
+
+type fontSetOptions struct {
+	dir         string
+	defaultName string
+	fallback    []string
+}
+
+// WithFontDir 從目錄載入 .ttf/.otf/.ttc 字型
+// 字型以小寫檔名（不含副檔名）與字型家族名稱（空白改為 -）註冊
+func WithFontDir(dir string) FontSetOption {
+	return func(o *fontSetOptions) {
+		o.dir = dir
+	}
+}
+
+// WithDefaultFont 設定未指定字型時使用的字型
+func WithDefaultFont(name string) FontSetOption {
+	return func(o *fontSetOptions) {
+		o.defaultName = name
+	}
+}
+
+// WithFallbackFonts 設定缺字時依序嘗試的字型
+// 未設定時使用字型目錄中的所有字型
+func WithFallbackFonts(names ...string) FontSetOption {
+	return func(o *fontSetOptions) {
+		o.fallback = append(o.fallback, names...)
+	}
+}
+
+// NewFontSet 建立字型集合，包含內嵌字型與字型目錄中的字型
+func NewFontSet(opts ...FontSetOption) (*FontSet, error) {
+	options := &fontSetOptions{defaultName: DefaultFontName}
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	fs := &FontSet{fonts: make(map[string]*sfnt.Font)}
+	for name, data := range embeddedFonts {
+		f, err := opentype.Parse(data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse embedded font %s: %w", name, err)
+		}
+		fs.fonts[name] = f
+	}
+
+	var dirFonts []*sfnt.Font
+	if options.dir != "" {
+		var err error
+		if dirFonts, err = fs.loadDir(options.dir); err != nil {
+			return nil, err
+		}
+	}
+
+	if options.defaultName == "" {
+		options.defaultName = DefaultFontName
+	}
+	f, ok := fs.Lookup(options.defaultName)
+	if !ok {
+		return nil, fmt.Errorf("default font not found: %s", options.defaultName)
+	}
+	fs.defaultFont = f
+
+	if len(options.fallback) == 0 {
+		fs.fallback = dirFonts
+	}
+	for _, name := range options.fallback {
+		f, ok := fs.Lookup(name)
+		if !ok {
+			return nil, fmt.Errorf("fallback font not found: %s", name)
+		}
+		fs.fallback = append(fs.fallback, f)
+	}
+
+	return fs, nil
+}
+
+// defaultFontSet 僅含內嵌字型的字型集合
+var defaultFontSet = sync.OnceValue(func() *FontSet {
+	fs, err := NewFontSet()
+	if err != nil {
+		panic(err)
+	}
+	return fs
+})
+
+// DefaultFontSet 取得僅含內嵌字型的字型集合
+func DefaultFontSet() *FontSet {
+	return defaultFontSet()
+}
+
+// loadDir 載入目錄中的字型，回傳依檔名排序的字型（供 fallback 使用）
+func (fs *FontSet) loadDir(dir string) ([]*sfnt.Font, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read font directory: %w", err)
+	}
+
+	var loaded []*sfnt.Font
+	for _, entry := range entries {
+		ext := strings.ToLower(filepath.Ext(entry.Name()))
+		if entry.IsDir() || (ext != ".ttf" && ext != ".otf" && ext != ".ttc" && ext != ".otc") {
+			continue
+		}
+
+		data, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read font %s: %w", entry.Name(), err)
+		}
+
+		var fonts []*sfnt.Font
+		if ext == ".ttc" || ext == ".otc" {
+			fonts, err = parseCollection(data)
+		} else {
+			var f *sfnt.Font
+			if f, err = opentype.Parse(data); err == nil {
+				fonts = []*sfnt.Font{f}
+			}
+		}
+		if err != nil {
+			logger.Warn("skipping unreadable font", logger.String("file", entry.Name()), logger.Err(err))
+			continue
+		}
+
+		fs.add(fontKey(strings.TrimSuffix(entry.Name(), filepath.Ext(entry.Name()))), fonts[0])
+		var buf sfnt.Buffer
+		for _, f := range fonts {
+			if family, err := f.Name(&buf, sfnt.NameIDFamily); err == nil && family != "" {
+				fs.add(fontKey(family), f)
+			}
+		}
+		loaded = append(loaded, fonts...)
+	}
+	return loaded, nil
+}
+
+// parseCollection 解析字型集合檔（.ttc/.otc）
+func parseCollection(data []byte) ([]*sfnt.Font, error) {
+	c, err := opentype.ParseCollection(data)
+	if err != nil {
+		return nil, err
+	}
+	fonts := make([]*sfnt.Font, 0, c.NumFonts())
+	for i := range c.NumFonts() {
+		f, err := c.Font(i)
+		if err != nil {
+			return nil, err
+		}
+		fonts = append(fonts, f)
+	}
+	return fonts, nil
+}
+
+// add 註冊字型，不覆蓋已存在的名稱
+func (fs *FontSet) add(name string, f *sfnt.Font) {
+	if _, exists := fs.fonts[name]; !exists {
+		fs.fonts[name] = f
+	}
+}
+
+// Lookup 依名稱取得字型（不分大小寫）
+func (fs *FontSet) Lookup(name string) (*sfnt.Font, bool) {
+	f, ok := fs.fonts[fontKey(name)]
+	return f, ok
+}
+
+// Names 列出所有字型名稱
+func (fs *FontSet) Names() []string {
+	names := make([]string, 0, len(fs.fonts))
+	for name := range fs.fonts {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// fontFor 取得可顯示 r 的字型：優先使用 primary，缺字時依序嘗試 fallback
+// 所有字型皆缺字時回傳 primary（顯示缺字符號）
+func (fs *FontSet) fontFor(primary *sfnt.Font, r rune, buf *sfnt.Buffer) *sfnt.Font {
+	if hasGlyph(primary, r, buf) {
+		return primary
+	}
+	for _, f := range fs.fallback {
+		if hasGlyph(f, r, buf) {
+			return f
+		}
+	}
+	return primary
+}
+
+// hasGlyph 檢查字型是否包含字元
+func hasGlyph(f *sfnt.Font, r rune, buf *sfnt.Buffer) bool {
+	idx, err := f.GlyphIndex(buf, r)
+	return err == nil && idx != 0
+}
+
+// fontKey 正規化字型名稱
+func fontKey(name string) string {
+	return strings.ReplaceAll(strings.ToLower(strings.TrimSpace(name)), " ", "-")
+}
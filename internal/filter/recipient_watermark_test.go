@@ -0,0 +1,144 @@
+package filter
+
+import (
+	"image"
+	"testing"
+	"time"
+
+	"github.com/disintegration/imaging"
+)
+
+func TestRecipientPayload(t *testing.T) {
+	ts := time.Date(2025, 12, 26, 8, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		id      string
+		wantErr bool
+	}{
+		{"numeric", "18446744073709551615", false},
+		{"string", "user-42", false},
+		{"leading zero kept as string", "007", false},
+		{"too long", "recipient-123", true},
+		{"empty", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			payload, err := encodeRecipientPayload(tt.id, ts)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("encodeRecipientPayload() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if len(payload) != bwDataBytes {
+				t.Fatalf("payload length = %d, want %d", len(payload), bwDataBytes)
+			}
+
+			got, ok := decodeRecipientPayload(payload)
+			if !ok {
+				t.Fatal("decodeRecipientPayload() failed")
+			}
+			if got.RecipientID != tt.id || !got.Timestamp.Equal(ts) {
+				t.Errorf("got %+v, want id %q at %v", got, tt.id, ts)
+			}
+
+			// 校驗碼不符時拒絕
+			payload[3] ^= 0x10
+			if _, ok := decodeRecipientPayload(payload); ok {
+				t.Error("expected checksum mismatch to be rejected")
+			}
+		})
+	}
+}
+
+func TestRecipientWatermarkFilter_Apply(t *testing.T) {
+	ts := time.Date(2025, 12, 26, 8, 30, 0, 0, time.UTC)
+	f := NewRecipientWatermarkFilter()
+	f.SecurityKey = "recipient-key"
+	f.now = func() time.Time { return ts }
+
+	if _, err := f.Apply(newPhotoLikeImage(64, 64, 1), nil); err == nil {
+		t.Error("expected error without recipient id")
+	}
+	if _, err := f.Apply(newPhotoLikeImage(64, 64, 1), []string{"much-too-long-id"}); err == nil {
+		t.Error("expected error for invalid recipient id")
+	}
+
+	// 先嵌入上傳時的文字浮水印，再於算圖時嵌入收件者浮水印
+	bw := NewBlindWatermarkFilter()
+	bw.Text = "ACME"
+	bw.SecurityKey = f.SecurityKey
+	uploaded, err := bw.Apply(newPhotoLikeImage(512, 384, 3), nil)
+	if err != nil {
+		t.Fatalf("blind watermark Apply failed: %v", err)
+	}
+	marked, err := f.Apply(uploaded, []string{"1234567"})
+	if err != nil {
+		t.Fatalf("Apply failed: %v", err)
+	}
+
+	// 縮放、裁切並重新壓縮後仍可讀出兩個通道
+	attacks := map[string]func(image.Image) image.Image{
+		"resize + jpeg q75": func(img image.Image) image.Image {
+			return reencodeJPEG(t, imaging.Resize(img, 400, 0, imaging.Lanczos), 75)
+		},
+		"resize 75% + crop + jpeg q60": func(img image.Image) image.Image {
+			img = imaging.Resize(img, 384, 0, imaging.Lanczos)
+			return reencodeJPEG(t, imaging.Crop(img, image.Rect(22, 15, 362, 273)), 60)
+		},
+	}
+	var reader *BlindWatermarkReader
+	for name, attack := range attacks {
+		reader = NewBlindWatermarkReader(attack(marked))
+		got, ok := reader.Recipient(f.SecurityKey)
+		if !ok {
+			t.Errorf("%s: recipient watermark not detected", name)
+			continue
+		}
+		if got.RecipientID != "1234567" || !got.Timestamp.Equal(ts) {
+			t.Errorf("%s: got %+v, want recipient 1234567 at %v", name, got, ts)
+		}
+		if det := reader.Detect(bw.SecurityKey); det.Text != "ACME" {
+			t.Errorf("%s: text watermark = %q, want %q", name, det.Text, "ACME")
+		}
+	}
+
+	// 實際算圖流程：上傳時嵌入文字浮水印，算圖時先縮小至約 300px 寬，再嵌入收件者浮水印
+	// 縮放比例 62.5% 仍在文字浮水印的偵測範圍（60% 以上）內
+	original, err := bw.Apply(newPhotoLikeImage(480, 360, 5), nil)
+	if err != nil {
+		t.Fatalf("blind watermark Apply failed: %v", err)
+	}
+	resized := imaging.Resize(original, 300, 0, imaging.Lanczos)
+	rendered, err := f.Apply(resized, []string{"1234567"})
+	if err != nil {
+		t.Fatalf("Apply after resize failed: %v", err)
+	}
+	for name, img := range map[string]image.Image{
+		"resize then recipient":            rendered,
+		"resize then recipient + jpeg q85": reencodeJPEG(t, rendered, 85),
+	} {
+		r := NewBlindWatermarkReader(img)
+		if det := r.Detect(bw.SecurityKey); det.Text != "ACME" {
+			t.Errorf("%s: text watermark = %q, want %q", name, det.Text, "ACME")
+		}
+		got, ok := r.Recipient(f.SecurityKey)
+		if !ok {
+			t.Errorf("%s: recipient watermark not detected", name)
+			continue
+		}
+		if got.RecipientID != "1234567" || !got.Timestamp.Equal(ts) {
+			t.Errorf("%s: got %+v, want recipient 1234567 at %v", name, got, ts)
+		}
+	}
+
+	// 其他金鑰或未嵌入的圖片不應讀出收件者
+	if _, ok := reader.Recipient("another-key"); ok {
+		t.Error("recipient detected with a different key")
+	}
+	if _, ok := NewBlindWatermarkReader(uploaded).Recipient(f.SecurityKey); ok {
+		t.Error("recipient detected on an image without recipient watermark")
+	}
+}
@@ -19,14 +19,38 @@ func RegisterDefaultFilters(r *Registry) {
 	r.MustRegister(NewGammaFilter())
 	r.MustRegister(NewHueFilter())
 
+	// 進階調整濾鏡
+	r.MustRegister(NewLevelsFilter())
+	r.MustRegister(NewCurvesFilter())
+	r.MustRegister(NewVibranceFilter())
+	r.MustRegister(NewTemperatureFilter())
+	r.MustRegister(NewTintFilter())
+	r.MustRegister(NewAutoContrastFilter())
+	r.MustRegister(NewAutoLevelFilter())
+
 	// 特效濾鏡
-	r.MustRegister(NewRotateFilter())
 	r.MustRegister(NewRoundCornersFilter())
 	r.MustRegister(NewNoiseFilter())
 	r.MustRegister(NewFlipHFilter())
 	r.MustRegister(NewFlipVFilter())
 	r.MustRegister(NewPixelateFilter())
 
+	// 幾何轉換濾鏡
+	r.MustRegister(NewRotateFilter())
+	r.MustRegister(NewShearFilter())
+	r.MustRegister(NewPerspectiveFilter())
+	r.MustRegister(NewDeskewFilter())
+
+	// 背景濾鏡
+	r.MustRegister(NewFillFilter())
+	r.MustRegister(NewBackgroundColorFilter())
+
+	// 合成濾鏡
+	r.MustRegister(NewMaskFilter(nil))
+	r.MustRegister(NewBorderFilter())
+	r.MustRegister(NewShadowFilter())
+	r.MustRegister(NewOverlayFilter(nil))
+
 	// 輸出控制濾鏡
 	r.MustRegister(NewQualityFilter())
 	r.MustRegister(NewFormatFilter())
@@ -37,6 +61,8 @@ func RegisterDefaultFilters(r *Registry) {
 	// 浮水印濾鏡
 	r.MustRegister(NewWatermarkFilter())
 	r.MustRegister(NewBlindWatermarkFilter())
+	r.MustRegister(NewRecipientWatermarkFilter())
+	r.MustRegister(NewTextFilter())
 }
 
 // init 自動註冊到全域 Registry
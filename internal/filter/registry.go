@@ -33,6 +33,13 @@ func (r *Registry) Register(filter Filter) error {
 	return nil
 }
 
+// Replace 註冊濾鏡，同名濾鏡已存在時取代（用於替換需要設定的預設濾鏡）
+func (r *Registry) Replace(filter Filter) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.filters[filter.Name()] = filter
+}
+
 // MustRegister 註冊濾鏡，失敗時 panic
 func (r *Registry) MustRegister(filter Filter) {
 	if err := r.Register(filter); err != nil {
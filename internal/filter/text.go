@@ -0,0 +1,523 @@
+package filter
+
+import (
+	"encoding/base64"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/disintegration/imaging"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/font/sfnt"
+	"golang.org/x/image/math/fixed"
+)
+
+const (
+	// maxTextRunes 文字長度上限
+	maxTextRunes = 256
+	// maxFontPixels 字型大小上限（像素）
+	maxFontPixels = 1024
+	// maxStrokeWidth 外框寬度上限（像素）
+	maxStrokeWidth = 32
+	// textBase64Prefix 以 base64url 編碼的文字前綴，用於含逗號、斜線或括號的文字
+	textBase64Prefix = "b64:"
+)
+
+// TextFilter 文字浮水印濾鏡
+type TextFilter struct {
+	fonts *FontSet
+}
+
+// TextFilterOption 文字濾鏡選項
+type TextFilterOption func(*TextFilter)
+
+// WithFontSet 設定文字濾鏡使用的字型集合
+func WithFontSet(fonts *FontSet) TextFilterOption {
+	return func(f *TextFilter) {
+		if fonts != nil {
+			f.fonts = fonts
+		}
+	}
+}
+
+// NewTextFilter 建立文字浮水印濾鏡，預設僅使用內嵌字型
+func NewTextFilter(opts ...TextFilterOption) *TextFilter {
+	f := &TextFilter{}
+	for _, opt := range opts {
+		opt(f)
+	}
+	if f.fonts == nil {
+		f.fonts = DefaultFontSet()
+	}
+	return f
+}
+
+// Name 返回濾鏡名稱
+func (f *TextFilter) Name() string {
+	return "text"
+}
+
+// textOptions 文字濾鏡參數
+type textOptions struct {
+	text         string
+	position     WatermarkPosition
+	size         float64 // 字型大小，圖片寬度的百分比
+	color        color.NRGBA
+	font         *sfnt.Font
+	xOffset      int
+	yOffset      int
+	rotation     float64
+	strokeWidth  int
+	strokeColor  color.NRGBA
+	shadow       bool
+	shadowX      int
+	shadowY      int
+	shadowColor  color.NRGBA
+	shadowRadius float64
+	stagger      float64
+}
+
+// Apply 應用文字浮水印
+// params[0]: text（含逗號、斜線或括號時使用 b64:{base64url}；可含換行）
+// params[1]: position (center, top-left, top-right, bottom-left, bottom-right, top, bottom, left, right, tile)
+// params[2]: size（字型大小，圖片寬度的百分比，預設 5）
+// params[3]: color（RGB、RGBA、RRGGBB 或 RRGGBBAA，預設 ffffff）
+// params[4]: font（字型名稱，預設為設定的預設字型）
+// params[5]: x offset (可選，平鋪時為水平間距)
+// params[6]: y offset (可選，平鋪時為垂直間距)
+// params[7]: rotation（旋轉角度，逆時針，可選；平鋪時同時旋轉排列方向）
+// params[8]: stroke（外框 width[:color]，如 2:000000，可選）
+// params[9]: shadow（陰影 x:y[:color[:blur]]，如 2:2:00000080:1，可選）
+// params[10]: stagger（僅平鋪，奇數列位移比例 0-1 或 true，可選）
+func (f *TextFilter) Apply(img image.Image, params []string) (image.Image, error) {
+	opts, err := f.parseParams(params)
+	if err != nil {
+		return nil, err
+	}
+	if opts == nil {
+		return img, nil
+	}
+
+	layer := f.render(opts, img.Bounds().Dx(), img.Bounds().Dy())
+	if layer == nil {
+		return img, nil
+	}
+
+	result := image.NewRGBA(img.Bounds())
+	draw.Draw(result, img.Bounds(), img, img.Bounds().Min, draw.Src)
+
+	// 平鋪模式
+	if opts.position == PositionTile {
+		drawTiled(result, layer, tileOptions{
+			spacingX: opts.xOffset,
+			spacingY: opts.yOffset,
+			angle:    opts.rotation,
+			stagger:  opts.stagger,
+		})
+		return result, nil
+	}
+
+	if opts.rotation != 0 {
+		layer = imaging.Rotate(layer, opts.rotation, color.Transparent)
+	}
+
+	// 計算位置並繪製
+	x, y := calculatePosition(img.Bounds(), layer.Bounds(), opts.position, opts.xOffset, opts.yOffset)
+	pt := img.Bounds().Min.Add(image.Point{X: x, Y: y})
+	draw.Draw(result, layer.Bounds().Add(pt), layer, image.Point{}, draw.Over)
+
+	return result, nil
+}
+
+// parseParams 解析參數，文字為空或無法解碼時回傳 nil（不繪製）
+func (f *TextFilter) parseParams(params []string) (*textOptions, error) {
+	if len(params) == 0 {
+		return nil, nil
+	}
+
+	text, ok := decodeText(params[0])
+	if !ok || strings.TrimSpace(text) == "" {
+		return nil, nil
+	}
+	if runes := []rune(text); len(runes) > maxTextRunes {
+		text = string(runes[:maxTextRunes])
+	}
+
+	opts := &textOptions{
+		text:        text,
+		position:    PositionBottomRight,
+		size:        5,
+		color:       color.NRGBA{R: 255, G: 255, B: 255, A: 255},
+		font:        f.fonts.defaultFont,
+		xOffset:     10,
+		yOffset:     10,
+		strokeColor: color.NRGBA{A: 255},
+		shadowColor: color.NRGBA{A: 128},
+	}
+
+	param := func(i int) string {
+		if i < len(params) {
+			return params[i]
+		}
+		return ""
+	}
+
+	if p := param(1); p != "" {
+		opts.position = parsePosition(p)
+	}
+	if s, err := strconv.ParseFloat(param(2), 64); err == nil && s > 0 {
+		opts.size = clamp(s, 0.5, 100)
+	}
+	if c, ok := parseHexColor(param(3)); ok {
+		opts.color = c
+	}
+	if font, ok := f.fonts.Lookup(param(4)); ok {
+		opts.font = font
+	}
+	if x, err := strconv.Atoi(param(5)); err == nil {
+		opts.xOffset = x
+	}
+	if y, err := strconv.Atoi(param(6)); err == nil {
+		opts.yOffset = y
+	}
+	if r, err := strconv.ParseFloat(param(7), 64); err == nil {
+		if math.IsNaN(r) || math.IsInf(r, 0) {
+			return nil, fmt.Errorf("invalid rotation angle: %s", param(7))
+		}
+		opts.rotation = math.Mod(r, 360)
+	}
+	if p := param(8); p != "" {
+		parts := strings.Split(p, ":")
+		if w, err := strconv.Atoi(parts[0]); err == nil && w > 0 {
+			opts.strokeWidth = clampInt(w, 1, maxStrokeWidth)
+		}
+		if len(parts) > 1 {
+			if c, ok := parseHexColor(parts[1]); ok {
+				opts.strokeColor = c
+			}
+		}
+	}
+	if p := param(9); p != "" {
+		parts := strings.Split(p, ":")
+		if len(parts) >= 2 {
+			dx, errX := strconv.Atoi(parts[0])
+			dy, errY := strconv.Atoi(parts[1])
+			if errX == nil && errY == nil {
+				opts.shadow = true
+				opts.shadowX = clampInt(dx, -maxStrokeWidth, maxStrokeWidth)
+				opts.shadowY = clampInt(dy, -maxStrokeWidth, maxStrokeWidth)
+			}
+		}
+		if len(parts) > 2 {
+			if c, ok := parseHexColor(parts[2]); ok {
+				opts.shadowColor = c
+			}
+		}
+		if len(parts) > 3 {
+			if b, err := strconv.ParseFloat(parts[3], 64); err == nil && b > 0 {
+				opts.shadowRadius = clamp(b, 0, maxStrokeWidth)
+			}
+		}
+	}
+
+	if p := param(10); p != "" {
+		opts.stagger = parseStagger(p)
+	}
+
+	return opts, nil
+}
+
+// textRun 使用同一字型的連續文字
+type textRun struct {
+	face font.Face
+	text string
+}
+
+// textLine 一行文字的排版結果
+type textLine struct {
+	runs    []textRun
+	width   fixed.Int26_6
+	ascent  fixed.Int26_6
+	descent fixed.Int26_6
+}
+
+// render 將文字（含外框與陰影）繪製為透明背景的圖層
+// 圖層上限為圖片長邊的兩倍（涵蓋旋轉後的對角線），超出的部分依錨點對齊方式裁切
+func (f *TextFilter) render(opts *textOptions, imgWidth, imgHeight int) *image.NRGBA {
+	px := clamp(float64(imgWidth)*opts.size/100, 4, maxFontPixels)
+
+	// Face 不可並行使用，每次繪製各自建立
+	faces := make(map[*sfnt.Font]font.Face)
+	faceFor := func(fnt *sfnt.Font) font.Face {
+		if face, ok := faces[fnt]; ok {
+			return face
+		}
+		face, err := opentype.NewFace(fnt, &opentype.FaceOptions{Size: px, DPI: 72, Hinting: font.HintingNone})
+		if err != nil {
+			return nil
+		}
+		faces[fnt] = face
+		return face
+	}
+	defer func() {
+		for _, face := range faces {
+			face.Close()
+		}
+	}()
+
+	primary := faceFor(opts.font)
+	if primary == nil {
+		return nil
+	}
+
+	// 依字型分段排版，缺字時改用 fallback 字型
+	var buf sfnt.Buffer
+	var lines []textLine
+	var width, height fixed.Int26_6
+	for _, s := range strings.Split(opts.text, "\n") {
+		line := textLine{ascent: primary.Metrics().Ascent, descent: primary.Metrics().Descent}
+		var current *sfnt.Font
+		var run strings.Builder
+		flush := func() {
+			if run.Len() == 0 {
+				return
+			}
+			face := faceFor(current)
+			if face == nil {
+				face = primary
+			}
+			line.runs = append(line.runs, textRun{face: face, text: run.String()})
+			line.width += font.MeasureString(face, run.String())
+			line.ascent = max(line.ascent, face.Metrics().Ascent)
+			line.descent = max(line.descent, face.Metrics().Descent)
+			run.Reset()
+		}
+		for _, r := range s {
+			fnt := f.fonts.fontFor(opts.font, r, &buf)
+			if fnt != current {
+				flush()
+				current = fnt
+			}
+			run.WriteRune(r)
+		}
+		flush()
+
+		lines = append(lines, line)
+		width = max(width, line.width)
+		height += line.ascent + line.descent
+	}
+
+	pad := opts.strokeWidth
+	if opts.shadow {
+		pad += max(abs(opts.shadowX), abs(opts.shadowY)) + int(math.Ceil(opts.shadowRadius*2))
+	}
+	w, h := width.Ceil()+2*pad, height.Ceil()+2*pad
+	if w <= 2*pad || h <= 2*pad {
+		return nil
+	}
+
+	// 在外框與陰影處理前裁切，避免超長文字配置過大的圖層
+	var origin image.Point
+	limit := 2 * max(imgWidth, imgHeight)
+	if w > limit {
+		origin.X = cropOffset(w, limit, textAlign(opts.position))
+		w = limit
+	}
+	if h > limit {
+		origin.Y = cropOffset(h, limit, textVerticalAlign(opts.position))
+		h = limit
+	}
+
+	// 以 alpha 遮罩繪製文字，多行時依錨點對齊
+	mask := image.NewAlpha(image.Rect(0, 0, w, h))
+	y := fixed.I(pad - origin.Y)
+	for _, line := range lines {
+		x := fixed.I(pad - origin.X)
+		switch textAlign(opts.position) {
+		case 0:
+			x += (width - line.width) / 2
+		case 1:
+			x += width - line.width
+		}
+		d := &font.Drawer{Dst: mask, Src: image.Opaque, Dot: fixed.Point26_6{X: x, Y: y + line.ascent}}
+		for _, run := range line.runs {
+			d.Face = run.face
+			d.DrawString(run.text)
+		}
+		y += line.ascent + line.descent
+	}
+
+	outline := mask
+	if opts.strokeWidth > 0 {
+		outline = dilateAlpha(mask, opts.strokeWidth)
+	}
+
+	layer := image.NewNRGBA(mask.Bounds())
+	if opts.shadow {
+		shadow := colorizeAlpha(outline, opts.shadowColor)
+		if opts.shadowRadius > 0 {
+			shadow = imaging.Blur(shadow, opts.shadowRadius)
+		}
+		draw.Draw(layer, layer.Bounds().Add(image.Point{X: opts.shadowX, Y: opts.shadowY}), shadow, image.Point{}, draw.Over)
+	}
+	if opts.strokeWidth > 0 {
+		draw.DrawMask(layer, layer.Bounds(), image.NewUniform(opts.strokeColor), image.Point{}, outline, image.Point{}, draw.Over)
+	}
+	draw.DrawMask(layer, layer.Bounds(), image.NewUniform(opts.color), image.Point{}, mask, image.Point{}, draw.Over)
+
+	return layer
+}
+
+// textAlign 依錨點決定多行文字的對齊方式：-1 靠左、0 置中、1 靠右
+func textAlign(pos WatermarkPosition) int {
+	switch pos {
+	case PositionTopLeft, PositionBottomLeft, PositionLeft:
+		return -1
+	case PositionTopRight, PositionBottomRight, PositionRight:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// textVerticalAlign 依錨點決定文字的垂直對齊方式：-1 靠上、0 置中、1 靠下
+func textVerticalAlign(pos WatermarkPosition) int {
+	switch pos {
+	case PositionTopLeft, PositionTopRight, PositionTop:
+		return -1
+	case PositionBottomLeft, PositionBottomRight, PositionBottom:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// cropOffset 依對齊方式決定裁切至 limit 時保留區段的起點
+func cropOffset(size, limit, align int) int {
+	switch align {
+	case -1:
+		return 0
+	case 1:
+		return size - limit
+	default:
+		return (size - limit) / 2
+	}
+}
+
+// dilateAlpha 以圓形範圍擴張遮罩（取範圍內最大值），用於產生外框
+// 圓形拆解為各列不同半寬的水平線段：水平方向每次擴張 1 像素，再將對應的列位移取最大值，
+// 複雜度為 O(n·r)
+func dilateAlpha(mask *image.Alpha, radius int) *image.Alpha {
+	b := mask.Bounds()
+	w, h := b.Dx(), b.Dy()
+	result := image.NewAlpha(b)
+	if w == 0 || h == 0 {
+		return result
+	}
+
+	// rows[k] 為水平半寬 k 的列位移
+	rows := make([][]int, radius+1)
+	for dy := -radius; dy <= radius; dy++ {
+		k := int(math.Sqrt(float64(radius*radius - dy*dy)))
+		rows[k] = append(rows[k], dy)
+	}
+
+	cur := make([]uint8, w*h)
+	for y := range h {
+		copy(cur[y*w:(y+1)*w], mask.Pix[y*mask.Stride:])
+	}
+	next := make([]uint8, w*h)
+
+	for k := 0; k <= radius; k++ {
+		if k > 0 {
+			for y := range h {
+				src, dst := cur[y*w:(y+1)*w], next[y*w:(y+1)*w]
+				for x, v := range src {
+					if x > 0 && src[x-1] > v {
+						v = src[x-1]
+					}
+					if x < w-1 && src[x+1] > v {
+						v = src[x+1]
+					}
+					dst[x] = v
+				}
+			}
+			cur, next = next, cur
+		}
+		for _, dy := range rows[k] {
+			for y := max(0, -dy); y < min(h, h-dy); y++ {
+				src, dst := cur[(y+dy)*w:(y+dy+1)*w], result.Pix[y*result.Stride:y*result.Stride+w]
+				for x, v := range src {
+					if v > dst[x] {
+						dst[x] = v
+					}
+				}
+			}
+		}
+	}
+	return result
+}
+
+// colorizeAlpha 以遮罩作為透明度產生單色圖片
+func colorizeAlpha(mask *image.Alpha, c color.NRGBA) *image.NRGBA {
+	result := image.NewNRGBA(mask.Bounds())
+	for i, a := range mask.Pix {
+		result.Pix[i*4] = c.R
+		result.Pix[i*4+1] = c.G
+		result.Pix[i*4+2] = c.B
+		result.Pix[i*4+3] = uint8(int(a) * int(c.A) / 255)
+	}
+	return result
+}
+
+// decodeText 解碼文字參數（b64: 前綴使用 base64url，可含或不含 padding）
+func decodeText(s string) (string, bool) {
+	if !strings.HasPrefix(s, textBase64Prefix) {
+		return s, true
+	}
+	data, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(strings.TrimPrefix(s, textBase64Prefix), "="))
+	if err != nil {
+		return "", false
+	}
+	return string(data), true
+}
+
+// parseHexColor 解析十六進位顏色（RGB、RGBA、RRGGBB、RRGGBBAA，可含 # 前綴）
+func parseHexColor(s string) (color.NRGBA, bool) {
+	s = strings.TrimPrefix(strings.TrimSpace(s), "#")
+	switch len(s) {
+	case 3, 4:
+		// 短格式展開為完整格式
+		var b strings.Builder
+		for _, c := range s {
+			b.WriteRune(c)
+			b.WriteRune(c)
+		}
+		s = b.String()
+	case 6, 8:
+	default:
+		return color.NRGBA{}, false
+	}
+	if len(s) == 6 {
+		s += "ff"
+	}
+
+	v, err := strconv.ParseUint(s, 16, 32)
+	if err != nil {
+		return color.NRGBA{}, false
+	}
+	return color.NRGBA{R: uint8(v >> 24), G: uint8(v >> 16), B: uint8(v >> 8), A: uint8(v)}, true
+}
+
+// abs 整數絕對值
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
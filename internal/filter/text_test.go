@@ -0,0 +1,259 @@
+package filter
+
+import (
+	"encoding/base64"
+	"image"
+	"image/color"
+	"image/draw"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/image/font/gofont/goregular"
+	"golang.org/x/image/font/sfnt"
+)
+
+// newSolidImage 建立單色圖片
+func newSolidImage(w, h int, c color.Color) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	draw.Draw(img, img.Bounds(), image.NewUniform(c), image.Point{}, draw.Src)
+	return img
+}
+
+// changedBounds 回傳與原圖不同的像素範圍與數量
+func changedBounds(before, after image.Image) (image.Rectangle, int) {
+	var rect image.Rectangle
+	count := 0
+	b := before.Bounds()
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			r1, g1, b1, _ := before.At(x, y).RGBA()
+			r2, g2, b2, _ := after.At(x, y).RGBA()
+			if r1 != r2 || g1 != g2 || b1 != b2 {
+				rect = rect.Union(image.Rect(x, y, x+1, y+1))
+				count++
+			}
+		}
+	}
+	return rect, count
+}
+
+func TestTextFilter_Name(t *testing.T) {
+	assert.Equal(t, "text", NewTextFilter().Name())
+}
+
+func TestTextFilter_Positions(t *testing.T) {
+	f := NewTextFilter()
+	img := newSolidImage(400, 200, color.White)
+
+	tests := []struct {
+		position string
+		inside   image.Rectangle
+	}{
+		{"top-left", image.Rect(0, 0, 200, 100)},
+		{"top-right", image.Rect(200, 0, 400, 100)},
+		{"bottom-left", image.Rect(0, 100, 200, 200)},
+		{"bottom-right", image.Rect(200, 100, 400, 200)},
+		{"center", image.Rect(100, 50, 300, 150)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.position, func(t *testing.T) {
+			result, err := f.Apply(img, []string{"Hi", tt.position, "10", "000000"})
+			require.NoError(t, err)
+
+			changed, count := changedBounds(img, result)
+			assert.Positive(t, count)
+			assert.True(t, changed.In(tt.inside), "text drawn at %v, want inside %v", changed, tt.inside)
+		})
+	}
+}
+
+func TestTextFilter_SizeIsRelativeToWidth(t *testing.T) {
+	f := NewTextFilter()
+
+	small := newSolidImage(200, 200, color.White)
+	large := newSolidImage(800, 800, color.White)
+
+	r1, err := f.Apply(small, []string{"Hi", "center", "10", "000000"})
+	require.NoError(t, err)
+	r2, err := f.Apply(large, []string{"Hi", "center", "10", "000000"})
+	require.NoError(t, err)
+
+	b1, _ := changedBounds(small, r1)
+	b2, _ := changedBounds(large, r2)
+	assert.InDelta(t, float64(b1.Dx())*4, float64(b2.Dx()), float64(b1.Dx()))
+}
+
+func TestTextFilter_StrokeShadowAndRotation(t *testing.T) {
+	f := NewTextFilter()
+	img := newSolidImage(400, 400, color.White)
+
+	plain, err := f.Apply(img, []string{"Hello", "center", "10", "ff0000"})
+	require.NoError(t, err)
+	_, plainCount := changedBounds(img, plain)
+
+	stroked, err := f.Apply(img, []string{"Hello", "center", "10", "ff0000", "", "", "", "", "3:000000", "4:4:00000080:2"})
+	require.NoError(t, err)
+	_, strokedCount := changedBounds(img, stroked)
+	assert.Greater(t, strokedCount, plainCount)
+
+	plainBounds, _ := changedBounds(img, plain)
+	rotated, err := f.Apply(img, []string{"Hello", "center", "10", "ff0000", "", "", "", "90"})
+	require.NoError(t, err)
+	rotatedBounds, _ := changedBounds(img, rotated)
+	assert.Greater(t, plainBounds.Dx(), plainBounds.Dy())
+	assert.Greater(t, rotatedBounds.Dy(), rotatedBounds.Dx())
+
+	// 非有限的角度回傳錯誤，不傳入旋轉
+	for _, angle := range []string{"NaN", "Inf", "-Inf"} {
+		_, err := f.Apply(img, []string{"Hi", "center", "5", "fff", "", "0", "0", angle})
+		assert.Error(t, err, "angle %s", angle)
+		_, err = f.Apply(img, []string{"Hi", "tile", "5", "fff", "", "0", "0", angle})
+		assert.Error(t, err, "tile angle %s", angle)
+	}
+}
+
+func TestTextFilter_LayerIsCapped(t *testing.T) {
+	f := NewTextFilter()
+	long := strings.Repeat("W", maxTextRunes)
+
+	// 超長文字的圖層不超過圖片長邊的兩倍
+	opts, err := f.parseParams([]string{long, "left", "100", "000000", "", "", "", "", "32:ffffff"})
+	require.NoError(t, err)
+	require.NotNil(t, opts)
+	layer := f.render(opts, 100, 50)
+	require.NotNil(t, layer)
+	assert.LessOrEqual(t, layer.Bounds().Dx(), 200)
+	assert.LessOrEqual(t, layer.Bounds().Dy(), 200)
+
+	// 靠左時保留開頭的文字
+	img := newSolidImage(100, 50, color.White)
+	result, err := f.Apply(img, []string{long, "left", "100", "000000"})
+	require.NoError(t, err)
+	_, changed := changedBounds(img, result)
+	assert.Positive(t, changed)
+}
+
+func TestDilateAlpha(t *testing.T) {
+	mask := image.NewAlpha(image.Rect(0, 0, 40, 30))
+	mask.SetAlpha(20, 15, color.Alpha{A: 255})
+	mask.SetAlpha(5, 5, color.Alpha{A: 100})
+
+	for _, radius := range []int{1, 3, 7} {
+		got := dilateAlpha(mask, radius)
+		// 與逐點檢查圓形範圍的結果相同
+		for y := range 30 {
+			for x := range 40 {
+				var want uint8
+				for _, p := range []image.Point{{20, 15}, {5, 5}} {
+					dx, dy := x-p.X, y-p.Y
+					if dx*dx+dy*dy <= radius*radius {
+						want = max(want, mask.AlphaAt(p.X, p.Y).A)
+					}
+				}
+				require.Equal(t, want, got.AlphaAt(x, y).A, "radius %d at (%d, %d)", radius, x, y)
+			}
+		}
+	}
+}
+
+func TestTextFilter_Base64AndMultiline(t *testing.T) {
+	f := NewTextFilter()
+	img := newSolidImage(400, 400, color.White)
+
+	single, err := f.Apply(img, []string{"(c) 2025, A/B", "center", "5", "000000"})
+	require.NoError(t, err)
+	singleBounds, _ := changedBounds(img, single)
+
+	encoded := "b64:" + base64.RawURLEncoding.EncodeToString([]byte("(c) 2025, A/B\nPhoto: Jane"))
+	multi, err := f.Apply(img, []string{encoded, "center", "5", "000000"})
+	require.NoError(t, err)
+	multiBounds, _ := changedBounds(img, multi)
+	assert.Greater(t, multiBounds.Dy(), singleBounds.Dy()*3/2)
+
+	// 無法解碼或空白文字時回傳原圖
+	for _, text := range []string{"b64:!!!", "  ", ""} {
+		result, err := f.Apply(img, []string{text})
+		require.NoError(t, err)
+		assert.Same(t, image.Image(img), result)
+	}
+	result, err := f.Apply(img, nil)
+	require.NoError(t, err)
+	assert.Same(t, image.Image(img), result)
+}
+
+func TestParseHexColor(t *testing.T) {
+	tests := []struct {
+		input string
+		want  color.NRGBA
+		ok    bool
+	}{
+		{"ffffff", color.NRGBA{255, 255, 255, 255}, true},
+		{"#ff000080", color.NRGBA{255, 0, 0, 128}, true},
+		{"0f0", color.NRGBA{0, 255, 0, 255}, true},
+		{"0f08", color.NRGBA{0, 255, 0, 136}, true},
+		{"zzzzzz", color.NRGBA{}, false},
+		{"12345", color.NRGBA{}, false},
+		{"", color.NRGBA{}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, ok := parseHexColor(tt.input)
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestFontSet(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "Brand Sans.ttf"), goregular.TTF, 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "broken.ttf"), []byte("not a font"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "readme.txt"), []byte("ignored"), 0o644))
+
+	fs, err := NewFontSet(WithFontDir(dir), WithDefaultFont("Brand Sans"))
+	require.NoError(t, err)
+
+	_, ok := fs.Lookup("brand-sans")
+	assert.True(t, ok)
+	_, ok = fs.Lookup("GO-MONO")
+	assert.True(t, ok)
+	_, ok = fs.Lookup("broken")
+	assert.False(t, ok)
+	assert.Contains(t, fs.Names(), "go-bold")
+	// 未設定 fallback 時使用字型目錄中的字型
+	assert.Len(t, fs.fallback, 1)
+
+	_, err = NewFontSet(WithDefaultFont("missing"))
+	assert.Error(t, err)
+	_, err = NewFontSet(WithFallbackFonts("missing"))
+	assert.Error(t, err)
+	_, err = NewFontSet(WithFontDir(filepath.Join(dir, "missing")))
+	assert.Error(t, err)
+}
+
+func TestFontSet_FontFor(t *testing.T) {
+	fs := DefaultFontSet()
+	primary, ok := fs.Lookup("go-mono")
+	require.True(t, ok)
+	fallback, ok := fs.Lookup("go")
+	require.True(t, ok)
+	fs = &FontSet{fonts: fs.fonts, fallback: []*sfnt.Font{fallback}, defaultFont: primary}
+
+	var buf sfnt.Buffer
+	assert.Same(t, primary, fs.fontFor(primary, 'A', &buf))
+	// 所有字型皆缺字時使用主要字型
+	assert.Same(t, primary, fs.fontFor(primary, '漢', &buf))
+
+	// CJK 字元在缺字時不影響其他文字繪製
+	img := newSolidImage(200, 100, color.White)
+	result, err := NewTextFilter(WithFontSet(fs)).Apply(img, []string{"版權 Hi", "center", "10", "000000"})
+	require.NoError(t, err)
+	_, count := changedBounds(img, result)
+	assert.Positive(t, count)
+}
@@ -0,0 +1,145 @@
+package filter
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+	"strconv"
+
+	"github.com/disintegration/imaging"
+)
+
+const (
+	// minTileStep 平鋪間距下限（像素），避免極小的浮水印產生過多繪製
+	minTileStep = 8
+	// maxTiles 單張圖片最多繪製的平鋪數量
+	maxTiles = 20000
+)
+
+// tileOptions 平鋪參數
+type tileOptions struct {
+	spacingX int     // 水平間距（像素）
+	spacingY int     // 垂直間距（像素）
+	angle    float64 // 旋轉角度（逆時針），同時旋轉浮水印與排列方向
+	stagger  float64 // 奇數列的位移比例（0-1），0.5 為磚牆式交錯
+}
+
+// parseStagger 解析交錯參數（true/false 或 0-1 比例）
+func parseStagger(s string) float64 {
+	if b, err := strconv.ParseBool(s); err == nil {
+		if b {
+			return 0.5
+		}
+		return 0
+	}
+	if v, err := strconv.ParseFloat(s, 64); err == nil {
+		return clamp(v, 0, 1)
+	}
+	return 0
+}
+
+// drawTiled 將浮水印以平鋪方式直接繪製於 dst
+// 浮水印只旋轉一次，每個位置直接繪製於目標圖片，不建立整張圖片大小的中間圖層
+func drawTiled(dst draw.Image, tile image.Image, opts tileOptions) {
+	bounds := dst.Bounds()
+	tw, th := tile.Bounds().Dx(), tile.Bounds().Dy()
+	// 非有限的角度會使旋轉後的尺寸溢位，呼叫端應先驗證，此處不繪製
+	if tw == 0 || th == 0 || bounds.Empty() || math.IsNaN(opts.angle) || math.IsInf(opts.angle, 0) {
+		return
+	}
+
+	stepX := float64(max(tw+opts.spacingX, minTileStep))
+	stepY := float64(max(th+opts.spacingY, minTileStep))
+
+	if opts.angle != 0 {
+		tile = imaging.Rotate(tile, opts.angle, color.Transparent)
+	}
+	rw, rh := tile.Bounds().Dx(), tile.Bounds().Dy()
+
+	// 排列方向：u 為列方向，v 為行方向（y 軸向下，逆時針旋轉）
+	rad := opts.angle * math.Pi / 180
+	ux, uy := math.Cos(rad), -math.Sin(rad)
+	vx, vy := math.Sin(rad), math.Cos(rad)
+
+	// 未旋轉時第一個浮水印位於左上角
+	ox := float64(bounds.Min.X) + float64(tw)/2
+	oy := float64(bounds.Min.Y) + float64(th)/2
+
+	// 將圖片四角（外擴旋轉後浮水印的半徑）轉換為排列座標，取得需要繪製的範圍
+	radius := math.Hypot(float64(rw), float64(rh)) / 2
+	minI, maxI := math.Inf(1), math.Inf(-1)
+	minJ, maxJ := math.Inf(1), math.Inf(-1)
+	for _, corner := range [][2]float64{
+		{float64(bounds.Min.X) - radius, float64(bounds.Min.Y) - radius},
+		{float64(bounds.Max.X) + radius, float64(bounds.Min.Y) - radius},
+		{float64(bounds.Min.X) - radius, float64(bounds.Max.Y) + radius},
+		{float64(bounds.Max.X) + radius, float64(bounds.Max.Y) + radius},
+	} {
+		dx, dy := corner[0]-ox, corner[1]-oy
+		i := (dx*ux + dy*uy) / stepX
+		j := (dx*vx + dy*vy) / stepY
+		minI, maxI = math.Min(minI, i), math.Max(maxI, i)
+		minJ, maxJ = math.Min(minJ, j), math.Max(maxJ, j)
+	}
+
+	count := 0
+	for j := int(math.Floor(minJ)); j <= int(math.Ceil(maxJ)); j++ {
+		shift := 0.0
+		if j%2 != 0 {
+			shift = opts.stagger
+		}
+		for i := int(math.Floor(minI)) - 1; i <= int(math.Ceil(maxI)); i++ {
+			fi := float64(i) + shift
+			cx := ox + fi*stepX*ux + float64(j)*stepY*vx
+			cy := oy + fi*stepX*uy + float64(j)*stepY*vy
+
+			pt := image.Point{X: int(math.Round(cx - float64(rw)/2)), Y: int(math.Round(cy - float64(rh)/2))}
+			r := image.Rectangle{Min: pt, Max: pt.Add(image.Point{X: rw, Y: rh})}
+			if !r.Overlaps(bounds) {
+				continue
+			}
+			draw.Draw(dst, r, tile, tile.Bounds().Min, draw.Over)
+
+			if count++; count >= maxTiles {
+				return
+			}
+		}
+	}
+}
+
+// repeatTile 將無縫平鋪的圖片重複組成較大的單元
+// 單元尺寸不小於 minTileStep，且覆蓋 w x h 的繪製次數不超過 maxTiles；平鋪結果與直接平鋪原圖相同
+func repeatTile(tile image.Image, w, h int) image.Image {
+	tb := tile.Bounds()
+	tw, th := tb.Dx(), tb.Dy()
+	if tw == 0 || th == 0 || w <= 0 || h <= 0 {
+		return tile
+	}
+
+	nx, ny := ceilDiv(minTileStep, tw), ceilDiv(minTileStep, th)
+	for ceilDiv(w, tw*nx)*ceilDiv(h, th*ny) > maxTiles {
+		// 優先放大仍小於圖片的一邊
+		if (tw*nx <= th*ny || th*ny >= h) && tw*nx < w {
+			nx *= 2
+		} else {
+			ny *= 2
+		}
+	}
+	if nx == 1 && ny == 1 {
+		return tile
+	}
+
+	dst := image.NewNRGBA(image.Rect(0, 0, tw*nx, th*ny))
+	for y := 0; y < ny; y++ {
+		for x := 0; x < nx; x++ {
+			draw.Draw(dst, tb.Sub(tb.Min).Add(image.Pt(x*tw, y*th)), tile, tb.Min, draw.Src)
+		}
+	}
+	return dst
+}
+
+// ceilDiv 正整數除法並無條件進位
+func ceilDiv(a, b int) int {
+	return (a + b - 1) / b
+}
@@ -0,0 +1,178 @@
+package filter
+
+import (
+	"image"
+	"image/color"
+	"image/png"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/disintegration/imaging"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// isDark 檢查像素是否被浮水印覆蓋（白色背景上的黑色浮水印）
+func isDark(img image.Image, x, y int) bool {
+	r, _, _, _ := img.At(x, y).RGBA()
+	return r < 0x8000
+}
+
+// paintedQuadrants 回傳有繪製內容的象限數
+func paintedQuadrants(before, after image.Image) int {
+	b := before.Bounds()
+	cx, cy := b.Dx()/2, b.Dy()/2
+	quadrants := []image.Rectangle{
+		image.Rect(0, 0, cx, cy), image.Rect(cx, 0, b.Dx(), cy),
+		image.Rect(0, cy, cx, b.Dy()), image.Rect(cx, cy, b.Dx(), b.Dy()),
+	}
+	count := 0
+	for _, q := range quadrants {
+		if _, n := changedBounds(before.(*image.RGBA).SubImage(q), after.(*image.RGBA).SubImage(q)); n > 0 {
+			count++
+		}
+	}
+	return count
+}
+
+func TestDrawTiled_Grid(t *testing.T) {
+	tile := newSolidImage(10, 10, color.Black)
+
+	dst := newSolidImage(100, 100, color.White)
+	drawTiled(dst, tile, tileOptions{spacingX: 10, spacingY: 10})
+	assert.True(t, isDark(dst, 5, 5))
+	assert.False(t, isDark(dst, 15, 5))
+	assert.True(t, isDark(dst, 25, 5))
+	assert.False(t, isDark(dst, 5, 15))
+	assert.True(t, isDark(dst, 85, 85))
+	assert.False(t, isDark(dst, 95, 95))
+	assert.False(t, isDark(dst, 15, 25))
+
+	// 交錯排列：奇數列位移半個間距
+	dst = newSolidImage(100, 100, color.White)
+	drawTiled(dst, tile, tileOptions{spacingX: 10, spacingY: 10, stagger: 0.5})
+	assert.True(t, isDark(dst, 5, 5))
+	assert.False(t, isDark(dst, 5, 25))
+	assert.True(t, isDark(dst, 15, 25))
+}
+
+func TestDrawTiled_Rotated(t *testing.T) {
+	tile := newSolidImage(40, 10, color.Black)
+	before := newSolidImage(300, 200, color.White)
+
+	dst := newSolidImage(300, 200, color.White)
+	drawTiled(dst, tile, tileOptions{spacingX: 30, spacingY: 30, angle: 30, stagger: 0.5})
+	assert.Equal(t, 4, paintedQuadrants(before, dst))
+
+	// 旋轉後仍覆蓋整張圖片：每一列都有浮水印
+	for y := 0; y < 200; y += 20 {
+		_, n := changedBounds(before.SubImage(image.Rect(0, y, 300, y+20)), dst.SubImage(image.Rect(0, y, 300, y+20)))
+		assert.Positive(t, n, "no watermark in rows %d-%d", y, y+20)
+	}
+}
+
+func TestDrawTiled_LimitsTinySteps(t *testing.T) {
+	tile := newSolidImage(1, 1, color.Black)
+	dst := newSolidImage(64, 64, color.White)
+	drawTiled(dst, tile, tileOptions{})
+
+	// 間距至少 minTileStep
+	assert.True(t, isDark(dst, 0, 0))
+	assert.False(t, isDark(dst, 1, 0))
+	assert.True(t, isDark(dst, minTileStep, 0))
+}
+
+func TestDrawTiled_NonFiniteAngle(t *testing.T) {
+	tile := newSolidImage(10, 10, color.Black)
+	before := newSolidImage(64, 64, color.White)
+	for _, angle := range []float64{math.NaN(), math.Inf(1), math.Inf(-1)} {
+		dst := newSolidImage(64, 64, color.White)
+		drawTiled(dst, tile, tileOptions{angle: angle})
+		_, n := changedBounds(before, dst)
+		assert.Zero(t, n, "angle %v", angle)
+	}
+}
+
+func TestRepeatTile(t *testing.T) {
+	checker := image.NewNRGBA(image.Rect(0, 0, 2, 1))
+	checker.SetNRGBA(0, 0, color.NRGBA{R: 255, A: 255})
+	checker.SetNRGBA(1, 0, color.NRGBA{B: 255, A: 255})
+
+	tests := []struct {
+		w, h int
+	}{
+		{20, 20},
+		{4000, 4000},
+		{100000, 3},
+		{1, 1},
+	}
+	for _, tt := range tests {
+		tile := repeatTile(checker, tt.w, tt.h)
+		b := tile.Bounds()
+		// 單元為原圖的整數倍，平鋪結果不變
+		assert.Zero(t, b.Dx()%2, "%dx%d", tt.w, tt.h)
+		assert.GreaterOrEqual(t, b.Dx(), minTileStep)
+		assert.GreaterOrEqual(t, b.Dy(), minTileStep)
+		assert.LessOrEqual(t, ceilDiv(tt.w, b.Dx())*ceilDiv(tt.h, b.Dy()), maxTiles, "%dx%d", tt.w, tt.h)
+		assert.Equal(t, checker.At(0, 0), tile.At(b.Dx()-2, b.Dy()-1))
+		assert.Equal(t, checker.At(1, 0), tile.At(b.Dx()-1, b.Dy()-1))
+	}
+
+	large := newSolidImage(32, 32, color.Black)
+	assert.Same(t, image.Image(large), repeatTile(large, 100, 100))
+}
+
+func TestOverlayFilter_TileSmallTexture(t *testing.T) {
+	texture := imaging.New(1, 1, color.NRGBA{R: 255, A: 255})
+	f := NewOverlayFilter(NewWatermarkFilter(WithAssetLoader(pngLoader(t, texture))))
+
+	result, err := f.Apply(imaging.New(999, 999, color.White), []string{"texture.png", "normal", "100", "tile"})
+	require.NoError(t, err)
+	out := result.(*image.NRGBA)
+	// 1x1 的材質仍無縫覆蓋整張圖片
+	for _, pt := range []image.Point{{0, 0}, {1, 0}, {500, 7}, {998, 998}} {
+		assert.Equal(t, color.NRGBA{R: 255, A: 255}, out.NRGBAAt(pt.X, pt.Y), pt)
+	}
+}
+
+func TestParseStagger(t *testing.T) {
+	assert.Equal(t, 0.5, parseStagger("true"))
+	assert.Equal(t, 0.0, parseStagger("false"))
+	assert.Equal(t, 0.25, parseStagger("0.25"))
+	assert.Equal(t, 1.0, parseStagger("3"))
+	assert.Equal(t, 0.0, parseStagger("x"))
+}
+
+func TestWatermarkFilter_ApplyTiled(t *testing.T) {
+	wmPath := filepath.Join(t.TempDir(), "wm.png")
+	file, err := os.Create(wmPath)
+	require.NoError(t, err)
+	require.NoError(t, png.Encode(file, newSolidImage(20, 20, color.Black)))
+	require.NoError(t, file.Close())
+
+	bg := newSolidImage(200, 200, color.White)
+	res, err := NewWatermarkFilter().Apply(bg, []string{wmPath, "tile", "100", "20", "20", "1", "45", "true"})
+	require.NoError(t, err)
+	assert.Equal(t, 4, paintedQuadrants(bg, res))
+
+	// 透明度套用至每個平鋪
+	res, err = NewWatermarkFilter().Apply(bg, []string{wmPath, "tile", "50", "20", "20"})
+	require.NoError(t, err)
+	r, _, _, _ := res.At(10, 10).RGBA()
+	assert.InDelta(t, 0x8000, r, 0x800)
+
+	// 非有限的平鋪角度回傳錯誤
+	for _, angle := range []string{"NaN", "Inf", "-Inf"} {
+		_, err = NewWatermarkFilter().Apply(bg, []string{wmPath, "tile", "50", "0", "0", "1", angle})
+		assert.Error(t, err, "angle %s", angle)
+	}
+}
+
+func TestTextFilter_ApplyTiled(t *testing.T) {
+	bg := newSolidImage(400, 300, color.White)
+	res, err := NewTextFilter().Apply(bg, []string{"PREVIEW", "tile", "8", "00000080", "", "40", "40", "30", "", "", "0.5"})
+	require.NoError(t, err)
+	assert.Equal(t, 4, paintedQuadrants(bg, res))
+}
@@ -0,0 +1,501 @@
+package filter
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/disintegration/imaging"
+)
+
+const (
+	// maxShearAngle 錯切角度上限（度）
+	maxShearAngle = 45.0
+	// defaultDeskewMaxAngle 自動校正預設的最大偵測角度（度）
+	defaultDeskewMaxAngle = 15.0
+	// deskewWorkSize 偵測傾斜角度時的處理尺寸上限（像素）
+	deskewWorkSize = 512
+	// deskewMinAngle 小於此角度（度）時視為已對齊，不進行旋轉
+	deskewMinAngle = 0.05
+)
+
+// inverseMap 逆向映射函式，將輸出像素座標轉換為來源圖片座標
+// 座標以像素左上角為原點，像素中心為 (x+0.5, y+0.5)
+type inverseMap func(x, y float64) (sx, sy float64)
+
+// warp 以逆向映射與雙線性插值產生 width x height 的圖片
+// 超出來源圖片的取樣點使用背景色，邊緣因插值自然反鋸齒；插值在預乘 alpha 空間進行以避免透明邊緣產生色邊
+func warp(src *image.NRGBA, width, height int, inv inverseMap, bg color.NRGBA) *image.NRGBA {
+	dst := image.NewNRGBA(image.Rect(0, 0, width, height))
+	sw, sh := src.Rect.Dx(), src.Rect.Dy()
+
+	bgA := float64(bg.A) / 255
+	bgP := [4]float64{float64(bg.R) * bgA, float64(bg.G) * bgA, float64(bg.B) * bgA, float64(bg.A)}
+	pixel := func(x, y int) [4]float64 {
+		if x < 0 || y < 0 || x >= sw || y >= sh {
+			return bgP
+		}
+		p := src.Pix[y*src.Stride+x*4:]
+		a := float64(p[3]) / 255
+		return [4]float64{float64(p[0]) * a, float64(p[1]) * a, float64(p[2]) * a, float64(p[3])}
+	}
+
+	for y := range height {
+		row := dst.Pix[y*dst.Stride:]
+		for x := range width {
+			sx, sy := inv(float64(x)+0.5, float64(y)+0.5)
+			sx, sy = sx-0.5, sy-0.5
+
+			var c [4]float64
+			if math.IsNaN(sx) || math.IsNaN(sy) || sx < -1 || sy < -1 || sx > float64(sw) || sy > float64(sh) {
+				c = bgP
+			} else {
+				x0, y0 := math.Floor(sx), math.Floor(sy)
+				fx, fy := sx-x0, sy-y0
+				ix, iy := int(x0), int(y0)
+				p00, p10 := pixel(ix, iy), pixel(ix+1, iy)
+				p01, p11 := pixel(ix, iy+1), pixel(ix+1, iy+1)
+				for i := range 4 {
+					top := p00[i] + (p10[i]-p00[i])*fx
+					bottom := p01[i] + (p11[i]-p01[i])*fx
+					c[i] = top + (bottom-top)*fy
+				}
+			}
+
+			o := row[x*4 : x*4+4 : x*4+4]
+			if c[3] <= 0 {
+				o[0], o[1], o[2], o[3] = 0, 0, 0, 0
+				continue
+			}
+			a := c[3] / 255
+			o[0] = uint8(clamp(c[0]/a+0.5, 0, 255))
+			o[1] = uint8(clamp(c[1]/a+0.5, 0, 255))
+			o[2] = uint8(clamp(c[2]/a+0.5, 0, 255))
+			o[3] = uint8(clamp(c[3]+0.5, 0, 255))
+		}
+	}
+	return dst
+}
+
+// linearMap 以圖片中心為原點的 2x2 線性轉換 [a b; c d]
+type linearMap [4]float64
+
+// rotationMap 逆時針旋轉（度）
+func rotationMap(degree float64) linearMap {
+	sin, cos := math.Sincos(degree * math.Pi / 180)
+	return linearMap{cos, sin, -sin, cos}
+}
+
+// bounds 轉換後包含整張圖片的畫布尺寸
+func (m linearMap) bounds(width, height int) (int, int) {
+	w, h := float64(width), float64(height)
+	outW := math.Abs(m[0])*w + math.Abs(m[1])*h
+	outH := math.Abs(m[2])*w + math.Abs(m[3])*h
+	// 去除浮點誤差造成的多餘像素
+	return max(int(math.Ceil(outW-1e-6)), 1), max(int(math.Ceil(outH-1e-6)), 1)
+}
+
+// apply 將圖片以線性轉換繪製於 width x height 的畫布（兩者中心對齊）
+func (m linearMap) apply(src *image.NRGBA, width, height int, bg color.NRGBA) (*image.NRGBA, error) {
+	det := m[0]*m[3] - m[1]*m[2]
+	if math.Abs(det) < 1e-6 {
+		return nil, fmt.Errorf("transform is not invertible")
+	}
+	inv := linearMap{m[3] / det, -m[1] / det, -m[2] / det, m[0] / det}
+	scx, scy := float64(src.Rect.Dx())/2, float64(src.Rect.Dy())/2
+	dcx, dcy := float64(width)/2, float64(height)/2
+	return warp(src, width, height, func(x, y float64) (float64, float64) {
+		dx, dy := x-dcx, y-dcy
+		return inv[0]*dx + inv[1]*dy + scx, inv[2]*dx + inv[3]*dy + scy
+	}, bg), nil
+}
+
+// rotateImage 以任意角度逆時針旋轉圖片
+// crop 為 false 時畫布擴大以容納整張圖片；為 true 時裁切為不含背景、與原圖比例相同的最大範圍
+func rotateImage(img image.Image, degree float64, bg color.NRGBA, crop bool) (*image.NRGBA, error) {
+	src := imaging.Clone(img)
+	w, h := src.Rect.Dx(), src.Rect.Dy()
+	m := rotationMap(degree)
+
+	outW, outH := m.bounds(w, h)
+	if crop {
+		sin, cos := math.Abs(m[1]), math.Abs(m[0])
+		fw, fh := float64(w), float64(h)
+		scale := math.Min(fw/(fw*cos+fh*sin), fh/(fw*sin+fh*cos))
+		outW, outH = max(int(math.Floor(fw*scale)), 1), max(int(math.Floor(fh*scale)), 1)
+	}
+	return m.apply(src, outW, outH, bg)
+}
+
+// RotateFilter 旋轉濾鏡
+type RotateFilter struct{}
+
+// NewRotateFilter 建立旋轉濾鏡
+func NewRotateFilter() *RotateFilter {
+	return &RotateFilter{}
+}
+
+// Name 返回濾鏡名稱
+func (f *RotateFilter) Name() string {
+	return "rotate"
+}
+
+// Apply 應用旋轉
+// params[0]: degree (逆時針角度，可為負數與小數)
+// params[1]: bgcolor (背景色：十六進位顏色、transparent 或 auto，預設 transparent)
+// params[2]: crop (可選，裁切為不含背景的最大範圍；90 度的倍數時無背景，忽略此參數)
+func (f *RotateFilter) Apply(img image.Image, params []string) (image.Image, error) {
+	degree := 0.0
+	if len(params) > 0 && params[0] != "" {
+		d, err := strconv.ParseFloat(params[0], 64)
+		if err != nil || math.IsNaN(d) || math.IsInf(d, 0) {
+			return nil, fmt.Errorf("invalid rotation angle: %s", params[0])
+		}
+		degree = d
+	}
+	bg, crop, err := parseTransformOptions(img, params, 1, "")
+	if err != nil {
+		return nil, err
+	}
+
+	degree = math.Mod(degree, 360)
+	if degree < 0 {
+		degree += 360
+	}
+	// 90 度的倍數直接搬移像素，不插值
+	switch degree {
+	case 0:
+		return img, nil
+	case 90:
+		return imaging.Rotate90(img), nil
+	case 180:
+		return imaging.Rotate180(img), nil
+	case 270:
+		return imaging.Rotate270(img), nil
+	}
+	return rotateImage(img, degree, bg, crop)
+}
+
+// parseTransformOptions 解析轉換濾鏡共用的背景色與裁切參數
+// params[start]: 背景色（空白時使用 def，def 為空字串時為透明）；也可直接寫 crop
+// params[start+1]: crop（可選）
+func parseTransformOptions(img image.Image, params []string, start int, def string) (color.NRGBA, bool, error) {
+	bgSpec := def
+	crop := false
+	for i := start; i < len(params) && i < start+2; i++ {
+		p := strings.ToLower(params[i])
+		switch {
+		case p == "":
+		case p == "crop" || p == "true" || p == "1":
+			crop = true
+		case i == start:
+			bgSpec = p
+		default:
+			return color.NRGBA{}, false, fmt.Errorf("invalid crop option: %s", params[i])
+		}
+	}
+	if bgSpec == "" {
+		return color.NRGBA{}, crop, nil
+	}
+	bg, ok := parseBackground(bgSpec, img)
+	if !ok {
+		return color.NRGBA{}, false, fmt.Errorf("invalid background color: %s", bgSpec)
+	}
+	return bg, crop, nil
+}
+
+// ShearFilter 錯切濾鏡
+// 水平與垂直錯切，畫布擴大以容納整張圖片
+type ShearFilter struct{}
+
+// NewShearFilter 建立錯切濾鏡
+func NewShearFilter() *ShearFilter {
+	return &ShearFilter{}
+}
+
+// Name 返回濾鏡名稱
+func (f *ShearFilter) Name() string {
+	return "shear"
+}
+
+// Apply 應用錯切
+// params[0]: x (水平錯切角度，-45~45 度，正值使下方向右偏移)
+// params[1]: y (垂直錯切角度，-45~45 度，正值使右側向下偏移，預設 0)
+// params[2]: bgcolor (背景色：十六進位顏色、transparent 或 auto，預設 transparent)
+func (f *ShearFilter) Apply(img image.Image, params []string) (image.Image, error) {
+	var angles [2]float64
+	for i := range angles {
+		if len(params) > i && params[i] != "" {
+			a, err := strconv.ParseFloat(params[i], 64)
+			if err != nil || math.IsNaN(a) || math.Abs(a) > maxShearAngle {
+				return nil, fmt.Errorf("invalid shear angle: %s (must be between -%g and %g)", params[i], maxShearAngle, maxShearAngle)
+			}
+			angles[i] = a
+		}
+	}
+	bg := color.NRGBA{}
+	if len(params) > 2 && params[2] != "" {
+		c, ok := parseBackground(params[2], img)
+		if !ok {
+			return nil, fmt.Errorf("invalid background color: %s", params[2])
+		}
+		bg = c
+	}
+	if angles[0] == 0 && angles[1] == 0 {
+		return img, nil
+	}
+
+	kx := math.Tan(angles[0] * math.Pi / 180)
+	ky := math.Tan(angles[1] * math.Pi / 180)
+	m := linearMap{1, kx, ky, 1}
+	src := imaging.Clone(img)
+	w, h := m.bounds(src.Rect.Dx(), src.Rect.Dy())
+	result, err := m.apply(src, w, h, bg)
+	if err != nil {
+		return nil, fmt.Errorf("invalid shear angles %g, %g: %w", angles[0], angles[1], err)
+	}
+	return result, nil
+}
+
+// homography 3x3 投影轉換矩陣（h[8] 固定為 1）
+type homography [9]float64
+
+// newHomography 由四組對應點求出將 from 映射至 to 的投影轉換
+func newHomography(from, to [4][2]float64) (homography, error) {
+	// 以高斯消去法解 8 元一次方程組
+	var a [8][9]float64
+	for i := range 4 {
+		x, y := from[i][0], from[i][1]
+		u, v := to[i][0], to[i][1]
+		a[2*i] = [9]float64{x, y, 1, 0, 0, 0, -x * u, -y * u, u}
+		a[2*i+1] = [9]float64{0, 0, 0, x, y, 1, -x * v, -y * v, v}
+	}
+	for col := range 8 {
+		pivot := col
+		for row := col + 1; row < 8; row++ {
+			if math.Abs(a[row][col]) > math.Abs(a[pivot][col]) {
+				pivot = row
+			}
+		}
+		if math.Abs(a[pivot][col]) < 1e-9 {
+			return homography{}, fmt.Errorf("degenerate quadrilateral")
+		}
+		a[col], a[pivot] = a[pivot], a[col]
+		for row := range 8 {
+			if row == col {
+				continue
+			}
+			factor := a[row][col] / a[col][col]
+			for k := col; k < 9; k++ {
+				a[row][k] -= factor * a[col][k]
+			}
+		}
+	}
+
+	var h homography
+	for i := range 8 {
+		h[i] = a[i][8] / a[i][i]
+	}
+	h[8] = 1
+	return h, nil
+}
+
+// apply 轉換座標；分母不為正（點位於地平線外）時回傳 NaN
+func (h homography) apply(x, y float64) (float64, float64) {
+	w := h[6]*x + h[7]*y + h[8]
+	if w <= 1e-9 {
+		return math.NaN(), math.NaN()
+	}
+	return (h[0]*x + h[1]*y + h[2]) / w, (h[3]*x + h[4]*y + h[5]) / w
+}
+
+// PerspectiveFilter 透視校正濾鏡
+// 將圖片中的四邊形（例如拍攝角度傾斜的文件）拉正為矩形
+type PerspectiveFilter struct{}
+
+// NewPerspectiveFilter 建立透視校正濾鏡
+func NewPerspectiveFilter() *PerspectiveFilter {
+	return &PerspectiveFilter{}
+}
+
+// Name 返回濾鏡名稱
+func (f *PerspectiveFilter) Name() string {
+	return "perspective"
+}
+
+// Apply 應用透視校正
+// params[0-7]: x1,y1,...,x4,y4 四邊形的左上、右上、右下、左下角座標（像素，須位於圖片範圍內）
+// 輸出寬度為上下兩邊較長者，高度為左右兩邊較長者
+func (f *PerspectiveFilter) Apply(img image.Image, params []string) (image.Image, error) {
+	if len(params) != 8 {
+		return nil, fmt.Errorf("perspective filter requires 8 coordinates, got %d", len(params))
+	}
+	b := img.Bounds()
+	var quad [4][2]float64
+	for i, p := range params {
+		v, err := strconv.ParseFloat(p, 64)
+		limit := float64(b.Dx())
+		if i%2 == 1 {
+			limit = float64(b.Dy())
+		}
+		if err != nil || math.IsNaN(v) || v < 0 || v > limit {
+			return nil, fmt.Errorf("invalid perspective coordinate: %s", p)
+		}
+		quad[i/2][i%2] = v
+	}
+
+	dist := func(p, q [2]float64) float64 { return math.Hypot(p[0]-q[0], p[1]-q[1]) }
+	width := int(math.Round(math.Max(dist(quad[0], quad[1]), dist(quad[3], quad[2]))))
+	height := int(math.Round(math.Max(dist(quad[0], quad[3]), dist(quad[1], quad[2]))))
+	if width < 1 || height < 1 {
+		return nil, fmt.Errorf("perspective quadrilateral is too small")
+	}
+
+	fw, fh := float64(width), float64(height)
+	rect := [4][2]float64{{0, 0}, {fw, 0}, {fw, fh}, {0, fh}}
+	h, err := newHomography(rect, quad)
+	if err != nil {
+		return nil, fmt.Errorf("invalid perspective quadrilateral: %w", err)
+	}
+	return warp(imaging.Clone(img), width, height, h.apply, color.NRGBA{}), nil
+}
+
+// DeskewFilter 自動校正傾斜濾鏡
+// 以 Hough 轉換估計圖片中主要直線（文字行、文件邊緣）的傾斜角度後旋轉拉正
+type DeskewFilter struct{}
+
+// NewDeskewFilter 建立自動校正傾斜濾鏡
+func NewDeskewFilter() *DeskewFilter {
+	return &DeskewFilter{}
+}
+
+// Name 返回濾鏡名稱
+func (f *DeskewFilter) Name() string {
+	return "deskew"
+}
+
+// Apply 應用自動校正
+// params[0]: max angle (最大偵測角度，0.5-45 度，預設 15)
+// params[1]: bgcolor (背景色：十六進位顏色、transparent 或 auto，預設 auto 使用邊緣主色)
+// params[2]: crop (可選，裁切為不含背景的最大範圍)
+func (f *DeskewFilter) Apply(img image.Image, params []string) (image.Image, error) {
+	maxAngle := defaultDeskewMaxAngle
+	if len(params) > 0 && params[0] != "" {
+		a, err := strconv.ParseFloat(params[0], 64)
+		if err != nil || a < 0.5 || a > 45 {
+			return nil, fmt.Errorf("invalid deskew max angle: %s", params[0])
+		}
+		maxAngle = a
+	}
+	bg, crop, err := parseTransformOptions(img, params, 1, "auto")
+	if err != nil {
+		return nil, err
+	}
+
+	angle := estimateSkew(img, maxAngle)
+	if math.Abs(angle) < deskewMinAngle {
+		return img, nil
+	}
+	return rotateImage(img, angle, bg, crop)
+}
+
+// estimateSkew 估計將圖片拉正所需的逆時針旋轉角度（度），範圍為 ±maxAngle
+// 對邊緣點計算各候選角度旋轉後的水平與垂直投影（Hough 轉換中接近水平與垂直的直線），
+// 取投影最集中（各格計數平方和最大）的角度；先以 0.5 度粗略搜尋，再以 0.05 度細部搜尋
+func estimateSkew(img image.Image, maxAngle float64) float64 {
+	points := edgePoints(img)
+	if len(points) < 16 {
+		return 0
+	}
+
+	var size float64
+	for _, p := range points {
+		size = math.Max(size, math.Max(p[0], p[1]))
+	}
+	bins := int(size*2) + 3
+	rows, cols := make([]int, 2*bins), make([]int, 2*bins)
+
+	score := func(degree float64) float64 {
+		clear(rows)
+		clear(cols)
+		sin, cos := math.Sincos(degree * math.Pi / 180)
+		for _, p := range points {
+			// 旋轉後的座標（與 rotationMap 相同方向）
+			rx := cos*p[0] + sin*p[1]
+			ry := -sin*p[0] + cos*p[1]
+			rows[int(math.Round(ry))+bins]++
+			cols[int(math.Round(rx))+bins]++
+		}
+		var s float64
+		for i := range rows {
+			s += float64(rows[i]*rows[i] + cols[i]*cols[i])
+		}
+		return s
+	}
+
+	search := func(from, to, step, best float64) float64 {
+		bestScore := score(best)
+		for a := from; a <= to+1e-9; a += step {
+			if s := score(a); s > bestScore {
+				best, bestScore = a, s
+			}
+		}
+		return best
+	}
+	best := search(-maxAngle, maxAngle, 0.5, 0)
+	best = search(math.Max(best-0.5, -maxAngle), math.Min(best+0.5, maxAngle), 0.05, best)
+	return math.Round(best*100) / 100
+}
+
+// edgePoints 以 Sobel 運算子取得明顯邊緣的座標（縮小至 deskewWorkSize 內，座標以原點為中心）
+// 透明像素視為黑色，因此透明背景上的內容輪廓同樣會被視為邊緣
+func edgePoints(img image.Image) [][2]float64 {
+	src := imaging.Clone(img)
+	if max(src.Rect.Dx(), src.Rect.Dy()) > deskewWorkSize {
+		src = imaging.Fit(src, deskewWorkSize, deskewWorkSize, imaging.Box)
+	}
+	w, h := src.Rect.Dx(), src.Rect.Dy()
+	if w < 3 || h < 3 {
+		return nil
+	}
+
+	gray := make([]float64, w*h)
+	for y := range h {
+		for x := range w {
+			p := src.Pix[y*src.Stride+x*4:]
+			a := float64(p[3]) / 255
+			gray[y*w+x] = (0.299*float64(p[0]) + 0.587*float64(p[1]) + 0.114*float64(p[2])) * a
+		}
+	}
+
+	mag := make([]float64, w*h)
+	var sum, sumSq float64
+	for y := 1; y < h-1; y++ {
+		for x := 1; x < w-1; x++ {
+			g := func(dx, dy int) float64 { return gray[(y+dy)*w+x+dx] }
+			gx := g(1, -1) + 2*g(1, 0) + g(1, 1) - g(-1, -1) - 2*g(-1, 0) - g(-1, 1)
+			gy := g(-1, 1) + 2*g(0, 1) + g(1, 1) - g(-1, -1) - 2*g(0, -1) - g(1, -1)
+			m := math.Hypot(gx, gy)
+			mag[y*w+x] = m
+			sum += m
+			sumSq += m * m
+		}
+	}
+	n := float64((w - 2) * (h - 2))
+	mean := sum / n
+	threshold := math.Max(mean+2*math.Sqrt(math.Max(sumSq/n-mean*mean, 0)), 64)
+
+	cx, cy := float64(w)/2, float64(h)/2
+	var points [][2]float64
+	for y := 1; y < h-1; y++ {
+		for x := 1; x < w-1; x++ {
+			if mag[y*w+x] >= threshold {
+				points = append(points, [2]float64{float64(x) + 0.5 - cx, float64(y) + 0.5 - cy})
+			}
+		}
+	}
+	return points
+}
@@ -0,0 +1,261 @@
+package filter
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"testing"
+
+	"github.com/disintegration/imaging"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newDocumentImage 建立類似文件的測試圖片：白底、多行深色文字列
+func newDocumentImage(w, h int) *image.NRGBA {
+	img := imaging.New(w, h, color.NRGBA{R: 255, G: 255, B: 255, A: 255})
+	for y := h / 8; y < h*7/8; y += 18 {
+		for x := w / 10; x < w*9/10; x++ {
+			// 以間隔模擬單字
+			if (x/23)%5 == 4 {
+				continue
+			}
+			for dy := range 8 {
+				img.SetNRGBA(x, y+dy, color.NRGBA{R: 30, G: 30, B: 30, A: 255})
+			}
+		}
+	}
+	return img
+}
+
+func TestWarp_Identity(t *testing.T) {
+	src := newAdjustTestImage()
+	w, h := src.Rect.Dx(), src.Rect.Dy()
+	result, err := linearMap{1, 0, 0, 1}.apply(src, w, h, color.NRGBA{})
+	require.NoError(t, err)
+	assert.Equal(t, src.Pix, result.Pix)
+
+	_, err = linearMap{1, 1, 1, 1}.apply(src, w, h, color.NRGBA{})
+	assert.Error(t, err)
+}
+
+func TestRotateFilter(t *testing.T) {
+	f := NewRotateFilter()
+	assert.Equal(t, "rotate", f.Name())
+
+	red := color.NRGBA{R: 255, A: 255}
+	img := imaging.New(100, 50, red)
+
+	// 0 度與未指定角度時不變
+	for _, params := range [][]string{nil, {"0"}, {"360"}} {
+		result, err := f.Apply(img, params)
+		require.NoError(t, err)
+		assert.Same(t, image.Image(img), result)
+	}
+
+	// 90 度的倍數不插值
+	result, err := f.Apply(img, []string{"-90"})
+	require.NoError(t, err)
+	assert.Equal(t, image.Rect(0, 0, 50, 100), result.Bounds())
+	assert.Equal(t, red, result.(*image.NRGBA).NRGBAAt(0, 0))
+
+	// 任意角度：畫布擴大，四角填背景色
+	result, err = f.Apply(img, []string{"30", "0000ff"})
+	require.NoError(t, err)
+	out := result.(*image.NRGBA)
+	wantW := int(math.Ceil(100*math.Cos(math.Pi/6) + 50*math.Sin(math.Pi/6)))
+	wantH := int(math.Ceil(100*math.Sin(math.Pi/6) + 50*math.Cos(math.Pi/6)))
+	assert.Equal(t, image.Rect(0, 0, wantW, wantH), out.Bounds())
+	assert.Equal(t, color.NRGBA{B: 255, A: 255}, out.NRGBAAt(0, 0))
+	assert.Equal(t, red, out.NRGBAAt(wantW/2, wantH/2))
+
+	// 逆時針：右緣中點往上移動
+	marked := imaging.New(100, 100, color.NRGBA{A: 255})
+	for y := 45; y < 55; y++ {
+		for x := 90; x < 100; x++ {
+			marked.SetNRGBA(x, y, color.NRGBA{G: 255, A: 255})
+		}
+	}
+	result, err = f.Apply(marked, []string{"45"})
+	require.NoError(t, err)
+	out = result.(*image.NRGBA)
+	c := out.Bounds().Dx() / 2
+	assert.Equal(t, uint8(255), out.NRGBAAt(c+33, c-33).G, "the right edge should move up and to the left")
+
+	// 預設背景透明
+	result, err = f.Apply(img, []string{"15"})
+	require.NoError(t, err)
+	assert.Equal(t, uint8(0), result.(*image.NRGBA).NRGBAAt(0, 0).A)
+
+	// crop：與原圖比例相同且不含背景
+	for _, params := range [][]string{{"15", "", "crop"}, {"15", "crop"}, {"-15", "0000ff", "true"}} {
+		result, err = f.Apply(img, params)
+		require.NoError(t, err)
+		out = result.(*image.NRGBA)
+		assert.InDelta(t, 2.0, float64(out.Bounds().Dx())/float64(out.Bounds().Dy()), 0.05, "params %v", params)
+		assert.Less(t, out.Bounds().Dx(), 100)
+		for _, pt := range []image.Point{{0, 0}, {out.Bounds().Dx() - 1, 0}, {0, out.Bounds().Dy() - 1}, {out.Bounds().Dx() - 1, out.Bounds().Dy() - 1}} {
+			assert.Equal(t, red, out.NRGBAAt(pt.X, pt.Y), "params %v, corner %v", params, pt)
+		}
+	}
+
+	for _, params := range [][]string{{"abc"}, {"10", "nocolor"}, {"10", "ffffff", "maybe"}} {
+		_, err := f.Apply(img, params)
+		assert.Error(t, err, "params %v", params)
+	}
+}
+
+func TestShearFilter(t *testing.T) {
+	f := NewShearFilter()
+	assert.Equal(t, "shear", f.Name())
+
+	img := imaging.New(100, 50, color.NRGBA{R: 255, A: 255})
+
+	// 水平 45 度：寬度增加高度
+	result, err := f.Apply(img, []string{"45"})
+	require.NoError(t, err)
+	out := result.(*image.NRGBA)
+	assert.Equal(t, image.Rect(0, 0, 150, 50), out.Bounds())
+	// 正值時下方向右偏移：左下與右上為背景
+	assert.Equal(t, uint8(0), out.NRGBAAt(2, 47).A)
+	assert.Equal(t, uint8(0), out.NRGBAAt(147, 2).A)
+	assert.Equal(t, uint8(255), out.NRGBAAt(10, 2).A)
+	assert.Equal(t, uint8(255), out.NRGBAAt(140, 47).A)
+
+	// 垂直錯切與背景色
+	result, err = f.Apply(img, []string{"0", "-20", "ffffff"})
+	require.NoError(t, err)
+	out = result.(*image.NRGBA)
+	assert.Equal(t, 100, out.Bounds().Dx())
+	assert.Equal(t, int(math.Ceil(50+100*math.Tan(20*math.Pi/180))), out.Bounds().Dy())
+	assert.Equal(t, color.NRGBA{R: 255, G: 255, B: 255, A: 255}, out.NRGBAAt(1, 1))
+
+	result, err = f.Apply(img, nil)
+	require.NoError(t, err)
+	assert.Same(t, image.Image(img), result)
+
+	for _, params := range [][]string{{"60"}, {"10", "x"}, {"10", "0", "nocolor"}} {
+		_, err := f.Apply(img, params)
+		assert.Error(t, err, "params %v", params)
+	}
+}
+
+func TestHomography(t *testing.T) {
+	from := [4][2]float64{{0, 0}, {100, 0}, {100, 50}, {0, 50}}
+	to := [4][2]float64{{10, 5}, {90, 20}, {95, 70}, {5, 60}}
+	h, err := newHomography(from, to)
+	require.NoError(t, err)
+	for i := range 4 {
+		x, y := h.apply(from[i][0], from[i][1])
+		assert.InDelta(t, to[i][0], x, 1e-6)
+		assert.InDelta(t, to[i][1], y, 1e-6)
+	}
+
+	_, err = newHomography(from, [4][2]float64{{0, 0}, {10, 10}, {20, 20}, {30, 30}})
+	assert.Error(t, err)
+}
+
+func TestPerspectiveFilter(t *testing.T) {
+	f := NewPerspectiveFilter()
+	assert.Equal(t, "perspective", f.Name())
+
+	// 白底上的紅色梯形（模擬傾斜拍攝的文件）
+	quad := [4][2]float64{{40, 20}, {160, 30}, {180, 130}, {20, 110}}
+	img := imaging.New(200, 150, color.NRGBA{R: 255, G: 255, B: 255, A: 255})
+	inside := func(x, y float64) bool {
+		for i := range 4 {
+			a, b := quad[i], quad[(i+1)%4]
+			if (b[0]-a[0])*(y-a[1])-(b[1]-a[1])*(x-a[0]) < 0 {
+				return false
+			}
+		}
+		return true
+	}
+	for y := range 150 {
+		for x := range 200 {
+			if inside(float64(x)+0.5, float64(y)+0.5) {
+				img.SetNRGBA(x, y, color.NRGBA{R: 255, A: 255})
+			}
+		}
+	}
+
+	result, err := f.Apply(img, []string{"40", "20", "160", "30", "180", "130", "20", "110"})
+	require.NoError(t, err)
+	out := result.(*image.NRGBA)
+	// 寬度為上下兩邊較長者，高度為左右兩邊較長者
+	assert.Equal(t, image.Rect(0, 0, 161, 102), out.Bounds())
+
+	// 校正後除邊緣外皆為紅色
+	for y := 2; y < 100; y++ {
+		for x := 2; x < 159; x++ {
+			if c := out.NRGBAAt(x, y); c.G > 8 || c.R != 255 {
+				t.Fatalf("pixel (%d, %d) = %v, want red", x, y, c)
+			}
+		}
+	}
+
+	for _, params := range [][]string{
+		{"0", "0", "10", "0", "10", "10"},
+		{"0", "0", "300", "0", "10", "10", "0", "10"},
+		{"0", "0", "10", "0", "x", "10", "0", "10"},
+		{"0", "0", "10", "10", "20", "20", "30", "30"},
+	} {
+		_, err := f.Apply(img, params)
+		assert.Error(t, err, "params %v", params)
+	}
+}
+
+func TestEstimateSkew(t *testing.T) {
+	white := color.NRGBA{R: 255, G: 255, B: 255, A: 255}
+	doc := newDocumentImage(400, 300)
+
+	assert.InDelta(t, 0, estimateSkew(doc, 15), 0.1)
+
+	for _, angle := range []float64{7, -3.5, 12} {
+		skewed, err := rotateImage(doc, angle, white, false)
+		require.NoError(t, err)
+		assert.InDelta(t, -angle, estimateSkew(skewed, 15), 0.2, "skew %v", angle)
+	}
+
+	// 超出最大偵測角度時不會回傳範圍外的角度
+	skewed, err := rotateImage(doc, 20, white, false)
+	require.NoError(t, err)
+	assert.LessOrEqual(t, math.Abs(estimateSkew(skewed, 10)), 10.0)
+
+	// 無邊緣的圖片
+	assert.Equal(t, 0.0, estimateSkew(imaging.New(50, 50, white), 15))
+}
+
+func TestDeskewFilter(t *testing.T) {
+	f := NewDeskewFilter()
+	assert.Equal(t, "deskew", f.Name())
+
+	white := color.NRGBA{R: 255, G: 255, B: 255, A: 255}
+	doc := newDocumentImage(400, 300)
+
+	// 已對齊的圖片不變
+	result, err := f.Apply(doc, nil)
+	require.NoError(t, err)
+	assert.Same(t, image.Image(doc), result)
+
+	// 傾斜的圖片校正後再次偵測接近 0 度，背景使用邊緣主色（白色）
+	skewed, err := rotateImage(doc, 6, white, false)
+	require.NoError(t, err)
+	result, err = f.Apply(skewed, nil)
+	require.NoError(t, err)
+	out := result.(*image.NRGBA)
+	assert.InDelta(t, 0, estimateSkew(out, 15), 0.2)
+	assert.Equal(t, white, out.NRGBAAt(0, 0))
+
+	// crop 時不含背景
+	result, err = f.Apply(skewed, []string{"10", "ff0000", "crop"})
+	require.NoError(t, err)
+	out = result.(*image.NRGBA)
+	assert.Less(t, out.Bounds().Dx(), skewed.Rect.Dx())
+	assert.Equal(t, white, out.NRGBAAt(0, 0))
+
+	for _, params := range [][]string{{"90"}, {"x"}, {"10", "nocolor"}} {
+		_, err := f.Apply(doc, params)
+		assert.Error(t, err, "params %v", params)
+	}
+}
@@ -1,19 +1,24 @@
 package filter
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"image"
 	"image/color"
 	"image/draw"
 	"io"
-	"net/http"
+	"math"
 	"os"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/disintegration/imaging"
+	"golang.org/x/sync/singleflight"
+
+	"github.com/vincent119/images-filters/internal/metrics"
+	"github.com/vincent119/images-filters/pkg/logger"
 )
 
 // WatermarkPosition 浮水印位置
@@ -29,16 +34,93 @@ const (
 	PositionBottom
 	PositionLeft
 	PositionRight
+	// PositionTile 平鋪整張圖片
+	PositionTile
+)
+
+// 浮水印素材預設值
+const (
+	defaultAssetTimeout  = 10 * time.Second
+	defaultAssetMaxBytes = 5 * 1024 * 1024
 )
 
+// AssetLoader 浮水印素材載入介面
+// 服務層以 loader.LoaderFactory 與 storage.Storage 實作，套用相同的來源白名單檢查
+type AssetLoader interface {
+	Load(ctx context.Context, source string) ([]byte, error)
+}
+
 // WatermarkFilter 浮水印濾鏡
+// 解碼後的浮水印圖片保存於 LRU 快取，並以 singleflight 合併同一素材的並行載入
 type WatermarkFilter struct {
-	baseURL string // 用於載入浮水印圖片的基礎 URL
+	loader   AssetLoader
+	cache    *assetCache
+	group    singleflight.Group
+	timeout  time.Duration
+	failOpen bool
+	metrics  metrics.Metrics
+}
+
+// WatermarkOption 浮水印濾鏡選項
+type WatermarkOption func(*WatermarkFilter)
+
+// WithAssetLoader 設定浮水印素材載入器
+func WithAssetLoader(l AssetLoader) WatermarkOption {
+	return func(f *WatermarkFilter) {
+		f.loader = l
+	}
+}
+
+// WithAssetCache 設定解碼後素材快取（maxBytes <= 0 表示停用快取，ttl <= 0 表示不過期）
+func WithAssetCache(maxBytes int64, ttl time.Duration) WatermarkOption {
+	return func(f *WatermarkFilter) {
+		if maxBytes <= 0 {
+			f.cache = nil
+			return
+		}
+		f.cache = newAssetCache(maxBytes, ttl)
+	}
+}
+
+// WithAssetTimeout 設定素材載入逾時
+func WithAssetTimeout(timeout time.Duration) WatermarkOption {
+	return func(f *WatermarkFilter) {
+		if timeout > 0 {
+			f.timeout = timeout
+		}
+	}
+}
+
+// WithFailOpen 設定素材載入失敗時的行為
+// true 時回傳未加浮水印的圖片；false 時濾鏡回傳錯誤，請求失敗
+func WithFailOpen(failOpen bool) WatermarkOption {
+	return func(f *WatermarkFilter) {
+		f.failOpen = failOpen
+	}
+}
+
+// WithWatermarkMetrics 設定指標收集器（快取命中、未命中、淘汰與載入失敗）
+func WithWatermarkMetrics(m metrics.Metrics) WatermarkOption {
+	return func(f *WatermarkFilter) {
+		f.metrics = m
+	}
 }
 
 // NewWatermarkFilter 建立浮水印濾鏡
-func NewWatermarkFilter() *WatermarkFilter {
-	return &WatermarkFilter{}
+// 未設定載入器時僅能讀取本機檔案（不載入遠端 URL），服務層會注入受來源白名單保護的載入器
+func NewWatermarkFilter(opts ...WatermarkOption) *WatermarkFilter {
+	f := &WatermarkFilter{
+		loader:   fileAssetLoader{},
+		timeout:  defaultAssetTimeout,
+		failOpen: true,
+	}
+	for _, opt := range opts {
+		opt(f)
+	}
+	if f.cache != nil && f.metrics != nil {
+		f.cache.onEvict = func() { f.metrics.RecordCacheEviction("watermark") }
+	}
+	return f
 }
 
 // Name 返回濾鏡名稱
@@ -48,11 +130,13 @@ func (f *WatermarkFilter) Name() string {
 
 // Apply 應用浮水印
 // params[0]: watermark image URL or path
-// params[1]: position (center, top-left, top-right, bottom-left, bottom-right, top, bottom, left, right)
+// params[1]: position (center, top-left, top-right, bottom-left, bottom-right, top, bottom, left, right, tile)
 // params[2]: alpha (0-100, 透明度，100 = 完全不透明)
-// params[3]: x offset (可選)
-// params[4]: y offset (可選)
+// params[3]: x offset (可選，平鋪時為水平間距)
+// params[4]: y offset (可選，平鋪時為垂直間距)
 // params[5]: scale (可選，浮水印縮放比例 0.1-2.0)
+// params[6]: angle (可選，僅平鋪，逆時針旋轉角度)
+// params[7]: stagger (可選，僅平鋪，奇數列位移比例 0-1 或 true)
 func (f *WatermarkFilter) Apply(img image.Image, params []string) (image.Image, error) {
 	if len(params) == 0 {
 		return img, nil // 沒有浮水印參數，返回原圖
@@ -89,11 +173,22 @@ func (f *WatermarkFilter) Apply(img image.Image, params []string) (image.Image,
 			scale = clamp(s, 0.1, 2.0)
 		}
 	}
+	tile := tileOptions{spacingX: xOffset, spacingY: yOffset}
+	if position == PositionTile && len(params) > 6 {
+		if a, err := strconv.ParseFloat(params[6], 64); err == nil {
+			if math.IsNaN(a) || math.IsInf(a, 0) {
+				return nil, fmt.Errorf("invalid tile angle: %s", params[6])
+			}
+			tile.angle = a
+		}
+	}
 
 	// 載入浮水印圖片
-	watermark, err := f.loadWatermark(watermarkPath)
+	watermark, err := f.loadOrSkip("watermark", watermarkPath)
 	if err != nil {
-		// 浮水印載入失敗，返回原圖
+		return nil, err
+	}
+	if watermark == nil {
 		return img, nil
 	}
 
@@ -109,61 +204,100 @@ func (f *WatermarkFilter) Apply(img image.Image, params []string) (image.Image,
 		watermark = adjustAlpha(watermark, float64(alpha)/100.0)
 	}
 
+	result := image.NewRGBA(img.Bounds())
+	draw.Draw(result, img.Bounds(), img, image.Point{}, draw.Over)
+
+	// 平鋪模式
+	if position == PositionTile {
+		if len(params) > 7 {
+			tile.stagger = parseStagger(params[7])
+		}
+		drawTiled(result, watermark, tile)
+		return result, nil
+	}
+
 	// 計算位置
 	x, y := calculatePosition(img.Bounds(), watermark.Bounds(), position, xOffset, yOffset)
 
 	// 繪製浮水印
-	result := image.NewRGBA(img.Bounds())
-	draw.Draw(result, img.Bounds(), img, image.Point{}, draw.Over)
 	draw.Draw(result, watermark.Bounds().Add(image.Point{X: x, Y: y}), watermark, image.Point{}, draw.Over)
 
 	return result, nil
 }
 
-// loadWatermark 載入浮水印圖片
-func (f *WatermarkFilter) loadWatermark(path string) (image.Image, error) {
-	// 判斷是 URL 還是本地路徑
-	if strings.HasPrefix(path, "http://") || strings.HasPrefix(path, "https://") {
-		return f.loadFromURL(path)
+// loadWatermark 載入並解碼浮水印圖片，優先使用快取
+func (f *WatermarkFilter) loadWatermark(source string) (image.Image, error) {
+	if f.cache != nil {
+		if img, ok := f.cache.get(source); ok {
+			if f.metrics != nil {
+				f.metrics.RecordCacheHit("watermark")
+			}
+			return img, nil
+		}
+		if f.metrics != nil {
+			f.metrics.RecordCacheMiss("watermark")
+		}
 	}
-	return f.loadFromFile(path)
-}
 
-// loadFromURL 從 URL 載入圖片
-func (f *WatermarkFilter) loadFromURL(url string) (image.Image, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
+	v, err, _ := f.group.Do(source, func() (any, error) {
+		ctx, cancel := context.WithTimeout(context.Background(), f.timeout)
+		defer cancel()
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		data, err := f.loader.Load(ctx, source)
+		if err != nil {
+			return nil, err
+		}
+		img, err := imaging.Decode(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode watermark: %w", err)
+		}
+		if f.cache != nil {
+			f.cache.add(source, img)
+		}
+		return img, nil
+	})
 	if err != nil {
 		return nil, err
 	}
+	return v.(image.Image), nil
+}
 
-	client := &http.Client{Timeout: 10 * time.Second}
-	resp, err := client.Do(req)
-	if err != nil {
-		return nil, err
+// loadOrSkip 載入素材並套用失敗處理策略
+// 載入失敗時：fail-open 記錄警告並回傳 nil（呼叫端應略過此濾鏡），否則回傳錯誤
+// kind 用於錯誤訊息與指標標籤（例如 watermark、overlay、mask）
+func (f *WatermarkFilter) loadOrSkip(kind, source string) (image.Image, error) {
+	img, err := f.loadWatermark(source)
+	if err == nil {
+		return img, nil
 	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("HTTP error: %d", resp.StatusCode)
+	if f.metrics != nil {
+		f.metrics.RecordProcessingError(kind + "_load_failed")
 	}
-
-	// 限制讀取大小（最大 5MB）
-	limitReader := io.LimitReader(resp.Body, 5*1024*1024)
-	return imaging.Decode(limitReader)
+	if !f.failOpen {
+		return nil, fmt.Errorf("failed to load %s: %w", kind, err)
+	}
+	logger.Warn("failed to load "+kind+", skipping",
+		logger.String("source", source),
+		logger.Err(err),
+	)
+	return nil, nil
 }
 
-// loadFromFile 從檔案載入圖片
-func (f *WatermarkFilter) loadFromFile(path string) (image.Image, error) {
-	file, err := os.Open(path)
+// fileAssetLoader 未注入載入器時使用的本機檔案載入器
+type fileAssetLoader struct{}
+
+// Load 讀取本機檔案（拒絕遠端 URL）
+func (fileAssetLoader) Load(_ context.Context, source string) ([]byte, error) {
+	if strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://") {
+		return nil, fmt.Errorf("remote watermark requires an asset loader: %s", source)
+	}
+	file, err := os.Open(source)
 	if err != nil {
 		return nil, err
 	}
 	defer file.Close()
 
-	return imaging.Decode(file)
+	return io.ReadAll(io.LimitReader(file, defaultAssetMaxBytes))
 }
 
 // parsePosition 解析位置字串
@@ -187,6 +321,8 @@ func parsePosition(s string) WatermarkPosition {
 		return PositionLeft
 	case "right", "r":
 		return PositionRight
+	case "tile", "tiled", "repeat":
+		return PositionTile
 	default:
 		return PositionBottomRight
 	}
@@ -1,6 +1,8 @@
 package filter
 
 import (
+	"bytes"
+	"context"
 	"fmt"
 	"image"
 	"image/color"
@@ -10,9 +12,12 @@ import (
 	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestWatermarkFilter_Name(t *testing.T) {
@@ -47,6 +52,8 @@ func TestParsePosition(t *testing.T) {
 		{"l", PositionLeft},
 		{"right", PositionRight},
 		{"r", PositionRight},
+		{"tile", PositionTile},
+		{"repeat", PositionTile},
 		{"unknown", PositionBottomRight},
 		{"", PositionBottomRight},
 	}
@@ -123,42 +130,113 @@ func TestWatermarkFilter_LoadFromFile(t *testing.T) {
 	assert.NoError(t, err)
 
 	filter := NewWatermarkFilter()
-	loadedImg, err := filter.loadFromFile(imgPath)
+	loadedImg, err := filter.loadWatermark(imgPath)
 	assert.NoError(t, err)
 	assert.NotNil(t, loadedImg)
 	assert.Equal(t, 10, loadedImg.Bounds().Dx())
 
 	// Test non-existent file
-	_, err = filter.loadFromFile(filepath.Join(tmpDir, "non_existent.png"))
+	_, err = filter.loadWatermark(filepath.Join(tmpDir, "non_existent.png"))
 	assert.Error(t, err)
 }
 
-func TestWatermarkFilter_LoadFromURL(t *testing.T) {
-	// Create a test server serving an image
+func TestWatermarkFilter_DefaultLoaderRejectsURL(t *testing.T) {
+	var hits atomic.Int32
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		img := image.NewRGBA(image.Rect(0, 0, 10, 10))
-		png.Encode(w, img)
+		hits.Add(1)
+		png.Encode(w, image.NewRGBA(image.Rect(0, 0, 10, 10)))
 	}))
 	defer server.Close()
 
-	filter := NewWatermarkFilter()
-	loadedImg, err := filter.loadFromURL(server.URL)
-	assert.NoError(t, err)
-	assert.NotNil(t, loadedImg)
-	assert.Equal(t, 10, loadedImg.Bounds().Dx())
-
-	// Test invalid URL (should timeout or fail dns)
-	// Using a closed port on localhost usually fails fast
-	_, err = filter.loadFromURL("http://localhost:54321/invalid")
+	// 未注入載入器時不直接連線遠端 URL
+	_, err := NewWatermarkFilter().loadWatermark(server.URL)
 	assert.Error(t, err)
+	assert.Zero(t, hits.Load())
+}
 
-	// Test 404
-	server404 := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		w.WriteHeader(http.StatusNotFound)
-	}))
-	defer server404.Close()
+// stubAssetLoader 測試用素材載入器
+type stubAssetLoader struct {
+	data  []byte
+	err   error
+	calls atomic.Int32
+}
+
+func (l *stubAssetLoader) Load(_ context.Context, _ string) ([]byte, error) {
+	l.calls.Add(1)
+	return l.data, l.err
+}
+
+func encodePNG(t *testing.T, w, h int) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, w, h))))
+	return buf.Bytes()
+}
+
+func TestWatermarkFilter_AssetCache(t *testing.T) {
+	loader := &stubAssetLoader{data: encodePNG(t, 10, 10)}
+	f := NewWatermarkFilter(WithAssetLoader(loader), WithAssetCache(1024, time.Minute))
+
+	for range 3 {
+		_, err := f.loadWatermark("wm.png")
+		require.NoError(t, err)
+	}
+	assert.Equal(t, int32(1), loader.calls.Load())
+
+	// 過期後重新載入
+	f.cache.now = func() time.Time { return time.Now().Add(2 * time.Minute) }
+	_, err := f.loadWatermark("wm.png")
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), loader.calls.Load())
+
+	// 停用快取時每次皆載入
+	uncached := NewWatermarkFilter(WithAssetLoader(loader), WithAssetCache(0, 0))
+	_, err = uncached.loadWatermark("wm.png")
+	require.NoError(t, err)
+	_, err = uncached.loadWatermark("wm.png")
+	require.NoError(t, err)
+	assert.Equal(t, int32(4), loader.calls.Load())
+}
+
+func TestAssetCache_Eviction(t *testing.T) {
+	c := newAssetCache(1000, 0)
+	evicted := 0
+	c.onEvict = func() { evicted++ }
+
+	small := image.NewRGBA(image.Rect(0, 0, 10, 10)) // 400 bytes
+	c.add("a", small)
+	c.add("b", small)
+	_, ok := c.get("a") // a 成為最近使用
+	require.True(t, ok)
+	c.add("c", small)
+
+	_, ok = c.get("b")
+	assert.False(t, ok)
+	_, ok = c.get("a")
+	assert.True(t, ok)
+	assert.Equal(t, 2, c.count())
+	assert.Equal(t, 1, evicted)
+
+	// 超過上限的單一圖片不快取
+	c.add("large", image.NewRGBA(image.Rect(0, 0, 20, 20)))
+	_, ok = c.get("large")
+	assert.False(t, ok)
+	assert.Equal(t, 2, c.count())
+}
+
+func TestWatermarkFilter_FailMode(t *testing.T) {
+	bg := image.NewRGBA(image.Rect(0, 0, 50, 50))
+	loader := &stubAssetLoader{err: fmt.Errorf("source not allowed")}
+
+	res, err := NewWatermarkFilter(WithAssetLoader(loader)).Apply(bg, []string{"https://evil.example/wm.png"})
+	require.NoError(t, err)
+	assert.Same(t, image.Image(bg), res)
+
+	_, err = NewWatermarkFilter(WithAssetLoader(loader), WithFailOpen(false)).Apply(bg, []string{"https://evil.example/wm.png"})
+	assert.ErrorContains(t, err, "source not allowed")
 
-	_, err = filter.loadFromURL(server404.URL)
+	// 無法解碼的素材同樣視為載入失敗
+	_, err = NewWatermarkFilter(WithAssetLoader(&stubAssetLoader{data: []byte("nope")}), WithFailOpen(false)).Apply(bg, []string{"wm.png"})
 	assert.Error(t, err)
 }
 
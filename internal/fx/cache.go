@@ -1,10 +1,13 @@
 package fx
 
 import (
+	"time"
+
 	"go.uber.org/fx"
 
 	"github.com/vincent119/images-filters/internal/cache"
 	"github.com/vincent119/images-filters/internal/config"
+	"github.com/vincent119/images-filters/internal/metrics"
 	"github.com/vincent119/images-filters/pkg/logger"
 )
 
@@ -13,8 +16,17 @@ var CacheModule = fx.Module("cache",
 	fx.Provide(NewCache),
 )
 
+// CacheParams cache module parameters
+type CacheParams struct {
+	fx.In
+
+	Config  *config.Config
+	Metrics metrics.Metrics `optional:"true"`
+}
+
 // NewCache creates a new cache instance based on config
-func NewCache(cfg *config.Config) (cache.Cache, error) {
+func NewCache(params CacheParams) (cache.Cache, error) {
+	cfg := params.Config
 	if !cfg.Cache.Enabled {
 		logger.Info("cache disabled, using no-op cache")
 		return cache.NewNoOpCache(), nil
@@ -25,8 +37,29 @@ func NewCache(cfg *config.Config) (cache.Cache, error) {
 		return cache.NewRedisCache(cfg.Cache.Redis)
 	case "memory":
 		return cache.NewMemoryCache(cfg.Cache.Memory)
+	case "tiered":
+		return newTieredCache(cfg.Cache, params.Metrics)
 	default:
 		logger.Warn("unknown cache type, using no-op cache", logger.String("type", cfg.Cache.Type))
 		return cache.NewNoOpCache(), nil
 	}
 }
+
+// newTieredCache composes an in-process L1 (memory) in front of a shared L2 (redis)
+func newTieredCache(cfg config.CacheConfig, m metrics.Metrics) (cache.Cache, error) {
+	l1, err := cache.NewMemoryCache(cfg.Memory)
+	if err != nil {
+		return nil, err
+	}
+	l2, err := cache.NewRedisCache(cfg.Redis)
+	if err != nil {
+		l1.Close()
+		return nil, err
+	}
+
+	opts := []cache.TieredOption{cache.WithTierMetrics(m)}
+	if cfg.Tiered.L1TTL > 0 {
+		opts = append(opts, cache.WithL1TTL(time.Duration(cfg.Tiered.L1TTL)*time.Second))
+	}
+	return cache.NewTieredCache(l1, l2, opts...), nil
+}
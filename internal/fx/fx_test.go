@@ -2,6 +2,7 @@ package fx
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"go.uber.org/fx"
@@ -56,6 +57,39 @@ func TestServiceModule(t *testing.T) {
 	}
 }
 
+// TestResumableUploadService verifies the resumable upload service is only provided when enabled
+func TestResumableUploadService(t *testing.T) {
+	for _, enabled := range []bool{false, true} {
+		cfg := &config.Config{
+			Storage: config.StorageConfig{
+				Type:  "local",
+				Local: config.LocalStorageConfig{RootPath: t.TempDir()},
+			},
+			Upload: config.UploadConfig{
+				Resumable: config.ResumableUploadConfig{Enabled: enabled, CleanupInterval: time.Minute},
+			},
+		}
+
+		var got service.ResumableUploadService
+		app := fxtest.New(t,
+			fx.Supply(cfg),
+			StorageModule,
+			CacheModule,
+			ServiceModule,
+			fx.Invoke(func(p struct {
+				fx.In
+				Service service.ResumableUploadService `optional:"true"`
+			}) {
+				got = p.Service
+			}),
+		)
+		app.RequireStart()
+		app.RequireStop()
+
+		assert.Equal(t, enabled, got != nil)
+	}
+}
+
 // TestStorageModule verifies StorageModule independently
 func TestStorageModule(t *testing.T) {
 	cfg := &config.Config{
@@ -0,0 +1,80 @@
+package fx
+
+import (
+	"context"
+
+	"go.uber.org/fx"
+
+	"github.com/vincent119/images-filters/internal/cache"
+	"github.com/vincent119/images-filters/internal/config"
+	"github.com/vincent119/images-filters/internal/purge"
+	"github.com/vincent119/images-filters/internal/service"
+	"github.com/vincent119/images-filters/internal/storage"
+	"github.com/vincent119/images-filters/pkg/logger"
+)
+
+// PurgeModule provides purge dependencies
+var PurgeModule = fx.Module("purge",
+	fx.Provide(NewVariantIndex),
+	fx.Provide(NewPurgeService),
+)
+
+// VariantIndexResult variant index module result
+type VariantIndexResult struct {
+	fx.Out
+	Index purge.Index `optional:"true"`
+}
+
+// NewVariantIndex creates the source-to-variants index (if purge is enabled)
+func NewVariantIndex(lc fx.Lifecycle, cfg *config.Config, store storage.Storage) (VariantIndexResult, error) {
+	if !cfg.Purge.Enabled {
+		return VariantIndexResult{}, nil
+	}
+
+	var index purge.Index
+	switch cfg.Purge.Index {
+	case "redis":
+		client, err := cache.NewRedisClient(cfg.Cache.Redis)
+		if err != nil {
+			return VariantIndexResult{}, err
+		}
+		lc.Append(fx.Hook{
+			OnStop: func(ctx context.Context) error {
+				return client.Close()
+			},
+		})
+		index = purge.NewRedisIndex(client)
+	default:
+		index = purge.NewStorageIndex(store)
+	}
+
+	logger.Info("",
+		logger.String("msg", "purge enabled"),
+		logger.String("index", cfg.Purge.Index),
+	)
+
+	return VariantIndexResult{Index: index}, nil
+}
+
+// PurgeServiceParams purge service parameters
+type PurgeServiceParams struct {
+	fx.In
+
+	Storage storage.Storage
+	Cache   cache.Cache
+	Index   purge.Index `optional:"true"`
+}
+
+// PurgeServiceResult purge service module result
+type PurgeServiceResult struct {
+	fx.Out
+	Service service.PurgeService `optional:"true"`
+}
+
+// NewPurgeService creates the purge service (if an index is available)
+func NewPurgeService(params PurgeServiceParams) PurgeServiceResult {
+	if params.Index == nil {
+		return PurgeServiceResult{}
+	}
+	return PurgeServiceResult{Service: service.NewPurgeService(params.Storage, params.Cache, params.Index)}
+}
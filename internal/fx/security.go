@@ -0,0 +1,61 @@
+package fx
+
+import (
+	"context"
+	"fmt"
+
+	"go.uber.org/fx"
+
+	"github.com/vincent119/images-filters/internal/config"
+	"github.com/vincent119/images-filters/internal/security"
+	"github.com/vincent119/images-filters/internal/storage"
+	"github.com/vincent119/images-filters/pkg/logger"
+)
+
+// SecurityModule provides security dependencies
+var SecurityModule = fx.Module("security",
+	fx.Provide(NewAPIKeyStore),
+)
+
+// APIKeyStoreResult API key store module result
+type APIKeyStoreResult struct {
+	fx.Out
+	Store security.APIKeyStore `optional:"true"`
+}
+
+// NewAPIKeyStore creates the API key store (if enabled)
+// With store "storage" the key list lives in its own storage, separate from the image storage
+func NewAPIKeyStore(cfg *config.Config) (APIKeyStoreResult, error) {
+	keysCfg := cfg.Security.APIKeys
+	if !keysCfg.Enabled {
+		return APIKeyStoreResult{}, nil
+	}
+
+	var backend security.KeyBackend
+	switch keysCfg.Store {
+	case "storage":
+		if keysCfg.Storage == nil {
+			return APIKeyStoreResult{}, fmt.Errorf("security.api_keys.storage is required when store is storage")
+		}
+		store, err := storage.NewStorageFromConfig(context.Background(), *keysCfg.Storage)
+		if err != nil {
+			return APIKeyStoreResult{}, fmt.Errorf("failed to create api key storage: %w", err)
+		}
+		backend = security.NewBlobKeyBackend(store, keysCfg.StorageKey)
+	default:
+		backend = security.NewFileKeyBackend(keysCfg.FilePath)
+	}
+
+	ring := security.NewKeyRing(backend, keysCfg.RefreshInterval)
+	// Load once at startup so that a broken key file fails fast
+	if err := ring.Reload(context.Background()); err != nil {
+		return APIKeyStoreResult{}, err
+	}
+
+	logger.Info("",
+		logger.String("msg", "api keys enabled"),
+		logger.String("store", keysCfg.Store),
+	)
+
+	return APIKeyStoreResult{Store: ring}, nil
+}
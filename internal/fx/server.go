@@ -9,6 +9,7 @@ import (
 
 	"github.com/vincent119/images-filters/internal/config"
 	"github.com/vincent119/images-filters/internal/metrics"
+	"github.com/vincent119/images-filters/internal/security"
 	"github.com/vincent119/images-filters/internal/service"
 	"github.com/vincent119/images-filters/pkg/logger"
 	"github.com/vincent119/images-filters/routes"
@@ -71,12 +72,25 @@ type RouteParams struct {
 	ImageService     service.ImageService
 	WatermarkService service.WatermarkService
 	Config           *config.Config
-	Metrics          metrics.Metrics `optional:"true"`
+	Metrics          metrics.Metrics                `optional:"true"`
+	APIKeys          security.APIKeyStore           `optional:"true"`
+	PurgeService     service.PurgeService           `optional:"true"`
+	ResumableUploads service.ResumableUploadService `optional:"true"`
 }
 
 // RegisterRoutes registers all routes
 func RegisterRoutes(params RouteParams) {
-	routes.Setup(params.Engine, params.ImageService, params.WatermarkService, params.Config, params.Metrics)
+	var opts []routes.Option
+	if params.APIKeys != nil {
+		opts = append(opts, routes.WithAPIKeyStore(params.APIKeys))
+	}
+	if params.PurgeService != nil {
+		opts = append(opts, routes.WithPurgeService(params.PurgeService))
+	}
+	if params.ResumableUploads != nil {
+		opts = append(opts, routes.WithResumableUploadService(params.ResumableUploads))
+	}
+	routes.Setup(params.Engine, params.ImageService, params.WatermarkService, params.Config, params.Metrics, opts...)
 }
 
 // ServerStartParams server start parameters
@@ -1,19 +1,25 @@
 package fx
 
 import (
+	"context"
+	"time"
+
 	"go.uber.org/fx"
 
 	"github.com/vincent119/images-filters/internal/cache"
 	"github.com/vincent119/images-filters/internal/config"
 	"github.com/vincent119/images-filters/internal/metrics"
+	"github.com/vincent119/images-filters/internal/purge"
 	"github.com/vincent119/images-filters/internal/service"
 	"github.com/vincent119/images-filters/internal/storage"
+	"github.com/vincent119/images-filters/pkg/logger"
 )
 
 // ServiceModule provides service dependencies
 var ServiceModule = fx.Module("service",
 	fx.Provide(NewImageService),
 	fx.Provide(NewWatermarkService),
+	fx.Provide(NewResumableUploadService),
 )
 
 // ServiceParams service module parameters
@@ -24,6 +30,7 @@ type ServiceParams struct {
 	Storage storage.Storage
 	Cache   cache.Cache
 	Metrics metrics.Metrics `optional:"true"`
+	Index   purge.Index     `optional:"true"`
 }
 
 // NewWatermarkService creates a new watermark service
@@ -38,6 +45,66 @@ func NewImageService(params ServiceParams) service.ImageService {
 	if params.Metrics != nil {
 		opts = append(opts, service.WithMetrics(params.Metrics))
 	}
+	if params.Index != nil {
+		opts = append(opts, service.WithVariantIndex(params.Index))
+	}
 
 	return service.NewImageService(params.Config, params.Storage, params.Cache, opts...)
 }
+
+// ResumableUploadResult resumable upload module result
+type ResumableUploadResult struct {
+	fx.Out
+	Service service.ResumableUploadService `optional:"true"`
+}
+
+// NewResumableUploadService creates the resumable upload service (if enabled)
+// and removes expired uploads every cleanup_interval while the app is running
+func NewResumableUploadService(lc fx.Lifecycle, cfg *config.Config, store storage.Storage, images service.ImageService) ResumableUploadResult {
+	resumableCfg := cfg.Upload.Resumable
+	if !resumableCfg.Enabled {
+		return ResumableUploadResult{}
+	}
+
+	svc := service.NewResumableUploadService(resumableCfg, store, images)
+
+	if resumableCfg.CleanupInterval > 0 {
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan struct{})
+		lc.Append(fx.Hook{
+			OnStart: func(context.Context) error {
+				go func() {
+					defer close(done)
+					ticker := time.NewTicker(resumableCfg.CleanupInterval)
+					defer ticker.Stop()
+					for {
+						select {
+						case <-ctx.Done():
+							return
+						case <-ticker.C:
+							if _, err := svc.Cleanup(ctx); err != nil {
+								logger.Warn("", logger.String("msg", "resumable upload cleanup failed"), logger.Err(err))
+							}
+						}
+					}
+				}()
+				return nil
+			},
+			OnStop: func(stopCtx context.Context) error {
+				cancel()
+				select {
+				case <-done:
+				case <-stopCtx.Done():
+				}
+				return nil
+			},
+		})
+	}
+
+	logger.Info("",
+		logger.String("msg", "resumable uploads enabled"),
+		logger.String("staging_prefix", resumableCfg.StagingPrefix),
+	)
+
+	return ResumableUploadResult{Service: svc}
+}
@@ -6,6 +6,8 @@ import (
 	"go.uber.org/fx"
 
 	"github.com/vincent119/images-filters/internal/config"
+	"github.com/vincent119/images-filters/internal/metrics"
+	"github.com/vincent119/images-filters/internal/resilience"
 	"github.com/vincent119/images-filters/internal/storage"
 )
 
@@ -14,20 +16,39 @@ var StorageModule = fx.Module("storage",
 	fx.Provide(NewStorage),
 )
 
+// StorageParams storage module parameters
+type StorageParams struct {
+	fx.In
+
+	Lifecycle fx.Lifecycle
+	Config    *config.Config
+	Metrics   metrics.Metrics `optional:"true"`
+}
+
 // NewStorage creates a new storage instance from config
-func NewStorage(lc fx.Lifecycle, cfg *config.Config) (storage.Storage, error) {
+func NewStorage(params StorageParams) (storage.Storage, error) {
 	// Context for storage creation (e.g. AWS session)
 	// We use background context as storage initialization might not need timeout control yet
 	// or we can use a timeout context if needed.
 	ctx := context.Background()
+	cfg := params.Config
 
 	s, err := storage.NewStorage(ctx, cfg)
 	if err != nil {
 		return nil, err
 	}
 
+	// Retry transient read failures (writes are passed through untouched)
+	if retryCfg := cfg.Resilience.Storage.Retry; retryCfg.MaxAttempts > 1 {
+		s = storage.NewRetryStorage(s, cfg.Storage.Type, resilience.RetryPolicy{
+			MaxAttempts: retryCfg.MaxAttempts,
+			BaseDelay:   retryCfg.BaseDelay,
+			MaxDelay:    retryCfg.MaxDelay,
+		}, params.Metrics)
+	}
+
 	// If needed, we can add lifecycle hooks for proper shutdown if storage supports it
-	// lc.Append(fx.Hook{
+	// params.Lifecycle.Append(fx.Hook{
 	// 	OnStop: func(ctx context.Context) error {
 	// 		return s.Close()
 	// 	},
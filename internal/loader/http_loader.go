@@ -2,21 +2,43 @@ package loader
 
 import (
 	"context"
+	"crypto/tls"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"strings"
 	"time"
 
+	"github.com/vincent119/images-filters/internal/metrics"
+	"github.com/vincent119/images-filters/internal/resilience"
 	"github.com/vincent119/images-filters/pkg/logger"
 )
 
+// defaultMaxRedirects 預設最大重導向次數
+const defaultMaxRedirects = 5
+
+// otherMetricHost 未列入白名單或 Profile 的主機於指標中使用的標籤
+const otherMetricHost = "other"
+
 // HTTPLoader HTTP/HTTPS 圖片載入器
+// 預設拒絕連線至私有、回環、鏈路本地與雲端中繼資料位址，並對每次重導向重新驗證來源
 type HTTPLoader struct {
-	client    *http.Client
-	maxSize   int64 // 最大檔案大小（位元組）
-	timeout   time.Duration
-	userAgent string
+	client               *http.Client
+	maxSize              int64 // 最大檔案大小（位元組）
+	timeout              time.Duration
+	userAgent            string
+	maxRedirects         int
+	allowPrivateNetworks bool
+	sourceChecker        SourceChecker
+	profiles             []*HostProfile
+
+	retryPolicy resilience.RetryPolicy
+	breakers    *resilience.BreakerGroup
+	hedgeDelay  time.Duration
+	metrics     metrics.Metrics
+	metricHosts SourceChecker
 }
 
 // HTTPLoaderOption HTTP 載入器選項
@@ -26,7 +48,6 @@ type HTTPLoaderOption func(*HTTPLoader)
 func WithHTTPTimeout(timeout time.Duration) HTTPLoaderOption {
 	return func(l *HTTPLoader) {
 		l.timeout = timeout
-		l.client.Timeout = timeout
 	}
 }
 
@@ -44,24 +65,181 @@ func WithUserAgent(userAgent string) HTTPLoaderOption {
 	}
 }
 
+// WithMaxRedirects 設定最大重導向次數（0 表示不跟隨重導向）
+func WithMaxRedirects(maxRedirects int) HTTPLoaderOption {
+	return func(l *HTTPLoader) {
+		if maxRedirects >= 0 {
+			l.maxRedirects = maxRedirects
+		}
+	}
+}
+
+// WithAllowPrivateNetworks 允許連線至私有網段（僅供開發與測試環境使用）
+func WithAllowPrivateNetworks(allow bool) HTTPLoaderOption {
+	return func(l *HTTPLoader) {
+		l.allowPrivateNetworks = allow
+	}
+}
+
+// WithSourceChecker 設定來源白名單，初始 URL 與每次重導向目標皆需通過檢查
+func WithSourceChecker(checker SourceChecker) HTTPLoaderOption {
+	return func(l *HTTPLoader) {
+		l.sourceChecker = checker
+	}
+}
+
+// WithRetryPolicy 設定暫時性錯誤（連線失敗、5xx、408、429）的重試策略
+func WithRetryPolicy(p resilience.RetryPolicy) HTTPLoaderOption {
+	return func(l *HTTPLoader) {
+		l.retryPolicy = p
+	}
+}
+
+// WithCircuitBreaker 設定依來源主機區分的斷路器
+func WithCircuitBreaker(g *resilience.BreakerGroup) HTTPLoaderOption {
+	return func(l *HTTPLoader) {
+		l.breakers = g
+	}
+}
+
+// WithHedgeDelay 設定對沖請求的延遲（0 表示停用）
+func WithHedgeDelay(d time.Duration) HTTPLoaderOption {
+	return func(l *HTTPLoader) {
+		l.hedgeDelay = d
+	}
+}
+
+// WithLoaderMetrics 設定重試與對沖請求的指標收集器
+func WithLoaderMetrics(m metrics.Metrics) HTTPLoaderOption {
+	return func(l *HTTPLoader) {
+		l.metrics = m
+	}
+}
+
+// WithMetricHosts 設定於指標中以主機名稱記錄的來源（通常為來源白名單）
+// 其餘主機（Profile 設定的主機除外）以 "other" 記錄，避免標籤數量無限制成長
+func WithMetricHosts(checker SourceChecker) HTTPLoaderOption {
+	return func(l *HTTPLoader) {
+		l.metricHosts = checker
+	}
+}
+
 // NewHTTPLoader 建立 HTTP 載入器
 func NewHTTPLoader(opts ...HTTPLoaderOption) *HTTPLoader {
 	loader := &HTTPLoader{
-		client: &http.Client{
-			Timeout: 30 * time.Second,
-		},
-		maxSize:   10 * 1024 * 1024, // 預設 10MB
-		timeout:   30 * time.Second,
-		userAgent: "ImageProcessor/1.0",
+		maxSize:      10 * 1024 * 1024, // 預設 10MB
+		timeout:      30 * time.Second,
+		userAgent:    "ImageProcessor/1.0",
+		maxRedirects: defaultMaxRedirects,
+		retryPolicy:  resilience.RetryPolicy{MaxAttempts: 1},
 	}
 
 	for _, opt := range opts {
 		opt(loader)
 	}
 
+	loader.client = loader.newClient(loader.timeout, nil)
+
+	for _, p := range loader.profiles {
+		timeout := p.Timeout
+		if timeout <= 0 {
+			timeout = loader.timeout
+		}
+		tlsConfig, err := p.tlsConfig()
+		if err != nil {
+			logger.Error("failed to build loader profile",
+				logger.String("profile", p.Name),
+				logger.Err(err),
+			)
+			p.err = err
+			continue
+		}
+		p.client = loader.newClient(timeout, tlsConfig)
+	}
+
 	return loader
 }
 
+// newClient 依設定建立 HTTP Client
+func (l *HTTPLoader) newClient(timeout time.Duration, tlsConfig *tls.Config) *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if tlsConfig != nil {
+		transport.TLSClientConfig = tlsConfig
+	}
+	if !l.allowPrivateNetworks {
+		// 代理伺服器會讓實際連線目標脫離 Dialer 的檢查，因此停用
+		transport.Proxy = nil
+		transport.DialContext = newSafeDialer(timeout).DialContext
+	}
+
+	return &http.Client{
+		Timeout:       timeout,
+		Transport:     transport,
+		CheckRedirect: l.checkRedirect,
+	}
+}
+
+// checkRedirect 驗證重導向目標
+func (l *HTTPLoader) checkRedirect(req *http.Request, via []*http.Request) error {
+	if len(via) > l.maxRedirects {
+		return fmt.Errorf("%w: limit %d", ErrTooManyRedirects, l.maxRedirects)
+	}
+	if !l.CanLoad(req.URL.String()) {
+		return fmt.Errorf("%w: unsupported redirect scheme %q", ErrSourceNotAllowed, req.URL.Scheme)
+	}
+	if l.sourceChecker != nil && !l.sourceChecker.IsAllowed(req.URL.String()) {
+		logger.Debug("redirect target not allowed",
+			logger.String("url", req.URL.String()),
+		)
+		return fmt.Errorf("%w: redirect to %s", ErrSourceNotAllowed, req.URL.Hostname())
+	}
+	// 重導向目標不再套用原 Profile 時移除其標頭與認證，避免 API 金鑰等機密送往其他主機
+	if len(via) > 0 {
+		if profile := l.profileFor(via[0].URL.String()); profile != nil && l.profileFor(req.URL.String()) != profile {
+			profile.removeHeaders(req)
+		}
+	}
+	return nil
+}
+
+// ForwardsRequestHeaders 檢查載入來源時是否會轉發原始請求的標頭（例如 Cookie）
+// 此時來源回應可能因使用者而異，呼叫端不應將結果寫入共用的快取或儲存
+func (l *HTTPLoader) ForwardsRequestHeaders(ctx context.Context, source string) bool {
+	if !l.CanLoad(source) {
+		return false
+	}
+	profile := l.profileFor(source)
+	return profile != nil && profile.forwardsHeaders(ctx)
+}
+
+// MetricHost 取得主機於指標中的標籤
+// 僅 WithMetricHosts 允許或符合 Profile 的主機使用主機名稱，其餘回傳 "other"
+func (l *HTTPLoader) MetricHost(host string) string {
+	source := "https://" + host + "/"
+	if (l.metricHosts != nil && l.metricHosts.IsAllowed(source)) || l.profileFor(source) != nil {
+		return host
+	}
+	return otherMetricHost
+}
+
+// profileFor 取得來源適用的 Profile（無符合時回傳 nil）
+func (l *HTTPLoader) profileFor(source string) *HostProfile {
+	for _, p := range l.profiles {
+		if p.Matcher != nil && p.Matcher.IsAllowed(source) {
+			return p
+		}
+	}
+	return nil
+}
+
+// maxSizeFor 取得來源適用的最大檔案大小
+func (l *HTTPLoader) maxSizeFor(profile *HostProfile) int64 {
+	if profile != nil && profile.MaxSize > 0 {
+		return profile.MaxSize
+	}
+	return l.maxSize
+}
+
 // CanLoad 檢查是否可以載入指定來源
 func (l *HTTPLoader) CanLoad(source string) bool {
 	return strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://")
@@ -75,9 +253,11 @@ func (l *HTTPLoader) Load(ctx context.Context, source string) ([]byte, error) {
 	}
 	defer rc.Close()
 
+	maxSize := l.maxSizeFor(l.profileFor(source))
+
 	var reader io.Reader = rc
-	if l.maxSize > 0 {
-		reader = io.LimitReader(rc, l.maxSize+1) // +1 用於偵測超過限制
+	if maxSize > 0 {
+		reader = io.LimitReader(rc, maxSize+1) // +1 用於偵測超過限制
 	}
 
 	data, err := io.ReadAll(reader)
@@ -86,8 +266,8 @@ func (l *HTTPLoader) Load(ctx context.Context, source string) ([]byte, error) {
 		return nil, fmt.Errorf("failed to read response: %w", err)
 	}
 
-	if l.maxSize > 0 && int64(len(data)) > l.maxSize {
-		return nil, fmt.Errorf("file too large: exceeds %d bytes limit", l.maxSize)
+	if maxSize > 0 && int64(len(data)) > maxSize {
+		return nil, fmt.Errorf("file too large: exceeds %d bytes limit", maxSize)
 	}
 
 	logger.Debug("HTTP load successful",
@@ -100,25 +280,179 @@ func (l *HTTPLoader) Load(ctx context.Context, source string) ([]byte, error) {
 
 // LoadStream 從 HTTP/HTTPS 載入圖片串流
 func (l *HTTPLoader) LoadStream(ctx context.Context, source string) (io.ReadCloser, error) {
+	resp, _, err := l.open(ctx, http.MethodGet, source, Validators{})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+// Version 以 HEAD 請求取得來源版本（ETag，無 ETag 時使用 Last-Modified 與 Content-Length）
+// 來源未提供任何驗證資訊時回傳空字串
+func (l *HTTPLoader) Version(ctx context.Context, source string) (string, error) {
+	resp, _, err := l.open(ctx, http.MethodHead, source, Validators{})
+	if err != nil {
+		return "", err
+	}
+	resp.Body.Close()
+
+	if etag := resp.Header.Get("ETag"); etag != "" {
+		return etag, nil
+	}
+	if lastModified := resp.Header.Get("Last-Modified"); lastModified != "" {
+		return lastModified + "|" + resp.Header.Get("Content-Length"), nil
+	}
+	return "", nil
+}
+
+// Validators 條件式請求的驗證資訊
+type Validators struct {
+	ETag         string // 送出 If-None-Match
+	LastModified string // 送出 If-Modified-Since
+}
+
+// FetchResult 條件式請求結果
+type FetchResult struct {
+	NotModified bool        // 來源回應 304，Data 為空
+	Data        []byte      // 圖片資料
+	Header      http.Header // 來源回應標頭（ETag、Last-Modified、Cache-Control 等）
+}
+
+// Fetch 以條件式 GET 載入圖片
+// 來源回應 304 Not Modified 時回傳 NotModified=true
+func (l *HTTPLoader) Fetch(ctx context.Context, source string, v Validators) (*FetchResult, error) {
+	resp, maxSize, err := l.open(ctx, http.MethodGet, source, v)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotModified {
+		return &FetchResult{NotModified: true, Header: resp.Header}, nil
+	}
+
+	var reader io.Reader = resp.Body
+	if maxSize > 0 {
+		reader = io.LimitReader(resp.Body, maxSize+1) // +1 用於偵測超過限制
+	}
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	if maxSize > 0 && int64(len(data)) > maxSize {
+		return nil, fmt.Errorf("file too large: exceeds %d bytes limit", maxSize)
+	}
+
+	return &FetchResult{Data: data, Header: resp.Header}, nil
+}
+
+// open 送出請求並驗證回應，回傳回應與適用的最大檔案大小
+// 帶有驗證資訊時 304 視為成功回應；暫時性錯誤依重試策略重試，並受來源主機的斷路器保護
+func (l *HTTPLoader) open(ctx context.Context, method, source string, v Validators) (*http.Response, int64, error) {
 	logger.Debug("HTTP loader stream starting",
 		logger.String("url", source),
 	)
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, source, nil)
+	if l.sourceChecker != nil && !l.sourceChecker.IsAllowed(source) {
+		return nil, 0, fmt.Errorf("%w: %s", ErrSourceNotAllowed, source)
+	}
+
+	profile := l.profileFor(source)
+	if profile != nil && profile.err != nil {
+		return nil, 0, fmt.Errorf("loader profile %q unavailable: %w", profile.Name, profile.err)
+	}
+	maxSize := l.maxSizeFor(profile)
+	host := hostOf(source)
+
+	var resp *http.Response
+	err := resilience.Retry(ctx, l.retryPolicy, func(ctx context.Context) error {
+		if l.breakers != nil {
+			if err := l.breakers.Allow(host); err != nil {
+				return fmt.Errorf("%w: %s", err, host)
+			}
+		}
+
+		r, err := resilience.Hedge(ctx, l.hedgeDelay,
+			func(ctx context.Context) (*http.Response, error) {
+				return l.attempt(ctx, method, source, v, profile, maxSize)
+			},
+			func(r *http.Response) { r.Body.Close() },
+			func() {
+				if l.metrics != nil {
+					l.metrics.RecordOriginHedge(l.MetricHost(host))
+				}
+			},
+		)
+
+		// 永久性錯誤（4xx、格式不符等）代表來源仍正常回應，不計入斷路器失敗；
+		// 用戶端取消時無法判斷來源狀態，僅釋放探測名額
+		if l.breakers != nil {
+			if ctx.Err() == nil {
+				l.breakers.Record(host, err == nil || resilience.IsPermanent(err))
+			} else {
+				l.breakers.Release(host)
+			}
+		}
+		if err != nil {
+			return err
+		}
+		resp = r
+		return nil
+	}, func(attempt int, err error) {
+		logger.Debug("retrying HTTP request",
+			logger.String("url", source),
+			logger.Int("attempt", attempt),
+			logger.Err(err),
+		)
+		if l.metrics != nil {
+			l.metrics.RecordOriginRetry(l.MetricHost(host))
+		}
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return resp, maxSize, nil
+}
+
+// attempt 送出單次請求並驗證回應
+// 不可重試的錯誤以 resilience.Permanent 標記
+func (l *HTTPLoader) attempt(ctx context.Context, method, source string, v Validators, profile *HostProfile, maxSize int64) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, source, nil)
 	if err != nil {
 		logger.Debug("failed to create HTTP request", logger.Err(err))
-		return nil, fmt.Errorf("failed to create request: %w", err)
+		return nil, resilience.Permanent(fmt.Errorf("failed to create request: %w", err))
 	}
 
 	req.Header.Set("User-Agent", l.userAgent)
+	if v.ETag != "" {
+		req.Header.Set("If-None-Match", v.ETag)
+	}
+	if v.LastModified != "" {
+		req.Header.Set("If-Modified-Since", v.LastModified)
+	}
 
-	resp, err := l.client.Do(req)
+	client := l.client
+	if profile != nil {
+		profile.applyHeaders(ctx, req)
+		client = profile.client
+	}
+
+	resp, err := client.Do(req)
 	if err != nil {
 		logger.Debug("HTTP request failed",
 			logger.String("url", source),
 			logger.Err(err),
 		)
-		return nil, fmt.Errorf("request failed: %w", err)
+		err = fmt.Errorf("request failed: %w", err)
+		if errors.Is(err, ErrBlockedAddress) || errors.Is(err, ErrSourceNotAllowed) || errors.Is(err, ErrTooManyRedirects) {
+			return nil, resilience.Permanent(err)
+		}
+		return nil, err
+	}
+
+	if resp.StatusCode == http.StatusNotModified && (v.ETag != "" || v.LastModified != "") {
+		return resp, nil
 	}
 
 	if resp.StatusCode != http.StatusOK {
@@ -127,17 +461,21 @@ func (l *HTTPLoader) LoadStream(ctx context.Context, source string) (io.ReadClos
 			logger.String("url", source),
 			logger.Int("status", resp.StatusCode),
 		)
-		return nil, fmt.Errorf("HTTP error: %d %s", resp.StatusCode, resp.Status)
+		err := fmt.Errorf("HTTP error: %d %s", resp.StatusCode, resp.Status)
+		if !isRetryableStatus(resp.StatusCode) {
+			return nil, resilience.Permanent(err)
+		}
+		return nil, err
 	}
 
 	// 檢查 Content-Length
-	if l.maxSize > 0 && resp.ContentLength > l.maxSize {
+	if maxSize > 0 && resp.ContentLength > maxSize {
 		resp.Body.Close()
 		logger.Debug("file too large",
 			logger.Int64("content_length", resp.ContentLength),
-			logger.Int64("max_size", l.maxSize),
+			logger.Int64("max_size", maxSize),
 		)
-		return nil, fmt.Errorf("file too large: %d bytes (limit: %d)", resp.ContentLength, l.maxSize)
+		return nil, resilience.Permanent(fmt.Errorf("file too large: %d bytes (limit: %d)", resp.ContentLength, maxSize))
 	}
 
 	// 驗證 Content-Type
@@ -147,10 +485,24 @@ func (l *HTTPLoader) LoadStream(ctx context.Context, source string) (io.ReadClos
 		logger.Debug("invalid Content-Type",
 			logger.String("content_type", contentType),
 		)
-		return nil, fmt.Errorf("invalid Content-Type: %s", contentType)
+		return nil, resilience.Permanent(fmt.Errorf("invalid Content-Type: %s", contentType))
 	}
 
-	return resp.Body, nil
+	return resp, nil
+}
+
+// isRetryableStatus 檢查 HTTP 狀態碼是否值得重試
+func isRetryableStatus(code int) bool {
+	return code == http.StatusRequestTimeout || code == http.StatusTooManyRequests || code >= 500
+}
+
+// hostOf 取得來源 URL 的主機名稱（斷路器與指標的標籤）
+func hostOf(source string) string {
+	u, err := url.Parse(source)
+	if err != nil {
+		return ""
+	}
+	return strings.ToLower(u.Hostname())
 }
 
 // isValidImageContentType 檢查是否為有效的圖片 Content-Type
@@ -2,12 +2,18 @@ package loader
 
 import (
 	"context"
+	"errors"
 	"net/http"
 	"net/http/httptest"
+	"net/netip"
+	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
+
+	"github.com/vincent119/images-filters/internal/resilience"
 )
 
 func TestHTTPLoader_CanLoad(t *testing.T) {
@@ -46,6 +52,7 @@ func TestHTTPLoader_Load(t *testing.T) {
 	loader := NewHTTPLoader(
 		WithMaxSize(1024*1024), // 1MB Limit
 		WithHTTPTimeout(500*time.Millisecond),
+		WithAllowPrivateNetworks(true), // httptest 伺服器位於回環位址
 	)
 
 	tests := []struct {
@@ -105,8 +112,304 @@ func TestHTTPLoader_Load(t *testing.T) {
 
 	// Test Timeout specifically with a text context
 	t.Run("Real Timeout", func(t *testing.T) {
-		timeoutLoader := NewHTTPLoader(WithHTTPTimeout(10 * time.Millisecond))
+		timeoutLoader := NewHTTPLoader(WithHTTPTimeout(10*time.Millisecond), WithAllowPrivateNetworks(true))
 		_, err := timeoutLoader.Load(context.Background(), server.URL+"/timeout")
 		assert.Error(t, err)
 	})
 }
+
+// hostChecker 僅允許指定主機的來源檢查器
+type hostChecker struct {
+	hosts []string
+}
+
+func (c hostChecker) IsAllowed(source string) bool {
+	for _, h := range c.hosts {
+		if strings.Contains(source, "://"+h+":") || strings.Contains(source, "://"+h+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+func TestIsBlockedIP(t *testing.T) {
+	tests := []struct {
+		ip      string
+		blocked bool
+	}{
+		{"127.0.0.1", true},
+		{"10.1.2.3", true},
+		{"172.16.0.1", true},
+		{"192.168.1.1", true},
+		{"169.254.169.254", true},
+		{"100.64.0.1", true},
+		{"0.0.0.0", true},
+		{"::1", true},
+		{"fe80::1", true},
+		{"fd00:ec2::254", true},
+		{"::ffff:127.0.0.1", true},
+		{"::ffff:169.254.169.254", true},
+		{"8.8.8.8", false},
+		{"93.184.216.34", false},
+		{"2606:4700:4700::1111", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.ip, func(t *testing.T) {
+			assert.Equal(t, tt.blocked, isBlockedIP(netip.MustParseAddr(tt.ip)))
+		})
+	}
+}
+
+func TestHTTPLoader_BlocksPrivateNetworks(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/jpeg")
+		w.Write([]byte("fake image data"))
+	}))
+	defer server.Close()
+
+	loader := NewHTTPLoader()
+
+	// 直接存取回環位址
+	_, err := loader.Load(context.Background(), server.URL+"/image.jpg")
+	assert.True(t, errors.Is(err, ErrBlockedAddress), "got %v", err)
+
+	// 經 DNS 解析後為回環位址
+	localhostURL := strings.Replace(server.URL, "127.0.0.1", "localhost", 1)
+	_, err = loader.Load(context.Background(), localhostURL+"/image.jpg")
+	assert.True(t, errors.Is(err, ErrBlockedAddress), "got %v", err)
+}
+
+func TestHTTPLoader_Redirects(t *testing.T) {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/image.jpg":
+			w.Header().Set("Content-Type", "image/jpeg")
+			w.Write([]byte("fake image data"))
+		case "/same-host":
+			http.Redirect(w, r, "/image.jpg", http.StatusFound)
+		case "/other-host":
+			// 同一伺服器但使用未列入白名單的主機名稱
+			http.Redirect(w, r, strings.Replace(server.URL, "127.0.0.1", "localhost", 1)+"/image.jpg", http.StatusFound)
+		case "/metadata":
+			http.Redirect(w, r, "http://169.254.169.254/latest/meta-data/", http.StatusFound)
+		case "/loop":
+			http.Redirect(w, r, "/loop", http.StatusFound)
+		case "/ftp":
+			http.Redirect(w, r, "ftp://127.0.0.1/image.jpg", http.StatusFound)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	loader := NewHTTPLoader(
+		WithAllowPrivateNetworks(true),
+		WithSourceChecker(hostChecker{hosts: []string{"127.0.0.1"}}),
+		WithMaxRedirects(3),
+	)
+
+	tests := []struct {
+		name    string
+		path    string
+		wantErr error
+	}{
+		{"Same Host Redirect", "/same-host", nil},
+		{"Redirect To Non-Whitelisted Host", "/other-host", ErrSourceNotAllowed},
+		{"Redirect To Metadata Service", "/metadata", ErrSourceNotAllowed},
+		{"Redirect Loop", "/loop", ErrTooManyRedirects},
+		{"Redirect To Unsupported Scheme", "/ftp", ErrSourceNotAllowed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := loader.Load(context.Background(), server.URL+tt.path)
+			if tt.wantErr == nil {
+				assert.NoError(t, err)
+				assert.Equal(t, "fake image data", string(data))
+				return
+			}
+			assert.True(t, errors.Is(err, tt.wantErr), "got %v", err)
+		})
+	}
+
+	t.Run("Initial Source Not Allowed", func(t *testing.T) {
+		localhostURL := strings.Replace(server.URL, "127.0.0.1", "localhost", 1)
+		_, err := loader.Load(context.Background(), localhostURL+"/image.jpg")
+		assert.True(t, errors.Is(err, ErrSourceNotAllowed), "got %v", err)
+	})
+
+	t.Run("Redirects Disabled", func(t *testing.T) {
+		noRedirect := NewHTTPLoader(WithAllowPrivateNetworks(true), WithMaxRedirects(0))
+		_, err := noRedirect.Load(context.Background(), server.URL+"/same-host")
+		assert.True(t, errors.Is(err, ErrTooManyRedirects), "got %v", err)
+	})
+}
+
+func TestHTTPLoader_Retry(t *testing.T) {
+	var flakyCalls, missingCalls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/flaky.jpg":
+			if flakyCalls.Add(1) < 3 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			w.Header().Set("Content-Type", "image/jpeg")
+			w.Write([]byte("fake image data"))
+		default:
+			missingCalls.Add(1)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	loader := NewHTTPLoader(
+		WithAllowPrivateNetworks(true),
+		WithRetryPolicy(resilience.RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond}),
+	)
+
+	data, err := loader.Load(context.Background(), server.URL+"/flaky.jpg")
+	assert.NoError(t, err)
+	assert.Equal(t, "fake image data", string(data))
+	assert.Equal(t, int32(3), flakyCalls.Load())
+
+	// 4xx 不重試
+	_, err = loader.Load(context.Background(), server.URL+"/missing.jpg")
+	assert.Error(t, err)
+	assert.Equal(t, int32(1), missingCalls.Load())
+}
+
+func TestHTTPLoader_CircuitBreaker(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	breakers := resilience.NewBreakerGroup(2, time.Minute, nil)
+	loader := NewHTTPLoader(
+		WithAllowPrivateNetworks(true),
+		WithCircuitBreaker(breakers),
+	)
+
+	for i := 0; i < 2; i++ {
+		_, err := loader.Load(context.Background(), server.URL+"/image.jpg")
+		assert.Error(t, err)
+	}
+	assert.Equal(t, resilience.StateOpen, breakers.State("127.0.0.1"))
+
+	_, err := loader.Load(context.Background(), server.URL+"/image.jpg")
+	assert.True(t, errors.Is(err, resilience.ErrCircuitOpen), "got %v", err)
+	assert.Equal(t, int32(2), calls.Load())
+}
+
+func TestHTTPLoader_CircuitBreakerCanceledProbe(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			// 探測請求卡住直到用戶端取消
+			<-r.Context().Done()
+			return
+		}
+		w.Header().Set("Content-Type", "image/jpeg")
+		w.Write([]byte("fake image data"))
+	}))
+	defer server.Close()
+
+	breakers := resilience.NewBreakerGroup(1, 0, nil)
+	loader := NewHTTPLoader(
+		WithAllowPrivateNetworks(true),
+		WithCircuitBreaker(breakers),
+	)
+	breakers.Record("127.0.0.1", false)
+	assert.Equal(t, resilience.StateOpen, breakers.State("127.0.0.1"))
+
+	// 半開狀態的探測請求被取消後，下一個請求仍可探測
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err := loader.Load(ctx, server.URL+"/image.jpg")
+	assert.Error(t, err)
+	assert.Equal(t, resilience.StateHalfOpen, breakers.State("127.0.0.1"))
+
+	data, err := loader.Load(context.Background(), server.URL+"/image.jpg")
+	assert.NoError(t, err)
+	assert.Equal(t, "fake image data", string(data))
+	assert.Equal(t, resilience.StateClosed, breakers.State("127.0.0.1"))
+}
+
+func TestHTTPLoader_MetricHost(t *testing.T) {
+	loader := NewHTTPLoader(
+		WithMetricHosts(hostChecker{hosts: []string{"cdn.example.com"}}),
+		WithHostProfiles(&HostProfile{Name: "origin", Matcher: hostChecker{hosts: []string{"origin.example.com"}}}),
+	)
+	assert.Equal(t, "cdn.example.com", loader.MetricHost("cdn.example.com"))
+	assert.Equal(t, "origin.example.com", loader.MetricHost("origin.example.com"))
+	assert.Equal(t, "other", loader.MetricHost("random-1234.example.net"))
+
+	// 未設定白名單時僅 Profile 主機使用主機名稱
+	assert.Equal(t, "other", NewHTTPLoader().MetricHost("cdn.example.com"))
+}
+
+func TestHTTPLoader_Hedge(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			// 第一個請求卡住直到被取消
+			<-r.Context().Done()
+			return
+		}
+		w.Header().Set("Content-Type", "image/jpeg")
+		w.Write([]byte("fake image data"))
+	}))
+	defer server.Close()
+
+	loader := NewHTTPLoader(
+		WithAllowPrivateNetworks(true),
+		WithHedgeDelay(20*time.Millisecond),
+	)
+
+	data, err := loader.Load(context.Background(), server.URL+"/image.jpg")
+	assert.NoError(t, err)
+	assert.Equal(t, "fake image data", string(data))
+	assert.Equal(t, int32(2), calls.Load())
+}
+
+func TestHTTPLoader_Version(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/jpeg")
+		switch r.URL.Path {
+		case "/etag.jpg":
+			w.Header().Set("ETag", `"abc"`)
+		case "/modified.jpg":
+			w.Header().Set("Last-Modified", "Mon, 02 Jan 2006 15:04:05 GMT")
+		case "/missing.jpg":
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if r.Method == http.MethodGet {
+			w.Write([]byte("fake image data"))
+		}
+	}))
+	defer server.Close()
+
+	loader := NewHTTPLoader(WithAllowPrivateNetworks(true))
+	ctx := context.Background()
+
+	v, err := loader.Version(ctx, server.URL+"/etag.jpg")
+	assert.NoError(t, err)
+	assert.Equal(t, `"abc"`, v)
+
+	v, err = loader.Version(ctx, server.URL+"/modified.jpg")
+	assert.NoError(t, err)
+	assert.Contains(t, v, "Mon, 02 Jan 2006 15:04:05 GMT")
+
+	v, err = loader.Version(ctx, server.URL+"/plain.jpg")
+	assert.NoError(t, err)
+	assert.Empty(t, v)
+
+	_, err = loader.Version(ctx, server.URL+"/missing.jpg")
+	assert.Error(t, err)
+}
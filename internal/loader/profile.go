@@ -0,0 +1,140 @@
+package loader
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+)
+
+// HostProfile 來源主機的請求設定
+// 依來源 URL 比對，第一個符合的 Profile 生效
+type HostProfile struct {
+	Name    string
+	Matcher SourceChecker // 判斷來源 URL 是否套用此 Profile
+
+	UserAgent      string            // 覆寫 User-Agent（空字串沿用載入器預設值）
+	Headers        map[string]string // 固定附加的請求標頭
+	BearerToken    string            // Authorization: Bearer <token>
+	BasicUsername  string            // HTTP Basic 驗證帳號
+	BasicPassword  string            // HTTP Basic 驗證密碼
+	ForwardHeaders []string          // 從原始請求轉發的標頭（例如 Cookie）
+
+	CertFile string // mTLS 用戶端憑證
+	KeyFile  string // mTLS 用戶端私鑰
+	CAFile   string // 驗證來源伺服器的 CA 憑證
+
+	Timeout time.Duration // 請求逾時（0 沿用載入器預設值）
+	MaxSize int64         // 最大檔案大小（0 沿用載入器預設值）
+
+	client *http.Client
+	err    error // 建立 Client 失敗時記錄錯誤，使用此 Profile 的請求一律失敗
+}
+
+// WithHostProfiles 設定來源主機 Profile
+func WithHostProfiles(profiles ...*HostProfile) HTTPLoaderOption {
+	return func(l *HTTPLoader) {
+		l.profiles = append(l.profiles, profiles...)
+	}
+}
+
+// tlsConfig 建立 Profile 的 TLS 設定（未設定憑證時回傳 nil）
+func (p *HostProfile) tlsConfig() (*tls.Config, error) {
+	if p.CertFile == "" && p.KeyFile == "" && p.CAFile == "" {
+		return nil, nil
+	}
+
+	cfg := &tls.Config{MinVersion: tls.VersionTLS12}
+
+	if p.CertFile != "" || p.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(p.CertFile, p.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %w", err)
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+
+	if p.CAFile != "" {
+		pem, err := os.ReadFile(p.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no valid certificates in CA file: %s", p.CAFile)
+		}
+		cfg.RootCAs = pool
+	}
+
+	return cfg, nil
+}
+
+// applyHeaders 將 Profile 設定套用至請求
+func (p *HostProfile) applyHeaders(ctx context.Context, req *http.Request) {
+	if forwarded := requestHeadersFromContext(ctx); forwarded != nil {
+		for _, name := range p.ForwardHeaders {
+			for _, v := range forwarded.Values(name) {
+				req.Header.Add(name, v)
+			}
+		}
+	}
+
+	if p.UserAgent != "" {
+		req.Header.Set("User-Agent", p.UserAgent)
+	}
+	for k, v := range p.Headers {
+		req.Header.Set(k, v)
+	}
+
+	switch {
+	case p.BearerToken != "":
+		req.Header.Set("Authorization", "Bearer "+p.BearerToken)
+	case p.BasicUsername != "":
+		req.SetBasicAuth(p.BasicUsername, p.BasicPassword)
+	}
+}
+
+// removeHeaders 移除 Profile 加入的固定標頭、轉發標頭與認證資訊
+// 重導向至不套用此 Profile 的主機時使用；Go 僅於跨主機時移除 Authorization 與 Cookie
+func (p *HostProfile) removeHeaders(req *http.Request) {
+	for k := range p.Headers {
+		req.Header.Del(k)
+	}
+	for _, name := range p.ForwardHeaders {
+		req.Header.Del(name)
+	}
+	if p.BearerToken != "" || p.BasicUsername != "" {
+		req.Header.Del("Authorization")
+	}
+}
+
+// forwardsHeaders 檢查原始請求是否帶有此 Profile 會轉發的標頭
+func (p *HostProfile) forwardsHeaders(ctx context.Context) bool {
+	forwarded := requestHeadersFromContext(ctx)
+	if forwarded == nil {
+		return false
+	}
+	for _, name := range p.ForwardHeaders {
+		if len(forwarded.Values(name)) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// requestHeadersKey context 中原始請求標頭的鍵值
+type requestHeadersKey struct{}
+
+// ContextWithRequestHeaders 將原始請求標頭放入 context，供 Profile 的 ForwardHeaders 使用
+func ContextWithRequestHeaders(ctx context.Context, header http.Header) context.Context {
+	return context.WithValue(ctx, requestHeadersKey{}, header)
+}
+
+// requestHeadersFromContext 取得原始請求標頭
+func requestHeadersFromContext(ctx context.Context) http.Header {
+	h, _ := ctx.Value(requestHeadersKey{}).(http.Header)
+	return h
+}
@@ -0,0 +1,188 @@
+package loader
+
+import (
+	"context"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHTTPLoader_HostProfiles(t *testing.T) {
+	var got http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Clone()
+		w.Header().Set("Content-Type", "image/jpeg")
+		w.Write([]byte(strings.Repeat("x", 64)))
+	}))
+	defer server.Close()
+
+	localhostURL := strings.Replace(server.URL, "127.0.0.1", "localhost", 1)
+
+	loader := NewHTTPLoader(
+		WithAllowPrivateNetworks(true),
+		WithHostProfiles(
+			&HostProfile{
+				Name:           "origin",
+				Matcher:        hostChecker{hosts: []string{"127.0.0.1"}},
+				UserAgent:      "OriginFetcher/2.0",
+				Headers:        map[string]string{"X-Origin-Key": "k1"},
+				BearerToken:    "token-123",
+				ForwardHeaders: []string{"Cookie"},
+			},
+			&HostProfile{
+				Name:          "limited",
+				Matcher:       hostChecker{hosts: []string{"localhost"}},
+				BasicUsername: "user",
+				BasicPassword: "pass",
+				MaxSize:       16,
+			},
+		),
+	)
+
+	t.Run("Headers And Bearer", func(t *testing.T) {
+		ctx := ContextWithRequestHeaders(context.Background(), http.Header{
+			"Cookie":        {"session=abc"},
+			"Authorization": {"Bearer client-token"},
+		})
+		_, err := loader.Load(ctx, server.URL+"/image.jpg")
+		assert.NoError(t, err)
+		assert.Equal(t, "OriginFetcher/2.0", got.Get("User-Agent"))
+		assert.Equal(t, "k1", got.Get("X-Origin-Key"))
+		assert.Equal(t, "Bearer token-123", got.Get("Authorization"))
+		assert.Equal(t, "session=abc", got.Get("Cookie"))
+	})
+
+	t.Run("Basic Auth And Size Limit", func(t *testing.T) {
+		_, err := loader.Load(context.Background(), localhostURL+"/image.jpg")
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "too large")
+
+		user, pass, ok := (&http.Request{Header: got}).BasicAuth()
+		assert.True(t, ok)
+		assert.Equal(t, "user", user)
+		assert.Equal(t, "pass", pass)
+		assert.Empty(t, got.Get("Cookie"))
+		assert.Equal(t, "ImageProcessor/1.0", got.Get("User-Agent"))
+	})
+
+	t.Run("No Matching Profile", func(t *testing.T) {
+		plain := NewHTTPLoader(WithAllowPrivateNetworks(true))
+		ctx := ContextWithRequestHeaders(context.Background(), http.Header{"Cookie": {"session=abc"}})
+		_, err := plain.Load(ctx, server.URL+"/image.jpg")
+		assert.NoError(t, err)
+		assert.Empty(t, got.Get("Cookie"))
+		assert.Empty(t, got.Get("Authorization"))
+	})
+}
+
+func TestHTTPLoader_HostProfileTLS(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/jpeg")
+		w.Write([]byte("fake image data"))
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	caFile := filepath.Join(dir, "ca.pem")
+	caPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	assert.NoError(t, os.WriteFile(caFile, caPEM, 0600))
+
+	t.Run("Custom CA", func(t *testing.T) {
+		loader := NewHTTPLoader(
+			WithAllowPrivateNetworks(true),
+			WithHostProfiles(&HostProfile{
+				Name:    "internal-ca",
+				Matcher: hostChecker{hosts: []string{"127.0.0.1"}},
+				CAFile:  caFile,
+			}),
+		)
+		data, err := loader.Load(context.Background(), server.URL+"/image.jpg")
+		assert.NoError(t, err)
+		assert.Equal(t, "fake image data", string(data))
+	})
+
+	t.Run("Without CA", func(t *testing.T) {
+		loader := NewHTTPLoader(WithAllowPrivateNetworks(true))
+		_, err := loader.Load(context.Background(), server.URL+"/image.jpg")
+		assert.Error(t, err)
+	})
+
+	t.Run("Invalid Client Certificate", func(t *testing.T) {
+		loader := NewHTTPLoader(
+			WithAllowPrivateNetworks(true),
+			WithHostProfiles(&HostProfile{
+				Name:     "broken",
+				Matcher:  hostChecker{hosts: []string{"127.0.0.1"}},
+				CertFile: filepath.Join(dir, "missing.crt"),
+				KeyFile:  filepath.Join(dir, "missing.key"),
+			}),
+		)
+		_, err := loader.Load(context.Background(), server.URL+"/image.jpg")
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), `loader profile "broken" unavailable`)
+	})
+}
+
+func TestHTTPLoader_HostProfileRedirect(t *testing.T) {
+	var got http.Header
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/redirect" {
+			http.Redirect(w, r, strings.Replace(server.URL, "127.0.0.1", "localhost", 1)+"/image.jpg", http.StatusFound)
+			return
+		}
+		got = r.Header.Clone()
+		w.Header().Set("Content-Type", "image/jpeg")
+		w.Write([]byte("fake image data"))
+	}))
+	defer server.Close()
+
+	loader := NewHTTPLoader(
+		WithAllowPrivateNetworks(true),
+		WithHostProfiles(&HostProfile{
+			Name:           "origin",
+			Matcher:        hostChecker{hosts: []string{"127.0.0.1"}},
+			Headers:        map[string]string{"X-Origin-Key": "k1"},
+			BearerToken:    "token-123",
+			ForwardHeaders: []string{"X-Session"},
+		}),
+	)
+	ctx := ContextWithRequestHeaders(context.Background(), http.Header{"X-Session": {"abc"}})
+
+	// 重導向至不符合 Profile 的主機時不帶 Profile 標頭與認證
+	_, err := loader.Load(ctx, server.URL+"/redirect")
+	assert.NoError(t, err)
+	assert.Empty(t, got.Get("X-Origin-Key"))
+	assert.Empty(t, got.Get("Authorization"))
+	assert.Empty(t, got.Get("X-Session"))
+
+	// 同主機內的重導向保留標頭
+	_, err = loader.Load(ctx, server.URL+"/image.jpg")
+	assert.NoError(t, err)
+	assert.Equal(t, "k1", got.Get("X-Origin-Key"))
+	assert.Equal(t, "abc", got.Get("X-Session"))
+}
+
+func TestHTTPLoader_ForwardsRequestHeaders(t *testing.T) {
+	loader := NewHTTPLoader(
+		WithAllowPrivateNetworks(true),
+		WithHostProfiles(&HostProfile{
+			Name:           "origin",
+			Matcher:        hostChecker{hosts: []string{"127.0.0.1"}},
+			ForwardHeaders: []string{"Cookie"},
+		}),
+	)
+	withCookie := ContextWithRequestHeaders(context.Background(), http.Header{"Cookie": {"session=abc"}})
+	withoutCookie := ContextWithRequestHeaders(context.Background(), http.Header{"Accept": {"image/webp"}})
+
+	assert.True(t, loader.ForwardsRequestHeaders(withCookie, "http://127.0.0.1/image.jpg"))
+	assert.False(t, loader.ForwardsRequestHeaders(withoutCookie, "http://127.0.0.1/image.jpg"))
+	assert.False(t, loader.ForwardsRequestHeaders(context.Background(), "http://127.0.0.1/image.jpg"))
+	assert.False(t, loader.ForwardsRequestHeaders(withCookie, "http://localhost/image.jpg"))
+}
@@ -0,0 +1,312 @@
+package loader
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/vincent119/images-filters/internal/storage/types"
+	"github.com/vincent119/images-filters/pkg/logger"
+)
+
+// SourceStore 來源快取的儲存介面（storage.Storage 的子集）
+type SourceStore interface {
+	Get(ctx context.Context, key string) ([]byte, error)
+	Put(ctx context.Context, key string, data []byte, opts ...types.PutOption) error
+	Delete(ctx context.Context, key string) error
+}
+
+// SourceFetcher 支援條件式請求的載入器（HTTPLoader 實作此介面）
+type SourceFetcher interface {
+	Fetch(ctx context.Context, source string, v Validators) (*FetchResult, error)
+}
+
+// sourceCachePrefix 來源快取在儲存層的鍵值前綴
+const sourceCachePrefix = "sources"
+
+// SourceMeta 來源快取的中繼資料
+type SourceMeta struct {
+	URL          string    `json:"url"`
+	ETag         string    `json:"etag,omitempty"`
+	LastModified string    `json:"last_modified,omitempty"`
+	ContentType  string    `json:"content_type,omitempty"`
+	Size         int       `json:"size"`
+	FetchedAt    time.Time `json:"fetched_at"`
+	ExpiresAt    time.Time `json:"expires_at"`
+}
+
+// IsFresh 檢查快取內容是否仍在有效期內
+func (m *SourceMeta) IsFresh(now time.Time) bool {
+	return now.Before(m.ExpiresAt)
+}
+
+// CachedSource 來源快取的讀取結果
+type CachedSource struct {
+	Data []byte
+	Meta SourceMeta
+}
+
+// SourceCache 遠端來源快取
+// 將來源圖片與 ETag/Last-Modified/有效期限一併保存於 Storage，
+// 過期後以條件式 GET 重新驗證，來源回應 304 時沿用既有內容
+type SourceCache struct {
+	store        SourceStore
+	fetcher      SourceFetcher
+	defaultTTL   time.Duration
+	maxTTL       time.Duration
+	staleOnError bool
+	now          func() time.Time
+}
+
+// SourceCacheOption 來源快取選項
+type SourceCacheOption func(*SourceCache)
+
+// WithSourceDefaultTTL 設定來源未提供 Cache-Control/Expires 時的有效期限
+func WithSourceDefaultTTL(ttl time.Duration) SourceCacheOption {
+	return func(c *SourceCache) {
+		c.defaultTTL = ttl
+	}
+}
+
+// WithSourceMaxTTL 設定有效期限上限（0 表示不限制）
+func WithSourceMaxTTL(ttl time.Duration) SourceCacheOption {
+	return func(c *SourceCache) {
+		c.maxTTL = ttl
+	}
+}
+
+// WithServeStaleOnError 來源重新驗證失敗時回傳過期內容
+func WithServeStaleOnError(enabled bool) SourceCacheOption {
+	return func(c *SourceCache) {
+		c.staleOnError = enabled
+	}
+}
+
+// NewSourceCache 建立遠端來源快取
+func NewSourceCache(store SourceStore, fetcher SourceFetcher, opts ...SourceCacheOption) *SourceCache {
+	c := &SourceCache{
+		store:        store,
+		fetcher:      fetcher,
+		defaultTTL:   time.Hour,
+		staleOnError: true,
+		now:          time.Now,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// sourceKeys 產生來源資料與中繼資料的儲存鍵值
+func sourceKeys(source string) (dataKey, metaKey string) {
+	sum := sha256.Sum256([]byte(source))
+	h := hex.EncodeToString(sum[:])
+	base := fmt.Sprintf("%s/%s/%s", sourceCachePrefix, h[:2], h[2:])
+	return base + ".bin", base + ".meta.json"
+}
+
+// Meta 讀取來源的中繼資料（不存在時回傳 nil）
+// 僅讀取一次中繼資料檔，以 types.ErrNotFound 判斷不存在，避免每次檢查都多一次 Exists 往返
+func (c *SourceCache) Meta(ctx context.Context, source string) (*SourceMeta, error) {
+	_, metaKey := sourceKeys(source)
+	raw, err := c.store.Get(ctx, metaKey)
+	if err != nil {
+		if errors.Is(err, types.ErrNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read source meta: %w", err)
+	}
+	var meta SourceMeta
+	if err := json.Unmarshal(raw, &meta); err != nil {
+		return nil, fmt.Errorf("failed to parse source meta: %w", err)
+	}
+	return &meta, nil
+}
+
+// Load 載入來源圖片
+// 快取有效時直接回傳；過期時送出條件式請求重新驗證
+func (c *SourceCache) Load(ctx context.Context, source string) (*CachedSource, error) {
+	meta, err := c.Meta(ctx, source)
+	if err != nil {
+		logger.Warn("source cache meta unavailable", logger.String("url", source), logger.Err(err))
+		meta = nil
+	}
+
+	var cached []byte
+	if meta != nil {
+		dataKey, _ := sourceKeys(source)
+		cached, err = c.store.Get(ctx, dataKey)
+		if err != nil {
+			logger.Warn("source cache data unavailable", logger.String("url", source), logger.Err(err))
+			meta, cached = nil, nil
+		}
+	}
+
+	if meta != nil && meta.IsFresh(c.now()) {
+		logger.Debug("source cache hit", logger.String("url", source))
+		return &CachedSource{Data: cached, Meta: *meta}, nil
+	}
+
+	var v Validators
+	if meta != nil {
+		v = Validators{ETag: meta.ETag, LastModified: meta.LastModified}
+	}
+
+	result, err := c.fetcher.Fetch(ctx, source, v)
+	if err != nil {
+		if meta != nil && c.staleOnError {
+			logger.Warn("source revalidation failed, serving stale copy",
+				logger.String("url", source),
+				logger.Err(err),
+			)
+			return &CachedSource{Data: cached, Meta: *meta}, nil
+		}
+		return nil, err
+	}
+
+	now := c.now()
+	ttl, cacheable := c.freshnessLifetime(result.Header, now)
+
+	if result.NotModified {
+		logger.Debug("source revalidated (not modified)", logger.String("url", source))
+		// 304 回應改為 no-store / private 時不再保存：刪除既有副本，本次內容僅使用一次
+		if !cacheable {
+			c.remove(ctx, source)
+			expired := *meta
+			expired.FetchedAt = now
+			expired.ExpiresAt = now
+			return &CachedSource{Data: cached, Meta: expired}, nil
+		}
+		updated := *meta
+		updated.FetchedAt = now
+		updated.ExpiresAt = now.Add(ttl)
+		if etag := result.Header.Get("ETag"); etag != "" {
+			updated.ETag = etag
+		}
+		if lm := result.Header.Get("Last-Modified"); lm != "" {
+			updated.LastModified = lm
+		}
+		c.saveMeta(ctx, source, &updated)
+		return &CachedSource{Data: cached, Meta: updated}, nil
+	}
+
+	fresh := SourceMeta{
+		URL:          source,
+		ETag:         result.Header.Get("ETag"),
+		LastModified: result.Header.Get("Last-Modified"),
+		ContentType:  result.Header.Get("Content-Type"),
+		Size:         len(result.Data),
+		FetchedAt:    now,
+		ExpiresAt:    now.Add(ttl),
+	}
+
+	if cacheable {
+		dataKey, _ := sourceKeys(source)
+		if err := c.store.Put(ctx, dataKey, result.Data); err != nil {
+			logger.Warn("failed to store source", logger.String("url", source), logger.Err(err))
+		} else {
+			c.saveMeta(ctx, source, &fresh)
+		}
+	}
+
+	return &CachedSource{Data: result.Data, Meta: fresh}, nil
+}
+
+// remove 刪除來源的快取資料與中繼資料（失敗僅記錄警告）
+func (c *SourceCache) remove(ctx context.Context, source string) {
+	dataKey, metaKey := sourceKeys(source)
+	for _, key := range []string{metaKey, dataKey} {
+		if err := c.store.Delete(ctx, key); err != nil && !errors.Is(err, types.ErrNotFound) {
+			logger.Warn("failed to remove source cache", logger.String("url", source), logger.Err(err))
+		}
+	}
+}
+
+// saveMeta 寫入中繼資料（失敗僅記錄警告）
+func (c *SourceCache) saveMeta(ctx context.Context, source string, meta *SourceMeta) {
+	_, metaKey := sourceKeys(source)
+	raw, err := json.Marshal(meta)
+	if err != nil {
+		logger.Warn("failed to encode source meta", logger.Err(err))
+		return
+	}
+	if err := c.store.Put(ctx, metaKey, raw); err != nil {
+		logger.Warn("failed to store source meta", logger.String("url", source), logger.Err(err))
+	}
+}
+
+// freshnessLifetime 依來源回應標頭計算有效期限
+// 回傳 cacheable=false 表示來源要求不得保存（no-store / private）
+func (c *SourceCache) freshnessLifetime(h http.Header, now time.Time) (time.Duration, bool) {
+	ttl := c.defaultTTL
+	cacheable := true
+
+	directives := parseCacheControl(h.Get("Cache-Control"))
+	_, noStore := directives["no-store"]
+	_, private := directives["private"]
+	_, noCache := directives["no-cache"]
+
+	switch {
+	case noStore || private:
+		cacheable = false
+		ttl = 0
+	case noCache:
+		// 可保存但每次使用前都必須重新驗證
+		ttl = 0
+	default:
+		if v, ok := directives["s-maxage"]; ok {
+			ttl = parseSeconds(v, ttl)
+		} else if v, ok := directives["max-age"]; ok {
+			ttl = parseSeconds(v, ttl)
+		} else if exp := h.Get("Expires"); exp != "" {
+			if t, err := http.ParseTime(exp); err == nil {
+				ttl = t.Sub(now)
+			} else {
+				ttl = 0 // 無效的 Expires 視為已過期
+			}
+		}
+
+		// 扣除來源中途快取已經過的時間
+		if age, err := strconv.Atoi(h.Get("Age")); err == nil && age > 0 {
+			ttl -= time.Duration(age) * time.Second
+		}
+	}
+
+	if ttl < 0 {
+		ttl = 0
+	}
+	if c.maxTTL > 0 && ttl > c.maxTTL {
+		ttl = c.maxTTL
+	}
+	return ttl, cacheable
+}
+
+// parseCacheControl 解析 Cache-Control 指令
+func parseCacheControl(value string) map[string]string {
+	directives := make(map[string]string)
+	for _, part := range strings.Split(value, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		name, arg, _ := strings.Cut(part, "=")
+		directives[strings.ToLower(strings.TrimSpace(name))] = strings.Trim(strings.TrimSpace(arg), `"`)
+	}
+	return directives
+}
+
+// parseSeconds 解析秒數，失敗時回傳預設值
+func parseSeconds(v string, fallback time.Duration) time.Duration {
+	n, err := strconv.ParseInt(v, 10, 64)
+	if err != nil || n < 0 {
+		return fallback
+	}
+	return time.Duration(n) * time.Second
+}
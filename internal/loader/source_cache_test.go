@@ -0,0 +1,260 @@
+package loader
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vincent119/images-filters/internal/storage/types"
+)
+
+// memSourceStore 記憶體 SourceStore
+type memSourceStore struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+}
+
+func newMemSourceStore() *memSourceStore {
+	return &memSourceStore{objects: make(map[string][]byte)}
+}
+
+func (s *memSourceStore) Get(ctx context.Context, key string) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	data, ok := s.objects[key]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", types.ErrNotFound, key)
+	}
+	return data, nil
+}
+
+func (s *memSourceStore) Put(ctx context.Context, key string, data []byte, opts ...types.PutOption) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.objects[key] = data
+	return nil
+}
+
+func (s *memSourceStore) Delete(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.objects, key)
+	return nil
+}
+
+// errSourceStore 讀取中繼資料失敗的 SourceStore
+type errSourceStore struct {
+	*memSourceStore
+}
+
+func (s errSourceStore) Get(ctx context.Context, key string) ([]byte, error) {
+	return nil, errors.New("storage unavailable")
+}
+
+// originServer 可控制回應內容與標頭的來源伺服器
+type originServer struct {
+	mu           sync.Mutex
+	body         string
+	etag         string
+	cacheControl string
+	fail         bool
+	requests     int
+	conditional  int
+}
+
+func (o *originServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.requests++
+
+	if o.fail {
+		w.WriteHeader(http.StatusBadGateway)
+		return
+	}
+	if o.cacheControl != "" {
+		w.Header().Set("Cache-Control", o.cacheControl)
+	}
+	w.Header().Set("ETag", o.etag)
+	if inm := r.Header.Get("If-None-Match"); inm != "" {
+		o.conditional++
+		if inm == o.etag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+	}
+	w.Header().Set("Content-Type", "image/jpeg")
+	w.Write([]byte(o.body))
+}
+
+func TestSourceCache_Revalidation(t *testing.T) {
+	origin := &originServer{body: "v1", etag: `"v1"`, cacheControl: "max-age=60"}
+	server := httptest.NewServer(origin)
+	defer server.Close()
+
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	store := newMemSourceStore()
+	sc := NewSourceCache(store, NewHTTPLoader(WithAllowPrivateNetworks(true)))
+	sc.now = func() time.Time { return now }
+
+	ctx := context.Background()
+	url := server.URL + "/image.jpg"
+
+	// 首次載入：完整下載並保存
+	src, err := sc.Load(ctx, url)
+	assert.NoError(t, err)
+	assert.Equal(t, "v1", string(src.Data))
+	assert.Equal(t, `"v1"`, src.Meta.ETag)
+	assert.Equal(t, now.Add(60*time.Second), src.Meta.ExpiresAt)
+	assert.Equal(t, 1, origin.requests)
+
+	// 有效期限內：不發送請求
+	now = now.Add(30 * time.Second)
+	src, err = sc.Load(ctx, url)
+	assert.NoError(t, err)
+	assert.Equal(t, "v1", string(src.Data))
+	assert.Equal(t, 1, origin.requests)
+
+	// 過期後：條件式請求，304 沿用既有內容並延長期限
+	now = now.Add(60 * time.Second)
+	src, err = sc.Load(ctx, url)
+	assert.NoError(t, err)
+	assert.Equal(t, "v1", string(src.Data))
+	assert.Equal(t, 2, origin.requests)
+	assert.Equal(t, 1, origin.conditional)
+	assert.Equal(t, now.Add(60*time.Second), src.Meta.ExpiresAt)
+
+	// 來源更新後：條件式請求取得新內容
+	origin.body, origin.etag = "v2", `"v2"`
+	now = now.Add(2 * time.Minute)
+	src, err = sc.Load(ctx, url)
+	assert.NoError(t, err)
+	assert.Equal(t, "v2", string(src.Data))
+	assert.Equal(t, `"v2"`, src.Meta.ETag)
+
+	meta, err := sc.Meta(ctx, url)
+	assert.NoError(t, err)
+	assert.Equal(t, `"v2"`, meta.ETag)
+}
+
+func TestSourceCache_StaleOnError(t *testing.T) {
+	origin := &originServer{body: "v1", etag: `"v1"`, cacheControl: "max-age=10"}
+	server := httptest.NewServer(origin)
+	defer server.Close()
+
+	now := time.Now()
+	ctx := context.Background()
+	url := server.URL + "/image.jpg"
+	store := newMemSourceStore()
+	fetcher := NewHTTPLoader(WithAllowPrivateNetworks(true))
+
+	sc := NewSourceCache(store, fetcher)
+	sc.now = func() time.Time { return now }
+	_, err := sc.Load(ctx, url)
+	assert.NoError(t, err)
+
+	origin.fail = true
+	now = now.Add(time.Minute)
+
+	src, err := sc.Load(ctx, url)
+	assert.NoError(t, err)
+	assert.Equal(t, "v1", string(src.Data))
+
+	strict := NewSourceCache(store, fetcher, WithServeStaleOnError(false))
+	strict.now = sc.now
+	_, err = strict.Load(ctx, url)
+	assert.Error(t, err)
+}
+
+func TestSourceCache_NoStore(t *testing.T) {
+	origin := &originServer{body: "secret", etag: `"s"`, cacheControl: "private, max-age=600"}
+	server := httptest.NewServer(origin)
+	defer server.Close()
+
+	store := newMemSourceStore()
+	sc := NewSourceCache(store, NewHTTPLoader(WithAllowPrivateNetworks(true)))
+
+	src, err := sc.Load(context.Background(), server.URL+"/image.jpg")
+	assert.NoError(t, err)
+	assert.Equal(t, "secret", string(src.Data))
+	assert.Empty(t, store.objects)
+}
+
+func TestSourceCache_NotModifiedNoStore(t *testing.T) {
+	origin := &originServer{body: "v1", etag: `"v1"`, cacheControl: "max-age=60"}
+	server := httptest.NewServer(origin)
+	defer server.Close()
+
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	ctx := context.Background()
+	url := server.URL + "/image.jpg"
+	store := newMemSourceStore()
+	sc := NewSourceCache(store, NewHTTPLoader(WithAllowPrivateNetworks(true)))
+	sc.now = func() time.Time { return now }
+
+	_, err := sc.Load(ctx, url)
+	assert.NoError(t, err)
+	assert.Len(t, store.objects, 2)
+
+	// 過期後來源回應 304 但改為 no-store：回傳既有內容，刪除快取且不視為有效
+	origin.cacheControl = "no-store"
+	now = now.Add(2 * time.Minute)
+	src, err := sc.Load(ctx, url)
+	assert.NoError(t, err)
+	assert.Equal(t, "v1", string(src.Data))
+	assert.False(t, src.Meta.IsFresh(now))
+	assert.Equal(t, 1, origin.conditional)
+	assert.Empty(t, store.objects)
+
+	meta, err := sc.Meta(ctx, url)
+	assert.NoError(t, err)
+	assert.Nil(t, meta)
+}
+
+func TestSourceCache_MetaError(t *testing.T) {
+	sc := NewSourceCache(errSourceStore{newMemSourceStore()}, nil)
+	_, err := sc.Meta(context.Background(), "https://example.com/a.jpg")
+	assert.Error(t, err)
+
+	meta, err := NewSourceCache(newMemSourceStore(), nil).Meta(context.Background(), "https://example.com/a.jpg")
+	assert.NoError(t, err)
+	assert.Nil(t, meta)
+}
+
+func TestSourceCache_FreshnessLifetime(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	sc := NewSourceCache(newMemSourceStore(), nil,
+		WithSourceDefaultTTL(5*time.Minute),
+		WithSourceMaxTTL(time.Hour),
+	)
+
+	tests := []struct {
+		name      string
+		header    http.Header
+		ttl       time.Duration
+		cacheable bool
+	}{
+		{"Default", http.Header{}, 5 * time.Minute, true},
+		{"Max Age", http.Header{"Cache-Control": {"public, max-age=120"}}, 2 * time.Minute, true},
+		{"Shared Max Age Preferred", http.Header{"Cache-Control": {"max-age=60, s-maxage=300"}}, 5 * time.Minute, true},
+		{"Age Subtracted", http.Header{"Cache-Control": {"max-age=120"}, "Age": {"100"}}, 20 * time.Second, true},
+		{"Clamped To Max", http.Header{"Cache-Control": {"max-age=86400"}}, time.Hour, true},
+		{"Expires", http.Header{"Expires": {now.Add(10 * time.Minute).Format(http.TimeFormat)}}, 10 * time.Minute, true},
+		{"Invalid Expires", http.Header{"Expires": {"0"}}, 0, true},
+		{"No Cache", http.Header{"Cache-Control": {"no-cache"}}, 0, true},
+		{"No Store", http.Header{"Cache-Control": {"no-store"}}, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ttl, cacheable := sc.freshnessLifetime(tt.header, now)
+			assert.Equal(t, tt.ttl, ttl)
+			assert.Equal(t, tt.cacheable, cacheable)
+		})
+	}
+}
@@ -0,0 +1,82 @@
+package loader
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+	"syscall"
+	"time"
+)
+
+var (
+	// ErrBlockedAddress 目標位址位於禁止存取的網段（私有、回環、鏈路本地、中繼資料等）
+	ErrBlockedAddress = errors.New("destination address is not allowed")
+	// ErrSourceNotAllowed 來源（含重導向目標）不在白名單中
+	ErrSourceNotAllowed = errors.New("source not allowed")
+	// ErrTooManyRedirects 重導向次數超過上限
+	ErrTooManyRedirects = errors.New("too many redirects")
+)
+
+// SourceChecker 來源白名單檢查介面（security.SourceValidator 實作此介面）
+type SourceChecker interface {
+	IsAllowed(source string) bool
+}
+
+// blockedPrefixes 禁止連線的網段
+// net/netip 的 IsPrivate/IsLoopback 等方法未涵蓋的網段另外列出
+var blockedPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),         // 本網路
+	netip.MustParsePrefix("100.64.0.0/10"),     // 電信級 NAT
+	netip.MustParsePrefix("192.0.0.0/24"),      // IETF 協定保留
+	netip.MustParsePrefix("198.18.0.0/15"),     // 效能測試
+	netip.MustParsePrefix("240.0.0.0/4"),       // 保留位址
+	netip.MustParsePrefix("64:ff9b::/96"),      // NAT64（可映射至內網 IPv4）
+	netip.MustParsePrefix("64:ff9b:1::/48"),    // 本地 NAT64
+	netip.MustParsePrefix("2001:db8::/32"),     // 文件用途
+	netip.MustParsePrefix("fd00:ec2::254/128"), // AWS IPv6 中繼資料服務
+}
+
+// isBlockedIP 檢查 IP 是否位於禁止存取的網段
+func isBlockedIP(ip netip.Addr) bool {
+	ip = ip.Unmap()
+	if !ip.IsValid() ||
+		ip.IsLoopback() ||
+		ip.IsPrivate() ||
+		ip.IsLinkLocalUnicast() || // 169.254.0.0/16（含 169.254.169.254 中繼資料服務）、fe80::/10
+		ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() ||
+		ip.IsMulticast() ||
+		ip.IsUnspecified() {
+		return true
+	}
+	for _, prefix := range blockedPrefixes {
+		if prefix.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// safeDialControl 於 DNS 解析完成、建立連線前檢查目標 IP
+// 在 Control 階段檢查可避免 DNS rebinding（檢查與連線使用同一個解析結果）
+func safeDialControl(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrBlockedAddress, address)
+	}
+	ip, err := netip.ParseAddr(host)
+	if err != nil || isBlockedIP(ip) {
+		return fmt.Errorf("%w: %s", ErrBlockedAddress, host)
+	}
+	return nil
+}
+
+// newSafeDialer 建立會拒絕私有網段的 Dialer
+func newSafeDialer(timeout time.Duration) *net.Dialer {
+	return &net.Dialer{
+		Timeout:   timeout,
+		KeepAlive: 30 * time.Second,
+		Control:   safeDialControl,
+	}
+}
@@ -53,6 +53,14 @@ type Metrics interface {
 	// RecordStorageRetry 記錄儲存重試次數
 	RecordStorageRetry(backend string)
 
+	// ======== 遠端來源指標 ========
+	// RecordOriginRetry 記錄遠端來源重試次數（host: 來源主機）
+	RecordOriginRetry(host string)
+	// RecordOriginHedge 記錄遠端來源對沖請求次數
+	RecordOriginHedge(host string)
+	// RecordCircuitBreakerState 記錄斷路器狀態（state: closed/half_open/open）
+	RecordCircuitBreakerState(target, state string)
+
 	// ======== 安全與風控指標 ========
 	// RecordSignatureValidation 記錄簽名驗證結果
 	RecordSignatureValidation(success bool)
@@ -41,6 +41,11 @@ type PrometheusMetrics struct {
 	storageErrors     *prometheus.CounterVec   // 儲存錯誤
 	storageRetries    *prometheus.CounterVec   // 儲存重試
 
+	// ======== 遠端來源指標 ========
+	originRetries       *prometheus.CounterVec // 來源重試
+	originHedges        *prometheus.CounterVec // 來源對沖請求
+	circuitBreakerState *prometheus.GaugeVec   // 斷路器狀態
+
 	// ======== 安全與風控指標 ========
 	signatureValidations *prometheus.CounterVec // 簽名驗證
 	rejectedRequests     *prometheus.CounterVec // 被拒絕請求
@@ -263,6 +268,34 @@ func NewPrometheusMetrics(namespace string) *PrometheusMetrics {
 			[]string{"backend"},
 		),
 
+		// ======== 遠端來源指標 ========
+		originRetries: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Name:      "origin_retries_total",
+				Help:      "遠端來源重試總數",
+			},
+			[]string{"host"},
+		),
+
+		originHedges: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Name:      "origin_hedged_requests_total",
+				Help:      "遠端來源對沖請求總數",
+			},
+			[]string{"host"},
+		),
+
+		circuitBreakerState: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace: namespace,
+				Name:      "circuit_breaker_state",
+				Help:      "斷路器狀態（0: closed, 1: half_open, 2: open）",
+			},
+			[]string{"target"},
+		),
+
 		// ======== 安全與風控指標 ========
 		signatureValidations: prometheus.NewCounterVec(
 			prometheus.CounterOpts{
@@ -328,6 +361,10 @@ func NewPrometheusMetrics(namespace string) *PrometheusMetrics {
 		m.storageLatency,
 		m.storageErrors,
 		m.storageRetries,
+		// 遠端來源
+		m.originRetries,
+		m.originHedges,
+		m.circuitBreakerState,
 		// 安全
 		m.signatureValidations,
 		m.rejectedRequests,
@@ -447,6 +484,30 @@ func (m *PrometheusMetrics) RecordStorageRetry(backend string) {
 	m.storageRetries.WithLabelValues(backend).Inc()
 }
 
+// ======== 遠端來源指標方法 ========
+
+// RecordOriginRetry 記錄遠端來源重試
+func (m *PrometheusMetrics) RecordOriginRetry(host string) {
+	m.originRetries.WithLabelValues(host).Inc()
+}
+
+// RecordOriginHedge 記錄遠端來源對沖請求
+func (m *PrometheusMetrics) RecordOriginHedge(host string) {
+	m.originHedges.WithLabelValues(host).Inc()
+}
+
+// RecordCircuitBreakerState 記錄斷路器狀態
+func (m *PrometheusMetrics) RecordCircuitBreakerState(target, state string) {
+	value := 0.0
+	switch state {
+	case "half_open":
+		value = 1
+	case "open":
+		value = 2
+	}
+	m.circuitBreakerState.WithLabelValues(target).Set(value)
+}
+
 // ======== 安全與風控指標方法 ========
 
 // RecordSignatureValidation 記錄簽名驗證結果
@@ -272,6 +272,31 @@ func TestRecordStorageMetrics(t *testing.T) {
 	}
 }
 
+func TestRecordOriginMetrics(t *testing.T) {
+	m := NewPrometheusMetrics("test")
+	m.RecordOriginRetry("example.com")
+	m.RecordOriginRetry("example.com")
+	m.RecordOriginHedge("example.com")
+
+	expected := `
+		# HELP test_origin_retries_total 遠端來源重試總數
+		# TYPE test_origin_retries_total counter
+		test_origin_retries_total{host="example.com"} 2
+	`
+	if err := testutil.CollectAndCompare(m.originRetries, strings.NewReader(expected)); err != nil {
+		t.Errorf("Unexpected collecting result: %v", err)
+	}
+
+	m.RecordCircuitBreakerState("example.com", "open")
+	if val := testutil.ToFloat64(m.circuitBreakerState.WithLabelValues("example.com")); val != 2 {
+		t.Errorf("Expected breaker state 2, got %v", val)
+	}
+	m.RecordCircuitBreakerState("example.com", "closed")
+	if val := testutil.ToFloat64(m.circuitBreakerState.WithLabelValues("example.com")); val != 0 {
+		t.Errorf("Expected breaker state 0, got %v", val)
+	}
+}
+
 func TestRecordSecurityMetrics(t *testing.T) {
 	m := NewPrometheusMetrics("test")
 	m.RecordSignatureValidation(true)
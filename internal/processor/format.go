@@ -0,0 +1,273 @@
+package processor
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"image"
+	"io"
+	"strings"
+	"unicode"
+
+	"github.com/gen2brain/avif"
+	"github.com/gen2brain/heic"
+	"github.com/srwiley/oksvg"
+)
+
+// 依內容偵測的圖片格式
+const (
+	FormatJPEG = "jpeg"
+	FormatPNG  = "png"
+	FormatGIF  = "gif"
+	FormatWebP = "webp"
+	FormatAVIF = "avif"
+	FormatHEIC = "heic"
+	FormatJXL  = "jxl"
+	FormatBMP  = "bmp"
+	FormatTIFF = "tiff"
+	FormatSVG  = "svg"
+)
+
+// DetectFormat 依檔頭（magic bytes）判斷圖片格式，無法辨識時回傳空字串
+// 不信任檔名與用戶端提供的 Content-Type
+func DetectFormat(data []byte) string {
+	switch {
+	case bytes.HasPrefix(data, []byte{0xFF, 0xD8, 0xFF}):
+		return FormatJPEG
+	case bytes.HasPrefix(data, []byte("\x89PNG\r\n\x1a\n")):
+		return FormatPNG
+	case bytes.HasPrefix(data, []byte("GIF87a")), bytes.HasPrefix(data, []byte("GIF89a")):
+		return FormatGIF
+	case len(data) >= 12 && bytes.Equal(data[:4], []byte("RIFF")) && bytes.Equal(data[8:12], []byte("WEBP")):
+		return FormatWebP
+	case bytes.HasPrefix(data, []byte{0xFF, 0x0A}),
+		bytes.HasPrefix(data, []byte("\x00\x00\x00\x0cJXL \r\n\x87\n")):
+		return FormatJXL
+	case bytes.HasPrefix(data, []byte("BM")):
+		return FormatBMP
+	case bytes.HasPrefix(data, []byte("II*\x00")), bytes.HasPrefix(data, []byte("MM\x00*")):
+		return FormatTIFF
+	}
+
+	if format := detectISOBMFF(data); format != "" {
+		return format
+	}
+	if isSVGDocument(data) {
+		return FormatSVG
+	}
+	return ""
+}
+
+// detectISOBMFF 依 ftyp box 的主要與相容品牌判斷 AVIF/HEIC
+func detectISOBMFF(data []byte) string {
+	if len(data) < 16 || !bytes.Equal(data[4:8], []byte("ftyp")) {
+		return ""
+	}
+	size := int(data[0])<<24 | int(data[1])<<16 | int(data[2])<<8 | int(data[3])
+	if size < 16 || size > len(data) {
+		size = len(data)
+	}
+
+	// 主要品牌位於 8:12，相容品牌自 16 起每 4 bytes 一個
+	brands := []string{string(data[8:12])}
+	for i := 16; i+4 <= size; i += 4 {
+		brands = append(brands, string(data[i:i+4]))
+	}
+
+	heic := false
+	for _, brand := range brands {
+		switch brand {
+		case "avif", "avis":
+			return FormatAVIF
+		case "heic", "heix", "hevc", "hevx", "heim", "heis":
+			heic = true
+		}
+	}
+	if heic {
+		return FormatHEIC
+	}
+	return ""
+}
+
+// isSVGDocument 檢查文件是否以 XML 宣告、註解或 <svg 開頭且根元素為 <svg>
+// 比 isSVG 嚴格，避免將內含 <svg> 元素的 HTML/XHTML 視為 SVG
+func isSVGDocument(data []byte) bool {
+	limit := 1024
+	if len(data) < limit {
+		limit = len(data)
+	}
+	head := bytes.TrimPrefix(data[:limit], []byte("\xef\xbb\xbf"))
+	head = bytes.TrimSpace(head)
+
+	lower := strings.ToLower(string(head))
+	if !strings.HasPrefix(lower, "<?xml") && !strings.HasPrefix(lower, "<svg") &&
+		!strings.HasPrefix(lower, "<!--") && !strings.HasPrefix(lower, "<!doctype svg") {
+		return false
+	}
+
+	// 略過宣告、註解與 DOCTYPE，第一個元素必須是 <svg>
+	d := xml.NewDecoder(bytes.NewReader(bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))))
+	for {
+		tok, err := d.RawToken()
+		if err != nil {
+			return false
+		}
+		if el, ok := tok.(xml.StartElement); ok {
+			return el.Name.Local == "svg"
+		}
+	}
+}
+
+// SVGHasActiveContent 檢查 SVG 是否含有瀏覽器會執行的內容
+// <script>、<foreignObject>、on* 事件屬性、javascript:/vbscript: 連結與 xml-stylesheet 皆視為主動內容；
+// 無法完整解析（例如未定義的實體）時也回傳 true，以免以 image/svg+xml 原樣提供時造成 XSS
+func SVGHasActiveContent(data []byte) bool {
+	d := xml.NewDecoder(bytes.NewReader(bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))))
+	for {
+		tok, err := d.Token()
+		if err == io.EOF {
+			return false
+		}
+		if err != nil {
+			return true
+		}
+		switch t := tok.(type) {
+		case xml.ProcInst:
+			if strings.EqualFold(t.Target, "xml-stylesheet") {
+				return true
+			}
+		case xml.StartElement:
+			if strings.EqualFold(t.Name.Local, "script") || strings.EqualFold(t.Name.Local, "foreignObject") {
+				return true
+			}
+			for _, attr := range t.Attr {
+				if strings.HasPrefix(strings.ToLower(attr.Name.Local), "on") || isScriptURL(attr.Value) {
+					return true
+				}
+			}
+		}
+	}
+}
+
+// isScriptURL 檢查屬性值是否含有腳本 URL（忽略大小寫、空白與控制字元，瀏覽器解析 URL 時同樣會略過）
+func isScriptURL(value string) bool {
+	normalized := strings.Map(func(r rune) rune {
+		if r <= ' ' {
+			return -1
+		}
+		return unicode.ToLower(r)
+	}, value)
+	return strings.Contains(normalized, "javascript:") || strings.Contains(normalized, "vbscript:")
+}
+
+// FormatFromExtension 將副檔名對應為 DetectFormat 使用的格式名稱，未知副檔名回傳空字串
+func FormatFromExtension(ext string) string {
+	switch strings.ToLower(strings.TrimPrefix(ext, ".")) {
+	case "jpg", "jpeg":
+		return FormatJPEG
+	case "png":
+		return FormatPNG
+	case "gif":
+		return FormatGIF
+	case "webp":
+		return FormatWebP
+	case "avif":
+		return FormatAVIF
+	case "heic", "heif":
+		return FormatHEIC
+	case "jxl":
+		return FormatJXL
+	case "bmp":
+		return FormatBMP
+	case "tif", "tiff":
+		return FormatTIFF
+	case "svg":
+		return FormatSVG
+	default:
+		return ""
+	}
+}
+
+// FormatFromContentType 將 Content-Type 對應為格式名稱，非圖片類型回傳空字串
+func FormatFromContentType(contentType string) string {
+	switch strings.ToLower(strings.TrimSpace(strings.SplitN(contentType, ";", 2)[0])) {
+	case "image/jpeg", "image/jpg", "image/pjpeg":
+		return FormatJPEG
+	case "image/png":
+		return FormatPNG
+	case "image/gif":
+		return FormatGIF
+	case "image/webp":
+		return FormatWebP
+	case "image/avif":
+		return FormatAVIF
+	case "image/heic", "image/heif":
+		return FormatHEIC
+	case "image/jxl":
+		return FormatJXL
+	case "image/bmp", "image/x-ms-bmp":
+		return FormatBMP
+	case "image/tiff":
+		return FormatTIFF
+	case "image/svg+xml":
+		return FormatSVG
+	default:
+		return ""
+	}
+}
+
+// Extension 取得格式的標準副檔名（含 "."）
+func Extension(format string) string {
+	switch format {
+	case FormatJPEG:
+		return ".jpg"
+	case FormatTIFF:
+		return ".tiff"
+	case "":
+		return ""
+	default:
+		return "." + format
+	}
+}
+
+// DecodeConfig 僅解析檔頭取得圖片尺寸，不解碼像素
+// SVG 使用 viewBox 尺寸
+func DecodeConfig(data []byte) (image.Config, error) {
+	if DetectFormat(data) == FormatSVG {
+		icon, err := oksvg.ReadIconStream(bytes.NewReader(data))
+		if err != nil {
+			return image.Config{}, fmt.Errorf("failed to parse svg: %w", err)
+		}
+		return image.Config{Width: int(icon.ViewBox.W), Height: int(icon.ViewBox.H)}, nil
+	}
+
+	var cfg image.Config
+	var err error
+	switch detectISOBMFF(data) {
+	case FormatAVIF:
+		cfg, err = avif.DecodeConfig(bytes.NewReader(data))
+	case FormatHEIC:
+		cfg, err = heic.DecodeConfig(bytes.NewReader(data))
+	default:
+		cfg, _, err = image.DecodeConfig(bytes.NewReader(data))
+	}
+	if err != nil {
+		return image.Config{}, fmt.Errorf("failed to decode image config: %w", err)
+	}
+	return cfg, nil
+}
+
+// Decode 解碼圖片並回傳格式名稱
+// AVIF/HEIC 依 detectISOBMFF 的結果直接交給對應解碼器：image.Decode 只比對 ftyp 的主要品牌，
+// 主要品牌為 mif1/msf1 等、僅於相容品牌標示 avif/heic 的檔案無法辨識
+func Decode(data []byte) (image.Image, string, error) {
+	switch format := detectISOBMFF(data); format {
+	case FormatAVIF:
+		img, err := avif.Decode(bytes.NewReader(data))
+		return img, format, err
+	case FormatHEIC:
+		img, err := heic.Decode(bytes.NewReader(data))
+		return img, format, err
+	}
+	return image.Decode(bytes.NewReader(data))
+}
@@ -0,0 +1,161 @@
+package processor
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"testing"
+
+	"github.com/gen2brain/avif"
+)
+
+func TestDetectFormat(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		want string
+	}{
+		{"jpeg", []byte{0xFF, 0xD8, 0xFF, 0xE0, 0x00}, FormatJPEG},
+		{"png", []byte("\x89PNG\r\n\x1a\n\x00\x00"), FormatPNG},
+		{"gif", []byte("GIF89a\x01\x00"), FormatGIF},
+		{"webp", []byte("RIFF\x00\x00\x00\x00WEBPVP8 "), FormatWebP},
+		{"avif", []byte("\x00\x00\x00\x1cftypavif\x00\x00\x00\x00avifmif1miaf"), FormatAVIF},
+		{"avif compatible brand", []byte("\x00\x00\x00\x1cftypmif1\x00\x00\x00\x00mif1avifmiaf"), FormatAVIF},
+		{"heic", []byte("\x00\x00\x00\x18ftypheic\x00\x00\x00\x00mif1heic"), FormatHEIC},
+		{"jxl codestream", []byte{0xFF, 0x0A, 0x00}, FormatJXL},
+		{"jxl container", []byte("\x00\x00\x00\x0cJXL \r\n\x87\n\x00"), FormatJXL},
+		{"bmp", []byte("BM\x00\x00"), FormatBMP},
+		{"tiff little endian", []byte("II*\x00\x08"), FormatTIFF},
+		{"tiff big endian", []byte("MM\x00*\x00"), FormatTIFF},
+		{"svg", []byte(`<svg xmlns="http://www.w3.org/2000/svg"></svg>`), FormatSVG},
+		{"svg with xml declaration", []byte("\xef\xbb\xbf<?xml version=\"1.0\"?>\n<svg></svg>"), FormatSVG},
+		{"html mentioning svg", []byte("<html><body><svg></svg></body></html>"), ""},
+		{"svg after comment and doctype", []byte("<!-- logo -->\n<!DOCTYPE svg PUBLIC \"-//W3C//DTD SVG 1.1//EN\" \"http://www.w3.org/Graphics/SVG/1.1/DTD/svg11.dtd\">\n<svg></svg>"), FormatSVG},
+		{"xhtml containing svg", []byte(`<?xml version="1.0"?><html xmlns="http://www.w3.org/1999/xhtml"><body><script>alert(1)</script><svg></svg></body></html>`), ""},
+		{"comment before html", []byte("<!-- <svg> --><html><svg></svg></html>"), ""},
+		{"mp4", []byte("\x00\x00\x00\x18ftypisom\x00\x00\x00\x00isomiso2"), ""},
+		{"text", []byte("hello"), ""},
+		{"empty", nil, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DetectFormat(tt.data); got != tt.want {
+				t.Errorf("DetectFormat() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatFromExtensionAndContentType(t *testing.T) {
+	if got := FormatFromExtension(".JPEG"); got != FormatJPEG {
+		t.Errorf("FormatFromExtension(.JPEG) = %q", got)
+	}
+	if got := FormatFromExtension(".heif"); got != FormatHEIC {
+		t.Errorf("FormatFromExtension(.heif) = %q", got)
+	}
+	if got := FormatFromExtension(".html"); got != "" {
+		t.Errorf("FormatFromExtension(.html) = %q", got)
+	}
+	if got := FormatFromContentType("image/png; charset=binary"); got != FormatPNG {
+		t.Errorf("FormatFromContentType() = %q", got)
+	}
+	if got := FormatFromContentType("application/octet-stream"); got != "" {
+		t.Errorf("FormatFromContentType(octet-stream) = %q", got)
+	}
+	if Extension(FormatJPEG) != ".jpg" || Extension(FormatWebP) != ".webp" {
+		t.Error("unexpected canonical extensions")
+	}
+}
+
+func TestDecodeConfig(t *testing.T) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 30, 20))); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := DecodeConfig(buf.Bytes())
+	if err != nil || cfg.Width != 30 || cfg.Height != 20 {
+		t.Errorf("DecodeConfig(png) = %+v, %v", cfg, err)
+	}
+
+	svg := []byte(`<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 64 32"></svg>`)
+	cfg, err = DecodeConfig(svg)
+	if err != nil || cfg.Width != 64 || cfg.Height != 32 {
+		t.Errorf("DecodeConfig(svg) = %+v, %v", cfg, err)
+	}
+
+	if _, err := DecodeConfig([]byte("\x89PNG\r\n\x1a\n")); err == nil {
+		t.Error("DecodeConfig should fail for a truncated image")
+	}
+}
+
+// newCompatibleBrandAVIF 產生主要品牌為 mif1、僅於相容品牌標示 avif 的 AVIF 檔案
+func newCompatibleBrandAVIF(t *testing.T, w, h int) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := avif.Encode(&buf, image.NewRGBA(image.Rect(0, 0, w, h)), avif.Options{Quality: 50, Speed: 10}); err != nil {
+		t.Fatal(err)
+	}
+	data := buf.Bytes()
+	if string(data[4:8]) != "ftyp" || string(data[8:12]) != "avif" {
+		t.Fatalf("unexpected ftyp box: %q", data[:32])
+	}
+	size := int(data[0])<<24 | int(data[1])<<16 | int(data[2])<<8 | int(data[3])
+	if !bytes.Contains(data[16:size], []byte("avif")) {
+		t.Fatalf("avif is not a compatible brand: %q", data[:size])
+	}
+	copy(data[8:12], "mif1")
+	return data
+}
+
+func TestDecode_CompatibleBrand(t *testing.T) {
+	data := newCompatibleBrandAVIF(t, 24, 16)
+	if got := DetectFormat(data); got != FormatAVIF {
+		t.Fatalf("DetectFormat() = %q, want %q", got, FormatAVIF)
+	}
+	// image.Decode 只比對主要品牌
+	if _, _, err := image.Decode(bytes.NewReader(data)); err == nil {
+		t.Fatal("expected image.Decode to reject a compatible-brand AVIF")
+	}
+
+	cfg, err := DecodeConfig(data)
+	if err != nil || cfg.Width != 24 || cfg.Height != 16 {
+		t.Errorf("DecodeConfig() = %+v, %v", cfg, err)
+	}
+	img, format, err := Decode(data)
+	if err != nil || format != FormatAVIF || img.Bounds() != image.Rect(0, 0, 24, 16) {
+		t.Fatalf("Decode() = %v, %q, %v", img, format, err)
+	}
+
+	p := NewProcessor(80, 1000, 1000)
+	if _, err := p.Process(bytes.NewReader(data), ProcessOptions{Width: 12}); err != nil {
+		t.Errorf("Process() error = %v", err)
+	}
+}
+
+func TestSVGHasActiveContent(t *testing.T) {
+	tests := []struct {
+		name string
+		svg  string
+		want bool
+	}{
+		{"plain", `<svg xmlns="http://www.w3.org/2000/svg"><a href="https://example.com"><rect width="4" height="4" fill="url(#g)"/></a></svg>`, false},
+		{"offset attribute", `<svg xmlns="http://www.w3.org/2000/svg"><stop offset="0.5"/></svg>`, false},
+		{"script", `<svg xmlns="http://www.w3.org/2000/svg"><script>alert(1)</script></svg>`, true},
+		{"namespaced script", `<svg xmlns="http://www.w3.org/2000/svg"><svg:script xmlns:svg="http://www.w3.org/2000/svg">alert(1)</svg:script></svg>`, true},
+		{"foreignObject", `<svg xmlns="http://www.w3.org/2000/svg"><foreignObject><div xmlns="http://www.w3.org/1999/xhtml">x</div></foreignObject></svg>`, true},
+		{"event handler", `<svg xmlns="http://www.w3.org/2000/svg"><rect onClick="alert(1)"/></svg>`, true},
+		{"javascript href", `<svg xmlns="http://www.w3.org/2000/svg" xmlns:xlink="http://www.w3.org/1999/xlink"><a xlink:href=" Java&#x09;Script:alert(1)">x</a></svg>`, true},
+		{"animated href", `<svg xmlns="http://www.w3.org/2000/svg"><a><animate attributeName="href" values="javascript:alert(1)"/>x</a></svg>`, true},
+		{"stylesheet", `<?xml-stylesheet type="text/xsl" href="x.xsl"?><svg xmlns="http://www.w3.org/2000/svg"></svg>`, true},
+		{"undefined entity", `<!DOCTYPE svg [<!ENTITY x "javascript:alert(1)">]><svg xmlns="http://www.w3.org/2000/svg"><a href="&x;">x</a></svg>`, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SVGHasActiveContent([]byte(tt.svg)); got != tt.want {
+				t.Errorf("SVGHasActiveContent() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
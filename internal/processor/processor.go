@@ -106,7 +106,7 @@ func (p *Processor) decodeImage(data []byte, opts ProcessOptions) (image.Image,
 		zlogger.Warn("Failed to decode as SVG, falling back to image.Decode", zlogger.Err(err))
 	}
 
-	img, _, err := image.Decode(bytes.NewReader(data))
+	img, _, err := Decode(data)
 	if err != nil {
 		return nil, fmt.Errorf("failed to decode image: %w", err)
 	}
@@ -158,14 +158,14 @@ func (p *Processor) applyTransformations(img image.Image, opts ProcessOptions) i
 
 // DecodeImage 解碼圖片資料
 func (p *Processor) DecodeImage(data []byte) (image.Image, string, error) {
-	return image.Decode(bytes.NewReader(data))
+	return Decode(data)
 }
 
 // GetImageSize 取得圖片尺寸
 func (p *Processor) GetImageSize(data []byte) (width, height int, err error) {
-	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	config, err := DecodeConfig(data)
 	if err != nil {
-		return 0, 0, fmt.Errorf("failed to decode image config: %w", err)
+		return 0, 0, err
 	}
 	return config.Width, config.Height, nil
 }
@@ -298,6 +298,12 @@ func GetContentType(format string) string {
 		return "image/jxl"
 	case "heic":
 		return "image/heic"
+	case "bmp":
+		return "image/bmp"
+	case "tiff":
+		return "image/tiff"
+	case "svg":
+		return "image/svg+xml"
 	default:
 		return "image/jpeg"
 	}
@@ -0,0 +1,26 @@
+// Package purge 提供來源圖片與處理結果（variant）的對應索引，用於依來源清除快取與儲存
+package purge
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrPrefixUnsupported 索引不支援前綴查詢
+var ErrPrefixUnsupported = errors.New("prefix purge is not supported by this index")
+
+// Index 來源到處理結果 key 的索引
+// 處理結果的 key 為參數雜湊，無法由來源路徑反推，因此需在寫入時記錄
+type Index interface {
+	// Add 記錄來源產生的處理結果 key
+	Add(ctx context.Context, source, key string) error
+
+	// Variants 取得來源的所有處理結果 key
+	Variants(ctx context.Context, source string) ([]string, error)
+
+	// Sources 取得以 prefix 開頭的所有來源（不支援時回傳 ErrPrefixUnsupported）
+	Sources(ctx context.Context, prefix string) ([]string, error)
+
+	// Remove 移除來源的索引
+	Remove(ctx context.Context, source string) error
+}
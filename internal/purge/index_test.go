@@ -0,0 +1,107 @@
+package purge
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/vincent119/images-filters/internal/storage/types"
+)
+
+// memBlobStore 記憶體 BlobStore
+type memBlobStore struct {
+	mu   sync.Mutex
+	data map[string][]byte
+}
+
+func newMemBlobStore() *memBlobStore {
+	return &memBlobStore{data: map[string][]byte{}}
+}
+
+func (s *memBlobStore) Get(ctx context.Context, key string) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if v, ok := s.data[key]; ok {
+		return v, nil
+	}
+	return nil, fmt.Errorf("%w: %s", types.ErrNotFound, key)
+}
+
+func (s *memBlobStore) Put(ctx context.Context, key string, data []byte, opts ...types.PutOption) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data[key] = data
+	return nil
+}
+
+func (s *memBlobStore) Delete(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.data, key)
+	return nil
+}
+
+func newTestRedisIndex(t *testing.T) *RedisIndex {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+	return NewRedisIndex(client)
+}
+
+func TestIndex(t *testing.T) {
+	indexes := map[string]Index{
+		"storage": NewStorageIndex(newMemBlobStore()),
+		"redis":   newTestRedisIndex(t),
+	}
+
+	for name, idx := range indexes {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+
+			assert.NoError(t, idx.Add(ctx, "uploads/a.jpg", "cache/11/aaa/a.jpg"))
+			assert.NoError(t, idx.Add(ctx, "uploads/a.jpg", "cache/22/bbb/a.jpg"))
+			assert.NoError(t, idx.Add(ctx, "uploads/a.jpg", "cache/22/bbb/a.jpg")) // 重複寫入
+			assert.NoError(t, idx.Add(ctx, "uploads/b.jpg", "cache/33/ccc/b.jpg"))
+
+			variants, err := idx.Variants(ctx, "uploads/a.jpg")
+			assert.NoError(t, err)
+			assert.ElementsMatch(t, []string{"cache/11/aaa/a.jpg", "cache/22/bbb/a.jpg"}, variants)
+
+			assert.NoError(t, idx.Remove(ctx, "uploads/a.jpg"))
+			variants, err = idx.Variants(ctx, "uploads/a.jpg")
+			assert.NoError(t, err)
+			assert.Empty(t, variants)
+
+			variants, err = idx.Variants(ctx, "uploads/b.jpg")
+			assert.NoError(t, err)
+			assert.Equal(t, []string{"cache/33/ccc/b.jpg"}, variants)
+		})
+	}
+}
+
+func TestRedisIndex_Sources(t *testing.T) {
+	idx := newTestRedisIndex(t)
+	ctx := context.Background()
+
+	for _, source := range []string{"uploads/2025/a.jpg", "uploads/2025/b.jpg", "uploads/2026/c.jpg", "other/d.jpg"} {
+		assert.NoError(t, idx.Add(ctx, source, "cache/"+source))
+	}
+
+	sources, err := idx.Sources(ctx, "uploads/2025/")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"uploads/2025/a.jpg", "uploads/2025/b.jpg"}, sources)
+
+	assert.NoError(t, idx.Remove(ctx, "uploads/2025/a.jpg"))
+	sources, err = idx.Sources(ctx, "uploads/")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"uploads/2025/b.jpg", "uploads/2026/c.jpg"}, sources)
+}
+
+func TestStorageIndex_SourcesUnsupported(t *testing.T) {
+	_, err := NewStorageIndex(newMemBlobStore()).Sources(context.Background(), "uploads/")
+	assert.ErrorIs(t, err, ErrPrefixUnsupported)
+}
@@ -0,0 +1,74 @@
+package purge
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	redisSourcesKey     = "purge:sources"   // 所有來源（sorted set，以字典序支援前綴查詢）
+	redisVariantsPrefix = "purge:variants:" // 每個來源的處理結果 key（set）
+)
+
+// RedisIndex 以 Redis 保存的索引，多個副本共用
+type RedisIndex struct {
+	client *redis.Client
+}
+
+// NewRedisIndex 建立 Redis 索引
+func NewRedisIndex(client *redis.Client) *RedisIndex {
+	return &RedisIndex{client: client}
+}
+
+// variantsKey 以來源雜湊作為 Redis key，避免來源 URL 過長
+func variantsKey(source string) string {
+	sum := sha256.Sum256([]byte(source))
+	return redisVariantsPrefix + hex.EncodeToString(sum[:])
+}
+
+// Add 記錄來源產生的處理結果 key
+func (i *RedisIndex) Add(ctx context.Context, source, key string) error {
+	pipe := i.client.TxPipeline()
+	pipe.ZAdd(ctx, redisSourcesKey, redis.Z{Member: source})
+	pipe.SAdd(ctx, variantsKey(source), key)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to add purge index: %w", err)
+	}
+	return nil
+}
+
+// Variants 取得來源的所有處理結果 key
+func (i *RedisIndex) Variants(ctx context.Context, source string) ([]string, error) {
+	keys, err := i.client.SMembers(ctx, variantsKey(source)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read purge index: %w", err)
+	}
+	return keys, nil
+}
+
+// Sources 取得以 prefix 開頭的所有來源
+func (i *RedisIndex) Sources(ctx context.Context, prefix string) ([]string, error) {
+	sources, err := i.client.ZRangeByLex(ctx, redisSourcesKey, &redis.ZRangeBy{
+		Min: "[" + prefix,
+		Max: "[" + prefix + "\xff",
+	}).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read purge index: %w", err)
+	}
+	return sources, nil
+}
+
+// Remove 移除來源的索引
+func (i *RedisIndex) Remove(ctx context.Context, source string) error {
+	pipe := i.client.TxPipeline()
+	pipe.ZRem(ctx, redisSourcesKey, source)
+	pipe.Del(ctx, variantsKey(source))
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to remove purge index: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,119 @@
+package purge
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/vincent119/images-filters/internal/storage/types"
+)
+
+const storageIndexPrefix = "variants"
+
+// BlobStore 索引使用的儲存操作（storage.Storage 的子集）
+type BlobStore interface {
+	Get(ctx context.Context, key string) ([]byte, error)
+	Put(ctx context.Context, key string, data []byte, opts ...types.PutOption) error
+	Delete(ctx context.Context, key string) error
+}
+
+// manifest 單一來源的索引檔內容
+type manifest struct {
+	Source   string   `json:"source"`
+	Variants []string `json:"variants"`
+}
+
+// StorageIndex 以儲存層 manifest 檔保存的索引
+// 每個來源一個 variants/xx/hash.json；更新為讀取後寫回，僅保證單一行程內一致，
+// 多副本部署請使用 RedisIndex。不支援前綴清除
+type StorageIndex struct {
+	store BlobStore
+	mu    sync.Mutex
+}
+
+// NewStorageIndex 建立儲存層索引
+func NewStorageIndex(store BlobStore) *StorageIndex {
+	return &StorageIndex{store: store}
+}
+
+// manifestKey 取得來源的 manifest 儲存 key
+func manifestKey(source string) string {
+	sum := sha256.Sum256([]byte(source))
+	h := hex.EncodeToString(sum[:])
+	return fmt.Sprintf("%s/%s/%s.json", storageIndexPrefix, h[:2], h[2:])
+}
+
+// Add 記錄來源產生的處理結果 key
+func (i *StorageIndex) Add(ctx context.Context, source, key string) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	m, err := i.load(ctx, source)
+	if err != nil {
+		return err
+	}
+	for _, v := range m.Variants {
+		if v == key {
+			return nil
+		}
+	}
+	m.Variants = append(m.Variants, key)
+
+	data, err := json.Marshal(m)
+	if err != nil {
+		return fmt.Errorf("failed to encode purge manifest: %w", err)
+	}
+	if err := i.store.Put(ctx, manifestKey(source), data); err != nil {
+		return fmt.Errorf("failed to save purge manifest: %w", err)
+	}
+	return nil
+}
+
+// Variants 取得來源的所有處理結果 key
+func (i *StorageIndex) Variants(ctx context.Context, source string) ([]string, error) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	m, err := i.load(ctx, source)
+	if err != nil {
+		return nil, err
+	}
+	return m.Variants, nil
+}
+
+// Sources 不支援前綴查詢
+func (i *StorageIndex) Sources(ctx context.Context, prefix string) ([]string, error) {
+	return nil, ErrPrefixUnsupported
+}
+
+// Remove 移除來源的索引
+func (i *StorageIndex) Remove(ctx context.Context, source string) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if err := i.store.Delete(ctx, manifestKey(source)); err != nil && !errors.Is(err, types.ErrNotFound) {
+		return fmt.Errorf("failed to delete purge manifest: %w", err)
+	}
+	return nil
+}
+
+// load 讀取 manifest（不存在時回傳空 manifest）
+func (i *StorageIndex) load(ctx context.Context, source string) (*manifest, error) {
+	data, err := i.store.Get(ctx, manifestKey(source))
+	if err != nil {
+		if errors.Is(err, types.ErrNotFound) {
+			return &manifest{Source: source}, nil
+		}
+		return nil, fmt.Errorf("failed to read purge manifest: %w", err)
+	}
+
+	var m manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("failed to decode purge manifest: %w", err)
+	}
+	return &m, nil
+}
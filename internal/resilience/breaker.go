@@ -0,0 +1,178 @@
+package resilience
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen 斷路器開啟，請求被直接拒絕
+var ErrCircuitOpen = errors.New("circuit breaker open")
+
+// maxBreakerTargets 同時保留狀態的目標數量上限，超過時移除最久未使用的目標
+const maxBreakerTargets = 1024
+
+// State 斷路器狀態
+type State int
+
+const (
+	StateClosed   State = iota // 正常
+	StateOpen                  // 開啟（拒絕請求）
+	StateHalfOpen              // 半開（允許單一探測請求）
+)
+
+// String 回傳狀態名稱
+func (s State) String() string {
+	switch s {
+	case StateOpen:
+		return "open"
+	case StateHalfOpen:
+		return "half_open"
+	default:
+		return "closed"
+	}
+}
+
+// BreakerGroup 依目標（例如來源主機）分別維護的斷路器
+// 連續失敗達 FailureThreshold 次後開啟，經過 Cooldown 後進入半開狀態放行一個探測請求；
+// 僅保留有失敗紀錄的目標，恢復正常的目標即移除
+type BreakerGroup struct {
+	failureThreshold int
+	cooldown         time.Duration
+	onStateChange    func(target string, state State)
+	now              func() time.Time
+
+	mu       sync.Mutex
+	breakers map[string]*breaker
+}
+
+type breaker struct {
+	state    State
+	failures int
+	openedAt time.Time
+	probing  bool
+	lastUsed time.Time
+}
+
+// NewBreakerGroup 建立斷路器群組
+// onStateChange 在狀態改變時呼叫（可為 nil），用於回報指標
+func NewBreakerGroup(failureThreshold int, cooldown time.Duration, onStateChange func(target string, state State)) *BreakerGroup {
+	if failureThreshold < 1 {
+		failureThreshold = 1
+	}
+	return &BreakerGroup{
+		failureThreshold: failureThreshold,
+		cooldown:         cooldown,
+		onStateChange:    onStateChange,
+		now:              time.Now,
+		breakers:         make(map[string]*breaker),
+	}
+}
+
+// Allow 檢查是否允許對目標發送請求
+func (g *BreakerGroup) Allow(target string) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	// 未記錄的目標為關閉狀態
+	b, ok := g.breakers[target]
+	if !ok {
+		return nil
+	}
+	b.lastUsed = g.now()
+	switch b.state {
+	case StateOpen:
+		if g.now().Sub(b.openedAt) < g.cooldown {
+			return ErrCircuitOpen
+		}
+		g.transition(target, b, StateHalfOpen)
+		b.probing = true
+		return nil
+	case StateHalfOpen:
+		if b.probing {
+			return ErrCircuitOpen
+		}
+		b.probing = true
+		return nil
+	default:
+		return nil
+	}
+}
+
+// Record 回報請求結果
+func (g *BreakerGroup) Record(target string, success bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	b := g.get(target)
+	b.probing = false
+
+	if success {
+		if b.state != StateClosed {
+			g.transition(target, b, StateClosed)
+		}
+		// 關閉且無失敗紀錄的狀態與未記錄相同
+		delete(g.breakers, target)
+		return
+	}
+
+	b.failures++
+	if b.state == StateHalfOpen || b.failures >= g.failureThreshold {
+		b.openedAt = g.now()
+		if b.state != StateOpen {
+			g.transition(target, b, StateOpen)
+		}
+	}
+}
+
+// Release 放棄探測請求而不回報結果（例如用戶端取消）
+// 半開狀態維持不變，下一個請求可再次探測；未放行探測時不做任何事
+func (g *BreakerGroup) Release(target string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if b, ok := g.breakers[target]; ok {
+		b.probing = false
+	}
+}
+
+// State 取得目標目前的狀態
+func (g *BreakerGroup) State(target string) State {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if b, ok := g.breakers[target]; ok {
+		return b.state
+	}
+	return StateClosed
+}
+
+func (g *BreakerGroup) get(target string) *breaker {
+	b, ok := g.breakers[target]
+	if !ok {
+		if len(g.breakers) >= maxBreakerTargets {
+			g.evictOldest()
+		}
+		b = &breaker{}
+		g.breakers[target] = b
+	}
+	b.lastUsed = g.now()
+	return b
+}
+
+// evictOldest 移除最久未使用的目標
+func (g *BreakerGroup) evictOldest() {
+	var oldest string
+	var oldestAt time.Time
+	for target, b := range g.breakers {
+		if oldest == "" || b.lastUsed.Before(oldestAt) {
+			oldest, oldestAt = target, b.lastUsed
+		}
+	}
+	delete(g.breakers, oldest)
+}
+
+func (g *BreakerGroup) transition(target string, b *breaker, state State) {
+	b.state = state
+	if g.onStateChange != nil {
+		g.onStateChange(target, state)
+	}
+}
@@ -0,0 +1,107 @@
+package resilience
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBreakerGroup(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	var changes []string
+	g := NewBreakerGroup(2, time.Minute, func(target string, state State) {
+		changes = append(changes, target+":"+state.String())
+	})
+	g.now = func() time.Time { return now }
+
+	// 連續失敗達門檻後開啟
+	assert.NoError(t, g.Allow("a.com"))
+	g.Record("a.com", false)
+	assert.Equal(t, StateClosed, g.State("a.com"))
+	g.Record("a.com", false)
+	assert.Equal(t, StateOpen, g.State("a.com"))
+	assert.ErrorIs(t, g.Allow("a.com"), ErrCircuitOpen)
+
+	// 其他主機不受影響
+	assert.NoError(t, g.Allow("b.com"))
+
+	// 冷卻後放行單一探測請求
+	now = now.Add(time.Minute)
+	assert.NoError(t, g.Allow("a.com"))
+	assert.Equal(t, StateHalfOpen, g.State("a.com"))
+	assert.ErrorIs(t, g.Allow("a.com"), ErrCircuitOpen)
+
+	// 探測失敗重新開啟
+	g.Record("a.com", false)
+	assert.Equal(t, StateOpen, g.State("a.com"))
+
+	// 探測成功後關閉
+	now = now.Add(time.Minute)
+	assert.NoError(t, g.Allow("a.com"))
+	g.Record("a.com", true)
+	assert.Equal(t, StateClosed, g.State("a.com"))
+	assert.NoError(t, g.Allow("a.com"))
+
+	assert.Equal(t, []string{
+		"a.com:open",
+		"a.com:half_open",
+		"a.com:open",
+		"a.com:half_open",
+		"a.com:closed",
+	}, changes)
+}
+
+func TestBreakerGroup_ReleaseProbe(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	g := NewBreakerGroup(1, time.Minute, nil)
+	g.now = func() time.Time { return now }
+
+	g.Record("a.com", false)
+	now = now.Add(time.Minute)
+	assert.NoError(t, g.Allow("a.com"))
+	assert.ErrorIs(t, g.Allow("a.com"), ErrCircuitOpen)
+
+	// 探測請求被取消：維持半開並放行下一個探測
+	g.Release("a.com")
+	assert.Equal(t, StateHalfOpen, g.State("a.com"))
+	assert.NoError(t, g.Allow("a.com"))
+	assert.ErrorIs(t, g.Allow("a.com"), ErrCircuitOpen)
+
+	// 未記錄的目標不建立狀態
+	g.Release("b.com")
+	assert.Equal(t, StateClosed, g.State("b.com"))
+}
+
+func TestBreakerGroup_SuccessResetsFailures(t *testing.T) {
+	g := NewBreakerGroup(2, time.Minute, nil)
+	g.Record("a.com", false)
+	g.Record("a.com", true)
+	g.Record("a.com", false)
+	assert.Equal(t, StateClosed, g.State("a.com"))
+}
+
+func TestBreakerGroup_BoundedTargets(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	g := NewBreakerGroup(1, time.Minute, nil)
+	g.now = func() time.Time { return now }
+
+	// 成功的目標不保留狀態
+	for i := range 10 {
+		target := fmt.Sprintf("ok-%d.com", i)
+		assert.NoError(t, g.Allow(target))
+		g.Record(target, true)
+	}
+	assert.Empty(t, g.breakers)
+
+	// 超過上限時移除最久未使用的目標
+	g.Record("first.com", false)
+	for i := range maxBreakerTargets {
+		now = now.Add(time.Millisecond)
+		g.Record(fmt.Sprintf("fail-%d.com", i), false)
+	}
+	assert.Len(t, g.breakers, maxBreakerTargets)
+	assert.Equal(t, StateClosed, g.State("first.com"))
+	assert.Equal(t, StateOpen, g.State(fmt.Sprintf("fail-%d.com", maxBreakerTargets-1)))
+}
@@ -0,0 +1,85 @@
+package resilience
+
+import (
+	"context"
+	"time"
+)
+
+// Hedge 對沖請求
+// 先執行 fn；若超過 delay 仍未完成，再平行發出第二個請求，採用最先成功的結果。
+// 未被採用的請求會被取消，若其仍成功則交由 discard 釋放（例如關閉回應 Body，可為 nil）；
+// 被採用請求的 context 在 ctx 結束前保持有效，因此回傳的串流可繼續讀取。
+// onHedge 在發出第二個請求時呼叫（可為 nil）
+func Hedge[T any](ctx context.Context, delay time.Duration, fn func(ctx context.Context) (T, error), discard func(T), onHedge func()) (T, error) {
+	if delay <= 0 {
+		return fn(ctx)
+	}
+
+	type result struct {
+		idx int
+		val T
+		err error
+	}
+	results := make(chan result, 2)
+	var cancels []context.CancelFunc
+
+	run := func() {
+		attemptCtx, cancel := context.WithCancel(ctx)
+		idx := len(cancels)
+		cancels = append(cancels, cancel)
+		go func() {
+			v, err := fn(attemptCtx)
+			results <- result{idx: idx, val: v, err: err}
+		}()
+	}
+
+	run()
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	var firstErr error
+	received := 0
+	for {
+		select {
+		case <-timer.C:
+			if len(cancels) == 1 && received == 0 {
+				if onHedge != nil {
+					onHedge()
+				}
+				run()
+			}
+		case r := <-results:
+			received++
+			if r.err == nil {
+				pending := len(cancels) - received
+				for i, cancel := range cancels {
+					if i != r.idx {
+						cancel()
+					}
+				}
+				if pending > 0 {
+					go func() {
+						for i := 0; i < pending; i++ {
+							if late := <-results; late.err == nil && discard != nil {
+								discard(late.val)
+							}
+						}
+					}()
+				}
+				return r.val, nil
+			}
+
+			if firstErr == nil {
+				firstErr = r.err
+			}
+			// 第一個請求在對沖前就失敗時不再發出第二個請求
+			if received == len(cancels) {
+				for _, cancel := range cancels {
+					cancel()
+				}
+				var zero T
+				return zero, firstErr
+			}
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package resilience
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHedge(t *testing.T) {
+	t.Run("disabled runs once", func(t *testing.T) {
+		var calls atomic.Int32
+		v, err := Hedge(context.Background(), 0, func(context.Context) (int, error) {
+			calls.Add(1)
+			return 1, nil
+		}, nil, nil)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, v)
+		assert.Equal(t, int32(1), calls.Load())
+	})
+
+	t.Run("fast response is not hedged", func(t *testing.T) {
+		hedged := false
+		v, err := Hedge(context.Background(), 50*time.Millisecond, func(context.Context) (int, error) {
+			return 1, nil
+		}, nil, func() { hedged = true })
+		assert.NoError(t, err)
+		assert.Equal(t, 1, v)
+		assert.False(t, hedged)
+	})
+
+	t.Run("slow response is hedged and loser cancelled", func(t *testing.T) {
+		var calls atomic.Int32
+		hedged := false
+		loserCancelled := make(chan struct{})
+		v, err := Hedge(context.Background(), 10*time.Millisecond, func(ctx context.Context) (int, error) {
+			n := calls.Add(1)
+			if n == 1 {
+				<-ctx.Done()
+				close(loserCancelled)
+				return 0, ctx.Err()
+			}
+			return 2, nil
+		}, nil, func() { hedged = true })
+		assert.NoError(t, err)
+		assert.Equal(t, 2, v)
+		assert.True(t, hedged)
+
+		select {
+		case <-loserCancelled:
+		case <-time.After(time.Second):
+			t.Fatal("losing attempt was not cancelled")
+		}
+	})
+
+	t.Run("early failure is returned without hedging", func(t *testing.T) {
+		errFail := errors.New("fail")
+		hedged := false
+		_, err := Hedge(context.Background(), 50*time.Millisecond, func(context.Context) (int, error) {
+			return 0, errFail
+		}, nil, func() { hedged = true })
+		assert.ErrorIs(t, err, errFail)
+		assert.False(t, hedged)
+	})
+
+	t.Run("late success is discarded", func(t *testing.T) {
+		var calls atomic.Int32
+		discarded := make(chan int, 1)
+		release := make(chan struct{})
+		v, err := Hedge(context.Background(), 10*time.Millisecond, func(ctx context.Context) (int, error) {
+			if calls.Add(1) == 1 {
+				<-release
+				return 1, nil
+			}
+			return 2, nil
+		}, func(v int) { discarded <- v }, nil)
+		close(release)
+		assert.NoError(t, err)
+		assert.Equal(t, 2, v)
+
+		select {
+		case d := <-discarded:
+			assert.Equal(t, 1, d)
+		case <-time.After(time.Second):
+			t.Fatal("late result was not discarded")
+		}
+	})
+}
@@ -0,0 +1,85 @@
+// Package resilience 提供遠端來源與儲存讀取的重試、斷路器與對沖請求
+package resilience
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"time"
+)
+
+// RetryPolicy 重試策略
+type RetryPolicy struct {
+	MaxAttempts int           // 最大嘗試次數（含第一次，1 表示不重試）
+	BaseDelay   time.Duration // 第一次重試前的等待時間，之後指數成長
+	MaxDelay    time.Duration // 單次等待上限
+}
+
+// permanentError 不可重試的錯誤
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Permanent 標記錯誤為不可重試
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
+
+// IsPermanent 檢查錯誤是否被標記為不可重試
+func IsPermanent(err error) bool {
+	var p *permanentError
+	return errors.As(err, &p)
+}
+
+// Retry 依策略重試 fn
+// 遇到 Permanent 錯誤、斷路器開啟或 context 結束時立即回傳；
+// onRetry 在每次重試前呼叫（可為 nil）
+func Retry(ctx context.Context, p RetryPolicy, fn func(ctx context.Context) error, onRetry func(attempt int, err error)) error {
+	attempts := p.MaxAttempts
+	if attempts < 1 {
+		attempts = 1
+	}
+
+	var err error
+	for attempt := 1; attempt <= attempts; attempt++ {
+		if err = fn(ctx); err == nil {
+			return nil
+		}
+		if attempt == attempts || IsPermanent(err) || errors.Is(err, ErrCircuitOpen) || ctx.Err() != nil {
+			break
+		}
+
+		if onRetry != nil {
+			onRetry(attempt, err)
+		}
+
+		timer := time.NewTimer(p.backoff(attempt))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+	}
+	return err
+}
+
+// backoff 計算第 attempt 次失敗後的等待時間（指數退避 + equal jitter）
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	if p.BaseDelay <= 0 {
+		return 0
+	}
+	d := p.BaseDelay << (attempt - 1)
+	if d <= 0 || (p.MaxDelay > 0 && d > p.MaxDelay) {
+		d = p.MaxDelay
+	}
+	// equal jitter：在 [d/2, d] 之間隨機，保留最短等待時間並避免多個副本同時重試
+	half := d / 2
+	return half + time.Duration(rand.Int64N(int64(half)+1))
+}
@@ -0,0 +1,95 @@
+package resilience
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRetry(t *testing.T) {
+	policy := RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: 5 * time.Millisecond}
+	errTransient := errors.New("transient")
+
+	t.Run("succeeds after transient errors", func(t *testing.T) {
+		calls, retries := 0, 0
+		err := Retry(context.Background(), policy, func(context.Context) error {
+			calls++
+			if calls < 3 {
+				return errTransient
+			}
+			return nil
+		}, func(int, error) { retries++ })
+		assert.NoError(t, err)
+		assert.Equal(t, 3, calls)
+		assert.Equal(t, 2, retries)
+	})
+
+	t.Run("gives up after max attempts", func(t *testing.T) {
+		calls := 0
+		err := Retry(context.Background(), policy, func(context.Context) error {
+			calls++
+			return errTransient
+		}, nil)
+		assert.ErrorIs(t, err, errTransient)
+		assert.Equal(t, 3, calls)
+	})
+
+	t.Run("stops on permanent error", func(t *testing.T) {
+		calls := 0
+		err := Retry(context.Background(), policy, func(context.Context) error {
+			calls++
+			return Permanent(errTransient)
+		}, nil)
+		assert.True(t, IsPermanent(err))
+		assert.ErrorIs(t, err, errTransient)
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("stops on open circuit", func(t *testing.T) {
+		calls := 0
+		err := Retry(context.Background(), policy, func(context.Context) error {
+			calls++
+			return ErrCircuitOpen
+		}, nil)
+		assert.ErrorIs(t, err, ErrCircuitOpen)
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("zero attempts runs once", func(t *testing.T) {
+		calls := 0
+		_ = Retry(context.Background(), RetryPolicy{}, func(context.Context) error {
+			calls++
+			return errTransient
+		}, nil)
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("stops when context is cancelled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		calls := 0
+		err := Retry(ctx, RetryPolicy{MaxAttempts: 5, BaseDelay: time.Hour}, func(context.Context) error {
+			calls++
+			cancel()
+			return errTransient
+		}, nil)
+		assert.ErrorIs(t, err, errTransient)
+		assert.Equal(t, 1, calls)
+	})
+}
+
+func TestRetryPolicy_Backoff(t *testing.T) {
+	p := RetryPolicy{BaseDelay: 100 * time.Millisecond, MaxDelay: 300 * time.Millisecond}
+	for i := 0; i < 20; i++ {
+		d := p.backoff(1)
+		assert.GreaterOrEqual(t, d, 50*time.Millisecond)
+		assert.LessOrEqual(t, d, 100*time.Millisecond)
+
+		d = p.backoff(5) // 1.6s 受 MaxDelay 限制
+		assert.GreaterOrEqual(t, d, 150*time.Millisecond)
+		assert.LessOrEqual(t, d, 300*time.Millisecond)
+	}
+	assert.Zero(t, RetryPolicy{}.backoff(1))
+}
@@ -0,0 +1,434 @@
+package security
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/vincent119/images-filters/internal/storage/types"
+	"github.com/vincent119/images-filters/pkg/logger"
+)
+
+// Scope API 金鑰權限範圍
+type Scope string
+
+const (
+	ScopeUpload Scope = "upload" // 上傳圖片
+	ScopeDetect Scope = "detect" // 浮水印檢測
+	ScopePurge  Scope = "purge"  // 快取與儲存清除
+	ScopeSign   Scope = "sign"   // 產生簽名 URL
+)
+
+// apiKeyPrefix API 金鑰字串前綴，格式：imgf_{id}_{secret}
+const apiKeyPrefix = "imgf_"
+
+var (
+	// ErrInvalidAPIKey API 金鑰格式錯誤或不存在
+	ErrInvalidAPIKey = errors.New("invalid api key")
+	// ErrAPIKeyRevoked API 金鑰已撤銷
+	ErrAPIKeyRevoked = errors.New("api key revoked")
+	// ErrAPIKeyNotFound 找不到指定 ID 的 API 金鑰
+	ErrAPIKeyNotFound = errors.New("api key not found")
+)
+
+// ParseScopes 解析以逗號分隔的權限範圍字串
+func ParseScopes(s string) ([]Scope, error) {
+	var scopes []Scope
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(strings.ToLower(part))
+		if part == "" {
+			continue
+		}
+		scope := Scope(part)
+		switch scope {
+		case ScopeUpload, ScopeDetect, ScopePurge, ScopeSign:
+			scopes = append(scopes, scope)
+		default:
+			return nil, fmt.Errorf("unknown scope: %s", part)
+		}
+	}
+	if len(scopes) == 0 {
+		return nil, fmt.Errorf("at least one scope is required")
+	}
+	return scopes, nil
+}
+
+// APIKey API 金鑰記錄（僅保存雜湊值，不保存明文）
+type APIKey struct {
+	ID        string     `json:"id"`
+	Name      string     `json:"name"`
+	Hash      string     `json:"hash"`
+	Scopes    []Scope    `json:"scopes"`
+	Prefix    string     `json:"prefix,omitempty"` // 允許存取的儲存路徑前綴（空字串表示不限制）
+	CreatedAt time.Time  `json:"created_at"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+}
+
+// HasScope 檢查金鑰是否具備指定權限
+func (k *APIKey) HasScope(scope Scope) bool {
+	for _, s := range k.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// IsRevoked 檢查金鑰是否已撤銷
+func (k *APIKey) IsRevoked() bool {
+	return k.RevokedAt != nil
+}
+
+// AllowsPath 檢查儲存路徑是否位於金鑰允許的前綴內
+func (k *APIKey) AllowsPath(p string) bool {
+	if k.Prefix == "" {
+		return true
+	}
+	cleaned := path.Clean("/" + p)
+	prefix := path.Clean("/" + k.Prefix)
+	return cleaned == prefix || strings.HasPrefix(cleaned, prefix+"/")
+}
+
+// HashAPIKey 計算 API 金鑰的 SHA-256 雜湊（hex 編碼）
+func HashAPIKey(rawKey string) string {
+	sum := sha256.Sum256([]byte(rawKey))
+	return hex.EncodeToString(sum[:])
+}
+
+// splitAPIKey 從金鑰字串中取出 ID
+func splitAPIKey(rawKey string) (id string, ok bool) {
+	if !strings.HasPrefix(rawKey, apiKeyPrefix) {
+		return "", false
+	}
+	rest := rawKey[len(apiKeyPrefix):]
+	idx := strings.Index(rest, "_")
+	if idx <= 0 || idx == len(rest)-1 {
+		return "", false
+	}
+	return rest[:idx], true
+}
+
+// generateAPIKey 產生新的金鑰 ID 與明文金鑰
+func generateAPIKey() (id, rawKey string, err error) {
+	idBytes := make([]byte, 6)
+	if _, err := rand.Read(idBytes); err != nil {
+		return "", "", fmt.Errorf("failed to generate key id: %w", err)
+	}
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", "", fmt.Errorf("failed to generate key secret: %w", err)
+	}
+	id = hex.EncodeToString(idBytes)
+	rawKey = apiKeyPrefix + id + "_" + base64.RawURLEncoding.EncodeToString(secret)
+	return id, rawKey, nil
+}
+
+// APIKeyStore API 金鑰驗證介面
+type APIKeyStore interface {
+	// Authenticate 驗證明文金鑰並回傳對應的金鑰記錄
+	Authenticate(ctx context.Context, rawKey string) (*APIKey, error)
+}
+
+// KeyBackend 金鑰清單的持久化後端
+type KeyBackend interface {
+	Load(ctx context.Context) ([]byte, error)
+	Save(ctx context.Context, data []byte) error
+}
+
+// BlobStore 以鍵值方式存取資料的儲存介面（storage.Storage 的子集）
+type BlobStore interface {
+	Get(ctx context.Context, key string) ([]byte, error)
+	Put(ctx context.Context, key string, data []byte, opts ...types.PutOption) error
+	Exists(ctx context.Context, key string) (bool, error)
+}
+
+// fileKeyBackend 以本地 JSON 檔案保存金鑰
+type fileKeyBackend struct {
+	path string
+}
+
+// NewFileKeyBackend 建立本地檔案金鑰後端
+func NewFileKeyBackend(filePath string) KeyBackend {
+	return &fileKeyBackend{path: filePath}
+}
+
+func (b *fileKeyBackend) Load(ctx context.Context) ([]byte, error) {
+	data, err := os.ReadFile(b.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read api key file: %w", err)
+	}
+	return data, nil
+}
+
+func (b *fileKeyBackend) Save(ctx context.Context, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(b.path), 0700); err != nil {
+		return fmt.Errorf("failed to create api key directory: %w", err)
+	}
+	// 先寫入暫存檔再改名，避免寫入中途被讀取到不完整內容
+	tmp := b.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("failed to write api key file: %w", err)
+	}
+	if err := os.Rename(tmp, b.path); err != nil {
+		return fmt.Errorf("failed to replace api key file: %w", err)
+	}
+	return nil
+}
+
+// blobKeyBackend 以 Storage 物件保存金鑰
+type blobKeyBackend struct {
+	store BlobStore
+	key   string
+}
+
+// NewBlobKeyBackend 建立以 Storage 為後端的金鑰儲存
+func NewBlobKeyBackend(store BlobStore, key string) KeyBackend {
+	return &blobKeyBackend{store: store, key: key}
+}
+
+func (b *blobKeyBackend) Load(ctx context.Context) ([]byte, error) {
+	exists, err := b.store.Exists(ctx, b.key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check api key object: %w", err)
+	}
+	if !exists {
+		return nil, nil
+	}
+	data, err := b.store.Get(ctx, b.key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read api key object: %w", err)
+	}
+	return data, nil
+}
+
+func (b *blobKeyBackend) Save(ctx context.Context, data []byte) error {
+	if err := b.store.Put(ctx, b.key, data); err != nil {
+		return fmt.Errorf("failed to write api key object: %w", err)
+	}
+	return nil
+}
+
+// keyFile 金鑰清單檔案格式
+type keyFile struct {
+	Keys []*APIKey `json:"keys"`
+}
+
+// KeyRing API 金鑰管理器
+// 金鑰清單快取於記憶體中，並定期從後端重新載入以反映撤銷操作；
+// 重新載入失敗時繼續使用上一次成功載入的清單
+type KeyRing struct {
+	backend         KeyBackend
+	refreshInterval time.Duration
+
+	mu        sync.RWMutex
+	keys      map[string]*APIKey
+	loadedAt  time.Time
+	reloading atomic.Bool
+}
+
+// NewKeyRing 建立 API 金鑰管理器
+// refreshInterval 為 0 時每次驗證都重新載入
+func NewKeyRing(backend KeyBackend, refreshInterval time.Duration) *KeyRing {
+	return &KeyRing{
+		backend:         backend,
+		refreshInterval: refreshInterval,
+		keys:            make(map[string]*APIKey),
+	}
+}
+
+// Reload 從後端重新載入金鑰清單
+func (r *KeyRing) Reload(ctx context.Context) error {
+	data, err := r.backend.Load(ctx)
+	if err != nil {
+		return err
+	}
+
+	keys := make(map[string]*APIKey)
+	if len(data) > 0 {
+		var f keyFile
+		if err := json.Unmarshal(data, &f); err != nil {
+			return fmt.Errorf("failed to parse api keys: %w", err)
+		}
+		for _, k := range f.Keys {
+			keys[k.ID] = k
+		}
+	}
+
+	r.mu.Lock()
+	r.keys = keys
+	r.loadedAt = time.Now()
+	r.mu.Unlock()
+	return nil
+}
+
+// ensureFresh 必要時重新載入金鑰清單
+// 尚未載入過時同步載入；之後於背景重新載入（refreshInterval 為 0 時同步），
+// 失敗時記錄錯誤並繼續使用目前的清單
+func (r *KeyRing) ensureFresh(ctx context.Context) error {
+	r.mu.RLock()
+	loaded := !r.loadedAt.IsZero()
+	stale := !loaded || time.Since(r.loadedAt) >= r.refreshInterval
+	r.mu.RUnlock()
+	if !stale {
+		return nil
+	}
+	if !loaded {
+		return r.Reload(ctx)
+	}
+	if r.refreshInterval <= 0 {
+		r.reloadOrKeep(ctx)
+		return nil
+	}
+	// 同時只進行一次背景載入
+	if r.reloading.CompareAndSwap(false, true) {
+		go func() {
+			defer r.reloading.Store(false)
+			r.reloadOrKeep(context.Background())
+		}()
+	}
+	return nil
+}
+
+// reloadOrKeep 重新載入金鑰清單，失敗時保留目前的清單
+func (r *KeyRing) reloadOrKeep(ctx context.Context) {
+	if err := r.Reload(ctx); err != nil {
+		logger.Error("failed to reload api keys, serving last loaded keys", logger.Err(err))
+	}
+}
+
+// Authenticate 驗證明文金鑰
+func (r *KeyRing) Authenticate(ctx context.Context, rawKey string) (*APIKey, error) {
+	id, ok := splitAPIKey(rawKey)
+	if !ok {
+		return nil, ErrInvalidAPIKey
+	}
+	if err := r.ensureFresh(ctx); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	key, exists := r.keys[id]
+	r.mu.RUnlock()
+
+	// 即使找不到金鑰仍進行一次比較，避免以回應時間推測 ID 是否存在
+	expected := strings.Repeat("0", sha256.Size*2)
+	if exists {
+		expected = key.Hash
+	}
+	if subtle.ConstantTimeCompare([]byte(HashAPIKey(rawKey)), []byte(expected)) != 1 || !exists {
+		return nil, ErrInvalidAPIKey
+	}
+	if key.IsRevoked() {
+		return nil, ErrAPIKeyRevoked
+	}
+	return key, nil
+}
+
+// Create 建立新的 API 金鑰，回傳僅此一次可見的明文金鑰
+func (r *KeyRing) Create(ctx context.Context, name string, scopes []Scope, prefix string) (string, *APIKey, error) {
+	if len(scopes) == 0 {
+		return "", nil, fmt.Errorf("at least one scope is required")
+	}
+	if err := r.Reload(ctx); err != nil {
+		return "", nil, err
+	}
+
+	id, rawKey, err := generateAPIKey()
+	if err != nil {
+		return "", nil, err
+	}
+
+	key := &APIKey{
+		ID:        id,
+		Name:      name,
+		Hash:      HashAPIKey(rawKey),
+		Scopes:    scopes,
+		Prefix:    strings.Trim(prefix, "/"),
+		CreatedAt: time.Now().UTC(),
+	}
+
+	r.mu.Lock()
+	r.keys[id] = key
+	r.mu.Unlock()
+
+	if err := r.persist(ctx); err != nil {
+		return "", nil, err
+	}
+	return rawKey, key, nil
+}
+
+// Revoke 撤銷指定 ID 的 API 金鑰
+func (r *KeyRing) Revoke(ctx context.Context, id string) error {
+	if err := r.Reload(ctx); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	key, exists := r.keys[id]
+	if exists && !key.IsRevoked() {
+		now := time.Now().UTC()
+		key.RevokedAt = &now
+	}
+	r.mu.Unlock()
+
+	if !exists {
+		return ErrAPIKeyNotFound
+	}
+	return r.persist(ctx)
+}
+
+// List 列出所有 API 金鑰（依建立時間排序）
+func (r *KeyRing) List(ctx context.Context) ([]*APIKey, error) {
+	if err := r.Reload(ctx); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	keys := make([]*APIKey, 0, len(r.keys))
+	for _, k := range r.keys {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].CreatedAt.Before(keys[j].CreatedAt)
+	})
+	return keys, nil
+}
+
+// persist 將目前金鑰清單寫回後端
+func (r *KeyRing) persist(ctx context.Context) error {
+	r.mu.RLock()
+	f := keyFile{Keys: make([]*APIKey, 0, len(r.keys))}
+	for _, k := range r.keys {
+		f.Keys = append(f.Keys, k)
+	}
+	r.mu.RUnlock()
+
+	sort.Slice(f.Keys, func(i, j int) bool {
+		return f.Keys[i].CreatedAt.Before(f.Keys[j].CreatedAt)
+	})
+
+	data, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode api keys: %w", err)
+	}
+	return r.backend.Save(ctx, data)
+}
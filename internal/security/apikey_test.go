@@ -0,0 +1,239 @@
+package security
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/vincent119/images-filters/internal/storage/types"
+)
+
+// memBlobStore 記憶體 BlobStore
+type memBlobStore struct {
+	objects map[string][]byte
+}
+
+func (s *memBlobStore) Get(ctx context.Context, key string) ([]byte, error) {
+	data, ok := s.objects[key]
+	if !ok {
+		return nil, errors.New("not found")
+	}
+	return data, nil
+}
+
+func (s *memBlobStore) Put(ctx context.Context, key string, data []byte, opts ...types.PutOption) error {
+	s.objects[key] = data
+	return nil
+}
+
+func (s *memBlobStore) Exists(ctx context.Context, key string) (bool, error) {
+	_, ok := s.objects[key]
+	return ok, nil
+}
+
+func TestParseScopes(t *testing.T) {
+	scopes, err := ParseScopes("upload, Sign")
+	if err != nil {
+		t.Fatalf("ParseScopes error: %v", err)
+	}
+	if len(scopes) != 2 || scopes[0] != ScopeUpload || scopes[1] != ScopeSign {
+		t.Errorf("ParseScopes = %v", scopes)
+	}
+
+	if _, err := ParseScopes("upload,admin"); err == nil {
+		t.Error("ParseScopes should reject unknown scope")
+	}
+	if _, err := ParseScopes(""); err == nil {
+		t.Error("ParseScopes should reject empty scopes")
+	}
+}
+
+func TestAPIKey_AllowsPath(t *testing.T) {
+	tests := []struct {
+		prefix string
+		path   string
+		want   bool
+	}{
+		{"", "anything/x.jpg", true},
+		{"tenants/acme", "tenants/acme/uploads/x.jpg", true},
+		{"tenants/acme", "/tenants/acme/x.jpg", true},
+		{"tenants/acme", "tenants/acme2/x.jpg", false},
+		{"tenants/acme", "tenants/acme/../other/x.jpg", false},
+		{"tenants/acme", "uploads/x.jpg", false},
+	}
+
+	for _, tt := range tests {
+		k := &APIKey{Prefix: tt.prefix}
+		if got := k.AllowsPath(tt.path); got != tt.want {
+			t.Errorf("AllowsPath(%q) with prefix %q = %v; want %v", tt.path, tt.prefix, got, tt.want)
+		}
+	}
+}
+
+func TestKeyRing_FileBackend(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "keys", "api_keys.json")
+	ring := NewKeyRing(NewFileKeyBackend(path), 0)
+
+	rawKey, key, err := ring.Create(ctx, "acme", []Scope{ScopeUpload}, "/tenants/acme/")
+	if err != nil {
+		t.Fatalf("Create error: %v", err)
+	}
+	if !strings.HasPrefix(rawKey, "imgf_"+key.ID+"_") {
+		t.Errorf("raw key %q has unexpected format", rawKey)
+	}
+	if key.Prefix != "tenants/acme" {
+		t.Errorf("Prefix = %q; want tenants/acme", key.Prefix)
+	}
+	if key.Hash == rawKey || strings.Contains(key.Hash, rawKey) {
+		t.Error("raw key should not be stored")
+	}
+
+	// 新的 KeyRing 從同一檔案載入
+	other := NewKeyRing(NewFileKeyBackend(path), 0)
+	got, err := other.Authenticate(ctx, rawKey)
+	if err != nil {
+		t.Fatalf("Authenticate error: %v", err)
+	}
+	if got.ID != key.ID || !got.HasScope(ScopeUpload) || got.HasScope(ScopePurge) {
+		t.Errorf("Authenticate returned unexpected key: %+v", got)
+	}
+
+	// 錯誤的 secret
+	if _, err := other.Authenticate(ctx, "imgf_"+key.ID+"_wrong"); !errors.Is(err, ErrInvalidAPIKey) {
+		t.Errorf("wrong secret error = %v; want ErrInvalidAPIKey", err)
+	}
+	// 格式錯誤
+	if _, err := other.Authenticate(ctx, "not-a-key"); !errors.Is(err, ErrInvalidAPIKey) {
+		t.Errorf("malformed key error = %v; want ErrInvalidAPIKey", err)
+	}
+
+	// 撤銷後立即失效（refreshInterval 為 0）
+	if err := ring.Revoke(ctx, key.ID); err != nil {
+		t.Fatalf("Revoke error: %v", err)
+	}
+	if _, err := other.Authenticate(ctx, rawKey); !errors.Is(err, ErrAPIKeyRevoked) {
+		t.Errorf("revoked key error = %v; want ErrAPIKeyRevoked", err)
+	}
+
+	if err := ring.Revoke(ctx, "missing"); !errors.Is(err, ErrAPIKeyNotFound) {
+		t.Errorf("Revoke missing error = %v; want ErrAPIKeyNotFound", err)
+	}
+
+	keys, err := ring.List(ctx)
+	if err != nil {
+		t.Fatalf("List error: %v", err)
+	}
+	if len(keys) != 1 || !keys[0].IsRevoked() {
+		t.Errorf("List = %+v", keys)
+	}
+}
+
+func TestKeyRing_BlobBackend(t *testing.T) {
+	ctx := context.Background()
+	store := &memBlobStore{objects: make(map[string][]byte)}
+	ring := NewKeyRing(NewBlobKeyBackend(store, "security/api_keys.json"), 0)
+
+	// 尚無物件時應視為空清單
+	if keys, err := ring.List(ctx); err != nil || len(keys) != 0 {
+		t.Fatalf("List on empty store = %v, %v", keys, err)
+	}
+
+	rawKey, _, err := ring.Create(ctx, "ci", []Scope{ScopeSign, ScopeDetect}, "")
+	if err != nil {
+		t.Fatalf("Create error: %v", err)
+	}
+	if _, ok := store.objects["security/api_keys.json"]; !ok {
+		t.Fatal("key list should be persisted to blob store")
+	}
+
+	key, err := NewKeyRing(NewBlobKeyBackend(store, "security/api_keys.json"), 0).Authenticate(ctx, rawKey)
+	if err != nil {
+		t.Fatalf("Authenticate error: %v", err)
+	}
+	if !key.HasScope(ScopeSign) || !key.AllowsPath("any/path.jpg") {
+		t.Errorf("unexpected key: %+v", key)
+	}
+}
+
+// flakyBackend 可切換為失敗的金鑰後端
+type flakyBackend struct {
+	mu   sync.Mutex
+	data []byte
+	err  error
+}
+
+func (b *flakyBackend) Load(ctx context.Context) ([]byte, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.data, b.err
+}
+
+func (b *flakyBackend) Save(ctx context.Context, data []byte) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.data = data
+	return b.err
+}
+
+func (b *flakyBackend) set(data []byte, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.data, b.err = data, err
+}
+
+func TestKeyRing_ReloadFailureKeepsLastKeys(t *testing.T) {
+	ctx := context.Background()
+	backend := &flakyBackend{}
+	rawKey, _, err := NewKeyRing(backend, 0).Create(ctx, "acme", []Scope{ScopeUpload}, "")
+	if err != nil {
+		t.Fatalf("Create error: %v", err)
+	}
+	good := backend.data
+
+	// 首次載入失敗時回傳錯誤
+	backend.set(good, errors.New("backend unavailable"))
+	if _, err := NewKeyRing(backend, 0).Authenticate(ctx, rawKey); err == nil {
+		t.Fatal("expected error when keys were never loaded")
+	}
+
+	// 同步重新載入失敗時沿用上一次的清單
+	backend.set(good, nil)
+	ring := NewKeyRing(backend, 0)
+	if _, err := ring.Authenticate(ctx, rawKey); err != nil {
+		t.Fatalf("Authenticate error: %v", err)
+	}
+	backend.set(nil, errors.New("backend unavailable"))
+	if _, err := ring.Authenticate(ctx, rawKey); err != nil {
+		t.Errorf("Authenticate after failed reload = %v; want last loaded keys", err)
+	}
+
+	// 背景重新載入：過期後仍立即以目前清單驗證，載入完成後反映撤銷
+	backend.set(good, nil)
+	ring = NewKeyRing(backend, time.Millisecond)
+	key, err := ring.Authenticate(ctx, rawKey)
+	if err != nil {
+		t.Fatalf("Authenticate error: %v", err)
+	}
+	if err := NewKeyRing(backend, 0).Revoke(ctx, key.ID); err != nil {
+		t.Fatalf("Revoke error: %v", err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		time.Sleep(2 * time.Millisecond)
+		_, err := ring.Authenticate(ctx, rawKey)
+		if errors.Is(err, ErrAPIKeyRevoked) {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Authenticate error: %v", err)
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("background reload did not pick up the revoked key")
+		}
+	}
+}
@@ -0,0 +1,226 @@
+package service
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"path"
+	"strings"
+	"sync"
+
+	"github.com/vincent119/images-filters/internal/config"
+)
+
+// 批次上傳錯誤
+var (
+	// ErrBatchTooManyFiles 檔案數超過 upload.batch.max_files
+	ErrBatchTooManyFiles = errors.New("too many files in batch")
+	// ErrBatchFileTooLarge 單一檔案超過 upload.batch.max_file_size
+	ErrBatchFileTooLarge = errors.New("file exceeds size limit")
+	// ErrBatchInvalidArchive 封存檔無法解析
+	ErrBatchInvalidArchive = errors.New("invalid archive")
+)
+
+// 封存檔格式
+const (
+	ArchiveZip   = "zip"
+	ArchiveTar   = "tar"
+	ArchiveTarGz = "tar.gz"
+)
+
+// archiveSniffLen 判斷封存檔格式所需的檔頭長度（tar 的 "ustar" 位於 offset 257）
+const archiveSniffLen = 512
+
+// BatchUploadItem 批次上傳中的單一檔案
+type BatchUploadItem struct {
+	// Name 表單檔名或封存檔內的路徑，用於回報結果
+	Name        string
+	ContentType string
+	// Open 開啟檔案內容，由上傳的 goroutine 呼叫
+	Open func() (io.ReadCloser, error)
+	// Err 列舉時即已失敗（例如封存檔損毀），不會上傳
+	Err error
+}
+
+// BatchUploadResult 單一檔案的上傳結果（Result 與 Err 擇一）
+type BatchUploadResult struct {
+	Name   string
+	Result *UploadResult
+	Err    error
+}
+
+// UploadBatch 以有限並行度逐一呼叫 UploadImage，單一檔案失敗不影響其他檔案
+// 結果順序與 items 列舉順序相同；超過 MaxFiles 的部分回報 ErrBatchTooManyFiles 後停止列舉
+func UploadBatch(ctx context.Context, uploader ImageService, items iter.Seq[BatchUploadItem], cfg config.BatchUploadConfig, opts ...UploadOption) []BatchUploadResult {
+	concurrency := cfg.Concurrency
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	var (
+		results []*BatchUploadResult
+		wg      sync.WaitGroup
+		sem     = make(chan struct{}, concurrency)
+	)
+
+	for item := range items {
+		result := &BatchUploadResult{Name: item.Name}
+		results = append(results, result)
+
+		if cfg.MaxFiles > 0 && len(results) > cfg.MaxFiles {
+			result.Err = fmt.Errorf("%w: max %d", ErrBatchTooManyFiles, cfg.MaxFiles)
+			break
+		}
+		if item.Err != nil {
+			result.Err = item.Err
+			continue
+		}
+
+		// 取得名額後才繼續列舉，串流讀取的封存檔最多只暫存 concurrency+1 個檔案
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			result.Err = ctx.Err()
+		}
+		if result.Err != nil {
+			break
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			result.Result, result.Err = uploadBatchItem(ctx, uploader, item, cfg.MaxFileSize, opts)
+		}()
+	}
+	wg.Wait()
+
+	out := make([]BatchUploadResult, len(results))
+	for i, r := range results {
+		out[i] = *r
+	}
+	return out
+}
+
+// uploadBatchItem 上傳單一檔案，讀取超過 maxSize 時回傳 ErrBatchFileTooLarge
+func uploadBatchItem(ctx context.Context, uploader ImageService, item BatchUploadItem, maxSize int64, opts []UploadOption) (*UploadResult, error) {
+	rc, err := item.Open()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file: %w", err)
+	}
+	defer rc.Close()
+
+	var r io.Reader = rc
+	if maxSize > 0 {
+		data, err := io.ReadAll(io.LimitReader(rc, maxSize+1))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read file: %w", err)
+		}
+		if int64(len(data)) > maxSize {
+			return nil, fmt.Errorf("%w: max %d bytes", ErrBatchFileTooLarge, maxSize)
+		}
+		r = bytes.NewReader(data)
+	}
+
+	return uploader.UploadImage(ctx, path.Base(item.Name), item.ContentType, r, opts...)
+}
+
+// DetectArchive 依檔頭判斷封存檔格式，非封存檔回傳空字串
+// gzip 一律視為 tar.gz
+func DetectArchive(head []byte) string {
+	switch {
+	case bytes.HasPrefix(head, []byte("PK\x03\x04")), bytes.HasPrefix(head, []byte("PK\x05\x06")):
+		return ArchiveZip
+	case bytes.HasPrefix(head, []byte{0x1F, 0x8B}):
+		return ArchiveTarGz
+	case len(head) >= 262 && bytes.Equal(head[257:262], []byte("ustar")):
+		return ArchiveTar
+	default:
+		return ""
+	}
+}
+
+// SniffArchive 預讀串流檔頭判斷封存檔格式，回傳的 reader 包含已預讀的內容
+func SniffArchive(r io.Reader) (string, io.Reader) {
+	br := bufio.NewReaderSize(r, archiveSniffLen)
+	head, _ := br.Peek(archiveSniffLen)
+	return DetectArchive(head), br
+}
+
+// ZipEntries 列舉 zip 中的檔案，內容於上傳時才解壓縮
+// r 必須在 UploadBatch 結束前保持可讀
+func ZipEntries(r io.ReaderAt, size int64) iter.Seq[BatchUploadItem] {
+	return func(yield func(BatchUploadItem) bool) {
+		zr, err := zip.NewReader(r, size)
+		if err != nil {
+			yield(BatchUploadItem{Err: fmt.Errorf("%w: %v", ErrBatchInvalidArchive, err)})
+			return
+		}
+		for _, f := range zr.File {
+			if !f.Mode().IsRegular() || skipArchiveEntry(f.Name) {
+				continue
+			}
+			if !yield(BatchUploadItem{Name: f.Name, Open: f.Open}) {
+				return
+			}
+		}
+	}
+}
+
+// TarEntries 依序讀取 tar（gzipped 時為 tar.gz）串流中的檔案
+// tar 無法隨機存取，每個檔案列舉時即讀入記憶體，超過 maxSize 的檔案回報 ErrBatchFileTooLarge
+func TarEntries(r io.Reader, gzipped bool, maxSize int64) iter.Seq[BatchUploadItem] {
+	return func(yield func(BatchUploadItem) bool) {
+		if gzipped {
+			gz, err := gzip.NewReader(r)
+			if err != nil {
+				yield(BatchUploadItem{Err: fmt.Errorf("%w: %v", ErrBatchInvalidArchive, err)})
+				return
+			}
+			defer gz.Close()
+			r = gz
+		}
+
+		tr := tar.NewReader(r)
+		for {
+			hdr, err := tr.Next()
+			if err == io.EOF {
+				return
+			}
+			if err != nil {
+				yield(BatchUploadItem{Err: fmt.Errorf("%w: %v", ErrBatchInvalidArchive, err)})
+				return
+			}
+			if hdr.Typeflag != tar.TypeReg || skipArchiveEntry(hdr.Name) {
+				continue
+			}
+
+			item := BatchUploadItem{Name: hdr.Name}
+			if maxSize > 0 && hdr.Size > maxSize {
+				item.Err = fmt.Errorf("%w: max %d bytes", ErrBatchFileTooLarge, maxSize)
+			} else if data, err := io.ReadAll(tr); err != nil {
+				yield(BatchUploadItem{Name: hdr.Name, Err: fmt.Errorf("%w: %v", ErrBatchInvalidArchive, err)})
+				return
+			} else {
+				item.Open = func() (io.ReadCloser, error) {
+					return io.NopCloser(bytes.NewReader(data)), nil
+				}
+			}
+			if !yield(item) {
+				return
+			}
+		}
+	}
+}
+
+// skipArchiveEntry 略過 macOS 資源檔與隱藏檔
+func skipArchiveEntry(name string) bool {
+	return strings.HasPrefix(name, "__MACOSX/") || strings.HasPrefix(path.Base(name), ".")
+}
@@ -0,0 +1,243 @@
+package service
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vincent119/images-filters/internal/config"
+	"github.com/vincent119/images-filters/internal/parser"
+)
+
+// fakeUploader 記錄上傳內容並追蹤最大並行數
+type fakeUploader struct {
+	mu       sync.Mutex
+	uploaded map[string]string
+	active   atomic.Int32
+	peak     atomic.Int32
+}
+
+func (f *fakeUploader) ProcessImage(ctx context.Context, parsedURL *parser.ParsedURL) ([]byte, string, error) {
+	return nil, "", nil
+}
+
+func (f *fakeUploader) UploadImage(ctx context.Context, filename string, contentType string, reader io.Reader, opts ...UploadOption) (*UploadResult, error) {
+	n := f.active.Add(1)
+	defer f.active.Add(-1)
+	for {
+		peak := f.peak.Load()
+		if n <= peak || f.peak.CompareAndSwap(peak, n) {
+			break
+		}
+	}
+	time.Sleep(5 * time.Millisecond)
+
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+	if strings.HasPrefix(filename, "bad") {
+		return nil, ErrUploadUnsupportedFormat
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.uploaded == nil {
+		f.uploaded = make(map[string]string)
+	}
+	f.uploaded[filename] = string(data)
+	return &UploadResult{Path: "uploads/" + filename}, nil
+}
+
+func memItem(name, data string) BatchUploadItem {
+	return BatchUploadItem{
+		Name: name,
+		Open: func() (io.ReadCloser, error) {
+			return io.NopCloser(strings.NewReader(data)), nil
+		},
+	}
+}
+
+func TestUploadBatch_PartialFailureAndOrder(t *testing.T) {
+	uploader := &fakeUploader{}
+	items := []BatchUploadItem{
+		memItem("a.jpg", "a"),
+		memItem("bad.jpg", "x"),
+		{Name: "broken.zip", Err: ErrBatchInvalidArchive},
+		memItem("dir/c.jpg", "c"),
+		memItem("d.jpg", "d"),
+		memItem("e.jpg", "e"),
+	}
+
+	results := UploadBatch(context.Background(), uploader, slices.Values(items), config.BatchUploadConfig{Concurrency: 2})
+	require.Len(t, results, len(items))
+
+	for i, r := range results {
+		assert.Equal(t, items[i].Name, r.Name)
+	}
+	assert.ErrorIs(t, results[1].Err, ErrUploadUnsupportedFormat)
+	assert.ErrorIs(t, results[2].Err, ErrBatchInvalidArchive)
+	require.NoError(t, results[3].Err)
+	assert.Equal(t, "uploads/c.jpg", results[3].Result.Path, "archive directories are not part of the upload filename")
+	assert.Len(t, uploader.uploaded, 4)
+	assert.LessOrEqual(t, uploader.peak.Load(), int32(2))
+}
+
+func TestUploadBatch_Limits(t *testing.T) {
+	uploader := &fakeUploader{}
+	items := []BatchUploadItem{
+		memItem("a.jpg", "small"),
+		memItem("b.jpg", "too large"),
+		memItem("c.jpg", "c"),
+		memItem("d.jpg", "d"),
+	}
+
+	results := UploadBatch(context.Background(), uploader, slices.Values(items), config.BatchUploadConfig{
+		MaxFiles:    2,
+		Concurrency: 4,
+		MaxFileSize: 5,
+	})
+
+	// 第三個檔案超過上限後停止列舉
+	require.Len(t, results, 3)
+	require.NoError(t, results[0].Err)
+	assert.ErrorIs(t, results[1].Err, ErrBatchFileTooLarge)
+	assert.ErrorIs(t, results[2].Err, ErrBatchTooManyFiles)
+	assert.Equal(t, map[string]string{"a.jpg": "small"}, uploader.uploaded)
+}
+
+func TestUploadBatch_CancelledContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// 並行度 1 且名額已滿時，取消的請求不再上傳後續檔案
+	uploader := &fakeUploader{}
+	items := []BatchUploadItem{memItem("a.jpg", "a"), memItem("b.jpg", "b"), memItem("c.jpg", "c")}
+	results := UploadBatch(ctx, uploader, slices.Values(items), config.BatchUploadConfig{Concurrency: 1})
+
+	require.NotEmpty(t, results)
+	assert.Less(t, len(results), len(items))
+	assert.ErrorIs(t, results[len(results)-1].Err, context.Canceled)
+	assert.LessOrEqual(t, len(uploader.uploaded), 1)
+}
+
+func TestDetectArchive(t *testing.T) {
+	assert.Equal(t, ArchiveZip, DetectArchive(buildZip(t, nil)))
+	assert.Equal(t, ArchiveTarGz, DetectArchive([]byte{0x1F, 0x8B, 0x08}))
+	assert.Equal(t, ArchiveTar, DetectArchive(buildTar(t, map[string]string{"a.jpg": "a"})))
+	assert.Equal(t, "", DetectArchive(createTestJPEG(2, 2)))
+	assert.Equal(t, "", DetectArchive(nil))
+
+	archive, r := SniffArchive(bytes.NewReader(buildZip(t, nil)))
+	assert.Equal(t, ArchiveZip, archive)
+	data, err := io.ReadAll(r)
+	require.NoError(t, err)
+	assert.Equal(t, buildZip(t, nil), data, "sniffing must not consume the stream")
+}
+
+func TestZipEntries(t *testing.T) {
+	data := buildZip(t, map[string]string{
+		"gallery/a.jpg":          "a",
+		"gallery/b.png":          "b",
+		"__MACOSX/gallery/a.jpg": "resource fork",
+		"gallery/.DS_Store":      "finder",
+	})
+
+	entries := collectEntries(t, ZipEntries(bytes.NewReader(data), int64(len(data))))
+	assert.Equal(t, map[string]string{"gallery/a.jpg": "a", "gallery/b.png": "b"}, entries)
+
+	var items []BatchUploadItem
+	for item := range ZipEntries(strings.NewReader("PK\x03\x04garbage"), 13) {
+		items = append(items, item)
+	}
+	require.Len(t, items, 1)
+	assert.ErrorIs(t, items[0].Err, ErrBatchInvalidArchive)
+}
+
+func TestTarEntries(t *testing.T) {
+	files := map[string]string{"a.jpg": "a", "sub/b.jpg": "bb", "sub/.hidden": "h"}
+
+	entries := collectEntries(t, TarEntries(bytes.NewReader(buildTar(t, files)), false, 0))
+	assert.Equal(t, map[string]string{"a.jpg": "a", "sub/b.jpg": "bb"}, entries)
+
+	var gz bytes.Buffer
+	zw := gzip.NewWriter(&gz)
+	_, err := zw.Write(buildTar(t, files))
+	require.NoError(t, err)
+	require.NoError(t, zw.Close())
+	entries = collectEntries(t, TarEntries(&gz, true, 0))
+	assert.Equal(t, map[string]string{"a.jpg": "a", "sub/b.jpg": "bb"}, entries)
+
+	// 超過大小上限的檔案回報錯誤，其餘照常列舉
+	var items []BatchUploadItem
+	for item := range TarEntries(bytes.NewReader(buildTar(t, files)), false, 1) {
+		items = append(items, item)
+	}
+	require.Len(t, items, 2)
+	assert.NoError(t, items[0].Err)
+	assert.ErrorIs(t, items[1].Err, ErrBatchFileTooLarge)
+}
+
+func buildZip(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, name := range sortedKeys(files) {
+		w, err := zw.Create(name)
+		require.NoError(t, err)
+		_, err = w.Write([]byte(files[name]))
+		require.NoError(t, err)
+	}
+	require.NoError(t, zw.Close())
+	return buf.Bytes()
+}
+
+func buildTar(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	require.NoError(t, tw.WriteHeader(&tar.Header{Name: "sub/", Typeflag: tar.TypeDir, Mode: 0o755}))
+	for _, name := range sortedKeys(files) {
+		require.NoError(t, tw.WriteHeader(&tar.Header{Name: name, Mode: 0o644, Size: int64(len(files[name]))}))
+		_, err := tw.Write([]byte(files[name]))
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.Close())
+	return buf.Bytes()
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+func collectEntries(t *testing.T, items func(func(BatchUploadItem) bool)) map[string]string {
+	t.Helper()
+	entries := make(map[string]string)
+	for item := range items {
+		require.NoError(t, item.Err)
+		rc, err := item.Open()
+		require.NoError(t, err)
+		data, err := io.ReadAll(rc)
+		require.NoError(t, err)
+		rc.Close()
+		entries[item.Name] = string(data)
+	}
+	return entries
+}
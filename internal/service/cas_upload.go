@@ -0,0 +1,102 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"path"
+	"path/filepath"
+	"time"
+
+	"github.com/vincent119/images-filters/internal/processor"
+	"github.com/vincent119/images-filters/internal/storage/types"
+)
+
+const (
+	// casDir 內容定址儲存目錄
+	casDir = "cas"
+	// casNamesDir 檔名對應內容雜湊的 manifest 目錄（每個檔名一個子目錄，每個內容雜湊一個 manifest）
+	casNamesDir = "cas/names"
+)
+
+// casManifest 使用者檔名與內容雜湊的對應
+type casManifest struct {
+	Name        string    `json:"name"`
+	Hash        string    `json:"hash"`
+	Path        string    `json:"path"`
+	ContentType string    `json:"content_type"`
+	Size        int       `json:"size"`
+	UploadedAt  time.Time `json:"uploaded_at"`
+}
+
+// casPath 產生內容定址路徑 ([{prefix}/]cas/ab/cdef...{ext})
+// 載入器依副檔名判斷是否為圖片，因此使用依內容判斷的格式副檔名，相同內容不論上傳檔名皆對應同一路徑
+func casPath(prefix, hash, format string) string {
+	p := path.Join(casDir, hash[:2], hash[2:]+processor.Extension(format))
+	if prefix != "" {
+		p = prefix + "/" + p
+	}
+	return p
+}
+
+// casManifestPath 產生檔名 manifest 路徑 ([{prefix}/]cas/names/{檔名雜湊}/{內容雜湊}.json)
+// 以檔名加內容雜湊命名，同名但內容不同的上傳各自保留 manifest，不互相覆寫
+func casManifestPath(prefix, filename, hash string) string {
+	sum := sha256.Sum256([]byte(filename))
+	p := path.Join(casNamesDir, hex.EncodeToString(sum[:]), hash+".json")
+	if prefix != "" {
+		p = prefix + "/" + p
+	}
+	return p
+}
+
+// uploadContentAddressable 依內容雜湊儲存上傳檔案
+// 相同內容已存在時不重複寫入，回傳既有路徑
+func (s *imageService) uploadContentAddressable(ctx context.Context, filename, format string, data []byte, prefix string) (*UploadResult, error) {
+	sum := sha256.Sum256(data)
+	hash := hex.EncodeToString(sum[:])
+	savedPath := casPath(prefix, hash, format)
+	contentType := processor.GetContentType(format)
+
+	exists, err := s.storage.Exists(ctx, savedPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check existing upload: %w", err)
+	}
+
+	if !exists {
+		if err := s.storage.Put(ctx, savedPath, data, types.WithContentType(contentType)); err != nil {
+			if s.metrics != nil {
+				s.metrics.RecordError("upload_error")
+			}
+			return nil, fmt.Errorf("failed to upload image: %w", err)
+		}
+		if s.metrics != nil {
+			s.metrics.RecordStorageOperation("local", "put")
+		}
+	}
+
+	manifest, err := json.Marshal(casManifest{
+		Name:        filepath.Base(filename),
+		Hash:        hash,
+		Path:        savedPath,
+		ContentType: contentType,
+		Size:        len(data),
+		UploadedAt:  time.Now().UTC(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode upload manifest: %w", err)
+	}
+	manifestPath := casManifestPath(prefix, filepath.Base(filename), hash)
+	if err := s.storage.Put(ctx, manifestPath, manifest, types.WithContentType("application/json")); err != nil {
+		return nil, fmt.Errorf("failed to save upload manifest: %w", err)
+	}
+
+	return &UploadResult{
+		Path:      savedPath,
+		SignedURL: s.generateSignedURL(savedPath),
+		Hash:      hash,
+		Duplicate: exists,
+	}, nil
+}
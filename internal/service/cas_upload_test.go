@@ -0,0 +1,128 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"image"
+	"strings"
+	"testing"
+
+	"github.com/disintegration/imaging"
+	"github.com/vincent119/images-filters/internal/config"
+)
+
+func newCASTestService() (*imageService, *MockStorage) {
+	cfg := &config.Config{
+		Security: config.SecurityConfig{Enabled: false},
+		Upload:   config.UploadConfig{ContentAddressable: true},
+	}
+	store := NewMockStorage()
+	svc := NewImageService(cfg, store, NewMockCache()).(*imageService)
+	return svc, store
+}
+
+func TestUploadImage_ContentAddressable(t *testing.T) {
+	svc, store := newCASTestService()
+	ctx := context.Background()
+	content := createTestImage(4, 4) // PNG
+
+	first, err := svc.UploadImage(ctx, "photo.PNG", "image/png", bytes.NewReader(content))
+	if err != nil {
+		t.Fatalf("UploadImage failed: %v", err)
+	}
+	wantPath := "cas/" + first.Hash[:2] + "/" + first.Hash[2:] + ".png"
+	if first.Path != wantPath {
+		t.Errorf("Path = %s, want %s", first.Path, wantPath)
+	}
+	if first.Duplicate {
+		t.Error("first upload should not be a duplicate")
+	}
+	if first.SignedURL != "/unsafe/"+wantPath {
+		t.Errorf("SignedURL = %s", first.SignedURL)
+	}
+
+	// 相同內容以不同檔名上傳，回傳既有路徑
+	second, err := svc.UploadImage(ctx, "copy.png", "image/png", bytes.NewReader(content))
+	if err != nil {
+		t.Fatalf("UploadImage failed: %v", err)
+	}
+	if second.Path != first.Path || !second.Duplicate {
+		t.Errorf("duplicate upload = %+v, want path %s and Duplicate", second, first.Path)
+	}
+
+	blobs := 0
+	for key := range store.data {
+		if strings.HasPrefix(key, "cas/") && !strings.HasPrefix(key, casNamesDir) {
+			blobs++
+		}
+	}
+	if blobs != 1 {
+		t.Errorf("stored %d content blobs, want 1", blobs)
+	}
+
+	// 檔名 manifest 指向內容雜湊
+	raw, ok := store.data[casManifestPath("", "copy.png", first.Hash)]
+	if !ok {
+		t.Fatal("manifest not saved")
+	}
+	var manifest casManifest
+	if err := json.Unmarshal(raw, &manifest); err != nil {
+		t.Fatalf("invalid manifest: %v", err)
+	}
+	if manifest.Name != "copy.png" || manifest.Hash != first.Hash || manifest.Path != first.Path {
+		t.Errorf("manifest = %+v", manifest)
+	}
+}
+
+func TestUploadImage_ContentAddressableWithPrefix(t *testing.T) {
+	svc, store := newCASTestService()
+	var buf bytes.Buffer
+	if err := imaging.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 2, 2)), imaging.BMP); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := svc.UploadImage(context.Background(), "a.BMP", "image/bmp", &buf, WithUploadPrefix("tenant-a"))
+	if err != nil {
+		t.Fatalf("UploadImage failed: %v", err)
+	}
+	// 副檔名依內容判斷的格式決定
+	if !strings.HasPrefix(result.Path, "tenant-a/cas/") || !strings.HasSuffix(result.Path, ".bmp") {
+		t.Errorf("Path = %s", result.Path)
+	}
+	if _, ok := store.data[casManifestPath("tenant-a", "a.BMP", result.Hash)]; !ok {
+		t.Error("manifest should be stored under the tenant prefix")
+	}
+}
+
+func TestUploadImage_ContentAddressableSameName(t *testing.T) {
+	svc, store := newCASTestService()
+	ctx := context.Background()
+
+	// 同一檔名上傳不同內容，兩個內容雜湊的 manifest 皆保留
+	first, err := svc.UploadImage(ctx, "avatar.png", "image/png", bytes.NewReader(createTestImage(4, 4)))
+	if err != nil {
+		t.Fatalf("UploadImage failed: %v", err)
+	}
+	second, err := svc.UploadImage(ctx, "avatar.png", "image/png", bytes.NewReader(createTestImage(8, 8)))
+	if err != nil {
+		t.Fatalf("UploadImage failed: %v", err)
+	}
+	if first.Hash == second.Hash {
+		t.Fatal("different content should have different hashes")
+	}
+
+	for _, result := range []*UploadResult{first, second} {
+		raw, ok := store.data[casManifestPath("", "avatar.png", result.Hash)]
+		if !ok {
+			t.Fatalf("manifest for hash %s not saved", result.Hash)
+		}
+		var manifest casManifest
+		if err := json.Unmarshal(raw, &manifest); err != nil {
+			t.Fatalf("invalid manifest: %v", err)
+		}
+		if manifest.Name != "avatar.png" || manifest.Hash != result.Hash || manifest.Path != result.Path {
+			t.Errorf("manifest = %+v", manifest)
+		}
+	}
+}
@@ -0,0 +1,187 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/vincent119/images-filters/internal/parser"
+	"github.com/vincent119/images-filters/internal/processor"
+	"github.com/vincent119/images-filters/pkg/logger"
+)
+
+// ErrInvalidVariant 預先產生的項目不是已知的預設名稱，也不是合法的處理選項
+var ErrInvalidVariant = errors.New("invalid variant")
+
+const (
+	// variantPlaceholder 驗證處理選項時使用的圖片路徑
+	variantPlaceholder = "uploads/variant.jpg"
+	// defaultMaxPendingVariants 未設定時等待或正在產生的項目上限
+	defaultMaxPendingVariants = 64
+)
+
+// eagerVariant 解析後的預先產生項目
+type eagerVariant struct {
+	name    string
+	options string
+}
+
+// resolveVariants 決定上傳後要預先產生的項目
+// 優先使用上傳時指定的項目，其次為最長相符的前綴設定，最後為 default
+func (s *imageService) resolveVariants(prefix string, requested []string) ([]eagerVariant, error) {
+	cfg := s.cfg.Upload.Variants
+
+	names := requested
+	if len(names) == 0 {
+		names = cfg.Default
+		matched := -1
+		for _, rule := range cfg.Prefixes {
+			p := strings.Trim(rule.Prefix, "/")
+			if (prefix == p || strings.HasPrefix(prefix, p+"/")) && len(p) > matched {
+				names, matched = rule.Variants, len(p)
+			}
+		}
+	}
+
+	urlParser := parser.NewURLParser()
+	variants := make([]eagerVariant, 0, len(names))
+	seen := make(map[string]bool, len(names))
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+
+		options := name
+		if preset, ok := lookupPreset(cfg.Presets, name); ok {
+			options = preset
+		}
+		options = strings.Trim(options, "/")
+
+		// 處理選項必須能完整解析，否則部分選項會被誤認為圖片路徑
+		parsed, err := urlParser.Parse("unsafe/" + options + "/" + variantPlaceholder)
+		if options == "" || err != nil || parsed.ImagePath != variantPlaceholder {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidVariant, name)
+		}
+
+		if seen[options] {
+			continue
+		}
+		seen[options] = true
+		variants = append(variants, eagerVariant{name: name, options: options})
+	}
+
+	if cfg.MaxPerUpload > 0 && len(variants) > cfg.MaxPerUpload {
+		return nil, fmt.Errorf("%w: %d variants requested, at most %d allowed", ErrInvalidVariant, len(variants), cfg.MaxPerUpload)
+	}
+	return variants, nil
+}
+
+// lookupPreset 依名稱取得預設的處理選項（viper 會將 map 鍵轉為小寫）
+func lookupPreset(presets map[string]string, name string) (string, bool) {
+	if options, ok := presets[name]; ok {
+		return options, true
+	}
+	options, ok := presets[strings.ToLower(name)]
+	return options, ok
+}
+
+// renderVariants 於背景產生處理結果並回傳各項目的簽名 URL
+// 結果寫入與 GET 請求相同的鍵值，首次存取即可命中快取或儲存；
+// 未指定 format 濾鏡時僅產生副檔名對應的格式，以 Accept 協商 AVIF/WebP 的請求不會命中。
+// 等待中的項目達到上限時略過，改於首次存取時產生
+func (s *imageService) renderVariants(savedPath string, data []byte, variants []eagerVariant) []VariantResult {
+	if len(variants) == 0 {
+		return nil
+	}
+
+	urlParser := parser.NewURLParser()
+	results := make([]VariantResult, 0, len(variants))
+	for _, v := range variants {
+		parsed, err := urlParser.Parse("unsafe/" + v.options + "/" + savedPath)
+		if err != nil || parsed.ImagePath != savedPath {
+			logger.Warn("failed to parse variant",
+				logger.String("saved_path", savedPath),
+				logger.String("options", v.options),
+				logger.Err(err),
+			)
+			continue
+		}
+
+		results = append(results, VariantResult{
+			Name:      v.name,
+			Options:   v.options,
+			SignedURL: s.generateSignedURL(v.options + "/" + savedPath),
+		})
+
+		select {
+		case s.variantSlots <- struct{}{}:
+			go func() {
+				defer func() { <-s.variantSlots }()
+				// 項目只驗證選項格式，濾鏡參數造成的 panic 不可使整個程序結束
+				defer func() {
+					if r := recover(); r != nil {
+						logger.Error("variant render panicked",
+							logger.String("image_path", parsed.ImagePath),
+							logger.String("options", v.options),
+							logger.Any("panic", r),
+						)
+						if s.metrics != nil {
+							s.metrics.RecordError("variant_panic")
+						}
+					}
+				}()
+				s.renderVariant(parsed, data)
+			}()
+		default:
+			logger.Warn("variant render queue full, skipping",
+				logger.String("saved_path", savedPath),
+				logger.String("options", v.options),
+			)
+			if s.metrics != nil {
+				s.metrics.RecordError("variant_skipped")
+			}
+		}
+	}
+	return results
+}
+
+// renderVariant 使用 worker pool 產生單一處理結果並寫入儲存與快取
+func (s *imageService) renderVariant(parsedURL *parser.ParsedURL, data []byte) {
+	ctx := context.Background()
+	key := s.resultKey(ctx, parsedURL)
+
+	// 內容定址上傳的重複內容已產生過
+	if exists, err := s.storage.Exists(ctx, key); err == nil && exists {
+		return
+	}
+
+	s.sem <- struct{}{}
+	defer func() { <-s.sem }()
+
+	output, format, err := s.processAndEncode(bytes.NewReader(data), parsedURL)
+	if err != nil {
+		logger.Warn("failed to render variant",
+			logger.String("image_path", parsedURL.ImagePath),
+			logger.String("key", key),
+			logger.Err(err),
+		)
+		if s.metrics != nil {
+			s.metrics.RecordError("variant_error")
+		}
+		return
+	}
+
+	if s.metrics != nil {
+		s.metrics.RecordImageProcessed(format, int64(len(output)))
+	}
+	s.save(parsedURL.ImagePath, key, output, 0)
+
+	logger.Debug("variant rendered",
+		logger.String("image_path", parsedURL.ImagePath),
+		logger.String("key", key),
+		logger.String("content_type", processor.GetContentType(format)),
+	)
+}
@@ -0,0 +1,163 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vincent119/images-filters/internal/config"
+	"github.com/vincent119/images-filters/internal/parser"
+	"github.com/vincent119/images-filters/internal/storage"
+)
+
+func newVariantTestService(t *testing.T, variants config.UploadVariantsConfig) (*imageService, *storage.LocalStorage) {
+	t.Helper()
+	store, err := storage.NewLocalStorage(t.TempDir())
+	require.NoError(t, err)
+
+	cfg := &config.Config{
+		Processing: config.ProcessingConfig{DefaultQuality: 80, DefaultFormat: "jpeg", Workers: 2},
+		Upload:     config.UploadConfig{Variants: variants},
+	}
+	return NewImageService(cfg, store, NewMockCache()).(*imageService), store
+}
+
+func TestResolveVariants(t *testing.T) {
+	svc, _ := newVariantTestService(t, config.UploadVariantsConfig{
+		Presets: map[string]string{"thumb": "fit-in/100x100/filters:format(webp)"},
+		Default: []string{"thumb"},
+		Prefixes: []config.UploadVariantsPrefixConfig{
+			{Prefix: "tenants", Variants: []string{"200x0"}},
+			{Prefix: "tenants/acme", Variants: []string{"300x0", "Thumb"}},
+		},
+		MaxPerUpload: 2,
+	})
+
+	tests := []struct {
+		name      string
+		prefix    string
+		requested []string
+		want      []string
+		wantErr   bool
+	}{
+		{name: "default", want: []string{"fit-in/100x100/filters:format(webp)"}},
+		{name: "longest prefix", prefix: "tenants/acme", want: []string{"300x0", "fit-in/100x100/filters:format(webp)"}},
+		{name: "parent prefix", prefix: "tenants/other", want: []string{"200x0"}},
+		{name: "prefix must match a whole segment", prefix: "tenantsx", want: []string{"fit-in/100x100/filters:format(webp)"}},
+		{name: "requested overrides config", prefix: "tenants/acme", requested: []string{"/50x50/smart/", "50x50/smart"}, want: []string{"50x50/smart"}},
+		{name: "unknown preset", requested: []string{"medium"}, wantErr: true},
+		{name: "invalid filter", requested: []string{"filters:blur("}, wantErr: true},
+		{name: "too many", requested: []string{"10x10", "20x20", "30x30"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			variants, err := svc.resolveVariants(tt.prefix, tt.requested)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrInvalidVariant)
+				return
+			}
+			require.NoError(t, err)
+			var got []string
+			for _, v := range variants {
+				got = append(got, v.options)
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestUploadImage_EagerVariants(t *testing.T) {
+	svc, store := newVariantTestService(t, config.UploadVariantsConfig{})
+	ctx := context.Background()
+
+	result, err := svc.UploadImage(ctx, "a.jpg", "image/jpeg", bytes.NewReader(createTestJPEG(64, 32)), WithVariants("32x16"))
+	require.NoError(t, err)
+	require.Len(t, result.Variants, 1)
+	assert.Equal(t, "32x16", result.Variants[0].Options)
+	assert.Equal(t, "/unsafe/32x16/"+result.Path, result.Variants[0].SignedURL)
+
+	// 背景產生的結果寫入與 GET 請求相同的鍵值
+	parsed, err := parser.NewURLParser().Parse(result.Variants[0].SignedURL)
+	require.NoError(t, err)
+	key := svc.resultKey(ctx, parsed)
+	assert.Eventually(t, func() bool {
+		exists, err := store.Exists(ctx, key)
+		return err == nil && exists
+	}, 5*time.Second, 10*time.Millisecond)
+
+	data, err := store.Get(ctx, key)
+	require.NoError(t, err)
+	img, _, err := image.Decode(bytes.NewReader(data))
+	require.NoError(t, err)
+	assert.Equal(t, image.Pt(32, 16), img.Bounds().Size())
+}
+
+func TestUploadImage_InvalidVariantRejected(t *testing.T) {
+	svc, store := newVariantTestService(t, config.UploadVariantsConfig{})
+
+	_, err := svc.UploadImage(context.Background(), "a.jpg", "image/jpeg", bytes.NewReader(createTestJPEG(8, 8)), WithVariants("not-an-option"))
+	assert.ErrorIs(t, err, ErrInvalidVariant)
+
+	exists, err := store.Exists(context.Background(), "uploads")
+	require.NoError(t, err)
+	assert.False(t, exists)
+}
+
+func TestRenderVariants_SkipsWhenQueueFull(t *testing.T) {
+	svc, store := newVariantTestService(t, config.UploadVariantsConfig{MaxPending: 1})
+	ctx := context.Background()
+	require.Equal(t, 1, cap(svc.variantSlots))
+
+	// 佔用唯一的名額：項目仍回傳簽名 URL，但不於背景產生
+	svc.variantSlots <- struct{}{}
+	results := svc.renderVariants("uploads/a.jpg", createTestJPEG(64, 32), []eagerVariant{{name: "32x16", options: "32x16"}})
+	<-svc.variantSlots
+	require.Len(t, results, 1)
+
+	parsed, err := parser.NewURLParser().Parse(results[0].SignedURL)
+	require.NoError(t, err)
+	key := svc.resultKey(ctx, parsed)
+	time.Sleep(50 * time.Millisecond)
+	exists, err := store.Exists(ctx, key)
+	require.NoError(t, err)
+	assert.False(t, exists)
+
+	// 名額釋放後正常產生
+	svc.renderVariants("uploads/a.jpg", createTestJPEG(64, 32), []eagerVariant{{name: "32x16", options: "32x16"}})
+	assert.Eventually(t, func() bool {
+		exists, err := store.Exists(ctx, key)
+		return err == nil && exists
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.Eventually(t, func() bool { return len(svc.variantSlots) == 0 }, 5*time.Second, 10*time.Millisecond)
+}
+
+// panicFilter 套用時 panic 的濾鏡
+type panicFilter struct{}
+
+func (panicFilter) Name() string { return "boom" }
+
+func (panicFilter) Apply(img image.Image, params []string) (image.Image, error) {
+	panic("boom")
+}
+
+func TestRenderVariants_RecoversFromPanic(t *testing.T) {
+	svc, store := newVariantTestService(t, config.UploadVariantsConfig{MaxPending: 1})
+	require.NoError(t, svc.filters.Register(panicFilter{}))
+
+	// 背景產生時 panic 不會結束程序，名額仍會釋放
+	results := svc.renderVariants("uploads/a.jpg", createTestJPEG(64, 32), []eagerVariant{{name: "boom", options: "filters:boom()"}})
+	require.Len(t, results, 1)
+	assert.Eventually(t, func() bool { return len(svc.variantSlots) == 0 }, 5*time.Second, 10*time.Millisecond)
+
+	parsed, err := parser.NewURLParser().Parse(results[0].SignedURL)
+	require.NoError(t, err)
+	exists, err := store.Exists(context.Background(), svc.resultKey(context.Background(), parsed))
+	require.NoError(t, err)
+	assert.False(t, exists)
+}
@@ -5,9 +5,12 @@ import (
 	"context"
 	"crypto/sha256"
 	"encoding/hex"
+	"errors"
 	"fmt"
+	"image"
 	"io"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 
@@ -19,8 +22,11 @@ import (
 	"github.com/vincent119/images-filters/internal/metrics"
 	"github.com/vincent119/images-filters/internal/parser"
 	"github.com/vincent119/images-filters/internal/processor"
+	"github.com/vincent119/images-filters/internal/purge"
+	"github.com/vincent119/images-filters/internal/resilience"
 	"github.com/vincent119/images-filters/internal/security"
 	"github.com/vincent119/images-filters/internal/storage"
+	"github.com/vincent119/images-filters/internal/storage/types"
 	"github.com/vincent119/images-filters/pkg/logger"
 )
 
@@ -33,18 +39,77 @@ type imageService struct {
 	storage   storage.Storage
 	cache     cache.Cache
 	sem       chan struct{} // Semaphore for concurrency control
+	filters   *filter.Registry
+
+	// httpLoader 用於判斷請求是否轉發用戶端標頭至來源
+	httpLoader *loader.HTTPLoader
+	// sourceCache 遠端來源快取（未啟用時為 nil）
+	sourceCache  *loader.SourceCache
+	variantIndex purge.Index
+	// variantSlots 限制等待或正在產生的預先產生項目數量（每個項目持有上傳內容）
+	variantSlots chan struct{}
+	versions     *sourceVersions // nil 表示快取鍵不含來源版本
 }
 
+// uploadHeaderSize 串流上傳時用於驗證格式與尺寸的檔頭大小
+const uploadHeaderSize = 1 << 20
+
 // UploadImage 上傳圖片並回傳簽名 URL
-func (s *imageService) UploadImage(ctx context.Context, filename string, contentType string, reader io.Reader) (*UploadResult, error) {
-	var inputReader io.Reader = reader
+func (s *imageService) UploadImage(ctx context.Context, filename string, contentType string, reader io.Reader, opts ...UploadOption) (*UploadResult, error) {
+	options := applyUploadOptions(opts)
+
+	// 預先產生的處理結果需在儲存前驗證，避免不合法的選項留下上傳檔案
+	variants, err := s.resolveVariants(options.prefix, options.variants)
+	if err != nil {
+		return nil, err
+	}
+
+	head, err := io.ReadAll(io.LimitReader(reader, uploadHeaderSize))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read upload: %w", err)
+	}
+
+	// 不需要完整內容時僅以檔頭驗證並串流寫入，避免大型上傳（例如可續傳上傳）整個讀入記憶體；
+	// SVG 需解析整份文件，檔頭不足以解析尺寸時也改讀入完整內容
+	if len(head) == uploadHeaderSize && len(variants) == 0 && s.streamableUpload() && processor.DetectFormat(head) != processor.FormatSVG {
+		upload, err := s.validateUpload(filename, contentType, head)
+		switch {
+		case err == nil:
+			return s.storeUpload(ctx, upload.filename, upload.format, io.MultiReader(bytes.NewReader(head), reader), options.prefix)
+		case !errors.Is(err, ErrUploadInvalidImage):
+			return nil, s.rejectUpload(filename, err)
+		}
+	}
+
+	rest, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read upload: %w", err)
+	}
+	data := append(head, rest...)
+
+	// 0. 依實際內容驗證格式與尺寸，不信任用戶端提供的 Content-Type
+	upload, err := s.validateUpload(filename, contentType, data)
+	if err != nil {
+		return nil, s.rejectUpload(filename, err)
+	}
+	filename = upload.filename
+
+	// 重新編碼以移除附加內容（浮水印流程本身即會重新編碼）
+	if s.cfg.Upload.Reencode && !s.cfg.BlindWatermark.Enabled {
+		if err := s.reencodeUpload(upload); err != nil {
+			return nil, err
+		}
+		filename = upload.filename
+	}
 
-	// 0. 檢查是否啟用隱形浮水印
+	stored := upload.data
+
+	// 檢查是否啟用隱形浮水印
 	if s.cfg.BlindWatermark.Enabled {
 		logger.Debug("applying blind watermark", logger.String("filename", filename))
 
 		// 必須解碼圖片才能處理
-		img, err := imaging.Decode(reader)
+		img, _, err := processor.Decode(upload.data)
 		if err != nil {
 			return nil, fmt.Errorf("failed to decode image for watermarking: %w", err)
 		}
@@ -52,7 +117,7 @@ func (s *imageService) UploadImage(ctx context.Context, filename string, content
 		// 建立濾鏡
 		bwFilter := filter.NewBlindWatermarkFilter()
 		bwFilter.Text = s.cfg.BlindWatermark.Text
-		bwFilter.SecurityKey = s.cfg.BlindWatermark.SecurityKey
+		bwFilter.SecurityKey = blindWatermarkKey(s.cfg)
 
 		// 應用濾鏡
 		// 這裡不傳 params，使用 config 設定的預設值
@@ -65,19 +130,60 @@ func (s *imageService) UploadImage(ctx context.Context, filename string, content
 		buf := new(bytes.Buffer)
 		format, err := imaging.FormatFromExtension(filepath.Ext(filename))
 		if err != nil {
-			// Fallback to JPEG if unknown
+			// Fallback to JPEG if unknown，副檔名一併改為 .jpg 以符合實際內容
 			format = imaging.JPEG
+			filename = strings.TrimSuffix(filename, filepath.Ext(filename)) + processor.Extension(processor.FormatJPEG)
+			upload.format = processor.FormatJPEG
 		}
 
 		if err := imaging.Encode(buf, watermarkedImg, format); err != nil {
 			return nil, fmt.Errorf("failed to encode watermarked image: %w", err)
 		}
 
-		// 使用新的 reader
-		inputReader = buf
+		// 使用浮水印後的內容
+		stored = buf.Bytes()
+	}
+
+	// 內容定址模式：依內容雜湊儲存並去除重複
+	if s.cfg.Upload.ContentAddressable {
+		result, err := s.uploadContentAddressable(ctx, filename, upload.format, stored, options.prefix)
+		if err != nil {
+			logger.Error("failed to upload image", logger.String("filename", filename), logger.Err(err))
+			return nil, err
+		}
+		logger.Info("image uploaded successfully",
+			logger.String("saved_path", result.Path),
+			logger.Bool("duplicate", result.Duplicate),
+		)
+		result.Variants = s.renderVariants(result.Path, stored, variants)
+		return result, nil
+	}
+
+	result, err := s.storeUpload(ctx, filename, upload.format, bytes.NewReader(stored), options.prefix)
+	if err != nil {
+		return nil, err
+	}
+	result.Variants = s.renderVariants(result.Path, stored, variants)
+	return result, nil
+}
+
+// streamableUpload 上傳流程是否不需要完整內容（重新編碼、隱形浮水印與內容定址皆需讀入完整內容）
+func (s *imageService) streamableUpload() bool {
+	return !s.cfg.Upload.Reencode && !s.cfg.BlindWatermark.Enabled && !s.cfg.Upload.ContentAddressable
+}
+
+// rejectUpload 記錄被拒絕的上傳並回傳原錯誤
+func (s *imageService) rejectUpload(filename string, err error) error {
+	logger.Warn("upload rejected", logger.String("filename", filename), logger.Err(err))
+	if s.metrics != nil {
+		s.metrics.RecordError("upload_rejected")
 	}
+	return err
+}
 
-	// 1. 產生儲存路徑 (uploads/{date}/{hash}_{filename})
+// storeUpload 以串流寫入已驗證的上傳內容並回傳簽名 URL
+func (s *imageService) storeUpload(ctx context.Context, filename, format string, r io.Reader, prefix string) (*UploadResult, error) {
+	// 1. 產生儲存路徑 ([{prefix}/]uploads/{date}/{hash}_{filename})
 	now := time.Now()
 	datePrefix := now.Format("2006/01/02")
 
@@ -87,15 +193,18 @@ func (s *imageService) UploadImage(ctx context.Context, filename string, content
 
 	// 組合完整路徑
 	savedPath := fmt.Sprintf("uploads/%s/%s_%s", datePrefix, hashStr, filepath.Base(filename))
+	if prefix != "" {
+		savedPath = prefix + "/" + savedPath
+	}
 
 	logger.Debug("uploading image",
 		logger.String("filename", filename),
 		logger.String("saved_path", savedPath),
-		logger.String("content_type", contentType),
+		logger.String("format", format),
 	)
 
 	// 2. 儲存至 Storage
-	if err := s.storage.PutStream(ctx, savedPath, inputReader); err != nil {
+	if err := s.storage.PutStream(ctx, savedPath, r, types.WithContentType(processor.GetContentType(format))); err != nil {
 		logger.Error("failed to upload image",
 			logger.String("saved_path", savedPath),
 			logger.Err(err),
@@ -126,6 +235,65 @@ func (s *imageService) UploadImage(ctx context.Context, filename string, content
 	}, nil
 }
 
+// buildHostProfiles 將設定轉換為載入器的來源主機 Profile
+func buildHostProfiles(profiles []config.LoaderProfileConfig) []*loader.HostProfile {
+	result := make([]*loader.HostProfile, 0, len(profiles))
+	for _, p := range profiles {
+		profile := &loader.HostProfile{
+			Name:           p.Name,
+			Matcher:        security.NewSourceValidator(p.Hosts),
+			UserAgent:      p.UserAgent,
+			Headers:        p.Headers,
+			ForwardHeaders: p.ForwardHeaders,
+			CertFile:       p.TLS.CertFile,
+			KeyFile:        p.TLS.KeyFile,
+			CAFile:         p.TLS.CAFile,
+			Timeout:        p.Timeout,
+			MaxSize:        p.MaxSize,
+		}
+		switch p.Auth.Type {
+		case "bearer":
+			profile.BearerToken = p.Auth.Token
+		case "basic":
+			profile.BasicUsername = p.Auth.Username
+			profile.BasicPassword = p.Auth.Password
+		}
+		result = append(result, profile)
+	}
+	return result
+}
+
+// loaderResilienceOptions 依設定建立 HTTP 載入器的重試、斷路器與對沖請求選項
+// metricHost 將斷路器的目標主機轉為指標標籤
+func loaderResilienceOptions(cfg config.LoaderResilienceConfig, m metrics.Metrics, metricHost func(host string) string) []loader.HTTPLoaderOption {
+	opts := []loader.HTTPLoaderOption{
+		loader.WithRetryPolicy(resilience.RetryPolicy{
+			MaxAttempts: cfg.Retry.MaxAttempts,
+			BaseDelay:   cfg.Retry.BaseDelay,
+			MaxDelay:    cfg.Retry.MaxDelay,
+		}),
+	}
+	if cfg.CircuitBreaker.Enabled {
+		opts = append(opts, loader.WithCircuitBreaker(resilience.NewBreakerGroup(
+			cfg.CircuitBreaker.FailureThreshold,
+			cfg.CircuitBreaker.Cooldown,
+			func(target string, state resilience.State) {
+				logger.Warn("origin circuit breaker state changed",
+					logger.String("host", target),
+					logger.String("state", state.String()),
+				)
+				if m != nil {
+					m.RecordCircuitBreakerState(metricHost(target), state.String())
+				}
+			},
+		)))
+	}
+	if cfg.Hedge.Enabled {
+		opts = append(opts, loader.WithHedgeDelay(cfg.Hedge.Delay))
+	}
+	return opts
+}
+
 // NewImageService 建立圖片處理服務
 func NewImageService(cfg *config.Config, store storage.Storage, c cache.Cache, opts ...ServiceOption) ImageService {
 	// 處理選項
@@ -135,15 +303,56 @@ func NewImageService(cfg *config.Config, store storage.Storage, c cache.Cache, o
 	}
 
 	// 建立載入器
-	httpLoader := loader.NewHTTPLoader(
+	loaderOpts := []loader.HTTPLoaderOption{
 		loader.WithMaxSize(cfg.Server.MaxRequestSize),
-	)
+		loader.WithMaxRedirects(cfg.Security.MaxRedirects),
+		loader.WithAllowPrivateNetworks(cfg.Security.AllowPrivateNetworks),
+		loader.WithSourceChecker(security.NewSourceValidator(cfg.Security.AllowedSources)),
+		loader.WithHostProfiles(buildHostProfiles(cfg.Loader.Profiles)...),
+		loader.WithLoaderMetrics(options.metrics),
+	}
+	// 指標僅以白名單與 Profile 的主機名稱作為標籤
+	if len(cfg.Security.AllowedSources) > 0 {
+		loaderOpts = append(loaderOpts, loader.WithMetricHosts(security.NewSourceValidator(cfg.Security.AllowedSources)))
+	}
+	// 斷路器狀態改變時載入器已建立
+	var httpLoader *loader.HTTPLoader
+	loaderOpts = append(loaderOpts, loaderResilienceOptions(cfg.Resilience.Loader, options.metrics, func(host string) string {
+		return httpLoader.MetricHost(host)
+	})...)
+	httpLoader = loader.NewHTTPLoader(loaderOpts...)
 	fileLoader := loader.NewFileLoader(
 		loader.WithRootPath(cfg.Storage.Local.RootPath),
 		loader.WithFileMaxSize(cfg.Server.MaxRequestSize),
 	)
 	loaderFactory := loader.NewLoaderFactory(httpLoader, fileLoader)
 
+	var sourceCache *loader.SourceCache
+	if scCfg := cfg.Loader.SourceCache; scCfg.Enabled {
+		sourceCache = loader.NewSourceCache(store, httpLoader,
+			loader.WithSourceDefaultTTL(scCfg.DefaultTTL),
+			loader.WithSourceMaxTTL(scCfg.MaxTTL),
+			loader.WithServeStaleOnError(scCfg.ServeStaleOnError),
+		)
+	}
+
+	var versions *sourceVersions
+	if svCfg := cfg.Cache.SourceVersion; svCfg.Enabled {
+		// 啟用來源快取時由其 Cache-Control 與重新驗證決定遠端來源的新鮮度，
+		// 此時不以 HEAD 取得版本，避免快取鍵與實際載入的內容不一致
+		var remote remoteVersioner
+		if sourceCache == nil {
+			remote = httpLoader
+		}
+		var err error
+		if versions, err = newSourceVersions(store, remote, svCfg.CheckInterval); err != nil {
+			logger.Error("failed to initialize source versioning", logger.Err(err))
+		}
+	}
+
+	// 建立 URL 濾鏡（文字濾鏡使用設定的字型，浮水印素材經由載入器與 Storage 載入）
+	filters := newFilterRegistry(cfg, watermarkAssets{storage: store, loader: loaderFactory}, options.metrics)
+
 	// 建立處理器
 	proc := processor.NewProcessor(
 		cfg.Processing.DefaultQuality,
@@ -164,6 +373,10 @@ func NewImageService(cfg *config.Config, store storage.Storage, c cache.Cache, o
 	if workers < 1 {
 		workers = 1
 	}
+	maxPendingVariants := cfg.Upload.Variants.MaxPending
+	if maxPendingVariants < 1 {
+		maxPendingVariants = defaultMaxPendingVariants
+	}
 
 	return &imageService{
 		cfg:       cfg,
@@ -173,9 +386,79 @@ func NewImageService(cfg *config.Config, store storage.Storage, c cache.Cache, o
 		storage:   store,
 		cache:     c,
 		sem:       make(chan struct{}, workers),
+		filters:   filters,
+
+		httpLoader:   httpLoader,
+		sourceCache:  sourceCache,
+		variantIndex: options.variantIndex,
+		variantSlots: make(chan struct{}, maxPendingVariants),
+		versions:     versions,
 	}
 }
 
+// newFilterRegistry 建立服務使用的濾鏡註冊表
+// 字型載入失敗時記錄錯誤並使用內嵌字型
+// 隱形浮水印與收件者浮水印濾鏡使用設定的金鑰
+// 浮水印、疊加與遮罩濾鏡透過服務的素材載入器載入圖片
+func newFilterRegistry(cfg *config.Config, assets filter.AssetLoader, m metrics.Metrics) *filter.Registry {
+	registry := filter.NewRegistry()
+	filter.RegisterDefaultFilters(registry)
+
+	filtersCfg := cfg.Filters
+	watermark := filter.NewWatermarkFilter(
+		filter.WithAssetLoader(assets),
+		filter.WithAssetCache(filtersCfg.Watermark.CacheMaxSize, filtersCfg.Watermark.CacheTTL),
+		filter.WithAssetTimeout(filtersCfg.Watermark.Timeout),
+		filter.WithFailOpen(filtersCfg.Watermark.FailOpen),
+		filter.WithWatermarkMetrics(m),
+	)
+	registry.Replace(watermark)
+	// 疊加與遮罩圖片共用浮水印的素材載入器與快取
+	registry.Replace(filter.NewOverlayFilter(watermark))
+	registry.Replace(filter.NewMaskFilter(watermark))
+
+	bwFilter := filter.NewBlindWatermarkFilter()
+	bwFilter.Text = cfg.BlindWatermark.Text
+	bwFilter.SecurityKey = blindWatermarkKey(cfg)
+	registry.Replace(bwFilter)
+
+	recipientFilter := filter.NewRecipientWatermarkFilter()
+	recipientFilter.SecurityKey = blindWatermarkKey(cfg)
+	registry.Replace(recipientFilter)
+
+	fonts, err := filter.NewFontSet(
+		filter.WithFontDir(filtersCfg.Text.FontDir),
+		filter.WithDefaultFont(filtersCfg.Text.DefaultFont),
+		filter.WithFallbackFonts(filtersCfg.Text.FallbackFonts...),
+	)
+	if err != nil {
+		logger.Error("failed to load fonts, using embedded fonts", logger.Err(err))
+		return registry
+	}
+	registry.Replace(filter.NewTextFilter(filter.WithFontSet(fonts)))
+	return registry
+}
+
+// watermarkAssets 浮水印素材載入器
+// 遠端 URL 經由 HTTP 載入器（套用 AllowedSources 與私有網段檢查），其餘路徑先讀取 Storage，找不到時由檔案載入器載入
+type watermarkAssets struct {
+	storage storage.Storage
+	loader  *loader.LoaderFactory
+}
+
+// Load 載入浮水印素材
+func (a watermarkAssets) Load(ctx context.Context, source string) ([]byte, error) {
+	if isRemoteSource(source) || a.storage == nil {
+		return a.loader.Load(ctx, source)
+	}
+	data, err := a.storage.Get(ctx, source)
+	if err == nil {
+		return data, nil
+	}
+	logger.Debug("storage load failed for watermark, falling back to loader", logger.Err(err))
+	return a.loader.Load(ctx, source)
+}
+
 // ProcessImage 處理圖片
 func (s *imageService) ProcessImage(ctx context.Context, parsedURL *parser.ParsedURL) ([]byte, string, error) {
 	logger.Debug("start processing image",
@@ -187,16 +470,25 @@ func (s *imageService) ProcessImage(ctx context.Context, parsedURL *parser.Parse
 		logger.Bool("fit_in", parsedURL.FitIn),
 	)
 
-	resultKey := s.generateKey(parsedURL)
+	// 轉發用戶端標頭（例如 Cookie）時來源回應可能因使用者而異，不讀寫共用的快取與儲存
+	personalized := s.personalized(ctx, parsedURL)
 
-	// 1. 檢查快取
-	if data, contentType, hit := s.checkCache(ctx, resultKey, parsedURL); hit {
-		return data, contentType, nil
-	}
+	var resultKey string
+	if !personalized {
+		resultKey = s.resultKey(ctx, parsedURL)
 
-	// 2. 檢查持久化儲存
-	if data, contentType, hit := s.checkStorage(ctx, resultKey, parsedURL); hit {
-		return data, contentType, nil
+		// 1. 檢查快取
+		if data, contentType, hit := s.checkCache(ctx, resultKey, parsedURL); hit {
+			return data, contentType, nil
+		}
+
+		// 2. 檢查持久化儲存
+		// 儲存層結果不會過期，遠端來源超過有效期限時需重新產生
+		if cacheTTL, fresh := s.sourceFreshness(ctx, parsedURL); fresh {
+			if data, contentType, hit := s.checkStorage(ctx, resultKey, parsedURL, cacheTTL); hit {
+				return data, contentType, nil
+			}
+		}
 	}
 
 	// 3. 限制並發處理 (Worker Pool)
@@ -209,7 +501,7 @@ func (s *imageService) ProcessImage(ctx context.Context, parsedURL *parser.Parse
 	}
 
 	// 4. 載入圖片Source
-	imageReader, err := s.loadSourceImage(ctx, parsedURL)
+	imageReader, cacheTTL, err := s.loadSourceImage(ctx, parsedURL)
 	if err != nil {
 		return nil, "", err
 	}
@@ -230,7 +522,9 @@ func (s *imageService) ProcessImage(ctx context.Context, parsedURL *parser.Parse
 	}
 
 	// 8. 非同步儲存結果
-	s.saveAsync(resultKey, outputData)
+	if !personalized {
+		s.saveAsync(parsedURL.ImagePath, resultKey, outputData, cacheTTL)
+	}
 
 	logger.Debug("image processing completed",
 		logger.String("image_path", parsedURL.ImagePath),
@@ -244,25 +538,33 @@ func (s *imageService) ProcessImage(ctx context.Context, parsedURL *parser.Parse
 
 func (s *imageService) checkCache(ctx context.Context, key string, parsedURL *parser.ParsedURL) ([]byte, string, bool) {
 	cacheStart := time.Now()
+	cacheType := cache.TypeOf(s.cache)
+	// 兩層快取自行依層級記錄命中與未命中，這裡只記錄整體延遲
+	recordHitMiss := s.metrics != nil && cacheType != cache.TypeTiered
+
 	data, err := s.cache.Get(ctx, key)
 	if err == nil {
 		logger.Debug("cache hit", logger.String("key", key))
+		if recordHitMiss {
+			s.metrics.RecordCacheHit(cacheType)
+		}
 		if s.metrics != nil {
-			s.metrics.RecordCacheHit("memory")
-			s.metrics.RecordCacheLatency("get", "memory", time.Since(cacheStart).Seconds())
+			s.metrics.RecordCacheLatency("get", cacheType, time.Since(cacheStart).Seconds())
 		}
 		format := s.determineFormat(parsedURL)
 		return data, processor.GetContentType(format), true
 	}
 
+	if recordHitMiss {
+		s.metrics.RecordCacheMiss(cacheType)
+	}
 	if s.metrics != nil {
-		s.metrics.RecordCacheMiss("memory")
-		s.metrics.RecordCacheLatency("get", "memory", time.Since(cacheStart).Seconds())
+		s.metrics.RecordCacheLatency("get", cacheType, time.Since(cacheStart).Seconds())
 	}
 	return nil, "", false
 }
 
-func (s *imageService) checkStorage(ctx context.Context, key string, parsedURL *parser.ParsedURL) ([]byte, string, bool) {
+func (s *imageService) checkStorage(ctx context.Context, key string, parsedURL *parser.ParsedURL, cacheTTL time.Duration) ([]byte, string, bool) {
 	storageStart := time.Now()
 	data, err := s.storage.Get(ctx, key)
 	if err == nil {
@@ -274,8 +576,10 @@ func (s *imageService) checkStorage(ctx context.Context, key string, parsedURL *
 		format := s.determineFormat(parsedURL)
 
 		// 回寫快取 (Cache Miss but Storage Hit)
-		if err := s.cache.Set(ctx, key, data, 0); err != nil {
-			logger.Warn("failed to set cache", logger.Err(err))
+		if cacheTTL >= 0 {
+			if err := s.cache.Set(ctx, key, data, cacheTTL); err != nil {
+				logger.Warn("failed to set cache", logger.Err(err))
+			}
 		}
 		return data, processor.GetContentType(format), true
 	}
@@ -286,12 +590,43 @@ func (s *imageService) checkStorage(ctx context.Context, key string, parsedURL *
 	return nil, "", false
 }
 
-func (s *imageService) loadSourceImage(ctx context.Context, parsedURL *parser.ParsedURL) (io.ReadCloser, error) {
+// sourceFreshness 檢查遠端來源快取是否仍有效
+// 回傳結果寫入快取時使用的 TTL（0 表示快取預設值）與儲存層結果是否可用
+func (s *imageService) sourceFreshness(ctx context.Context, parsedURL *parser.ParsedURL) (time.Duration, bool) {
+	if s.sourceCache == nil || !isRemoteSource(parsedURL.ImagePath) {
+		return 0, true
+	}
+	meta, err := s.sourceCache.Meta(ctx, parsedURL.ImagePath)
+	if err != nil || meta == nil {
+		return 0, false
+	}
+	ttl := time.Until(meta.ExpiresAt)
+	if ttl <= 0 {
+		return 0, false
+	}
+	return ttl, true
+}
+
+// loadSourceImage 載入來源圖片
+// 回傳結果寫入快取時使用的 TTL：0 表示快取預設值，負值表示不寫入快取
+func (s *imageService) loadSourceImage(ctx context.Context, parsedURL *parser.ParsedURL) (io.ReadCloser, time.Duration, error) {
 	var imageReader io.ReadCloser
+	var cacheTTL time.Duration
 	var err error
 
-	// 如果是 HTTP URL，使用 Loader
-	if strings.HasPrefix(parsedURL.ImagePath, "http") {
+	// 如果是 HTTP URL，使用 Loader（啟用來源快取時經由條件式請求重新驗證）
+	// 轉發用戶端標頭的來源不寫入來源快取
+	if isRemoteSource(parsedURL.ImagePath) && s.sourceCache != nil && !s.personalized(ctx, parsedURL) {
+		var src *loader.CachedSource
+		src, err = s.sourceCache.Load(ctx, parsedURL.ImagePath)
+		if err == nil {
+			imageReader = io.NopCloser(bytes.NewReader(src.Data))
+			cacheTTL = time.Until(src.Meta.ExpiresAt)
+			if cacheTTL <= 0 {
+				cacheTTL = -1
+			}
+		}
+	} else if isRemoteSource(parsedURL.ImagePath) {
 		imageReader, err = s.loader.LoadStream(ctx, parsedURL.ImagePath)
 	} else {
 		// 嘗試從 Storage 讀取 (Source)
@@ -313,13 +648,24 @@ func (s *imageService) loadSourceImage(ctx context.Context, parsedURL *parser.Pa
 		if s.metrics != nil {
 			s.metrics.RecordError("load_error")
 		}
-		return nil, fmt.Errorf("failed to load image: %w", err)
+		return nil, 0, fmt.Errorf("failed to load image: %w", err)
 	}
 
 	logger.Debug("image stream loaded successfully",
 		logger.String("image_path", parsedURL.ImagePath),
 	)
-	return imageReader, nil
+	return imageReader, cacheTTL, nil
+}
+
+// personalized 檢查載入來源時是否轉發用戶端標頭（來源回應可能因使用者而異）
+func (s *imageService) personalized(ctx context.Context, parsedURL *parser.ParsedURL) bool {
+	return s.httpLoader != nil && isRemoteSource(parsedURL.ImagePath) &&
+		s.httpLoader.ForwardsRequestHeaders(ctx, parsedURL.ImagePath)
+}
+
+// isRemoteSource 檢查是否為遠端 HTTP 來源
+func isRemoteSource(imagePath string) bool {
+	return strings.HasPrefix(imagePath, "http")
 }
 
 func (s *imageService) processAndEncode(reader io.Reader, parsedURL *parser.ParsedURL) ([]byte, string, error) {
@@ -363,6 +709,26 @@ func (s *imageService) processAndEncode(reader io.Reader, parsedURL *parser.Pars
 		return nil, "", fmt.Errorf("failed to process image: %w", err)
 	}
 
+	// 套用 URL 濾鏡
+	if len(parsedURL.Filters) > 0 {
+		filterStart := time.Now()
+		processedImage, err = s.applyFilters(processedImage, s.canvasFilters(parsedURL))
+		if s.metrics != nil {
+			s.metrics.RecordProcessingDuration("filters", time.Since(filterStart).Seconds())
+		}
+		if err != nil {
+			logger.Error("failed to apply filters",
+				logger.String("image_path", parsedURL.ImagePath),
+				logger.Err(err),
+			)
+			if s.metrics != nil {
+				s.metrics.RecordProcessingError("filter_failed")
+				s.metrics.RecordError("process_error")
+			}
+			return nil, "", fmt.Errorf("failed to apply filters: %w", err)
+		}
+	}
+
 	// 記錄輸出圖片尺寸
 	if s.metrics != nil {
 		bounds := processedImage.Bounds()
@@ -391,6 +757,55 @@ func (s *imageService) processAndEncode(reader io.Reader, parsedURL *parser.Pars
 	return outputData, opts.Format, nil
 }
 
+// applyFilters 依序套用 URL 中的濾鏡
+func (s *imageService) applyFilters(img image.Image, filters []parser.Filter) (image.Image, error) {
+	pipeline := filter.NewPipeline(s.filters)
+	for _, f := range filters {
+		pipeline.Add(filter.FilterSpec{Name: f.Name, Params: f.Params})
+	}
+	return pipeline.Apply(img)
+}
+
+// canvasFilters 為未指定畫布尺寸的 fill 濾鏡帶入 fit-in 的目標尺寸；
+// 所有 fill 畫布尺寸皆套用最大尺寸限制
+func (s *imageService) canvasFilters(p *parser.ParsedURL) []parser.Filter {
+	fitIn := p.FitIn && p.Width > 0 && p.Height > 0
+	filters := make([]parser.Filter, len(p.Filters))
+	for i, f := range p.Filters {
+		if f.Name == "fill" {
+			if len(f.Params) >= 3 {
+				// 無法解析的尺寸交由濾鏡回報錯誤
+				if width, errW := strconv.Atoi(f.Params[1]); errW == nil {
+					if height, errH := strconv.Atoi(f.Params[2]); errH == nil {
+						width, height = s.limitCanvas(width, height)
+						f.Params = append([]string{f.Params[0], strconv.Itoa(width), strconv.Itoa(height)}, f.Params[3:]...)
+					}
+				}
+			} else if fitIn {
+				mode := ""
+				if len(f.Params) > 0 {
+					mode = f.Params[0]
+				}
+				width, height := s.limitCanvas(p.Width, p.Height)
+				f.Params = []string{mode, strconv.Itoa(width), strconv.Itoa(height)}
+			}
+		}
+		filters[i] = f
+	}
+	return filters
+}
+
+// limitCanvas 將畫布尺寸限制於處理設定的最大寬高
+func (s *imageService) limitCanvas(width, height int) (int, int) {
+	if maxWidth := s.cfg.Processing.MaxWidth; maxWidth > 0 {
+		width = min(width, maxWidth)
+	}
+	if maxHeight := s.cfg.Processing.MaxHeight; maxHeight > 0 {
+		height = min(height, maxHeight)
+	}
+	return width, height
+}
+
 func (s *imageService) recordProcessingMetrics(opts processor.ProcessOptions, parsedURL *parser.ParsedURL) {
 	if opts.Width > 0 || opts.Height > 0 {
 		s.metrics.RecordProcessingOperation("resize")
@@ -412,20 +827,38 @@ func (s *imageService) recordProcessingMetrics(opts processor.ProcessOptions, pa
 	}
 }
 
-func (s *imageService) saveAsync(key string, data []byte) {
-	go func() {
-		// 寫入儲存
-		if err := s.storage.Put(context.Background(), key, data); err != nil {
-			logger.Warn("failed to save image to storage",
+// saveAsync 非同步寫入儲存與快取，並記錄來源與處理結果的索引
+// cacheTTL 為 0 時使用快取預設值，負值表示不寫入快取
+func (s *imageService) saveAsync(source, key string, data []byte, cacheTTL time.Duration) {
+	go s.save(source, key, data, cacheTTL)
+}
+
+// save 寫入儲存與快取，並記錄來源與處理結果的索引
+func (s *imageService) save(source, key string, data []byte, cacheTTL time.Duration) {
+	// 先記錄索引，確保寫入的結果都能被清除
+	if s.variantIndex != nil {
+		if err := s.variantIndex.Add(context.Background(), source, key); err != nil {
+			logger.Warn("failed to index image variant",
+				logger.String("source", source),
 				logger.String("key", key),
 				logger.Err(err),
 			)
 		}
-		// 寫入快取
-		if err := s.cache.Set(context.Background(), key, data, 0); err != nil {
-			logger.Warn("failed to set cache", logger.String("key", key), logger.Err(err))
-		}
-	}()
+	}
+	// 寫入儲存
+	if err := s.storage.Put(context.Background(), key, data); err != nil {
+		logger.Warn("failed to save image to storage",
+			logger.String("key", key),
+			logger.Err(err),
+		)
+	}
+	// 寫入快取
+	if cacheTTL < 0 {
+		return
+	}
+	if err := s.cache.Set(context.Background(), key, data, cacheTTL); err != nil {
+		logger.Warn("failed to set cache", logger.String("key", key), logger.Err(err))
+	}
 }
 
 // determineFormat 決定輸出格式
@@ -491,8 +924,21 @@ func normalizeFormat(format string) string {
 	}
 }
 
-// generateKey 產生快取鍵值
+// resultKey 產生處理結果的鍵值，啟用來源版本時包含來源版本
+func (s *imageService) resultKey(ctx context.Context, p *parser.ParsedURL) string {
+	if s.versions == nil {
+		return s.generateKey(p)
+	}
+	return s.generateVersionedKey(p, s.versions.Get(ctx, p.ImagePath))
+}
+
+// generateKey 產生快取鍵值（不含來源版本）
 func (s *imageService) generateKey(p *parser.ParsedURL) string {
+	return s.generateVersionedKey(p, "")
+}
+
+// generateVersionedKey 產生鍵值；version 不為空時與來源路徑一併納入雜湊
+func (s *imageService) generateVersionedKey(p *parser.ParsedURL, version string) string {
 	// 基礎鍵值：路徑
 	base := p.ImagePath
 
@@ -520,6 +966,11 @@ func (s *imageService) generateKey(p *parser.ParsedURL) string {
 	params = append(params, fmt.Sprintf("fmt_%s", format))
 	params = append(params, fmt.Sprintf("q%d", s.cfg.Processing.DefaultQuality))
 
+	// 來源版本（來源被覆寫時產生新的鍵值）
+	if version != "" {
+		params = append(params, fmt.Sprintf("v_%s@%s", base, version))
+	}
+
 	// 組合
 	paramStr := strings.Join(params, "-")
 
@@ -4,13 +4,23 @@ import (
 	"bytes"
 	"context"
 	"errors"
+	"fmt"
+	"image"
+	"image/png"
 	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/vincent119/images-filters/internal/cache"
 	"github.com/vincent119/images-filters/internal/config"
+	"github.com/vincent119/images-filters/internal/filter"
+	"github.com/vincent119/images-filters/internal/loader"
 	"github.com/vincent119/images-filters/internal/parser"
+	"github.com/vincent119/images-filters/internal/storage"
+	"github.com/vincent119/images-filters/internal/storage/types"
 )
 
 // MockCache 模擬快取
@@ -69,10 +79,10 @@ func (m *MockStorage) Get(ctx context.Context, key string) ([]byte, error) {
 	if val, ok := m.data[key]; ok {
 		return val, nil
 	}
-	return nil, errors.New("file not found")
+	return nil, fmt.Errorf("%w: %s", types.ErrNotFound, key)
 }
 
-func (m *MockStorage) Put(ctx context.Context, key string, data []byte) error {
+func (m *MockStorage) Put(ctx context.Context, key string, data []byte, opts ...types.PutOption) error {
 	m.data[key] = data
 	return nil
 }
@@ -92,10 +102,10 @@ func (m *MockStorage) GetStream(ctx context.Context, key string) (io.ReadCloser,
 		// Create a ReadCloser
 		return io.NopCloser(bytes.NewReader(val)), nil
 	}
-	return nil, errors.New("file not found")
+	return nil, fmt.Errorf("%w: %s", types.ErrNotFound, key)
 }
 
-func (m *MockStorage) PutStream(ctx context.Context, key string, r io.Reader) error {
+func (m *MockStorage) PutStream(ctx context.Context, key string, r io.Reader, opts ...types.PutOption) error {
 	data, err := io.ReadAll(r)
 	if err != nil {
 		return err
@@ -104,6 +114,17 @@ func (m *MockStorage) PutStream(ctx context.Context, key string, r io.Reader) er
 	return nil
 }
 
+func (m *MockStorage) Stat(ctx context.Context, key string) (*types.ObjectInfo, error) {
+	if val, ok := m.data[key]; ok {
+		return &types.ObjectInfo{Key: key, Size: int64(len(val))}, nil
+	}
+	return nil, types.ErrNotFound
+}
+
+func (m *MockStorage) List(ctx context.Context, prefix string, opts types.ListOptions) (*types.ListResult, error) {
+	return &types.ListResult{}, nil
+}
+
 func TestProcessImage_CacheHit(t *testing.T) {
 	// Setup
 	cfg := &config.Config{
@@ -347,7 +368,7 @@ func TestUploadImage(t *testing.T) {
 
 	filename := "test.jpg"
 	contentType := "image/jpeg"
-	content := []byte("fake-image-content")
+	content := createTestJPEG(8, 8)
 	reader := bytes.NewReader(content)
 
 	// Execute
@@ -408,3 +429,411 @@ func TestGenerateSignedURL(t *testing.T) {
 		t.Error("Secure URL should not contain unsafe")
 	}
 }
+
+func TestProcessImage_RemoteSourceFreshness(t *testing.T) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 4, 4))); err != nil {
+		t.Fatalf("failed to encode test image: %v", err)
+	}
+	origin := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		w.Header().Set("Cache-Control", "max-age=300")
+		w.Write(buf.Bytes())
+	}))
+	defer origin.Close()
+
+	cfg := &config.Config{
+		Processing: config.ProcessingConfig{
+			DefaultQuality: 80,
+			MaxWidth:       1000,
+			MaxHeight:      1000,
+			Workers:        1,
+			DefaultFormat:  "png",
+		},
+		Server:   config.ServerConfig{MaxRequestSize: 1024 * 1024},
+		Security: config.SecurityConfig{AllowPrivateNetworks: true},
+		Storage:  config.StorageConfig{Type: "local"},
+		Loader: config.LoaderConfig{
+			SourceCache: config.SourceCacheConfig{Enabled: true, DefaultTTL: time.Hour},
+		},
+	}
+
+	mockStore := NewMockStorage()
+	var cacheTTL time.Duration
+	var mu sync.Mutex
+	mockCache := NewMockCache()
+	mockCache.SetFunc = func(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+		mu.Lock()
+		defer mu.Unlock()
+		cacheTTL = ttl
+		return nil
+	}
+	svc := NewImageService(cfg, mockStore, mockCache)
+	impl := svc.(*imageService)
+
+	parsedURL := &parser.ParsedURL{ImagePath: origin.URL + "/image.png", Width: 2, Height: 2}
+	key := impl.generateKey(parsedURL)
+
+	// 儲存層已有結果，但來源快取沒有紀錄：必須重新產生
+	mockStore.data[key] = []byte("stale-result")
+
+	data, _, err := svc.ProcessImage(context.Background(), parsedURL)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if string(data) == "stale-result" {
+		t.Fatal("Expected stored result to be bypassed for unknown source freshness")
+	}
+
+	// 等待 saveAsync 完成
+	time.Sleep(50 * time.Millisecond)
+
+	meta, err := impl.sourceCache.Meta(context.Background(), parsedURL.ImagePath)
+	if err != nil || meta == nil {
+		t.Fatalf("Expected source meta to be stored, got %v, %v", meta, err)
+	}
+
+	// 快取 TTL 不應超過來源 max-age
+	mu.Lock()
+	gotTTL := cacheTTL
+	mu.Unlock()
+	if gotTTL <= 0 || gotTTL > 300*time.Second {
+		t.Errorf("Expected cache TTL bounded by origin max-age, got %v", gotTTL)
+	}
+
+	// 來源仍有效時可使用儲存層結果
+	if _, fresh := impl.sourceFreshness(context.Background(), parsedURL); !fresh {
+		t.Error("Expected source to be fresh after load")
+	}
+}
+
+func TestProcessImage_ForwardedHeadersBypassCache(t *testing.T) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 4, 4))); err != nil {
+		t.Fatalf("failed to encode test image: %v", err)
+	}
+	var mu sync.Mutex
+	var cookies []string
+	origin := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		cookies = append(cookies, r.Header.Get("Cookie"))
+		mu.Unlock()
+		w.Header().Set("Content-Type", "image/png")
+		w.Write(buf.Bytes())
+	}))
+	defer origin.Close()
+
+	cfg := &config.Config{
+		Processing: config.ProcessingConfig{
+			DefaultQuality: 80,
+			MaxWidth:       1000,
+			MaxHeight:      1000,
+			Workers:        1,
+			DefaultFormat:  "png",
+		},
+		Server:   config.ServerConfig{MaxRequestSize: 1024 * 1024},
+		Security: config.SecurityConfig{AllowPrivateNetworks: true},
+		Storage:  config.StorageConfig{Type: "local"},
+		Loader: config.LoaderConfig{
+			Profiles: []config.LoaderProfileConfig{
+				{Name: "session", Hosts: []string{"127.0.0.1"}, ForwardHeaders: []string{"Cookie"}},
+			},
+			SourceCache: config.SourceCacheConfig{Enabled: true, DefaultTTL: time.Hour},
+		},
+	}
+
+	mockStore := NewMockStorage()
+	cacheWrites := 0
+	mockCache := NewMockCache()
+	mockCache.SetFunc = func(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+		mu.Lock()
+		defer mu.Unlock()
+		cacheWrites++
+		return nil
+	}
+	mockCache.GetFunc = func(ctx context.Context, key string) ([]byte, error) {
+		return []byte("other-user-result"), nil
+	}
+	svc := NewImageService(cfg, mockStore, mockCache)
+
+	parsedURL := &parser.ParsedURL{ImagePath: origin.URL + "/image.png", Width: 2, Height: 2}
+	for _, session := range []string{"session=alice", "session=bob"} {
+		ctx := loader.ContextWithRequestHeaders(context.Background(), http.Header{"Cookie": {session}})
+		data, _, err := svc.ProcessImage(ctx, parsedURL)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if string(data) == "other-user-result" {
+			t.Fatal("Expected shared cache to be bypassed when forwarding request headers")
+		}
+	}
+
+	// 等待可能的 saveAsync
+	time.Sleep(50 * time.Millisecond)
+
+	mu.Lock()
+	defer mu.Unlock()
+	if cacheWrites != 0 {
+		t.Errorf("Expected no cache writes, got %d", cacheWrites)
+	}
+	if len(mockStore.data) != 0 {
+		t.Errorf("Expected no storage writes, got %d keys", len(mockStore.data))
+	}
+	// 每位使用者皆以自己的 Cookie 向來源取得圖片
+	if len(cookies) != 2 || cookies[0] != "session=alice" || cookies[1] != "session=bob" {
+		t.Errorf("Expected each request to reach the origin with its own cookie, got %v", cookies)
+	}
+}
+
+// versionedStorage 可回報來源版本的模擬儲存
+type versionedStorage struct {
+	*MockStorage
+	versions map[string]string
+}
+
+func (m *versionedStorage) Version(ctx context.Context, key string) (string, error) {
+	if v, ok := m.versions[key]; ok {
+		return v, nil
+	}
+	return "", errors.New("file not found")
+}
+
+func TestProcessImage_SourceVersionedKey(t *testing.T) {
+	cfg := &config.Config{
+		Processing: config.ProcessingConfig{
+			DefaultQuality: 80,
+			MaxWidth:       1000,
+			MaxHeight:      1000,
+			DefaultFormat:  "jpeg",
+		},
+		Cache: config.CacheConfig{
+			SourceVersion: config.SourceVersionConfig{Enabled: true},
+		},
+	}
+
+	store := &versionedStorage{MockStorage: NewMockStorage(), versions: map[string]string{"uploads/a.jpg": "v1"}}
+	svc := NewImageService(cfg, store, NewMockCache())
+	impl := svc.(*imageService)
+	ctx := context.Background()
+
+	parsedURL := &parser.ParsedURL{ImagePath: "uploads/a.jpg", Width: 100, Height: 100}
+	keyV1 := impl.resultKey(ctx, parsedURL)
+	if keyV1 == impl.generateKey(parsedURL) {
+		t.Fatal("Expected versioned key to differ from unversioned key")
+	}
+
+	// 舊版本的結果仍可由儲存層取得
+	store.data[keyV1] = []byte("result-v1")
+	data, _, err := svc.ProcessImage(ctx, parsedURL)
+	if err != nil || string(data) != "result-v1" {
+		t.Fatalf("Expected stored v1 result, got %q (%v)", data, err)
+	}
+
+	// 來源被覆寫後鍵值改變，不再使用舊結果
+	store.versions["uploads/a.jpg"] = "v2"
+	if keyV2 := impl.resultKey(ctx, parsedURL); keyV2 == keyV1 {
+		t.Error("Expected key to change with source version")
+	}
+
+	// 同名但不同路徑的來源不共用鍵值
+	store.versions["other/a.jpg"] = "v2"
+	other := &parser.ParsedURL{ImagePath: "other/a.jpg", Width: 100, Height: 100}
+	if impl.resultKey(ctx, other) == impl.resultKey(ctx, parsedURL) {
+		t.Error("Expected different sources to have different keys")
+	}
+
+	// 無法取得版本時使用不含版本的鍵值
+	missing := &parser.ParsedURL{ImagePath: "uploads/missing.jpg", Width: 100, Height: 100}
+	if impl.resultKey(ctx, missing) != impl.generateKey(missing) {
+		t.Error("Expected unversioned key when version is unavailable")
+	}
+}
+
+func TestApplyFilters(t *testing.T) {
+	cfg := &config.Config{
+		Processing: config.ProcessingConfig{DefaultQuality: 80},
+		// 字型目錄不存在時改用內嵌字型，不影響其他濾鏡
+		Filters: config.FiltersConfig{Text: config.TextFilterConfig{FontDir: t.TempDir() + "/missing"}},
+	}
+	svc := NewImageService(cfg, NewMockStorage(), NewMockCache()).(*imageService)
+
+	img := image.NewNRGBA(image.Rect(0, 0, 200, 100))
+	for i := range img.Pix {
+		img.Pix[i] = 255
+	}
+	img.Pix[0], img.Pix[1] = 0, 128
+
+	result, err := svc.applyFilters(img, []parser.Filter{
+		{Name: "grayscale"},
+		{Name: "unknown"},
+		{Name: "text", Params: []string{"Hi", "center", "20", "000000"}},
+	})
+	if err != nil {
+		t.Fatalf("applyFilters() error = %v", err)
+	}
+
+	r, g, b, _ := result.At(0, 0).RGBA()
+	if r != g || g != b {
+		t.Errorf("grayscale not applied: got (%d, %d, %d)", r, g, b)
+	}
+	if r, _, _, _ := result.At(100, 50).RGBA(); r == 0xffff {
+		t.Error("text filter not applied at the center")
+	}
+}
+
+func TestApplyFilters_WatermarkAssets(t *testing.T) {
+	var hits int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits++
+		png.Encode(w, image.NewRGBA(image.Rect(0, 0, 20, 20)))
+	}))
+	defer server.Close()
+
+	store, err := storage.NewLocalStorage(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	wm := image.NewNRGBA(image.Rect(0, 0, 20, 20))
+	for i := 0; i < len(wm.Pix); i += 4 {
+		wm.Pix[i], wm.Pix[i+3] = 255, 255
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, wm); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Put(context.Background(), "assets/wm.png", buf.Bytes()); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := &config.Config{
+		Processing: config.ProcessingConfig{DefaultQuality: 80},
+		Security:   config.SecurityConfig{AllowedSources: []string{"cdn.example.com"}},
+		Filters: config.FiltersConfig{Watermark: config.WatermarkFilterConfig{
+			CacheMaxSize: 1 << 20,
+			CacheTTL:     time.Minute,
+			Timeout:      time.Second,
+		}},
+	}
+	svc := NewImageService(cfg, store, NewMockCache()).(*imageService)
+	img := image.NewNRGBA(image.Rect(0, 0, 100, 100))
+
+	// 素材由 Storage 載入
+	result, err := svc.applyFilters(img, []parser.Filter{{Name: "watermark", Params: []string{"assets/wm.png", "center"}}})
+	if err != nil {
+		t.Fatalf("applyFilters() error = %v", err)
+	}
+	if r, g, _, _ := result.At(50, 50).RGBA(); r != 0xffff || g != 0 {
+		t.Errorf("watermark not applied at the center: got r=%d g=%d", r, g)
+	}
+
+	// 未列於 AllowedSources 的遠端素材不會被下載，fail_open 關閉時請求失敗
+	_, err = svc.applyFilters(img, []parser.Filter{{Name: "watermark", Params: []string{server.URL + "/wm.png"}}})
+	if err == nil {
+		t.Error("expected error for a watermark outside the allowed sources")
+	}
+	if hits != 0 {
+		t.Errorf("origin contacted %d times, want 0", hits)
+	}
+}
+
+func TestApplyFilters_Recipient(t *testing.T) {
+	cfg := &config.Config{
+		Processing:     config.ProcessingConfig{DefaultQuality: 80},
+		Security:       config.SecurityConfig{SecurityKey: "signing-key"},
+		BlindWatermark: config.BlindWatermarkConfig{Enabled: true},
+	}
+	svc := NewImageService(cfg, NewMockStorage(), NewMockCache()).(*imageService)
+
+	// 不同收件者的結果使用不同快取鍵值
+	alice := &parser.ParsedURL{ImagePath: "uploads/a.jpg", Filters: []parser.Filter{{Name: "recipient", Params: []string{"1001"}}}}
+	bob := &parser.ParsedURL{ImagePath: "uploads/a.jpg", Filters: []parser.Filter{{Name: "recipient", Params: []string{"1002"}}}}
+	if svc.generateKey(alice) == svc.generateKey(bob) {
+		t.Error("Expected different cache keys for different recipients")
+	}
+
+	// 收件者浮水印使用設定的金鑰（未設定 blind_watermark.security_key 時使用簽名金鑰）
+	img := image.NewNRGBA(image.Rect(0, 0, 256, 256))
+	for i := range img.Pix {
+		img.Pix[i] = byte(i * 7 % 251)
+	}
+	result, err := svc.applyFilters(img, alice.Filters)
+	if err != nil {
+		t.Fatalf("applyFilters() error = %v", err)
+	}
+	payload, ok := filter.NewBlindWatermarkReader(result).Recipient("signing-key")
+	if !ok || payload.RecipientID != "1001" {
+		t.Errorf("Expected recipient 1001, got %+v (%v)", payload, ok)
+	}
+}
+
+func TestProcessAndEncode_FitInFill(t *testing.T) {
+	cfg := &config.Config{
+		Processing: config.ProcessingConfig{
+			DefaultQuality: 80,
+			MaxWidth:       150,
+			MaxHeight:      1000,
+			DefaultFormat:  "png",
+		},
+	}
+	svc := NewImageService(cfg, NewMockStorage(), NewMockCache()).(*imageService)
+
+	// 100x100 的透明 PNG，中央為藍色方塊
+	src := image.NewNRGBA(image.Rect(0, 0, 100, 100))
+	for y := 25; y < 75; y++ {
+		for x := 25; x < 75; x++ {
+			src.Pix[y*src.Stride+x*4+2], src.Pix[y*src.Stride+x*4+3] = 255, 255
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, src); err != nil {
+		t.Fatal(err)
+	}
+
+	// 寬度受 max_width 限制為 150
+	parsedURL := &parser.ParsedURL{
+		ImagePath: "test/logo.png",
+		FitIn:     true,
+		Width:     300,
+		Height:    100,
+		Filters:   []parser.Filter{{Name: "fill", Params: []string{"ff0000"}}, {Name: "format", Params: []string{"png"}}},
+	}
+	data, format, err := svc.processAndEncode(bytes.NewReader(buf.Bytes()), parsedURL)
+	if err != nil {
+		t.Fatalf("processAndEncode() error = %v", err)
+	}
+	if format != "png" {
+		t.Errorf("Expected png, got %s", format)
+	}
+
+	out, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out.Bounds().Dx() != 150 || out.Bounds().Dy() != 100 {
+		t.Fatalf("Expected 150x100 canvas, got %v", out.Bounds())
+	}
+	// 畫布邊緣與原圖透明處填滿紅色，中央維持藍色
+	for _, pt := range []image.Point{{0, 0}, {149, 99}, {30, 10}} {
+		if r, g, b, a := out.At(pt.X, pt.Y).RGBA(); r != 0xffff || g != 0 || b != 0 || a != 0xffff {
+			t.Errorf("Expected red at %v, got (%d, %d, %d, %d)", pt, r, g, b, a)
+		}
+	}
+	if r, _, b, _ := out.At(75, 50).RGBA(); r != 0 || b != 0xffff {
+		t.Errorf("Expected blue at the center, got r=%d b=%d", r, b)
+	}
+
+	// 未使用 fit-in 時不帶入畫布尺寸
+	if got := svc.canvasFilters(&parser.ParsedURL{Width: 300, Height: 100, Filters: parsedURL.Filters}); len(got[0].Params) != 1 {
+		t.Errorf("Expected fill params unchanged without fit-in, got %v", got[0].Params)
+	}
+
+	// 明確指定的畫布尺寸同樣受最大尺寸限制
+	explicit := []parser.Filter{{Name: "fill", Params: []string{"blur", "60000", "60000"}}}
+	got := svc.canvasFilters(&parser.ParsedURL{Filters: explicit})
+	if got[0].Params[1] != "150" || got[0].Params[2] != "1000" {
+		t.Errorf("Expected explicit fill canvas limited to 150x1000, got %v", got[0].Params)
+	}
+	if explicit[0].Params[1] != "60000" {
+		t.Error("Expected original filters to be left untouched")
+	}
+}
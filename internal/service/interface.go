@@ -5,9 +5,11 @@ import (
 	"context"
 	"image"
 	"io"
+	"strings"
 
 	"github.com/vincent119/images-filters/internal/metrics"
 	"github.com/vincent119/images-filters/internal/parser"
+	"github.com/vincent119/images-filters/internal/purge"
 )
 
 // ImageService 圖片處理服務介面
@@ -18,7 +20,31 @@ type ImageService interface {
 
 	// UploadImage 上傳圖片
 	// 儲存圖片並回傳儲存路徑與簽名 URL
-	UploadImage(ctx context.Context, filename string, contentType string, reader io.Reader) (*UploadResult, error)
+	UploadImage(ctx context.Context, filename string, contentType string, reader io.Reader, opts ...UploadOption) (*UploadResult, error)
+}
+
+// UploadOption 上傳選項
+type UploadOption func(*uploadOptions)
+
+type uploadOptions struct {
+	prefix   string
+	variants []string
+}
+
+// WithUploadPrefix 設定上傳路徑前綴（多租戶隔離用）
+// 圖片將儲存於 {prefix}/uploads/{date}/... 之下
+func WithUploadPrefix(prefix string) UploadOption {
+	return func(o *uploadOptions) {
+		o.prefix = strings.Trim(prefix, "/")
+	}
+}
+
+// WithVariants 指定上傳後預先產生的處理結果（預設名稱或處理選項字串）
+// 未指定時依設定檔 upload.variants 決定
+func WithVariants(variants ...string) UploadOption {
+	return func(o *uploadOptions) {
+		o.variants = append(o.variants, variants...)
+	}
 }
 
 // UploadResult 上傳結果
@@ -27,6 +53,22 @@ type UploadResult struct {
 	Path string `json:"path"`
 	// SignedURL 簽名後的存取 URL
 	SignedURL string `json:"url"`
+	// Hash 內容 SHA-256（僅內容定址模式）
+	Hash string `json:"hash,omitempty"`
+	// Duplicate 內容已存在，未重複寫入（僅內容定址模式）
+	Duplicate bool `json:"duplicate,omitempty"`
+	// Variants 預先產生的處理結果（於背景產生，回傳時可能尚未完成）
+	Variants []VariantResult `json:"variants,omitempty"`
+}
+
+// VariantResult 預先產生的處理結果
+type VariantResult struct {
+	// Name 指定的預設名稱或處理選項
+	Name string `json:"name"`
+	// Options 實際使用的處理選項
+	Options string `json:"options"`
+	// SignedURL 簽名後的存取 URL
+	SignedURL string `json:"url"`
 }
 
 // ImageResult 圖片處理結果
@@ -43,7 +85,8 @@ type ImageResult struct {
 type ServiceOption func(*serviceOptions)
 
 type serviceOptions struct {
-	metrics metrics.Metrics
+	metrics      metrics.Metrics
+	variantIndex purge.Index
 }
 
 // WithMetrics 設定 metrics 收集器
@@ -52,3 +95,11 @@ func WithMetrics(m metrics.Metrics) ServiceOption {
 		o.metrics = m
 	}
 }
+
+// WithVariantIndex 設定來源與處理結果的索引
+// 設定後每個寫入儲存的處理結果都會記錄於索引，供 PurgeService 依來源清除
+func WithVariantIndex(index purge.Index) ServiceOption {
+	return func(o *serviceOptions) {
+		o.variantIndex = index
+	}
+}
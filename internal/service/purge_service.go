@@ -0,0 +1,98 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/vincent119/images-filters/internal/cache"
+	"github.com/vincent119/images-filters/internal/purge"
+	"github.com/vincent119/images-filters/internal/storage"
+	"github.com/vincent119/images-filters/internal/storage/types"
+	"github.com/vincent119/images-filters/pkg/logger"
+)
+
+// PurgeResult 清除結果
+type PurgeResult struct {
+	Sources  int `json:"sources"`  // 清除的來源數量
+	Variants int `json:"variants"` // 清除的處理結果數量
+}
+
+// PurgeService 依來源清除快取與儲存的處理結果
+type PurgeService interface {
+	// Purge 清除 path 的所有處理結果；prefix 為 true 時清除所有以 path 開頭的來源
+	Purge(ctx context.Context, path string, prefix bool) (*PurgeResult, error)
+}
+
+// purgeService 實作
+type purgeService struct {
+	storage storage.Storage
+	cache   cache.Cache
+	index   purge.Index
+}
+
+// NewPurgeService 建立清除服務
+// index 需與 ImageService 的 WithVariantIndex 使用同一個實例或後端
+func NewPurgeService(store storage.Storage, c cache.Cache, index purge.Index) PurgeService {
+	return &purgeService{
+		storage: store,
+		cache:   c,
+		index:   index,
+	}
+}
+
+// Purge 清除來源的所有處理結果
+func (s *purgeService) Purge(ctx context.Context, path string, prefix bool) (*PurgeResult, error) {
+	sources := []string{path}
+	if prefix {
+		var err error
+		if sources, err = s.index.Sources(ctx, path); err != nil {
+			return nil, err
+		}
+	}
+
+	result := &PurgeResult{}
+	for _, source := range sources {
+		n, err := s.purgeSource(ctx, source)
+		result.Variants += n
+		if err != nil {
+			return result, err
+		}
+		if n > 0 {
+			result.Sources++
+		}
+	}
+
+	logger.Info("purged image variants",
+		logger.String("path", path),
+		logger.Bool("prefix", prefix),
+		logger.Int("sources", result.Sources),
+		logger.Int("variants", result.Variants),
+	)
+
+	return result, nil
+}
+
+// purgeSource 刪除單一來源的處理結果，全部成功後才移除索引以便失敗時重試
+func (s *purgeService) purgeSource(ctx context.Context, source string) (int, error) {
+	keys, err := s.index.Variants(ctx, source)
+	if err != nil {
+		return 0, err
+	}
+
+	purged := 0
+	for _, key := range keys {
+		if err := s.cache.Delete(ctx, key); err != nil {
+			return purged, fmt.Errorf("failed to delete cache %s: %w", key, err)
+		}
+		if err := s.storage.Delete(ctx, key); err != nil && !errors.Is(err, types.ErrNotFound) {
+			return purged, fmt.Errorf("failed to delete storage %s: %w", key, err)
+		}
+		purged++
+	}
+
+	if err := s.index.Remove(ctx, source); err != nil {
+		return purged, err
+	}
+	return purged, nil
+}
@@ -0,0 +1,74 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/vincent119/images-filters/internal/config"
+	"github.com/vincent119/images-filters/internal/purge"
+)
+
+// chanIndex 以 channel 回報 Add 的索引
+type chanIndex struct {
+	purge.Index
+	added chan [2]string
+}
+
+func (i *chanIndex) Add(ctx context.Context, source, key string) error {
+	i.added <- [2]string{source, key}
+	return nil
+}
+
+func TestPurgeService_Purge(t *testing.T) {
+	ctx := context.Background()
+	store := NewMockStorage()
+	c := NewMockCache()
+	index := purge.NewStorageIndex(store)
+
+	for _, key := range []string{"cache/11/aaa/a.jpg", "cache/22/bbb/a.jpg"} {
+		store.data[key] = []byte("variant")
+		c.data[key] = []byte("variant")
+		assert.NoError(t, index.Add(ctx, "uploads/a.jpg", key))
+	}
+	store.data["cache/33/ccc/b.jpg"] = []byte("other")
+	assert.NoError(t, index.Add(ctx, "uploads/b.jpg", "cache/33/ccc/b.jpg"))
+
+	svc := NewPurgeService(store, c, index)
+	result, err := svc.Purge(ctx, "uploads/a.jpg", false)
+	assert.NoError(t, err)
+	assert.Equal(t, &PurgeResult{Sources: 1, Variants: 2}, result)
+
+	assert.NotContains(t, store.data, "cache/11/aaa/a.jpg")
+	assert.NotContains(t, store.data, "cache/22/bbb/a.jpg")
+	assert.Empty(t, c.data)
+	assert.Contains(t, store.data, "cache/33/ccc/b.jpg")
+
+	// 再次清除時索引已為空
+	result, err = svc.Purge(ctx, "uploads/a.jpg", false)
+	assert.NoError(t, err)
+	assert.Equal(t, &PurgeResult{}, result)
+
+	_, err = svc.Purge(ctx, "uploads/", true)
+	assert.ErrorIs(t, err, purge.ErrPrefixUnsupported)
+}
+
+func TestImageService_IndexesVariants(t *testing.T) {
+	cfg := &config.Config{
+		Processing: config.ProcessingConfig{DefaultQuality: 80, DefaultFormat: "jpeg"},
+	}
+	index := &chanIndex{added: make(chan [2]string, 1)}
+	svc := NewImageService(cfg, NewMockStorage(), NewMockCache(), WithVariantIndex(index))
+
+	impl := svc.(*imageService)
+	impl.saveAsync("uploads/a.jpg", "cache/11/aaa/a.jpg", []byte("data"), -1)
+
+	select {
+	case added := <-index.added:
+		assert.Equal(t, [2]string{"uploads/a.jpg", "cache/11/aaa/a.jpg"}, added)
+	case <-time.After(time.Second):
+		t.Fatal("variant was not indexed")
+	}
+}
@@ -0,0 +1,489 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/vincent119/images-filters/internal/config"
+	"github.com/vincent119/images-filters/internal/storage"
+	"github.com/vincent119/images-filters/internal/storage/types"
+	"github.com/vincent119/images-filters/pkg/logger"
+)
+
+// 可續傳上傳錯誤
+var (
+	// ErrResumableNotFound 上傳不存在、已過期或屬於其他路徑前綴
+	ErrResumableNotFound = errors.New("resumable upload not found")
+	// ErrResumableOffsetMismatch 寫入位移與已接收的大小不符
+	ErrResumableOffsetMismatch = errors.New("upload offset mismatch")
+	// ErrResumableTooLarge 宣告的總大小超過 upload.resumable.max_size
+	ErrResumableTooLarge = errors.New("upload length exceeds limit")
+	// ErrResumableChunkTooLarge 單次寫入超過 max_chunk_size 或超出宣告的總大小
+	ErrResumableChunkTooLarge = errors.New("chunk exceeds limit")
+	// ErrResumableIncomplete 尚未接收完整內容即要求完成
+	ErrResumableIncomplete = errors.New("upload is incomplete")
+)
+
+// resumableInfoFile 上傳狀態檔名
+const resumableInfoFile = "info.json"
+
+// ResumableUpload 可續傳上傳的狀態
+type ResumableUpload struct {
+	ID          string `json:"id"`
+	Filename    string `json:"filename"`
+	ContentType string `json:"content_type,omitempty"`
+	Length      int64  `json:"length"`
+	Offset      int64  `json:"offset"`
+	// Prefix 建立時的上傳路徑前綴，僅相同前綴可存取
+	Prefix string `json:"prefix,omitempty"`
+	// Chunks 已暫存分段的起始位移（依序）
+	Chunks    []int64   `json:"chunks,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// ResumableUploadService 可續傳分段上傳
+// 建立後以 WriteChunk 依位移寫入分段，完整後以 Complete 經由 ImageService.UploadImage 上傳
+type ResumableUploadService interface {
+	// Create 建立上傳，length 為完整檔案大小
+	Create(ctx context.Context, filename, contentType string, length int64, opts ...UploadOption) (*ResumableUpload, error)
+	// Status 取得上傳狀態（含已接收的位移）
+	Status(ctx context.Context, id string, opts ...UploadOption) (*ResumableUpload, error)
+	// WriteChunk 自 offset 寫入分段；連線中斷時保留已接收的部分
+	WriteChunk(ctx context.Context, id string, offset int64, r io.Reader, opts ...UploadOption) (*ResumableUpload, error)
+	// Complete 組合分段並執行上傳流程（含驗證與隱形浮水印），成功後刪除暫存
+	Complete(ctx context.Context, id string, opts ...UploadOption) (*UploadResult, error)
+	// Abort 放棄上傳並刪除暫存
+	Abort(ctx context.Context, id string, opts ...UploadOption) error
+	// Cleanup 刪除所有過期的上傳，回傳刪除數量
+	Cleanup(ctx context.Context) (int, error)
+}
+
+// resumableUploadService 實作
+type resumableUploadService struct {
+	cfg      config.ResumableUploadConfig
+	storage  storage.Storage
+	uploader ImageService
+	// locks 同一上傳的寫入依序進行（僅限單一行程）；無人持有時即移除，不隨請求的 ID 增長
+	locksMu sync.Mutex
+	locks   map[string]*uploadLock
+}
+
+// uploadLock 單一上傳的鎖與等待中的請求數
+type uploadLock struct {
+	mu   sync.Mutex
+	refs int
+}
+
+// NewResumableUploadService 建立可續傳上傳服務
+func NewResumableUploadService(cfg config.ResumableUploadConfig, store storage.Storage, uploader ImageService) ResumableUploadService {
+	cfg.StagingPrefix = strings.Trim(cfg.StagingPrefix, "/")
+	if cfg.StagingPrefix == "" {
+		cfg.StagingPrefix = "staging/resumable"
+	}
+	if cfg.Expiry <= 0 {
+		cfg.Expiry = 24 * time.Hour
+	}
+	return &resumableUploadService{
+		cfg:      cfg,
+		storage:  store,
+		uploader: uploader,
+		locks:    make(map[string]*uploadLock),
+	}
+}
+
+// Create 建立上傳
+func (s *resumableUploadService) Create(ctx context.Context, filename, contentType string, length int64, opts ...UploadOption) (*ResumableUpload, error) {
+	if length <= 0 {
+		return nil, fmt.Errorf("invalid upload length: %d", length)
+	}
+	if s.cfg.MaxSize > 0 && length > s.cfg.MaxSize {
+		return nil, fmt.Errorf("%w: %d bytes, max %d", ErrResumableTooLarge, length, s.cfg.MaxSize)
+	}
+
+	id, err := newResumableID()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	upload := &ResumableUpload{
+		ID:          id,
+		Filename:    path.Base(filename),
+		ContentType: contentType,
+		Length:      length,
+		Prefix:      applyUploadOptions(opts).prefix,
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(s.cfg.Expiry),
+	}
+	if err := s.save(ctx, upload); err != nil {
+		return nil, err
+	}
+
+	logger.Info("resumable upload created",
+		logger.String("id", id),
+		logger.String("filename", upload.Filename),
+		logger.Int64("length", length),
+	)
+	return upload, nil
+}
+
+// Status 取得上傳狀態
+func (s *resumableUploadService) Status(ctx context.Context, id string, opts ...UploadOption) (*ResumableUpload, error) {
+	return s.load(ctx, id, applyUploadOptions(opts).prefix)
+}
+
+// WriteChunk 自 offset 寫入分段
+func (s *resumableUploadService) WriteChunk(ctx context.Context, id string, offset int64, r io.Reader, opts ...UploadOption) (*ResumableUpload, error) {
+	if !isResumableID(id) {
+		return nil, ErrResumableNotFound
+	}
+	unlock := s.lock(id)
+	defer unlock()
+
+	upload, err := s.load(ctx, id, applyUploadOptions(opts).prefix)
+	if err != nil {
+		return nil, err
+	}
+	if offset != upload.Offset {
+		return upload, fmt.Errorf("%w: expected %d, got %d", ErrResumableOffsetMismatch, upload.Offset, offset)
+	}
+
+	limit := upload.Length - upload.Offset
+	if s.cfg.MaxChunkSize > 0 && limit > s.cfg.MaxChunkSize {
+		limit = s.cfg.MaxChunkSize
+	}
+	data, readErr := io.ReadAll(io.LimitReader(r, limit+1))
+	if int64(len(data)) > limit {
+		return upload, fmt.Errorf("%w: max %d bytes at offset %d", ErrResumableChunkTooLarge, limit, offset)
+	}
+
+	// 連線中斷時仍保留已接收的部分，用戶端可依 Status 的位移續傳
+	if len(data) > 0 {
+		if err := s.storage.Put(ctx, s.chunkKey(id, offset), data, types.WithContentType("application/octet-stream")); err != nil {
+			return upload, fmt.Errorf("failed to stage chunk: %w", err)
+		}
+		upload.Chunks = append(upload.Chunks, offset)
+		upload.Offset += int64(len(data))
+		upload.ExpiresAt = time.Now().UTC().Add(s.cfg.Expiry)
+		if err := s.save(ctx, upload); err != nil {
+			return upload, err
+		}
+	}
+
+	if readErr != nil {
+		return upload, fmt.Errorf("failed to read chunk: %w", readErr)
+	}
+	return upload, nil
+}
+
+// Complete 組合分段並上傳
+func (s *resumableUploadService) Complete(ctx context.Context, id string, opts ...UploadOption) (*UploadResult, error) {
+	if !isResumableID(id) {
+		return nil, ErrResumableNotFound
+	}
+	unlock := s.lock(id)
+	defer unlock()
+
+	upload, err := s.load(ctx, id, applyUploadOptions(opts).prefix)
+	if err != nil {
+		return nil, err
+	}
+	if upload.Offset < upload.Length {
+		return nil, fmt.Errorf("%w: received %d of %d bytes", ErrResumableIncomplete, upload.Offset, upload.Length)
+	}
+
+	keys := make([]string, len(upload.Chunks))
+	for i, offset := range upload.Chunks {
+		keys[i] = s.chunkKey(id, offset)
+	}
+	reader := &chunkReader{ctx: ctx, storage: s.storage, keys: keys}
+	defer reader.Close()
+
+	result, err := s.uploader.UploadImage(ctx, upload.Filename, upload.ContentType, reader, WithUploadPrefix(upload.Prefix))
+	if err != nil {
+		// 內容驗證失敗時重試也不會成功，直接刪除暫存
+		if isUploadValidationError(err) {
+			s.discard(ctx, id)
+		}
+		return nil, err
+	}
+
+	s.discard(ctx, id)
+	logger.Info("resumable upload completed",
+		logger.String("id", id),
+		logger.String("saved_path", result.Path),
+	)
+	return result, nil
+}
+
+// Abort 放棄上傳
+func (s *resumableUploadService) Abort(ctx context.Context, id string, opts ...UploadOption) error {
+	if !isResumableID(id) {
+		return ErrResumableNotFound
+	}
+	unlock := s.lock(id)
+	defer unlock()
+
+	if _, err := s.load(ctx, id, applyUploadOptions(opts).prefix); err != nil {
+		return err
+	}
+	return s.remove(ctx, id)
+}
+
+// Cleanup 刪除所有過期的上傳
+func (s *resumableUploadService) Cleanup(ctx context.Context) (int, error) {
+	var expired []string
+	now := time.Now()
+
+	opts := types.ListOptions{}
+	for {
+		page, err := s.storage.List(ctx, s.cfg.StagingPrefix+"/", opts)
+		if err != nil {
+			return 0, fmt.Errorf("failed to list resumable uploads: %w", err)
+		}
+		for _, obj := range page.Objects {
+			if path.Base(obj.Key) != resumableInfoFile {
+				continue
+			}
+			upload, err := s.read(ctx, obj.Key)
+			// 無法解析的狀態檔同樣視為過期
+			if err != nil || now.After(upload.ExpiresAt) {
+				expired = append(expired, path.Base(path.Dir(obj.Key)))
+			}
+		}
+		if page.NextCursor == "" {
+			break
+		}
+		opts.Cursor = page.NextCursor
+	}
+
+	removed := 0
+	for _, id := range expired {
+		if err := s.remove(ctx, id); err != nil {
+			logger.Warn("failed to remove expired resumable upload", logger.String("id", id), logger.Err(err))
+			continue
+		}
+		removed++
+	}
+	if removed > 0 {
+		logger.Info("expired resumable uploads removed", logger.Int("count", removed))
+	}
+	return removed, nil
+}
+
+// load 讀取上傳狀態，不存在、過期或前綴不符時回傳 ErrResumableNotFound
+func (s *resumableUploadService) load(ctx context.Context, id, prefix string) (*ResumableUpload, error) {
+	if !isResumableID(id) {
+		return nil, ErrResumableNotFound
+	}
+
+	key := s.infoKey(id)
+	exists, err := s.storage.Exists(ctx, key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check resumable upload: %w", err)
+	}
+	if !exists {
+		return nil, ErrResumableNotFound
+	}
+
+	upload, err := s.read(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	if upload.Prefix != prefix {
+		return nil, ErrResumableNotFound
+	}
+	if time.Now().After(upload.ExpiresAt) {
+		s.discard(ctx, id)
+		return nil, ErrResumableNotFound
+	}
+	return upload, nil
+}
+
+// read 讀取並解析狀態檔
+func (s *resumableUploadService) read(ctx context.Context, key string) (*ResumableUpload, error) {
+	data, err := s.storage.Get(ctx, key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read resumable upload: %w", err)
+	}
+	var upload ResumableUpload
+	if err := json.Unmarshal(data, &upload); err != nil {
+		return nil, fmt.Errorf("failed to decode resumable upload: %w", err)
+	}
+	return &upload, nil
+}
+
+// save 寫入狀態檔
+func (s *resumableUploadService) save(ctx context.Context, upload *ResumableUpload) error {
+	data, err := json.Marshal(upload)
+	if err != nil {
+		return fmt.Errorf("failed to encode resumable upload: %w", err)
+	}
+	if err := s.storage.Put(ctx, s.infoKey(upload.ID), data, types.WithContentType("application/json")); err != nil {
+		return fmt.Errorf("failed to save resumable upload: %w", err)
+	}
+	return nil
+}
+
+// remove 刪除上傳的所有暫存（包含未記錄於狀態檔的分段），狀態檔最後刪除以便失敗時由 Cleanup 重試
+func (s *resumableUploadService) remove(ctx context.Context, id string) error {
+	dir := s.dir(id) + "/"
+	var keys []string
+	opts := types.ListOptions{}
+	for {
+		page, err := s.storage.List(ctx, dir, opts)
+		if err != nil {
+			return fmt.Errorf("failed to list resumable upload: %w", err)
+		}
+		for _, obj := range page.Objects {
+			if obj.Key != s.infoKey(id) {
+				keys = append(keys, obj.Key)
+			}
+		}
+		if page.NextCursor == "" {
+			break
+		}
+		opts.Cursor = page.NextCursor
+	}
+
+	for _, key := range append(keys, s.infoKey(id)) {
+		if err := s.storage.Delete(ctx, key); err != nil {
+			return fmt.Errorf("failed to delete %s: %w", key, err)
+		}
+	}
+	return nil
+}
+
+// discard 刪除暫存，失敗時僅記錄（狀態檔仍在時由 Cleanup 重試）
+func (s *resumableUploadService) discard(ctx context.Context, id string) {
+	if err := s.remove(ctx, id); err != nil {
+		logger.Warn("failed to remove resumable upload", logger.String("id", id), logger.Err(err))
+	}
+}
+
+// lock 取得單一上傳的鎖，最後一個持有者釋放時移除
+func (s *resumableUploadService) lock(id string) func() {
+	s.locksMu.Lock()
+	l, ok := s.locks[id]
+	if !ok {
+		l = &uploadLock{}
+		s.locks[id] = l
+	}
+	l.refs++
+	s.locksMu.Unlock()
+
+	l.mu.Lock()
+	return func() {
+		l.mu.Unlock()
+		s.locksMu.Lock()
+		if l.refs--; l.refs == 0 {
+			delete(s.locks, id)
+		}
+		s.locksMu.Unlock()
+	}
+}
+
+func (s *resumableUploadService) dir(id string) string {
+	return s.cfg.StagingPrefix + "/" + id
+}
+
+func (s *resumableUploadService) infoKey(id string) string {
+	return s.dir(id) + "/" + resumableInfoFile
+}
+
+// chunkKey 分段路徑，以補零位移命名使列舉順序與位移順序一致
+func (s *resumableUploadService) chunkKey(id string, offset int64) string {
+	return fmt.Sprintf("%s/%016d.part", s.dir(id), offset)
+}
+
+// newResumableID 產生隨機上傳 ID
+func newResumableID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate upload id: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// isResumableID 檢查 ID 格式，避免以 ID 組出暫存目錄以外的路徑
+func isResumableID(id string) bool {
+	if len(id) != 32 {
+		return false
+	}
+	_, err := hex.DecodeString(id)
+	return err == nil
+}
+
+// applyUploadOptions 套用上傳選項
+func applyUploadOptions(opts []UploadOption) *uploadOptions {
+	options := &uploadOptions{}
+	for _, opt := range opts {
+		opt(options)
+	}
+	return options
+}
+
+// isUploadValidationError 是否為內容驗證錯誤（內容本身有誤，重試不會成功）
+func isUploadValidationError(err error) bool {
+	return errors.Is(err, ErrUploadUnsupportedFormat) ||
+		errors.Is(err, ErrUploadFormatMismatch) ||
+		errors.Is(err, ErrUploadInvalidImage) ||
+		errors.Is(err, ErrUploadDimensionsExceeded) ||
+		errors.Is(err, ErrUploadPixelsExceeded) ||
+		errors.Is(err, ErrUploadActiveContent)
+}
+
+// chunkReader 依序讀取暫存分段
+type chunkReader struct {
+	ctx     context.Context
+	storage storage.Storage
+	keys    []string
+	cur     io.ReadCloser
+}
+
+func (r *chunkReader) Read(p []byte) (int, error) {
+	for {
+		if r.cur == nil {
+			if len(r.keys) == 0 {
+				return 0, io.EOF
+			}
+			rc, err := r.storage.GetStream(r.ctx, r.keys[0])
+			if err != nil {
+				return 0, fmt.Errorf("failed to read staged chunk: %w", err)
+			}
+			r.cur = rc
+			r.keys = r.keys[1:]
+		}
+
+		n, err := r.cur.Read(p)
+		if err == io.EOF {
+			r.cur.Close()
+			r.cur = nil
+			if n > 0 {
+				return n, nil
+			}
+			continue
+		}
+		return n, err
+	}
+}
+
+// Close 關閉目前讀取中的分段
+func (r *chunkReader) Close() error {
+	if r.cur == nil {
+		return nil
+	}
+	err := r.cur.Close()
+	r.cur = nil
+	return err
+}
@@ -0,0 +1,210 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vincent119/images-filters/internal/config"
+	"github.com/vincent119/images-filters/internal/storage"
+	"github.com/vincent119/images-filters/internal/storage/types"
+)
+
+func newResumableTestService(t *testing.T, cfg config.ResumableUploadConfig) (*resumableUploadService, *storage.LocalStorage) {
+	t.Helper()
+	store, err := storage.NewLocalStorage(t.TempDir())
+	require.NoError(t, err)
+
+	images := NewImageService(&config.Config{
+		Processing: config.ProcessingConfig{DefaultQuality: 80},
+	}, store, NewMockCache())
+	return NewResumableUploadService(cfg, store, images).(*resumableUploadService), store
+}
+
+// failingReader 讀出部分內容後回傳錯誤，模擬連線中斷
+type failingReader struct {
+	data []byte
+}
+
+func (r *failingReader) Read(p []byte) (int, error) {
+	if len(r.data) == 0 {
+		return 0, io.ErrUnexpectedEOF
+	}
+	n := copy(p, r.data)
+	r.data = r.data[n:]
+	return n, nil
+}
+
+func TestResumableUpload_ChunksAndComplete(t *testing.T) {
+	svc, store := newResumableTestService(t, config.ResumableUploadConfig{MaxChunkSize: 100})
+	ctx := context.Background()
+	img := createTestJPEG(32, 32)
+
+	upload, err := svc.Create(ctx, "photos/DSC_0001.jpg", "image/jpeg", int64(len(img)))
+	require.NoError(t, err)
+	assert.Equal(t, "DSC_0001.jpg", upload.Filename)
+	assert.Len(t, upload.ID, 32)
+
+	// 完成前必須接收完整內容
+	_, err = svc.Complete(ctx, upload.ID)
+	assert.ErrorIs(t, err, ErrResumableIncomplete)
+
+	// 超過 max_chunk_size 的分段整個拒絕
+	_, err = svc.WriteChunk(ctx, upload.ID, 0, bytes.NewReader(img))
+	assert.ErrorIs(t, err, ErrResumableChunkTooLarge)
+
+	// 連線中斷時保留已接收的部分
+	upload, err = svc.WriteChunk(ctx, upload.ID, 0, &failingReader{data: img[:60]})
+	assert.ErrorIs(t, err, io.ErrUnexpectedEOF)
+	assert.Equal(t, int64(60), upload.Offset)
+
+	status, err := svc.Status(ctx, upload.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(60), status.Offset)
+
+	// 位移不符時拒絕
+	_, err = svc.WriteChunk(ctx, upload.ID, 0, bytes.NewReader(img[:60]))
+	assert.ErrorIs(t, err, ErrResumableOffsetMismatch)
+
+	for offset := int64(60); offset < int64(len(img)); offset += 100 {
+		end := min(offset+100, int64(len(img)))
+		upload, err = svc.WriteChunk(ctx, upload.ID, offset, bytes.NewReader(img[offset:end]))
+		require.NoError(t, err)
+	}
+	assert.Equal(t, upload.Length, upload.Offset)
+
+	result, err := svc.Complete(ctx, upload.ID)
+	require.NoError(t, err)
+	assert.True(t, strings.HasSuffix(result.Path, "DSC_0001.jpg"))
+
+	saved, err := store.Get(ctx, result.Path)
+	require.NoError(t, err)
+	assert.Equal(t, img, saved)
+
+	// 完成後刪除暫存
+	_, err = svc.Status(ctx, upload.ID)
+	assert.ErrorIs(t, err, ErrResumableNotFound)
+	page, err := store.List(ctx, "staging/", types.ListOptions{})
+	require.NoError(t, err)
+	assert.Empty(t, page.Objects)
+}
+
+func TestResumableUpload_Limits(t *testing.T) {
+	svc, _ := newResumableTestService(t, config.ResumableUploadConfig{MaxSize: 10})
+	ctx := context.Background()
+
+	_, err := svc.Create(ctx, "a.jpg", "image/jpeg", 11)
+	assert.ErrorIs(t, err, ErrResumableTooLarge)
+
+	upload, err := svc.Create(ctx, "a.jpg", "image/jpeg", 10)
+	require.NoError(t, err)
+
+	// 超出宣告的總大小
+	_, err = svc.WriteChunk(ctx, upload.ID, 0, strings.NewReader("01234567890"))
+	assert.ErrorIs(t, err, ErrResumableChunkTooLarge)
+
+	// 不合法的 ID 不會組出暫存目錄以外的路徑
+	_, err = svc.Status(ctx, "../../etc/passwd")
+	assert.ErrorIs(t, err, ErrResumableNotFound)
+}
+
+func TestResumableUpload_PrefixIsolation(t *testing.T) {
+	svc, _ := newResumableTestService(t, config.ResumableUploadConfig{})
+	ctx := context.Background()
+
+	upload, err := svc.Create(ctx, "a.jpg", "image/jpeg", 10, WithUploadPrefix("tenants/acme"))
+	require.NoError(t, err)
+
+	_, err = svc.Status(ctx, upload.ID, WithUploadPrefix("tenants/other"))
+	assert.ErrorIs(t, err, ErrResumableNotFound)
+	_, err = svc.Status(ctx, upload.ID)
+	assert.ErrorIs(t, err, ErrResumableNotFound)
+
+	_, err = svc.Status(ctx, upload.ID, WithUploadPrefix("tenants/acme"))
+	assert.NoError(t, err)
+}
+
+func TestResumableUpload_InvalidContentIsDiscarded(t *testing.T) {
+	svc, _ := newResumableTestService(t, config.ResumableUploadConfig{})
+	ctx := context.Background()
+	data := []byte("<html>not an image</html>")
+
+	upload, err := svc.Create(ctx, "a.jpg", "image/jpeg", int64(len(data)))
+	require.NoError(t, err)
+	_, err = svc.WriteChunk(ctx, upload.ID, 0, bytes.NewReader(data))
+	require.NoError(t, err)
+
+	_, err = svc.Complete(ctx, upload.ID)
+	assert.ErrorIs(t, err, ErrUploadUnsupportedFormat)
+	_, err = svc.Status(ctx, upload.ID)
+	assert.ErrorIs(t, err, ErrResumableNotFound)
+}
+
+func TestResumableUpload_AbortAndCleanup(t *testing.T) {
+	svc, store := newResumableTestService(t, config.ResumableUploadConfig{Expiry: time.Hour})
+	ctx := context.Background()
+
+	aborted, err := svc.Create(ctx, "a.jpg", "image/jpeg", 10)
+	require.NoError(t, err)
+	_, err = svc.WriteChunk(ctx, aborted.ID, 0, strings.NewReader("01234"))
+	require.NoError(t, err)
+	require.NoError(t, svc.Abort(ctx, aborted.ID))
+	assert.ErrorIs(t, svc.Abort(ctx, aborted.ID), ErrResumableNotFound)
+
+	active, err := svc.Create(ctx, "b.jpg", "image/jpeg", 10)
+	require.NoError(t, err)
+	expired, err := svc.Create(ctx, "c.jpg", "image/jpeg", 10)
+	require.NoError(t, err)
+	_, err = svc.WriteChunk(ctx, expired.ID, 0, strings.NewReader("01234"))
+	require.NoError(t, err)
+
+	// 將其中一個上傳改為已過期
+	expired, err = svc.Status(ctx, expired.ID)
+	require.NoError(t, err)
+	expired.ExpiresAt = time.Now().Add(-time.Minute)
+	data, err := json.Marshal(expired)
+	require.NoError(t, err)
+	require.NoError(t, store.Put(ctx, svc.infoKey(expired.ID), data))
+
+	removed, err := svc.Cleanup(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, removed)
+
+	_, err = svc.Status(ctx, active.ID)
+	assert.NoError(t, err)
+	exists, err := store.Exists(ctx, svc.chunkKey(expired.ID, 0))
+	require.NoError(t, err)
+	assert.False(t, exists)
+}
+
+func TestResumableUpload_LocksAreReleased(t *testing.T) {
+	svc, _ := newResumableTestService(t, config.ResumableUploadConfig{})
+	ctx := context.Background()
+
+	// 不存在或格式錯誤的 ID 不留下鎖
+	for _, id := range []string{strings.Repeat("ab", 16), "../../etc", ""} {
+		_, err := svc.WriteChunk(ctx, id, 0, bytes.NewReader([]byte("x")))
+		assert.ErrorIs(t, err, ErrResumableNotFound)
+		_, err = svc.Complete(ctx, id)
+		assert.ErrorIs(t, err, ErrResumableNotFound)
+		assert.ErrorIs(t, svc.Abort(ctx, id), ErrResumableNotFound)
+	}
+	assert.Empty(t, svc.locks)
+
+	img := createTestJPEG(16, 16)
+	upload, err := svc.Create(ctx, "a.jpg", "image/jpeg", int64(len(img)))
+	require.NoError(t, err)
+	_, err = svc.WriteChunk(ctx, upload.ID, 0, bytes.NewReader(img))
+	require.NoError(t, err)
+	assert.Empty(t, svc.locks)
+	_, err = svc.Complete(ctx, upload.ID)
+	require.NoError(t, err)
+	assert.Empty(t, svc.locks)
+}
@@ -0,0 +1,80 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/dgraph-io/ristretto"
+
+	"github.com/vincent119/images-filters/internal/storage"
+	"github.com/vincent119/images-filters/pkg/logger"
+)
+
+// 版本記錄最多保留的來源數量
+const maxTrackedVersions = 100000
+
+// remoteVersioner 可取得遠端來源版本的載入器（loader.HTTPLoader）
+type remoteVersioner interface {
+	Version(ctx context.Context, source string) (string, error)
+}
+
+// sourceVersions 取得來源版本並在 interval 內重複使用，避免每個請求都發出 HEAD
+type sourceVersions struct {
+	storage  storage.Storage
+	remote   remoteVersioner // nil 時不對遠端來源加上版本
+	interval time.Duration
+	memo     *ristretto.Cache
+}
+
+// newSourceVersions 建立來源版本查詢
+func newSourceVersions(store storage.Storage, remote remoteVersioner, interval time.Duration) (*sourceVersions, error) {
+	v := &sourceVersions{
+		storage:  store,
+		remote:   remote,
+		interval: interval,
+	}
+	if interval > 0 {
+		memo, err := ristretto.NewCache(&ristretto.Config{
+			NumCounters: maxTrackedVersions * 10,
+			MaxCost:     maxTrackedVersions,
+			BufferItems: 64,
+		})
+		if err != nil {
+			return nil, err
+		}
+		v.memo = memo
+	}
+	return v, nil
+}
+
+// Get 取得來源版本；無法取得時回傳空字串（使用不含版本的快取鍵）
+func (v *sourceVersions) Get(ctx context.Context, source string) string {
+	if v.memo != nil {
+		if cached, ok := v.memo.Get(source); ok {
+			return cached.(string)
+		}
+	}
+
+	var version string
+	var err error
+	if isRemoteSource(source) {
+		if v.remote == nil {
+			return ""
+		}
+		version, err = v.remote.Version(ctx, source)
+	} else {
+		version, err = storage.VersionOf(ctx, v.storage, source)
+	}
+	if err != nil {
+		logger.Debug("failed to get source version",
+			logger.String("source", source),
+			logger.Err(err),
+		)
+		return ""
+	}
+
+	if v.memo != nil {
+		v.memo.SetWithTTL(source, version, 1, v.interval)
+	}
+	return version
+}
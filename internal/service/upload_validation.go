@@ -0,0 +1,96 @@
+package service
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/vincent119/images-filters/internal/processor"
+)
+
+// 上傳驗證錯誤，API 層依此回傳不同錯誤碼
+var (
+	// ErrUploadUnsupportedFormat 內容不是可辨識的圖片格式
+	ErrUploadUnsupportedFormat = errors.New("unsupported image format")
+	// ErrUploadFormatMismatch 宣告的 Content-Type 或副檔名與實際內容不符
+	ErrUploadFormatMismatch = errors.New("declared type does not match image content")
+	// ErrUploadInvalidImage 檔頭可辨識但無法解析
+	ErrUploadInvalidImage = errors.New("invalid image")
+	// ErrUploadDimensionsExceeded 寬或高超過上限
+	ErrUploadDimensionsExceeded = errors.New("image dimensions exceed limit")
+	// ErrUploadPixelsExceeded 像素總數超過上限
+	ErrUploadPixelsExceeded = errors.New("image pixel count exceeds limit")
+	// ErrUploadActiveContent SVG 含有腳本等瀏覽器會執行的內容
+	ErrUploadActiveContent = errors.New("image contains active content")
+)
+
+// validatedUpload 通過驗證的上傳內容
+type validatedUpload struct {
+	data     []byte
+	filename string
+	format   string
+}
+
+// validateUpload 依內容判斷格式並檢查與宣告是否一致、尺寸是否超過上限
+// 檔名沒有副檔名時補上實際格式的副檔名（載入器依副檔名判斷是否為圖片）
+func (s *imageService) validateUpload(filename, contentType string, data []byte) (*validatedUpload, error) {
+	format := processor.DetectFormat(data)
+	if format == "" {
+		return nil, ErrUploadUnsupportedFormat
+	}
+	// 未重新編碼時 SVG 會原樣以 image/svg+xml 提供，含腳本時構成 stored XSS
+	if format == processor.FormatSVG && processor.SVGHasActiveContent(data) {
+		return nil, ErrUploadActiveContent
+	}
+
+	if declared := processor.FormatFromContentType(contentType); declared != "" && declared != format {
+		return nil, fmt.Errorf("%w: content type %s, detected %s", ErrUploadFormatMismatch, contentType, format)
+	}
+
+	ext := filepath.Ext(filename)
+	switch {
+	case ext == "":
+		filename += processor.Extension(format)
+	case processor.FormatFromExtension(ext) != format:
+		return nil, fmt.Errorf("%w: extension %s, detected %s", ErrUploadFormatMismatch, ext, format)
+	}
+
+	cfg, err := processor.DecodeConfig(data)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUploadInvalidImage, err)
+	}
+
+	limits := s.cfg.Upload
+	if (limits.MaxWidth > 0 && cfg.Width > limits.MaxWidth) || (limits.MaxHeight > 0 && cfg.Height > limits.MaxHeight) {
+		return nil, fmt.Errorf("%w: %dx%d, max %dx%d", ErrUploadDimensionsExceeded, cfg.Width, cfg.Height, limits.MaxWidth, limits.MaxHeight)
+	}
+	if pixels := int64(cfg.Width) * int64(cfg.Height); limits.MaxPixels > 0 && pixels > limits.MaxPixels {
+		return nil, fmt.Errorf("%w: %d pixels, max %d", ErrUploadPixelsExceeded, pixels, limits.MaxPixels)
+	}
+
+	return &validatedUpload{data: data, filename: filename, format: format}, nil
+}
+
+// reencodeUpload 解碼後重新編碼，移除中繼資料與附加在圖片後的內容
+// 沒有編碼器的格式（HEIC、BMP、TIFF、SVG）轉為 PNG；GIF 只保留第一個影格
+func (s *imageService) reencodeUpload(u *validatedUpload) error {
+	img, err := s.processor.Process(bytes.NewReader(u.data), processor.ProcessOptions{})
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrUploadInvalidImage, err)
+	}
+
+	switch u.format {
+	case processor.FormatHEIC, processor.FormatBMP, processor.FormatTIFF, processor.FormatSVG:
+		u.filename = strings.TrimSuffix(u.filename, filepath.Ext(u.filename)) + processor.Extension(processor.FormatPNG)
+		u.format = processor.FormatPNG
+	}
+
+	data, err := s.processor.Encode(img, u.format, s.cfg.Processing.DefaultQuality)
+	if err != nil {
+		return fmt.Errorf("failed to re-encode upload: %w", err)
+	}
+	u.data = data
+	return nil
+}
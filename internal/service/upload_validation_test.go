@@ -0,0 +1,191 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"math/rand/v2"
+	"strings"
+	"testing"
+
+	"github.com/vincent119/images-filters/internal/config"
+	"github.com/vincent119/images-filters/internal/processor"
+	"github.com/vincent119/images-filters/internal/storage/types"
+)
+
+// createTestJPEG 建立測試用的 JPEG 圖片
+func createTestJPEG(width, height int) []byte {
+	var buf bytes.Buffer
+	_ = jpeg.Encode(&buf, image.NewRGBA(image.Rect(0, 0, width, height)), nil)
+	return buf.Bytes()
+}
+
+func newUploadTestService(upload config.UploadConfig) (*imageService, *MockStorage) {
+	cfg := &config.Config{
+		Processing: config.ProcessingConfig{DefaultQuality: 80},
+		Upload:     upload,
+	}
+	store := NewMockStorage()
+	return NewImageService(cfg, store, NewMockCache()).(*imageService), store
+}
+
+func TestUploadImage_Validation(t *testing.T) {
+	png := createTestImage(20, 10)
+	jpg := createTestJPEG(20, 10)
+
+	tests := []struct {
+		name        string
+		upload      config.UploadConfig
+		filename    string
+		contentType string
+		data        []byte
+		wantErr     error
+	}{
+		{"valid png", config.UploadConfig{}, "a.png", "image/png", png, nil},
+		{"generic content type", config.UploadConfig{}, "a.jpeg", "application/octet-stream", jpg, nil},
+		{"renamed html", config.UploadConfig{}, "a.png", "image/png", []byte("<html><script>alert(1)</script></html>"), ErrUploadUnsupportedFormat},
+		{"content type mismatch", config.UploadConfig{}, "a.png", "image/jpeg", png, ErrUploadFormatMismatch},
+		{"extension mismatch", config.UploadConfig{}, "a.jpg", "image/png", png, ErrUploadFormatMismatch},
+		{"non-image extension", config.UploadConfig{}, "a.html", "image/png", png, ErrUploadFormatMismatch},
+		{"truncated image", config.UploadConfig{}, "a.png", "image/png", png[:20], ErrUploadInvalidImage},
+		{"width limit", config.UploadConfig{MaxWidth: 19}, "a.png", "image/png", png, ErrUploadDimensionsExceeded},
+		{"height limit", config.UploadConfig{MaxHeight: 9}, "a.png", "image/png", png, ErrUploadDimensionsExceeded},
+		{"pixel limit", config.UploadConfig{MaxPixels: 199}, "a.png", "image/png", png, ErrUploadPixelsExceeded},
+		{"within limits", config.UploadConfig{MaxWidth: 20, MaxHeight: 10, MaxPixels: 200}, "a.png", "image/png", png, nil},
+		{"plain svg", config.UploadConfig{}, "a.svg", "image/svg+xml", []byte(`<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 4 4"><rect width="4" height="4"/></svg>`), nil},
+		{"svg with script", config.UploadConfig{}, "a.svg", "image/svg+xml", []byte(`<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 4 4"><script>alert(1)</script></svg>`), ErrUploadActiveContent},
+		{"svg with event handler", config.UploadConfig{}, "a.svg", "image/svg+xml", []byte(`<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 4 4" onload="alert(1)"></svg>`), ErrUploadActiveContent},
+		{"xhtml with svg", config.UploadConfig{}, "a.svg", "image/svg+xml", []byte(`<?xml version="1.0"?><html xmlns="http://www.w3.org/1999/xhtml"><script>alert(1)</script><svg></svg></html>`), ErrUploadUnsupportedFormat},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, _ := newUploadTestService(tt.upload)
+			_, err := svc.UploadImage(context.Background(), tt.filename, tt.contentType, bytes.NewReader(tt.data))
+			if tt.wantErr == nil {
+				if err != nil {
+					t.Fatalf("UploadImage() error = %v", err)
+				}
+				return
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("UploadImage() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestUploadImage_AppendsDetectedExtension(t *testing.T) {
+	svc, _ := newUploadTestService(config.UploadConfig{})
+
+	result, err := svc.UploadImage(context.Background(), "photo", "", bytes.NewReader(createTestImage(2, 2)))
+	if err != nil {
+		t.Fatalf("UploadImage() error = %v", err)
+	}
+	if !strings.HasSuffix(result.Path, "_photo.png") {
+		t.Errorf("Path = %s, want suffix _photo.png", result.Path)
+	}
+}
+
+func TestUploadImage_Reencode(t *testing.T) {
+	svc, store := newUploadTestService(config.UploadConfig{Reencode: true})
+
+	// 圖片後附加的內容（polyglot）在重新編碼後被移除
+	payload := []byte("<script>alert(1)</script>")
+	data := append(createTestImage(4, 4), payload...)
+
+	result, err := svc.UploadImage(context.Background(), "a.png", "image/png", bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("UploadImage() error = %v", err)
+	}
+	stored := store.data[result.Path]
+	if bytes.Contains(stored, payload) {
+		t.Error("re-encoded upload still contains the appended payload")
+	}
+	if processor.DetectFormat(stored) != processor.FormatPNG {
+		t.Error("re-encoded upload should stay PNG")
+	}
+}
+
+// createNoisePNG 建立無法壓縮的 PNG，用於產生大於 uploadHeaderSize 的上傳
+func createNoisePNG(width, height int) []byte {
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	rng := rand.New(rand.NewPCG(1, 2))
+	for i := range img.Pix {
+		img.Pix[i] = uint8(rng.Uint32())
+	}
+	var buf bytes.Buffer
+	_ = png.Encode(&buf, img)
+	return buf.Bytes()
+}
+
+// countingReader 記錄已讀取的位元組數
+type countingReader struct {
+	r io.Reader
+	n int
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += n
+	return n, err
+}
+
+// streamCheckStorage 記錄 PutStream 開始時來源已被讀取的位元組數
+type streamCheckStorage struct {
+	*MockStorage
+	source     *countingReader
+	readBefore int
+}
+
+func (s *streamCheckStorage) PutStream(ctx context.Context, key string, r io.Reader, opts ...types.PutOption) error {
+	s.readBefore = s.source.n
+	return s.MockStorage.PutStream(ctx, key, r, opts...)
+}
+
+func TestUploadImage_StreamsLargeUploads(t *testing.T) {
+	data := createNoisePNG(800, 800)
+	if len(data) <= uploadHeaderSize {
+		t.Fatalf("test image is %d bytes, want more than %d", len(data), uploadHeaderSize)
+	}
+
+	tests := []struct {
+		name       string
+		upload     config.UploadConfig
+		wantStream bool
+	}{
+		{"streamed", config.UploadConfig{}, true},
+		{"reencode reads everything", config.UploadConfig{Reencode: true}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source := &countingReader{r: bytes.NewReader(data)}
+			store := &streamCheckStorage{MockStorage: NewMockStorage(), source: source}
+			cfg := &config.Config{Processing: config.ProcessingConfig{DefaultQuality: 80}, Upload: tt.upload}
+			svc := NewImageService(cfg, store, NewMockCache())
+
+			result, err := svc.UploadImage(context.Background(), "noise.png", "image/png", source)
+			if err != nil {
+				t.Fatalf("UploadImage() error = %v", err)
+			}
+			if streamed := store.readBefore < len(data); streamed != tt.wantStream {
+				t.Errorf("read %d of %d bytes before PutStream, want streamed = %v", store.readBefore, len(data), tt.wantStream)
+			}
+			if tt.wantStream && !bytes.Equal(store.data[result.Path], data) {
+				t.Error("streamed upload does not match the original content")
+			}
+		})
+	}
+
+	// 以檔頭驗證時仍拒絕不符的宣告
+	svc, _ := newUploadTestService(config.UploadConfig{MaxWidth: 100})
+	_, err := svc.UploadImage(context.Background(), "noise.png", "image/png", bytes.NewReader(data))
+	if !errors.Is(err, ErrUploadDimensionsExceeded) {
+		t.Errorf("UploadImage() error = %v, want %v", err, ErrUploadDimensionsExceeded)
+	}
+}
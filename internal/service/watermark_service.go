@@ -4,7 +4,7 @@ import (
 	"bytes"
 	"context"
 	"io"
-	"strings"
+	"time"
 
 	"github.com/disintegration/imaging"
 	"github.com/vincent119/images-filters/internal/config"
@@ -15,9 +15,16 @@ import (
 
 // DetectionResult 浮水印檢測結果
 type DetectionResult struct {
-	Detected   bool    `json:"detected"`
-	Text       string  `json:"text"`
-	Confidence float64 `json:"confidence"`
+	Detected   bool           `json:"detected"`
+	Text       string         `json:"text"`
+	Confidence float64        `json:"confidence"`
+	Recipient  *RecipientInfo `json:"recipient,omitempty"`
+}
+
+// RecipientInfo 收件者浮水印內容（由 recipient(id) 濾鏡於算圖時嵌入）
+type RecipientInfo struct {
+	ID         string    `json:"id"`
+	RenderedAt time.Time `json:"rendered_at"`
 }
 
 // WatermarkService 浮水印服務介面
@@ -62,64 +69,34 @@ func (s *watermarkService) DetectWatermark(ctx context.Context, file io.Reader)
 		return nil, err
 	}
 
-	// 2. 準備濾鏡與參數
-	bwFilter := filter.NewBlindWatermarkFilter()
-	expectedText := s.cfg.BlindWatermark.Text
-	// 如果配置中沒有設定文字，預設使用一個合理的長度嘗試提取，或者直接報錯？
-	// 這裡假設我們想檢測"任何"浮水印，但我們需要長度。
-	// 如果配置有文字，就用該長度。否則預設 16。
-	length := len(expectedText)
+	// 2. 檢測文字浮水印（酬載自帶長度與校驗碼，不需預先知道文字內容）
+	reader := filter.NewBlindWatermarkReader(img)
+	key := blindWatermarkKey(s.cfg)
+	det := reader.Detect(key)
 
-	// 3. 提取
-	extractedText, err := bwFilter.Extract(img, length)
-	if err != nil {
-		return nil, err
+	result := &DetectionResult{
+		Detected:   det.Detected,
+		Text:       det.Text,
+		Confidence: det.Confidence,
 	}
 
-	// 4. 分析結果
-	detected := false
-	confidence := 0.0
-
-	// 簡單移除空字符
-	extractedText = strings.Trim(extractedText, "\x00")
-
-	if expectedText != "" {
-		// 計算相似度 (Levenshtein Distance 簡易版或直接比較)
-		// 這裡做一個簡單的包含檢查或相等檢查
-		if extractedText == expectedText {
-			detected = true
-			confidence = 1.0
-		} else {
-			// 如果部分匹配（例如雜訊導致幾個字元錯了）
-			// 計算匹配率
-			matchCount := 0
-			minLen := len(expectedText)
-			if len(extractedText) < minLen {
-				minLen = len(extractedText)
-			}
-			for i := 0; i < minLen; i++ {
-				if extractedText[i] == expectedText[i] {
-					matchCount++
-				}
-			}
-			if minLen > 0 {
-				confidence = float64(matchCount) / float64(len(expectedText))
-			}
-			// 設定一個閾值，例如 70% 相似度視為檢測到
-			if confidence >= 0.7 {
-				detected = true
-			}
+	// 3. 收件者浮水印（校驗碼相符才視為檢測到）
+	if payload, ok := reader.Recipient(key); ok {
+		result.Detected = true
+		result.Confidence = max(result.Confidence, payload.Confidence)
+		result.Recipient = &RecipientInfo{
+			ID:         payload.RecipientID,
+			RenderedAt: payload.Timestamp,
 		}
-	} else {
-		// 如果沒有預期文字，只要提取出的文字看起來是 ASCII 可讀的，就當作檢測到？
-		// 這裡簡單回傳提取結果
-		detected = true
-		confidence = 0.5 // 不確定
 	}
 
-	return &DetectionResult{
-		Detected:   detected,
-		Text:       extractedText,
-		Confidence: confidence,
-	}, nil
+	return result, nil
+}
+
+// blindWatermarkKey 取得隱形浮水印金鑰，未設定時使用簽名金鑰
+func blindWatermarkKey(cfg *config.Config) string {
+	if cfg.BlindWatermark.SecurityKey != "" {
+		return cfg.BlindWatermark.SecurityKey
+	}
+	return cfg.Security.SecurityKey
 }
@@ -9,8 +9,11 @@ import (
 	"image/png"
 	"io"
 	"testing"
+	"time"
 
 	"github.com/vincent119/images-filters/internal/config"
+	"github.com/vincent119/images-filters/internal/filter"
+	"github.com/vincent119/images-filters/internal/storage/types"
 )
 
 // mockStorage 模擬儲存（專供 watermark_service 測試使用）
@@ -31,7 +34,7 @@ func (m *mockStorage) Get(_ context.Context, key string) ([]byte, error) {
 	return nil, errors.New("file not found")
 }
 
-func (m *mockStorage) Put(_ context.Context, key string, data []byte) error {
+func (m *mockStorage) Put(_ context.Context, key string, data []byte, _ ...types.PutOption) error {
 	m.data[key] = data
 	return nil
 }
@@ -50,10 +53,18 @@ func (m *mockStorage) GetStream(_ context.Context, _ string) (io.ReadCloser, err
 	return nil, errors.New("not implemented")
 }
 
-func (m *mockStorage) PutStream(_ context.Context, _ string, _ io.Reader) error {
+func (m *mockStorage) PutStream(_ context.Context, _ string, _ io.Reader, _ ...types.PutOption) error {
 	return errors.New("not implemented")
 }
 
+func (m *mockStorage) Stat(_ context.Context, _ string) (*types.ObjectInfo, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (m *mockStorage) List(_ context.Context, _ string, _ types.ListOptions) (*types.ListResult, error) {
+	return nil, errors.New("not implemented")
+}
+
 // createTestImage 建立測試用的簡單圖片
 func createTestImage(width, height int) []byte {
 	img := image.NewRGBA(image.Rect(0, 0, width, height))
@@ -179,8 +190,95 @@ func TestDetectWatermark_EmptyExpectedText(t *testing.T) {
 		t.Fatalf("預期無錯誤，但得到 %v", err)
 	}
 
-	// 當沒有預期文字時，confidence 應為 0.5
-	if result.Confidence != 0.5 {
-		t.Errorf("預期 confidence 為 0.5，但得到 %v", result.Confidence)
+	// 未嵌入浮水印的圖片不應檢測到
+	if result.Detected || result.Confidence != 0 {
+		t.Errorf("預期未檢測到浮水印，但得到 %+v", result)
+	}
+
+	// 嵌入浮水印後不需設定文字即可讀出
+	img, err := png.Decode(bytes.NewReader(createPatternImage(256, 256)))
+	if err != nil {
+		t.Fatalf("png decode failed: %v", err)
+	}
+	bw := filter.NewBlindWatermarkFilter()
+	bw.Text = "ACME"
+	marked, err := bw.Apply(img, nil)
+	if err != nil {
+		t.Fatalf("Apply failed: %v", err)
+	}
+	buf := new(bytes.Buffer)
+	if err := png.Encode(buf, marked); err != nil {
+		t.Fatalf("png encode failed: %v", err)
+	}
+
+	result, err = svc.DetectWatermark(context.Background(), buf)
+	if err != nil {
+		t.Fatalf("預期無錯誤，但得到 %v", err)
+	}
+	if !result.Detected || result.Text != "ACME" || result.Confidence < 0.5 {
+		t.Errorf("預期檢測到 ACME，但得到 %+v", result)
+	}
+}
+
+// createPatternImage 建立具有紋理的測試圖片
+func createPatternImage(width, height int) []byte {
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for i := range img.Pix {
+		img.Pix[i] = byte(i * 7 % 251)
+	}
+	for i := 3; i < len(img.Pix); i += 4 {
+		img.Pix[i] = 255
+	}
+
+	buf := new(bytes.Buffer)
+	_ = png.Encode(buf, img)
+	return buf.Bytes()
+}
+
+func TestDetectWatermark_Recipient(t *testing.T) {
+	cfg := &config.Config{
+		BlindWatermark: config.BlindWatermarkConfig{
+			Enabled:     true,
+			SecurityKey: "detect-key",
+		},
+	}
+	svc := NewWatermarkService(cfg, newMockStorage())
+
+	img := image.NewNRGBA(image.Rect(0, 0, 256, 256))
+	for i := range img.Pix {
+		img.Pix[i] = byte(i * 7 % 251)
+	}
+	f := filter.NewRecipientWatermarkFilter()
+	f.SecurityKey = "detect-key"
+	marked, err := f.Apply(img, []string{"user-42"})
+	if err != nil {
+		t.Fatalf("Apply failed: %v", err)
+	}
+	buf := new(bytes.Buffer)
+	if err := png.Encode(buf, marked); err != nil {
+		t.Fatalf("png encode failed: %v", err)
+	}
+
+	result, err := svc.DetectWatermark(context.Background(), buf)
+	if err != nil {
+		t.Fatalf("預期無錯誤，但得到 %v", err)
+	}
+	if !result.Detected || result.Recipient == nil {
+		t.Fatalf("預期檢測到收件者浮水印，但得到 %+v", result)
+	}
+	if result.Recipient.ID != "user-42" {
+		t.Errorf("預期收件者 user-42，但得到 %q", result.Recipient.ID)
+	}
+	if time.Since(result.Recipient.RenderedAt) > time.Minute {
+		t.Errorf("算圖時間不正確: %v", result.Recipient.RenderedAt)
+	}
+
+	// 未嵌入收件者浮水印的圖片
+	result, err = svc.DetectWatermark(context.Background(), bytes.NewReader(createTestImage(128, 128)))
+	if err != nil {
+		t.Fatalf("預期無錯誤，但得到 %v", err)
+	}
+	if result.Recipient != nil {
+		t.Errorf("預期無收件者，但得到 %+v", result.Recipient)
 	}
 }
@@ -0,0 +1,280 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/runtime"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/blob"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/bloberror"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/container"
+	"github.com/vincent119/images-filters/internal/config"
+	stypes "github.com/vincent119/images-filters/internal/storage/types"
+)
+
+// AzureBlobAPI defines the Azure Blob client operations used by AzureBlobStorage to allow mocking
+type AzureBlobAPI interface {
+	DownloadStream(ctx context.Context, containerName string, blobName string, o *azblob.DownloadStreamOptions) (azblob.DownloadStreamResponse, error)
+	UploadStream(ctx context.Context, containerName string, blobName string, body io.Reader, o *azblob.UploadStreamOptions) (azblob.UploadStreamResponse, error)
+	DeleteBlob(ctx context.Context, containerName string, blobName string, o *azblob.DeleteBlobOptions) (azblob.DeleteBlobResponse, error)
+	NewListBlobsFlatPager(containerName string, o *azblob.ListBlobsFlatOptions) *runtime.Pager[azblob.ListBlobsFlatResponse]
+	GetProperties(ctx context.Context, containerName string, blobName string, o *blob.GetPropertiesOptions) (blob.GetPropertiesResponse, error)
+}
+
+// azureClient adds GetProperties to azblob.Client, which only exposes it on blob clients
+type azureClient struct {
+	*azblob.Client
+}
+
+func (c *azureClient) GetProperties(ctx context.Context, containerName string, blobName string, o *blob.GetPropertiesOptions) (blob.GetPropertiesResponse, error) {
+	return c.ServiceClient().NewContainerClient(containerName).NewBlobClient(blobName).GetProperties(ctx, o)
+}
+
+// AzureBlobStorage implements Storage interface using Azure Blob Storage
+type AzureBlobStorage struct {
+	client    AzureBlobAPI
+	container string
+}
+
+// NewAzureBlobStorage creates a new Azure Blob storage instance
+// A connection string takes precedence (e.g. Azurite); otherwise the account name and key are used.
+// Without a key, the endpoint must carry its own authorization (e.g. a SAS token).
+func NewAzureBlobStorage(cfg config.AzureBlobStorageConfig) (*AzureBlobStorage, error) {
+	var (
+		client *azblob.Client
+		err    error
+	)
+
+	serviceURL := cfg.Endpoint
+	if serviceURL == "" {
+		serviceURL = fmt.Sprintf("https://%s.blob.core.windows.net/", cfg.AccountName)
+	}
+
+	switch {
+	case cfg.ConnectionString != "":
+		client, err = azblob.NewClientFromConnectionString(cfg.ConnectionString, nil)
+	case cfg.AccountKey != "":
+		var cred *azblob.SharedKeyCredential
+		cred, err = azblob.NewSharedKeyCredential(cfg.AccountName, cfg.AccountKey)
+		if err == nil {
+			client, err = azblob.NewClientWithSharedKeyCredential(serviceURL, cred, nil)
+		}
+	default:
+		client, err = azblob.NewClientWithNoCredential(serviceURL, nil)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create azure blob client: %w", err)
+	}
+
+	return &AzureBlobStorage{
+		client:    &azureClient{Client: client},
+		container: cfg.Container,
+	}, nil
+}
+
+// Get retrieves image data from Azure Blob Storage
+func (s *AzureBlobStorage) Get(ctx context.Context, key string) ([]byte, error) {
+	r, err := s.GetStream(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read blob body: %w", err)
+	}
+	return data, nil
+}
+
+// Put saves image data to Azure Blob Storage
+// Content-Type is detected from the data unless set via options
+func (s *AzureBlobStorage) Put(ctx context.Context, key string, data []byte, opts ...stypes.PutOption) error {
+	o := stypes.ApplyPutOptions(opts...)
+	if o.ContentType == "" {
+		o.ContentType = http.DetectContentType(data)
+	}
+	return s.upload(ctx, key, bytes.NewReader(data), o)
+}
+
+// Exists checks if image exists in Azure Blob Storage
+func (s *AzureBlobStorage) Exists(ctx context.Context, key string) (bool, error) {
+	_, err := s.client.GetProperties(ctx, s.container, key, nil)
+	if err != nil {
+		if bloberror.HasCode(err, bloberror.BlobNotFound) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to get blob properties: %w", err)
+	}
+	return true, nil
+}
+
+// Version returns the blob VersionId when versioning is enabled, otherwise the ETag
+func (s *AzureBlobStorage) Version(ctx context.Context, key string) (string, error) {
+	props, err := s.client.GetProperties(ctx, s.container, key, nil)
+	if err != nil {
+		if bloberror.HasCode(err, bloberror.BlobNotFound) {
+			return "", fmt.Errorf("%w: %s", stypes.ErrNotFound, key)
+		}
+		return "", fmt.Errorf("failed to get blob properties: %w", err)
+	}
+	if props.VersionID != nil && *props.VersionID != "" {
+		return *props.VersionID, nil
+	}
+	if props.ETag != nil {
+		return strings.Trim(string(*props.ETag), `"`), nil
+	}
+	return "", fmt.Errorf("blob has no version: %s", key)
+}
+
+// Delete removes image from Azure Blob Storage; a missing blob is not an error
+func (s *AzureBlobStorage) Delete(ctx context.Context, key string) error {
+	_, err := s.client.DeleteBlob(ctx, s.container, key, nil)
+	if err != nil && !bloberror.HasCode(err, bloberror.BlobNotFound) {
+		return fmt.Errorf("failed to delete blob: %w", err)
+	}
+	return nil
+}
+
+// GetStream retrieves image data stream from Azure Blob Storage
+func (s *AzureBlobStorage) GetStream(ctx context.Context, key string) (io.ReadCloser, error) {
+	resp, err := s.client.DownloadStream(ctx, s.container, key, nil)
+	if err != nil {
+		if bloberror.HasCode(err, bloberror.BlobNotFound) {
+			return nil, fmt.Errorf("%w: %s", stypes.ErrNotFound, key)
+		}
+		return nil, fmt.Errorf("failed to download blob: %w", err)
+	}
+	return resp.Body, nil
+}
+
+// PutStream saves image data stream to Azure Blob Storage
+func (s *AzureBlobStorage) PutStream(ctx context.Context, key string, r io.Reader, opts ...stypes.PutOption) error {
+	o := stypes.ApplyPutOptions(opts...)
+	if o.ContentType == "" {
+		o.ContentType = "application/octet-stream"
+	}
+	return s.upload(ctx, key, r, o)
+}
+
+func (s *AzureBlobStorage) upload(ctx context.Context, key string, r io.Reader, o stypes.PutOptions) error {
+	headers := &blob.HTTPHeaders{BlobContentType: &o.ContentType}
+	if o.CacheControl != "" {
+		headers.BlobCacheControl = &o.CacheControl
+	}
+
+	var metadata map[string]*string
+	if len(o.Metadata) > 0 {
+		metadata = make(map[string]*string, len(o.Metadata))
+		for k, v := range o.Metadata {
+			metadata[k] = &v
+		}
+	}
+
+	_, err := s.client.UploadStream(ctx, s.container, key, r, &azblob.UploadStreamOptions{
+		HTTPHeaders: headers,
+		Metadata:    metadata,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to upload blob: %w", err)
+	}
+	return nil
+}
+
+// Stat retrieves blob properties
+func (s *AzureBlobStorage) Stat(ctx context.Context, key string) (*stypes.ObjectInfo, error) {
+	props, err := s.client.GetProperties(ctx, s.container, key, nil)
+	if err != nil {
+		if bloberror.HasCode(err, bloberror.BlobNotFound) {
+			return nil, fmt.Errorf("%w: %s", stypes.ErrNotFound, key)
+		}
+		return nil, fmt.Errorf("failed to get blob properties: %w", err)
+	}
+
+	info := &stypes.ObjectInfo{
+		Key:          key,
+		Size:         deref(props.ContentLength),
+		ModTime:      deref(props.LastModified),
+		ContentType:  deref(props.ContentType),
+		CacheControl: deref(props.CacheControl),
+		Metadata:     azureMetadata(props.Metadata),
+	}
+	if props.ETag != nil {
+		info.ETag = strings.Trim(string(*props.ETag), `"`)
+	}
+	return info, nil
+}
+
+// List lists blobs by prefix; the cursor is the Azure continuation marker
+func (s *AzureBlobStorage) List(ctx context.Context, prefix string, opts stypes.ListOptions) (*stypes.ListResult, error) {
+	limit := int32(opts.Limit)
+	if limit <= 0 {
+		limit = stypes.DefaultListLimit
+	}
+
+	listOpts := &azblob.ListBlobsFlatOptions{
+		Prefix:     &prefix,
+		MaxResults: &limit,
+		Include:    azblob.ListBlobsInclude{Metadata: true},
+	}
+	if opts.Cursor != "" {
+		listOpts.Marker = &opts.Cursor
+	}
+
+	page, err := s.client.NewListBlobsFlatPager(s.container, listOpts).NextPage(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list blobs: %w", err)
+	}
+
+	result := &stypes.ListResult{NextCursor: deref(page.NextMarker)}
+	if page.Segment == nil {
+		return result, nil
+	}
+	result.Objects = make([]stypes.ObjectInfo, 0, len(page.Segment.BlobItems))
+	for _, item := range page.Segment.BlobItems {
+		result.Objects = append(result.Objects, azureObjectInfo(item))
+	}
+	return result, nil
+}
+
+func azureObjectInfo(item *container.BlobItem) stypes.ObjectInfo {
+	info := stypes.ObjectInfo{
+		Key:      deref(item.Name),
+		Metadata: azureMetadata(item.Metadata),
+	}
+	if p := item.Properties; p != nil {
+		info.Size = deref(p.ContentLength)
+		info.ModTime = deref(p.LastModified)
+		info.ContentType = deref(p.ContentType)
+		info.CacheControl = deref(p.CacheControl)
+		if p.ETag != nil {
+			info.ETag = strings.Trim(string(*p.ETag), `"`)
+		}
+	}
+	return info
+}
+
+func azureMetadata(m map[string]*string) map[string]string {
+	if len(m) == 0 {
+		return nil
+	}
+	out := make(map[string]string, len(m))
+	for k, v := range m {
+		out[k] = deref(v)
+	}
+	return out
+}
+
+// deref returns the value of p or the zero value when p is nil
+func deref[T any](p *T) T {
+	if p == nil {
+		var zero T
+		return zero
+	}
+	return *p
+}
@@ -0,0 +1,280 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/runtime"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/blob"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/bloberror"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/container"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	stypes "github.com/vincent119/images-filters/internal/storage/types"
+)
+
+// MockAzureBlobAPI is an in-process stand-in for an Azure Blob container
+type MockAzureBlobAPI struct {
+	mu    sync.RWMutex
+	blobs map[string]*mockAzureBlob
+	etag  int
+}
+
+type mockAzureBlob struct {
+	data     []byte
+	headers  blob.HTTPHeaders
+	metadata map[string]*string
+	etag     azcore.ETag
+	modified time.Time
+}
+
+func NewMockAzureBlobAPI() *MockAzureBlobAPI {
+	return &MockAzureBlobAPI{blobs: make(map[string]*mockAzureBlob)}
+}
+
+func blobNotFound() error {
+	return &azcore.ResponseError{ErrorCode: string(bloberror.BlobNotFound), StatusCode: http.StatusNotFound}
+}
+
+func (m *MockAzureBlobAPI) DownloadStream(ctx context.Context, containerName string, blobName string, o *azblob.DownloadStreamOptions) (azblob.DownloadStreamResponse, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	b, ok := m.blobs[blobName]
+	if !ok {
+		return azblob.DownloadStreamResponse{}, blobNotFound()
+	}
+	var resp azblob.DownloadStreamResponse
+	resp.Body = io.NopCloser(bytes.NewReader(b.data))
+	return resp, nil
+}
+
+func (m *MockAzureBlobAPI) UploadStream(ctx context.Context, containerName string, blobName string, body io.Reader, o *azblob.UploadStreamOptions) (azblob.UploadStreamResponse, error) {
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return azblob.UploadStreamResponse{}, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.etag++
+	b := &mockAzureBlob{
+		data:     data,
+		etag:     azcore.ETag(fmt.Sprintf(`"0x%X"`, m.etag)),
+		modified: time.Now(),
+	}
+	if o != nil {
+		if o.HTTPHeaders != nil {
+			b.headers = *o.HTTPHeaders
+		}
+		b.metadata = o.Metadata
+	}
+	m.blobs[blobName] = b
+	return azblob.UploadStreamResponse{}, nil
+}
+
+func (m *MockAzureBlobAPI) DeleteBlob(ctx context.Context, containerName string, blobName string, o *azblob.DeleteBlobOptions) (azblob.DeleteBlobResponse, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.blobs[blobName]; !ok {
+		return azblob.DeleteBlobResponse{}, blobNotFound()
+	}
+	delete(m.blobs, blobName)
+	return azblob.DeleteBlobResponse{}, nil
+}
+
+// NewListBlobsFlatPager lists sorted names; the marker is the last returned name
+func (m *MockAzureBlobAPI) NewListBlobsFlatPager(containerName string, o *azblob.ListBlobsFlatOptions) *runtime.Pager[azblob.ListBlobsFlatResponse] {
+	prefix, marker, limit := "", "", 5000
+	if o != nil {
+		if o.Prefix != nil {
+			prefix = *o.Prefix
+		}
+		if o.Marker != nil {
+			marker = *o.Marker
+		}
+		if o.MaxResults != nil {
+			limit = int(*o.MaxResults)
+		}
+	}
+
+	return runtime.NewPager(runtime.PagingHandler[azblob.ListBlobsFlatResponse]{
+		More: func(page azblob.ListBlobsFlatResponse) bool {
+			return page.NextMarker != nil && *page.NextMarker != ""
+		},
+		Fetcher: func(ctx context.Context, page *azblob.ListBlobsFlatResponse) (azblob.ListBlobsFlatResponse, error) {
+			after := marker
+			if page != nil && page.NextMarker != nil {
+				after = *page.NextMarker
+			}
+			return m.listPage(prefix, after, limit), nil
+		},
+	})
+}
+
+func (m *MockAzureBlobAPI) listPage(prefix, after string, limit int) azblob.ListBlobsFlatResponse {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	names := make([]string, 0, len(m.blobs))
+	for name := range m.blobs {
+		if strings.HasPrefix(name, prefix) && name > after {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	var resp azblob.ListBlobsFlatResponse
+	if len(names) > limit {
+		names = names[:limit]
+		next := names[len(names)-1]
+		resp.NextMarker = &next
+	}
+	resp.Segment = &container.BlobFlatListSegment{}
+	for _, name := range names {
+		b := m.blobs[name]
+		size := int64(len(b.data))
+		resp.Segment.BlobItems = append(resp.Segment.BlobItems, &container.BlobItem{
+			Name:     &name,
+			Metadata: b.metadata,
+			Properties: &container.BlobProperties{
+				ContentLength: &size,
+				ContentType:   b.headers.BlobContentType,
+				CacheControl:  b.headers.BlobCacheControl,
+				ETag:          &b.etag,
+				LastModified:  &b.modified,
+			},
+		})
+	}
+	return resp
+}
+
+func (m *MockAzureBlobAPI) GetProperties(ctx context.Context, containerName string, blobName string, o *blob.GetPropertiesOptions) (blob.GetPropertiesResponse, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	b, ok := m.blobs[blobName]
+	if !ok {
+		return blob.GetPropertiesResponse{}, blobNotFound()
+	}
+	size := int64(len(b.data))
+	return blob.GetPropertiesResponse{
+		ContentLength: &size,
+		ContentType:   b.headers.BlobContentType,
+		CacheControl:  b.headers.BlobCacheControl,
+		ETag:          &b.etag,
+		LastModified:  &b.modified,
+		Metadata:      b.metadata,
+	}, nil
+}
+
+func setupTestAzureBlobStorage() (*AzureBlobStorage, *MockAzureBlobAPI) {
+	mockAPI := NewMockAzureBlobAPI()
+	return &AzureBlobStorage{client: mockAPI, container: "test-container"}, mockAPI
+}
+
+func TestAzureBlobStorage_Basic(t *testing.T) {
+	storage, mockAPI := setupTestAzureBlobStorage()
+	ctx := context.Background()
+	key := "test/image.png"
+	data := []byte("\x89PNG\r\n\x1a\nrest")
+
+	_, err := storage.Get(ctx, key)
+	assert.ErrorIs(t, err, stypes.ErrNotFound)
+	exists, err := storage.Exists(ctx, key)
+	require.NoError(t, err)
+	assert.False(t, exists)
+
+	require.NoError(t, storage.Put(ctx, key, data))
+	assert.Equal(t, "image/png", *mockAPI.blobs[key].headers.BlobContentType)
+
+	got, err := storage.Get(ctx, key)
+	require.NoError(t, err)
+	assert.Equal(t, data, got)
+	exists, err = storage.Exists(ctx, key)
+	require.NoError(t, err)
+	assert.True(t, exists)
+
+	// Delete is idempotent
+	require.NoError(t, storage.Delete(ctx, key))
+	require.NoError(t, storage.Delete(ctx, key))
+	exists, _ = storage.Exists(ctx, key)
+	assert.False(t, exists)
+}
+
+func TestAzureBlobStorage_Stream(t *testing.T) {
+	storage, mockAPI := setupTestAzureBlobStorage()
+	ctx := context.Background()
+
+	require.NoError(t, storage.PutStream(ctx, "s.bin", strings.NewReader("stream data")))
+	assert.Equal(t, "application/octet-stream", *mockAPI.blobs["s.bin"].headers.BlobContentType)
+
+	r, err := storage.GetStream(ctx, "s.bin")
+	require.NoError(t, err)
+	defer r.Close()
+	data, err := io.ReadAll(r)
+	require.NoError(t, err)
+	assert.Equal(t, "stream data", string(data))
+
+	_, err = storage.GetStream(ctx, "missing")
+	assert.ErrorIs(t, err, stypes.ErrNotFound)
+}
+
+func TestAzureBlobStorage_StatVersionAndList(t *testing.T) {
+	storage, _ := setupTestAzureBlobStorage()
+	ctx := context.Background()
+
+	_, err := storage.Stat(ctx, "missing.jpg")
+	assert.ErrorIs(t, err, stypes.ErrNotFound)
+	_, err = storage.Version(ctx, "missing.jpg")
+	assert.ErrorIs(t, err, stypes.ErrNotFound)
+
+	require.NoError(t, storage.Put(ctx, "img/a.jpg", []byte("v1"),
+		stypes.WithContentType("image/jpeg"),
+		stypes.WithCacheControl("public, max-age=60"),
+		stypes.WithMetadata(map[string]string{"owner": "alice"}),
+	))
+	info, err := storage.Stat(ctx, "img/a.jpg")
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), info.Size)
+	assert.Equal(t, "image/jpeg", info.ContentType)
+	assert.Equal(t, "public, max-age=60", info.CacheControl)
+	assert.Equal(t, "alice", info.Metadata["owner"])
+	assert.NotContains(t, info.ETag, `"`)
+
+	// Overwriting changes the ETag
+	v1, err := storage.Version(ctx, "img/a.jpg")
+	require.NoError(t, err)
+	require.NoError(t, storage.Put(ctx, "img/a.jpg", []byte("v2")))
+	v2, err := storage.Version(ctx, "img/a.jpg")
+	require.NoError(t, err)
+	assert.NotEqual(t, v1, v2)
+
+	require.NoError(t, storage.Put(ctx, "img/b.jpg", []byte("b")))
+	require.NoError(t, storage.Put(ctx, "other/c.jpg", []byte("c")))
+
+	page, err := storage.List(ctx, "img/", stypes.ListOptions{Limit: 1})
+	require.NoError(t, err)
+	require.Len(t, page.Objects, 1)
+	assert.Equal(t, "img/a.jpg", page.Objects[0].Key)
+	assert.Equal(t, int64(2), page.Objects[0].Size)
+	require.NotEmpty(t, page.NextCursor)
+
+	page, err = storage.List(ctx, "img/", stypes.ListOptions{Limit: 1, Cursor: page.NextCursor})
+	require.NoError(t, err)
+	require.Len(t, page.Objects, 1)
+	assert.Equal(t, "img/b.jpg", page.Objects[0].Key)
+	assert.Empty(t, page.NextCursor)
+}
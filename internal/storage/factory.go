@@ -13,6 +13,12 @@ func NewStorage(ctx context.Context, cfg *config.Config) (Storage, error) {
 	return NewStorageByType(ctx, cfg, cfg.Storage.Type)
 }
 
+// NewStorageFromConfig creates a standalone storage instance from its own storage section,
+// e.g. the dedicated API key store, independent of the image storage
+func NewStorageFromConfig(ctx context.Context, sc config.StorageConfig) (Storage, error) {
+	return NewStorageByType(ctx, &config.Config{Storage: sc}, sc.Type)
+}
+
 // NewStorageByType creates a storage instance by specific type name
 // This allows recursive creation for mixed storage
 func NewStorageByType(ctx context.Context, cfg *config.Config, storageType string) (Storage, error) {
@@ -38,6 +44,12 @@ func NewStorageByType(ctx context.Context, cfg *config.Config, storageType strin
 	case "s3":
 		return NewS3Storage(ctx, cfg.Storage.S3)
 
+	case "gcs":
+		return NewGCSStorage(ctx, cfg.Storage.GCS)
+
+	case "azblob":
+		return NewAzureBlobStorage(cfg.Storage.AzBlob)
+
 	case "mixed":
 		// Recursive creation
 		sourceType := cfg.Storage.Mixed.SourceStorage
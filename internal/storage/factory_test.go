@@ -10,6 +10,11 @@ import (
 	"github.com/vincent119/images-filters/internal/config"
 )
 
+// azuriteConnectionString uses Azurite's well-known development account
+const azuriteConnectionString = "DefaultEndpointsProtocol=http;AccountName=devstoreaccount1;" +
+	"AccountKey=Eby8vdM02xNOcqFlqUwJPLlmEtlCDXJ1OUzFT50uSRZ6IFsuFq2UVErCz4I6tq/K1SZFPTOtr/KBHBeksoGMGw==;" +
+	"BlobEndpoint=http://127.0.0.1:10000/devstoreaccount1;"
+
 func TestNewStorage(t *testing.T) {
 	tmpDir, _ := ioutil.TempDir("", "storage_test")
 	defer os.RemoveAll(tmpDir)
@@ -78,6 +83,66 @@ func TestNewStorage(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name: "GCS Storage - Emulator Endpoint",
+			cfg: &config.Config{
+				Storage: config.StorageConfig{
+					Type: "gcs",
+					GCS: config.GCSStorageConfig{
+						Bucket:   "images",
+						Endpoint: "http://127.0.0.1:4443/storage/v1/",
+					},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "Azure Blob Storage - Azurite Connection String",
+			cfg: &config.Config{
+				Storage: config.StorageConfig{
+					Type: "azblob",
+					AzBlob: config.AzureBlobStorageConfig{
+						Container:        "images",
+						ConnectionString: azuriteConnectionString,
+					},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "Mixed Storage - GCS Source, Azure Result",
+			cfg: &config.Config{
+				Storage: config.StorageConfig{
+					Type: "mixed",
+					Mixed: config.MixedStorageConfig{
+						SourceStorage: "gcs",
+						ResultStorage: "azblob",
+					},
+					GCS: config.GCSStorageConfig{
+						Bucket:   "images",
+						Endpoint: "http://127.0.0.1:4443/storage/v1/",
+					},
+					AzBlob: config.AzureBlobStorageConfig{
+						Container:        "images",
+						ConnectionString: azuriteConnectionString,
+					},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "Azure Blob Storage - Invalid Connection String",
+			cfg: &config.Config{
+				Storage: config.StorageConfig{
+					Type: "azblob",
+					AzBlob: config.AzureBlobStorageConfig{
+						Container:        "images",
+						ConnectionString: "not-a-connection-string",
+					},
+				},
+			},
+			wantErr: true,
+		},
 		{
 			name: "Mixed Storage - Nested Mixed Error",
 			cfg: &config.Config{
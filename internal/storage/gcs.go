@@ -0,0 +1,242 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+
+	gcs "cloud.google.com/go/storage"
+	"github.com/vincent119/images-filters/internal/config"
+	stypes "github.com/vincent119/images-filters/internal/storage/types"
+	"google.golang.org/api/iterator"
+	"google.golang.org/api/option"
+)
+
+// GCSAPI defines the bucket operations used by GCSStorage to allow mocking
+type GCSAPI interface {
+	NewReader(ctx context.Context, object string) (io.ReadCloser, error)
+	// NewWriter returns a writer that uploads the object on Close
+	NewWriter(ctx context.Context, object string, attrs gcs.ObjectAttrs) io.WriteCloser
+	Attrs(ctx context.Context, object string) (*gcs.ObjectAttrs, error)
+	Delete(ctx context.Context, object string) error
+	// ListPage returns one page of objects and the token of the next page (empty when done)
+	ListPage(ctx context.Context, prefix, pageToken string, pageSize int) ([]*gcs.ObjectAttrs, string, error)
+}
+
+// gcsBucket adapts a GCS bucket handle to GCSAPI
+type gcsBucket struct {
+	bucket *gcs.BucketHandle
+}
+
+func (b *gcsBucket) NewReader(ctx context.Context, object string) (io.ReadCloser, error) {
+	return b.bucket.Object(object).NewReader(ctx)
+}
+
+func (b *gcsBucket) NewWriter(ctx context.Context, object string, attrs gcs.ObjectAttrs) io.WriteCloser {
+	w := b.bucket.Object(object).NewWriter(ctx)
+	w.ContentType = attrs.ContentType
+	w.CacheControl = attrs.CacheControl
+	w.Metadata = attrs.Metadata
+	return w
+}
+
+func (b *gcsBucket) Attrs(ctx context.Context, object string) (*gcs.ObjectAttrs, error) {
+	return b.bucket.Object(object).Attrs(ctx)
+}
+
+func (b *gcsBucket) Delete(ctx context.Context, object string) error {
+	return b.bucket.Object(object).Delete(ctx)
+}
+
+func (b *gcsBucket) ListPage(ctx context.Context, prefix, pageToken string, pageSize int) ([]*gcs.ObjectAttrs, string, error) {
+	it := b.bucket.Objects(ctx, &gcs.Query{Prefix: prefix})
+	var objects []*gcs.ObjectAttrs
+	next, err := iterator.NewPager(it, pageSize, pageToken).NextPage(&objects)
+	return objects, next, err
+}
+
+// GCSStorage implements Storage interface using Google Cloud Storage
+type GCSStorage struct {
+	client GCSAPI
+	bucket string
+}
+
+// NewGCSStorage creates a new GCS storage instance
+// Credentials come from credentials_file or Application Default Credentials.
+// A custom endpoint (e.g. fake-gcs-server) without credentials_file uses anonymous access.
+func NewGCSStorage(ctx context.Context, cfg config.GCSStorageConfig) (*GCSStorage, error) {
+	var opts []option.ClientOption
+	if cfg.CredentialsFile != "" {
+		opts = append(opts, option.WithCredentialsFile(cfg.CredentialsFile))
+	}
+	if cfg.Endpoint != "" {
+		opts = append(opts, option.WithEndpoint(cfg.Endpoint))
+		if cfg.CredentialsFile == "" {
+			opts = append(opts, option.WithoutAuthentication())
+		}
+	}
+
+	client, err := gcs.NewClient(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create gcs client: %w", err)
+	}
+
+	return &GCSStorage{
+		client: &gcsBucket{bucket: client.Bucket(cfg.Bucket)},
+		bucket: cfg.Bucket,
+	}, nil
+}
+
+// Get retrieves image data from GCS
+func (s *GCSStorage) Get(ctx context.Context, key string) ([]byte, error) {
+	r, err := s.GetStream(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read object body: %w", err)
+	}
+	return data, nil
+}
+
+// Put saves image data to GCS
+// Content-Type is detected from the data unless set via options
+func (s *GCSStorage) Put(ctx context.Context, key string, data []byte, opts ...stypes.PutOption) error {
+	o := stypes.ApplyPutOptions(opts...)
+	if o.ContentType == "" {
+		o.ContentType = http.DetectContentType(data)
+	}
+	return s.write(ctx, key, bytes.NewReader(data), o)
+}
+
+// Exists checks if image exists in GCS
+func (s *GCSStorage) Exists(ctx context.Context, key string) (bool, error) {
+	_, err := s.client.Attrs(ctx, key)
+	if err != nil {
+		if errors.Is(err, gcs.ErrObjectNotExist) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to get object attrs: %w", err)
+	}
+	return true, nil
+}
+
+// Version returns the object generation, which changes on every overwrite
+func (s *GCSStorage) Version(ctx context.Context, key string) (string, error) {
+	attrs, err := s.client.Attrs(ctx, key)
+	if err != nil {
+		if errors.Is(err, gcs.ErrObjectNotExist) {
+			return "", fmt.Errorf("%w: %s", stypes.ErrNotFound, key)
+		}
+		return "", fmt.Errorf("failed to get object attrs: %w", err)
+	}
+	if attrs.Generation != 0 {
+		return strconv.FormatInt(attrs.Generation, 10), nil
+	}
+	return attrs.Etag, nil
+}
+
+// Delete removes image from GCS; a missing object is not an error
+func (s *GCSStorage) Delete(ctx context.Context, key string) error {
+	if err := s.client.Delete(ctx, key); err != nil && !errors.Is(err, gcs.ErrObjectNotExist) {
+		return fmt.Errorf("failed to delete object from gcs: %w", err)
+	}
+	return nil
+}
+
+// GetStream retrieves image data stream from GCS
+func (s *GCSStorage) GetStream(ctx context.Context, key string) (io.ReadCloser, error) {
+	r, err := s.client.NewReader(ctx, key)
+	if err != nil {
+		if errors.Is(err, gcs.ErrObjectNotExist) {
+			return nil, fmt.Errorf("%w: %s", stypes.ErrNotFound, key)
+		}
+		return nil, fmt.Errorf("failed to get object from gcs: %w", err)
+	}
+	return r, nil
+}
+
+// PutStream saves image data stream to GCS
+func (s *GCSStorage) PutStream(ctx context.Context, key string, r io.Reader, opts ...stypes.PutOption) error {
+	o := stypes.ApplyPutOptions(opts...)
+	if o.ContentType == "" {
+		o.ContentType = "application/octet-stream"
+	}
+	return s.write(ctx, key, r, o)
+}
+
+// write uploads r; the object is only committed when the writer closes successfully
+func (s *GCSStorage) write(ctx context.Context, key string, r io.Reader, o stypes.PutOptions) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	w := s.client.NewWriter(ctx, key, gcs.ObjectAttrs{
+		ContentType:  o.ContentType,
+		CacheControl: o.CacheControl,
+		Metadata:     o.Metadata,
+	})
+	if _, err := io.Copy(w, r); err != nil {
+		// Cancelling the context aborts the upload
+		cancel()
+		_ = w.Close()
+		return fmt.Errorf("failed to put object to gcs: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("failed to put object to gcs: %w", err)
+	}
+	return nil
+}
+
+// Stat retrieves object metadata
+func (s *GCSStorage) Stat(ctx context.Context, key string) (*stypes.ObjectInfo, error) {
+	attrs, err := s.client.Attrs(ctx, key)
+	if err != nil {
+		if errors.Is(err, gcs.ErrObjectNotExist) {
+			return nil, fmt.Errorf("%w: %s", stypes.ErrNotFound, key)
+		}
+		return nil, fmt.Errorf("failed to get object attrs: %w", err)
+	}
+	info := gcsObjectInfo(attrs)
+	return &info, nil
+}
+
+// List lists objects by prefix; the cursor is the GCS page token
+func (s *GCSStorage) List(ctx context.Context, prefix string, opts stypes.ListOptions) (*stypes.ListResult, error) {
+	limit := opts.Limit
+	if limit <= 0 {
+		limit = stypes.DefaultListLimit
+	}
+
+	objects, next, err := s.client.ListPage(ctx, prefix, opts.Cursor, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list objects: %w", err)
+	}
+
+	result := &stypes.ListResult{
+		Objects:    make([]stypes.ObjectInfo, 0, len(objects)),
+		NextCursor: next,
+	}
+	for _, attrs := range objects {
+		result.Objects = append(result.Objects, gcsObjectInfo(attrs))
+	}
+	return result, nil
+}
+
+func gcsObjectInfo(attrs *gcs.ObjectAttrs) stypes.ObjectInfo {
+	return stypes.ObjectInfo{
+		Key:          attrs.Name,
+		Size:         attrs.Size,
+		ModTime:      attrs.Updated,
+		ETag:         attrs.Etag,
+		ContentType:  attrs.ContentType,
+		CacheControl: attrs.CacheControl,
+		Metadata:     attrs.Metadata,
+	}
+}
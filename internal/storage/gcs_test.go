@@ -0,0 +1,267 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	gcs "cloud.google.com/go/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	stypes "github.com/vincent119/images-filters/internal/storage/types"
+)
+
+// MockGCSAPI is an in-process stand-in for a GCS bucket
+type MockGCSAPI struct {
+	mu         sync.RWMutex
+	objects    map[string][]byte
+	attrs      map[string]gcs.ObjectAttrs
+	generation int64
+}
+
+func NewMockGCSAPI() *MockGCSAPI {
+	return &MockGCSAPI{
+		objects: make(map[string][]byte),
+		attrs:   make(map[string]gcs.ObjectAttrs),
+	}
+}
+
+func (m *MockGCSAPI) NewReader(ctx context.Context, object string) (io.ReadCloser, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	data, ok := m.objects[object]
+	if !ok {
+		return nil, gcs.ErrObjectNotExist
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+func (m *MockGCSAPI) NewWriter(ctx context.Context, object string, attrs gcs.ObjectAttrs) io.WriteCloser {
+	return &mockGCSWriter{api: m, ctx: ctx, object: object, attrs: attrs}
+}
+
+func (m *MockGCSAPI) Attrs(ctx context.Context, object string) (*gcs.ObjectAttrs, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	attrs, ok := m.attrs[object]
+	if !ok {
+		return nil, gcs.ErrObjectNotExist
+	}
+	return &attrs, nil
+}
+
+func (m *MockGCSAPI) Delete(ctx context.Context, object string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.objects[object]; !ok {
+		return gcs.ErrObjectNotExist
+	}
+	delete(m.objects, object)
+	delete(m.attrs, object)
+	return nil
+}
+
+// ListPage lists sorted names; the page token is the last returned name
+func (m *MockGCSAPI) ListPage(ctx context.Context, prefix, pageToken string, pageSize int) ([]*gcs.ObjectAttrs, string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	names := make([]string, 0, len(m.objects))
+	for name := range m.objects {
+		if strings.HasPrefix(name, prefix) && name > pageToken {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	next := ""
+	if len(names) > pageSize {
+		names = names[:pageSize]
+		next = names[len(names)-1]
+	}
+	objects := make([]*gcs.ObjectAttrs, 0, len(names))
+	for _, name := range names {
+		attrs := m.attrs[name]
+		objects = append(objects, &attrs)
+	}
+	return objects, next, nil
+}
+
+type mockGCSWriter struct {
+	api    *MockGCSAPI
+	ctx    context.Context
+	object string
+	attrs  gcs.ObjectAttrs
+	buf    bytes.Buffer
+}
+
+func (w *mockGCSWriter) Write(p []byte) (int, error) {
+	return w.buf.Write(p)
+}
+
+// Close commits the object unless the context was cancelled, like the real writer
+func (w *mockGCSWriter) Close() error {
+	if err := w.ctx.Err(); err != nil {
+		return err
+	}
+
+	w.api.mu.Lock()
+	defer w.api.mu.Unlock()
+
+	w.api.generation++
+	attrs := w.attrs
+	attrs.Name = w.object
+	attrs.Size = int64(w.buf.Len())
+	attrs.Updated = time.Now()
+	attrs.Generation = w.api.generation
+	attrs.Etag = strconv.FormatInt(w.api.generation, 16)
+	w.api.objects[w.object] = w.buf.Bytes()
+	w.api.attrs[w.object] = attrs
+	return nil
+}
+
+func setupTestGCSStorage() (*GCSStorage, *MockGCSAPI) {
+	mockAPI := NewMockGCSAPI()
+	return &GCSStorage{client: mockAPI, bucket: "test-bucket"}, mockAPI
+}
+
+func TestGCSStorage_Basic(t *testing.T) {
+	storage, mockAPI := setupTestGCSStorage()
+	ctx := context.Background()
+	key := "test/image.png"
+	data := []byte("\x89PNG\r\n\x1a\nrest")
+
+	// Get/Exists on a missing object
+	_, err := storage.Get(ctx, key)
+	assert.ErrorIs(t, err, stypes.ErrNotFound)
+	exists, err := storage.Exists(ctx, key)
+	require.NoError(t, err)
+	assert.False(t, exists)
+
+	// Put detects content type
+	require.NoError(t, storage.Put(ctx, key, data))
+	assert.Equal(t, "image/png", mockAPI.attrs[key].ContentType)
+
+	got, err := storage.Get(ctx, key)
+	require.NoError(t, err)
+	assert.Equal(t, data, got)
+	exists, err = storage.Exists(ctx, key)
+	require.NoError(t, err)
+	assert.True(t, exists)
+
+	// Delete is idempotent
+	require.NoError(t, storage.Delete(ctx, key))
+	require.NoError(t, storage.Delete(ctx, key))
+	exists, _ = storage.Exists(ctx, key)
+	assert.False(t, exists)
+}
+
+func TestGCSStorage_Stream(t *testing.T) {
+	storage, mockAPI := setupTestGCSStorage()
+	ctx := context.Background()
+
+	require.NoError(t, storage.PutStream(ctx, "s.bin", strings.NewReader("stream data")))
+	assert.Equal(t, "application/octet-stream", mockAPI.attrs["s.bin"].ContentType)
+
+	r, err := storage.GetStream(ctx, "s.bin")
+	require.NoError(t, err)
+	defer r.Close()
+	data, err := io.ReadAll(r)
+	require.NoError(t, err)
+	assert.Equal(t, "stream data", string(data))
+
+	_, err = storage.GetStream(ctx, "missing")
+	assert.ErrorIs(t, err, stypes.ErrNotFound)
+}
+
+func TestGCSStorage_StatVersionAndList(t *testing.T) {
+	storage, _ := setupTestGCSStorage()
+	ctx := context.Background()
+
+	_, err := storage.Stat(ctx, "missing.jpg")
+	assert.ErrorIs(t, err, stypes.ErrNotFound)
+	_, err = storage.Version(ctx, "missing.jpg")
+	assert.ErrorIs(t, err, stypes.ErrNotFound)
+
+	require.NoError(t, storage.Put(ctx, "img/a.jpg", []byte("v1"),
+		stypes.WithContentType("image/jpeg"),
+		stypes.WithCacheControl("public, max-age=60"),
+		stypes.WithMetadata(map[string]string{"owner": "alice"}),
+	))
+	info, err := storage.Stat(ctx, "img/a.jpg")
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), info.Size)
+	assert.Equal(t, "image/jpeg", info.ContentType)
+	assert.Equal(t, "public, max-age=60", info.CacheControl)
+	assert.Equal(t, "alice", info.Metadata["owner"])
+
+	// Overwriting changes the generation
+	v1, err := storage.Version(ctx, "img/a.jpg")
+	require.NoError(t, err)
+	require.NoError(t, storage.Put(ctx, "img/a.jpg", []byte("v2")))
+	v2, err := storage.Version(ctx, "img/a.jpg")
+	require.NoError(t, err)
+	assert.NotEqual(t, v1, v2)
+
+	require.NoError(t, storage.Put(ctx, "img/b.jpg", []byte("b")))
+	require.NoError(t, storage.Put(ctx, "other/c.jpg", []byte("c")))
+
+	page, err := storage.List(ctx, "img/", stypes.ListOptions{Limit: 1})
+	require.NoError(t, err)
+	require.Len(t, page.Objects, 1)
+	assert.Equal(t, "img/a.jpg", page.Objects[0].Key)
+	require.NotEmpty(t, page.NextCursor)
+
+	page, err = storage.List(ctx, "img/", stypes.ListOptions{Limit: 1, Cursor: page.NextCursor})
+	require.NoError(t, err)
+	require.Len(t, page.Objects, 1)
+	assert.Equal(t, "img/b.jpg", page.Objects[0].Key)
+	assert.Empty(t, page.NextCursor)
+}
+
+func TestGCSStorage_PutStreamAbortsOnReadError(t *testing.T) {
+	storage, mockAPI := setupTestGCSStorage()
+
+	err := storage.PutStream(context.Background(), "broken.bin", io.MultiReader(strings.NewReader("partial"), errReader{}))
+	assert.Error(t, err)
+	_, ok := mockAPI.objects["broken.bin"]
+	assert.False(t, ok, "a failed upload must not be committed")
+}
+
+type errReader struct{}
+
+func (errReader) Read([]byte) (int, error) {
+	return 0, io.ErrUnexpectedEOF
+}
+
+func TestMixedStorage_CloudBackends(t *testing.T) {
+	source, _ := setupTestGCSStorage()
+	result, _ := setupTestAzureBlobStorage()
+	mixed := NewMixedStorage(source, result)
+	ctx := context.Background()
+
+	require.NoError(t, source.Put(ctx, "origin.jpg", []byte("origin")))
+
+	// Reads fall back to the GCS source
+	data, err := mixed.Get(ctx, "origin.jpg")
+	require.NoError(t, err)
+	assert.Equal(t, "origin", string(data))
+
+	// Writes go to the Azure result storage only
+	require.NoError(t, mixed.PutStream(ctx, "result.jpg", strings.NewReader("result")))
+	exists, err := result.Exists(ctx, "result.jpg")
+	require.NoError(t, err)
+	assert.True(t, exists)
+	exists, err = source.Exists(ctx, "result.jpg")
+	require.NoError(t, err)
+	assert.False(t, exists)
+}
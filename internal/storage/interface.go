@@ -5,6 +5,8 @@ package storage
 import (
 	"context"
 	"io"
+
+	"github.com/vincent119/images-filters/internal/storage/types"
 )
 
 // Storage 儲存介面
@@ -12,8 +14,8 @@ type Storage interface {
 	// Get 取得圖片資料
 	Get(ctx context.Context, key string) ([]byte, error)
 
-	// Put 儲存圖片資料
-	Put(ctx context.Context, key string, data []byte) error
+	// Put 儲存圖片資料（可設定 Content-Type、Cache-Control 與自訂中繼資料）
+	Put(ctx context.Context, key string, data []byte, opts ...types.PutOption) error
 
 	// Exists 檢查圖片是否存在
 	Exists(ctx context.Context, key string) (bool, error)
@@ -25,5 +27,28 @@ type Storage interface {
 	GetStream(ctx context.Context, key string) (io.ReadCloser, error)
 
 	// PutStream 儲存圖片資料串流
-	PutStream(ctx context.Context, key string, r io.Reader) error
+	PutStream(ctx context.Context, key string, r io.Reader, opts ...types.PutOption) error
+
+	// Stat 取得物件資訊（大小、修改時間、ETag、Content-Type），不讀取內容
+	// 物件不存在時回傳包裝 types.ErrNotFound 的錯誤
+	Stat(ctx context.Context, key string) (*types.ObjectInfo, error)
+
+	// List 依前綴列出物件（依 key 排序，以 Cursor 分頁）
+	List(ctx context.Context, prefix string, opts types.ListOptions) (*types.ListResult, error)
+}
+
+// Versioner 可低成本取得物件版本的儲存（不讀取內容）
+// 版本在物件內容改變時必須改變，用於來源版本感知的快取鍵
+type Versioner interface {
+	// Version 取得物件版本（物件不存在時回傳錯誤）
+	Version(ctx context.Context, key string) (string, error)
+}
+
+// VersionOf 取得物件版本；儲存未實作 Versioner 時回傳空字串
+func VersionOf(ctx context.Context, s Storage, key string) (string, error) {
+	v, ok := s.(Versioner)
+	if !ok {
+		return "", nil
+	}
+	return v.Version(ctx, key)
 }
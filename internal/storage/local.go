@@ -7,6 +7,8 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+
+	"github.com/vincent119/images-filters/internal/storage/types"
 )
 
 // LocalStorage 本地檔案儲存
@@ -33,7 +35,7 @@ func (s *LocalStorage) Get(ctx context.Context, key string) ([]byte, error) {
 	data, err := os.ReadFile(path)
 	if err != nil {
 		if os.IsNotExist(err) {
-			return nil, fmt.Errorf("file not found: %s", key)
+			return nil, fmt.Errorf("%w: %s", types.ErrNotFound, key)
 		}
 		return nil, fmt.Errorf("failed to read file: %w", err)
 	}
@@ -42,7 +44,7 @@ func (s *LocalStorage) Get(ctx context.Context, key string) ([]byte, error) {
 }
 
 // Put 儲存圖片資料
-func (s *LocalStorage) Put(ctx context.Context, key string, data []byte) error {
+func (s *LocalStorage) Put(ctx context.Context, key string, data []byte, opts ...types.PutOption) error {
 	path := s.resolvePath(key)
 
 	// 確保目錄存在
@@ -56,7 +58,7 @@ func (s *LocalStorage) Put(ctx context.Context, key string, data []byte) error {
 		return fmt.Errorf("failed to write file: %w", err)
 	}
 
-	return nil
+	return s.writeMeta(key, types.ApplyPutOptions(opts...))
 }
 
 // Exists 檢查圖片是否存在
@@ -74,6 +76,15 @@ func (s *LocalStorage) Exists(ctx context.Context, key string) (bool, error) {
 	return true, nil
 }
 
+// Version 以修改時間與檔案大小作為版本
+func (s *LocalStorage) Version(ctx context.Context, key string) (string, error) {
+	info, err := s.Stat(ctx, key)
+	if err != nil {
+		return "", err
+	}
+	return info.ETag, nil
+}
+
 // Delete 刪除圖片
 func (s *LocalStorage) Delete(ctx context.Context, key string) error {
 	path := s.resolvePath(key)
@@ -85,6 +96,10 @@ func (s *LocalStorage) Delete(ctx context.Context, key string) error {
 		return fmt.Errorf("failed to delete file: %w", err)
 	}
 
+	if err := os.Remove(s.metaPath(key)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to delete file metadata: %w", err)
+	}
+
 	return nil
 }
 
@@ -105,7 +120,7 @@ func (s *LocalStorage) GetStream(ctx context.Context, key string) (io.ReadCloser
 	f, err := os.Open(path)
 	if err != nil {
 		if os.IsNotExist(err) {
-			return nil, fmt.Errorf("file not found: %s", key)
+			return nil, fmt.Errorf("%w: %s", types.ErrNotFound, key)
 		}
 		return nil, fmt.Errorf("failed to open file: %w", err)
 	}
@@ -114,7 +129,7 @@ func (s *LocalStorage) GetStream(ctx context.Context, key string) (io.ReadCloser
 }
 
 // PutStream 儲存圖片資料串流
-func (s *LocalStorage) PutStream(ctx context.Context, key string, r io.Reader) error {
+func (s *LocalStorage) PutStream(ctx context.Context, key string, r io.Reader, opts ...types.PutOption) error {
 	path := s.resolvePath(key)
 
 	// 確保目錄存在
@@ -135,5 +150,5 @@ func (s *LocalStorage) PutStream(ctx context.Context, key string, r io.Reader) e
 		return fmt.Errorf("failed to write stream to file: %w", err)
 	}
 
-	return nil
+	return s.writeMeta(key, types.ApplyPutOptions(opts...))
 }
@@ -0,0 +1,210 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/vincent119/images-filters/internal/storage/types"
+)
+
+// localMetaDir 本地儲存的中繼資料目錄（位於根目錄下，List 時略過）
+const localMetaDir = ".meta"
+
+// metaPath 取得物件中繼資料檔路徑
+func (s *LocalStorage) metaPath(key string) string {
+	cleanKey := strings.TrimPrefix(filepath.Clean(key), string(filepath.Separator))
+	return filepath.Join(s.rootPath, localMetaDir, cleanKey+".json")
+}
+
+// writeMeta 寫入 Put 選項；未設定任何選項時移除舊的中繼資料，避免覆寫後沿用舊值
+func (s *LocalStorage) writeMeta(key string, opts types.PutOptions) error {
+	path := s.metaPath(key)
+	if opts.IsZero() {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove file metadata: %w", err)
+		}
+		return nil
+	}
+
+	data, err := json.Marshal(opts)
+	if err != nil {
+		return fmt.Errorf("failed to encode file metadata: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write file metadata: %w", err)
+	}
+	return nil
+}
+
+// readMeta 讀取 Put 選項（不存在時回傳零值）
+func (s *LocalStorage) readMeta(key string) (types.PutOptions, error) {
+	var opts types.PutOptions
+	data, err := os.ReadFile(s.metaPath(key))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return opts, nil
+		}
+		return opts, fmt.Errorf("failed to read file metadata: %w", err)
+	}
+	if err := json.Unmarshal(data, &opts); err != nil {
+		return opts, fmt.Errorf("failed to decode file metadata: %w", err)
+	}
+	return opts, nil
+}
+
+// Stat 取得檔案資訊
+// ETag 由修改時間與大小組成；未以 Put 選項設定 Content-Type 時依副檔名或內容判斷
+func (s *LocalStorage) Stat(ctx context.Context, key string) (*types.ObjectInfo, error) {
+	path := s.resolvePath(key)
+
+	fi, err := os.Stat(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("%w: %s", types.ErrNotFound, key)
+		}
+		return nil, fmt.Errorf("failed to check file: %w", err)
+	}
+	if fi.IsDir() {
+		return nil, fmt.Errorf("%w: %s", types.ErrNotFound, key)
+	}
+
+	meta, err := s.readMeta(key)
+	if err != nil {
+		return nil, err
+	}
+
+	info := &types.ObjectInfo{
+		Key:          key,
+		Size:         fi.Size(),
+		ModTime:      fi.ModTime(),
+		ETag:         fmt.Sprintf("%x-%x", fi.ModTime().UnixNano(), fi.Size()),
+		ContentType:  meta.ContentType,
+		CacheControl: meta.CacheControl,
+		Metadata:     meta.Metadata,
+	}
+	if info.ContentType == "" {
+		info.ContentType = detectFileContentType(path)
+	}
+	return info, nil
+}
+
+// detectFileContentType 依副檔名判斷 Content-Type，無法判斷時讀取檔頭
+func detectFileContentType(path string) string {
+	if ct := mime.TypeByExtension(filepath.Ext(path)); ct != "" {
+		return ct
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+
+	buf := make([]byte, 512)
+	n, _ := f.Read(buf)
+	return http.DetectContentType(buf[:n])
+}
+
+// List 依前綴列出檔案（依 key 排序）
+// Cursor 為上一頁最後一個 key；依 key 順序走訪，略過 cursor 之前與前綴之外的目錄，取滿一頁即停止
+func (s *LocalStorage) List(ctx context.Context, prefix string, opts types.ListOptions) (*types.ListResult, error) {
+	limit := opts.Limit
+	if limit <= 0 {
+		limit = types.DefaultListLimit
+	}
+
+	// 從前綴所在的最深目錄開始走訪，避免掃描整個根目錄
+	startDir := ""
+	if i := strings.LastIndex(prefix, "/"); i >= 0 {
+		startDir = prefix[:i+1]
+	}
+
+	// 多取一筆以判斷是否還有下一頁
+	var keys []string
+	_, err := s.walkKeys(ctx, startDir, prefix, opts.Cursor, func(key string) bool {
+		keys = append(keys, key)
+		return len(keys) > limit
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list files: %w", err)
+	}
+
+	result := &types.ListResult{}
+	if len(keys) > limit {
+		keys = keys[:limit]
+		result.NextCursor = keys[limit-1]
+	}
+	for _, key := range keys {
+		info, err := s.Stat(ctx, key)
+		if err != nil {
+			// 走訪後被刪除的檔案略過
+			if errors.Is(err, types.ErrNotFound) {
+				continue
+			}
+			return nil, err
+		}
+		result.Objects = append(result.Objects, *info)
+	}
+	return result, nil
+}
+
+// walkKeys 依 key 的字典順序走訪 dir（以 "/" 結尾的 key 前綴，空字串為根目錄）下符合 prefix 且大於 cursor 的檔案
+// 目錄以「名稱/」參與排序，使走訪順序與 key 順序一致；fn 回傳 true 時停止走訪
+func (s *LocalStorage) walkKeys(ctx context.Context, dir, prefix, cursor string, fn func(key string) bool) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+	entries, err := os.ReadDir(s.resolvePath(dir))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() {
+			if dir == "" && name == localMetaDir {
+				continue
+			}
+			name += "/"
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		key := dir + name
+		if strings.HasSuffix(name, "/") {
+			// 子目錄的 key 皆以 key 為前綴：與 prefix 無交集，或整個目錄都不大於 cursor 時略過
+			if !strings.HasPrefix(key, prefix) && !strings.HasPrefix(prefix, key) {
+				continue
+			}
+			if key <= cursor && !strings.HasPrefix(cursor, key) {
+				continue
+			}
+			stop, err := s.walkKeys(ctx, key, prefix, cursor, fn)
+			if err != nil || stop {
+				return stop, err
+			}
+			continue
+		}
+		if strings.HasPrefix(key, prefix) && key > cursor && fn(key) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
@@ -2,9 +2,13 @@ package storage
 
 import (
 	"context"
+	"errors"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+
+	"github.com/vincent119/images-filters/internal/storage/types"
 )
 
 func TestLocalStorage(t *testing.T) {
@@ -66,8 +70,8 @@ func TestLocalStorage(t *testing.T) {
 
 		// 測試不存在的檔案
 		_, err = storage.Get(ctx, "nonexistent.jpg")
-		if err == nil {
-			t.Error("Get() 應該返回錯誤")
+		if !errors.Is(err, types.ErrNotFound) {
+			t.Errorf("Get() error = %v, want ErrNotFound", err)
 		}
 	})
 
@@ -124,3 +128,185 @@ func TestLocalStorage_PathTraversal(t *testing.T) {
 		})
 	}
 }
+
+func TestLocalStorage_Version(t *testing.T) {
+	storage, err := NewLocalStorage(t.TempDir())
+	if err != nil {
+		t.Fatalf("建立儲存失敗: %v", err)
+	}
+	ctx := context.Background()
+
+	if _, err := storage.Version(ctx, "missing.jpg"); err == nil {
+		t.Error("不存在的檔案應回傳錯誤")
+	}
+
+	if err := storage.Put(ctx, "a.jpg", []byte("v1")); err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+	v1, err := storage.Version(ctx, "a.jpg")
+	if err != nil || v1 == "" {
+		t.Fatalf("Version() = %q, %v", v1, err)
+	}
+
+	// 覆寫後版本改變（大小與修改時間皆不同）
+	if err := storage.Put(ctx, "a.jpg", []byte("version 2")); err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+	v2, err := storage.Version(ctx, "a.jpg")
+	if err != nil {
+		t.Fatalf("Version() error = %v", err)
+	}
+	if v1 == v2 {
+		t.Error("覆寫後版本應改變")
+	}
+}
+
+func TestLocalStorage_StatAndPutOptions(t *testing.T) {
+	storage, err := NewLocalStorage(t.TempDir())
+	if err != nil {
+		t.Fatalf("建立儲存失敗: %v", err)
+	}
+	ctx := context.Background()
+
+	if _, err := storage.Stat(ctx, "missing.jpg"); !errors.Is(err, types.ErrNotFound) {
+		t.Errorf("Stat() error = %v, want ErrNotFound", err)
+	}
+
+	// 未設定選項時依副檔名判斷 Content-Type
+	if err := storage.Put(ctx, "a.png", []byte("data")); err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+	info, err := storage.Stat(ctx, "a.png")
+	if err != nil {
+		t.Fatalf("Stat() error = %v", err)
+	}
+	if info.Size != 4 || info.ContentType != "image/png" || info.ETag == "" {
+		t.Errorf("Stat() = %+v", info)
+	}
+
+	// 以選項設定的屬性優先
+	err = storage.Put(ctx, "a.png", []byte("data"),
+		types.WithContentType("image/webp"),
+		types.WithCacheControl("no-cache"),
+		types.WithMetadata(map[string]string{"owner": "alice"}),
+	)
+	if err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+	info, err = storage.Stat(ctx, "a.png")
+	if err != nil {
+		t.Fatalf("Stat() error = %v", err)
+	}
+	if info.ContentType != "image/webp" || info.CacheControl != "no-cache" || info.Metadata["owner"] != "alice" {
+		t.Errorf("Stat() = %+v", info)
+	}
+
+	// 不帶選項覆寫時清除舊的中繼資料
+	if err := storage.Put(ctx, "a.png", []byte("data")); err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+	info, _ = storage.Stat(ctx, "a.png")
+	if info.ContentType != "image/png" || info.CacheControl != "" {
+		t.Errorf("覆寫後應清除中繼資料: %+v", info)
+	}
+}
+
+func TestLocalStorage_List(t *testing.T) {
+	storage, err := NewLocalStorage(t.TempDir())
+	if err != nil {
+		t.Fatalf("建立儲存失敗: %v", err)
+	}
+	ctx := context.Background()
+
+	for _, key := range []string{"img/c.jpg", "img/a.jpg", "img/sub/b.jpg", "other/d.jpg"} {
+		if err := storage.Put(ctx, key, []byte(key), types.WithCacheControl("no-cache")); err != nil {
+			t.Fatalf("Put() error = %v", err)
+		}
+	}
+
+	var keys []string
+	opts := types.ListOptions{Limit: 2}
+	for pages := 0; ; pages++ {
+		if pages > 3 {
+			t.Fatal("分頁未結束")
+		}
+		page, err := storage.List(ctx, "img/", opts)
+		if err != nil {
+			t.Fatalf("List() error = %v", err)
+		}
+		for _, obj := range page.Objects {
+			keys = append(keys, obj.Key)
+		}
+		if page.NextCursor == "" {
+			break
+		}
+		opts.Cursor = page.NextCursor
+	}
+
+	// 中繼資料目錄不應出現在結果中
+	want := []string{"img/a.jpg", "img/c.jpg", "img/sub/b.jpg"}
+	if len(keys) != len(want) {
+		t.Fatalf("List() keys = %v, want %v", keys, want)
+	}
+	for i := range want {
+		if keys[i] != want[i] {
+			t.Errorf("List() keys = %v, want %v", keys, want)
+			break
+		}
+	}
+
+	all, err := storage.List(ctx, "", types.ListOptions{})
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if len(all.Objects) != 4 {
+		t.Errorf("List(\"\") = %d objects, want 4", len(all.Objects))
+	}
+}
+
+func TestLocalStorage_ListKeyOrder(t *testing.T) {
+	storage, err := NewLocalStorage(t.TempDir())
+	if err != nil {
+		t.Fatalf("建立儲存失敗: %v", err)
+	}
+	ctx := context.Background()
+
+	// "a-c.jpg" 排在 "a/" 目錄之前（'-' < '/'），分頁須依 key 順序而非目錄走訪順序
+	want := []string{"img/a-c.jpg", "img/a/b.jpg", "img/a/z/y.jpg", "img/b.jpg", "img/c/d.jpg"}
+	for _, key := range append([]string{"imgx/e.jpg", "a.jpg"}, want...) {
+		if err := storage.Put(ctx, key, []byte(key)); err != nil {
+			t.Fatalf("Put() error = %v", err)
+		}
+	}
+
+	var keys []string
+	opts := types.ListOptions{Limit: 1}
+	for pages := 0; ; pages++ {
+		if pages > len(want) {
+			t.Fatal("分頁未結束")
+		}
+		page, err := storage.List(ctx, "img/", opts)
+		if err != nil {
+			t.Fatalf("List() error = %v", err)
+		}
+		for _, obj := range page.Objects {
+			keys = append(keys, obj.Key)
+		}
+		if page.NextCursor == "" {
+			break
+		}
+		opts.Cursor = page.NextCursor
+	}
+	if strings.Join(keys, ",") != strings.Join(want, ",") {
+		t.Errorf("List() keys = %v, want %v", keys, want)
+	}
+
+	// 前綴不以 "/" 結尾時也比對同層的檔名
+	page, err := storage.List(ctx, "img/a", types.ListOptions{})
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if len(page.Objects) != 3 || page.Objects[0].Key != "img/a-c.jpg" {
+		t.Errorf("List(\"img/a\") = %+v", page.Objects)
+	}
+}
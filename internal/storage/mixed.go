@@ -3,6 +3,15 @@ package storage
 import (
 	"context"
 	"io"
+	"strings"
+
+	"github.com/vincent119/images-filters/internal/storage/types"
+)
+
+// Cursor prefixes for MixedStorage.List, recording which backend a page came from
+const (
+	mixedCursorResult = "r:"
+	mixedCursorSource = "s:"
 )
 
 // MixedStorage implements hybrid storage strategy
@@ -44,8 +53,8 @@ func (s *MixedStorage) Get(ctx context.Context, key string) ([]byte, error) {
 }
 
 // Put saves to Result storage only. Source is considered read-only in this pattern.
-func (s *MixedStorage) Put(ctx context.Context, key string, data []byte) error {
-	return s.result.Put(ctx, key, data)
+func (s *MixedStorage) Put(ctx context.Context, key string, data []byte, opts ...types.PutOption) error {
+	return s.result.Put(ctx, key, data, opts...)
 }
 
 // Exists checks Result then Source
@@ -61,6 +70,14 @@ func (s *MixedStorage) Exists(ctx context.Context, key string) (bool, error) {
 	return s.source.Exists(ctx, key)
 }
 
+// Version checks Result then Source, mirroring Get
+func (s *MixedStorage) Version(ctx context.Context, key string) (string, error) {
+	if exists, err := s.result.Exists(ctx, key); err == nil && exists {
+		return VersionOf(ctx, s.result, key)
+	}
+	return VersionOf(ctx, s.source, key)
+}
+
 // Delete removes from Result storage.
 // Optionally, we could decide if we want to delete from Source too,
 // but for "Source/Result" separation, usually Source is immutable or managed separately.
@@ -83,6 +100,44 @@ func (s *MixedStorage) GetStream(ctx context.Context, key string) (io.ReadCloser
 }
 
 // PutStream saves to Result storage only
-func (s *MixedStorage) PutStream(ctx context.Context, key string, r io.Reader) error {
-	return s.result.PutStream(ctx, key, r)
+func (s *MixedStorage) PutStream(ctx context.Context, key string, r io.Reader, opts ...types.PutOption) error {
+	return s.result.PutStream(ctx, key, r, opts...)
+}
+
+// Stat checks Result then Source, mirroring Get
+func (s *MixedStorage) Stat(ctx context.Context, key string) (*types.ObjectInfo, error) {
+	info, err := s.result.Stat(ctx, key)
+	if err == nil {
+		return info, nil
+	}
+	return s.source.Stat(ctx, key)
+}
+
+// List pages through Result storage first, then Source storage.
+// Keys present in both backends are listed twice; callers that need
+// a merged view should de-duplicate.
+func (s *MixedStorage) List(ctx context.Context, prefix string, opts types.ListOptions) (*types.ListResult, error) {
+	backend, prefixTag := s.result, mixedCursorResult
+	cursor := opts.Cursor
+	switch {
+	case strings.HasPrefix(cursor, mixedCursorSource):
+		backend, prefixTag = s.source, mixedCursorSource
+		cursor = strings.TrimPrefix(cursor, mixedCursorSource)
+	default:
+		cursor = strings.TrimPrefix(cursor, mixedCursorResult)
+	}
+
+	result, err := backend.List(ctx, prefix, types.ListOptions{Cursor: cursor, Limit: opts.Limit})
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case result.NextCursor != "":
+		result.NextCursor = prefixTag + result.NextCursor
+	case prefixTag == mixedCursorResult:
+		// Result storage exhausted, continue with Source storage
+		result.NextCursor = mixedCursorSource
+	}
+	return result, nil
 }
@@ -4,7 +4,11 @@ import (
 	"context"
 	"errors"
 	"io"
+	"sort"
+	"strings"
 	"testing"
+
+	"github.com/vincent119/images-filters/internal/storage/types"
 )
 
 // MockStorage for testing MixedStorage
@@ -25,7 +29,7 @@ func (m *MockStorage) Get(ctx context.Context, key string) ([]byte, error) {
 	return nil, errors.New("not found")
 }
 
-func (m *MockStorage) Put(ctx context.Context, key string, data []byte) error {
+func (m *MockStorage) Put(ctx context.Context, key string, data []byte, opts ...types.PutOption) error {
 	m.data[key] = data
 	return nil
 }
@@ -47,10 +51,38 @@ func (m *MockStorage) GetStream(ctx context.Context, key string) (io.ReadCloser,
 	return nil, errors.New("not implemented")
 }
 
-func (m *MockStorage) PutStream(ctx context.Context, key string, r io.Reader) error {
+func (m *MockStorage) PutStream(ctx context.Context, key string, r io.Reader, opts ...types.PutOption) error {
 	return errors.New("not implemented")
 }
 
+func (m *MockStorage) Stat(ctx context.Context, key string) (*types.ObjectInfo, error) {
+	if val, ok := m.data[key]; ok {
+		return &types.ObjectInfo{Key: key, Size: int64(len(val))}, nil
+	}
+	return nil, types.ErrNotFound
+}
+
+// List returns sorted keys; the cursor is the last key of the previous page
+func (m *MockStorage) List(ctx context.Context, prefix string, opts types.ListOptions) (*types.ListResult, error) {
+	keys := make([]string, 0, len(m.data))
+	for k := range m.data {
+		if strings.HasPrefix(k, prefix) && k > opts.Cursor {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	result := &types.ListResult{}
+	if opts.Limit > 0 && len(keys) > opts.Limit {
+		keys = keys[:opts.Limit]
+		result.NextCursor = keys[len(keys)-1]
+	}
+	for _, k := range keys {
+		result.Objects = append(result.Objects, types.ObjectInfo{Key: k, Size: int64(len(m.data[k]))})
+	}
+	return result, nil
+}
+
 func TestMixedStorage(t *testing.T) {
 	source := NewMockStorage()
 	result := NewMockStorage()
@@ -151,3 +183,44 @@ func TestMixedStorage(t *testing.T) {
 		}
 	})
 }
+
+func TestMixedStorage_List(t *testing.T) {
+	source := NewMockStorage()
+	result := NewMockStorage()
+	mixed := NewMixedStorage(source, result)
+	ctx := context.Background()
+
+	result.data["r1.jpg"] = []byte("r")
+	result.data["r2.jpg"] = []byte("r")
+	source.data["s1.jpg"] = []byte("s")
+
+	var keys []string
+	opts := types.ListOptions{Limit: 1}
+	for pages := 0; ; pages++ {
+		if pages > 5 {
+			t.Fatal("pagination did not terminate")
+		}
+		page, err := mixed.List(ctx, "", opts)
+		if err != nil {
+			t.Fatalf("List failed: %v", err)
+		}
+		for _, obj := range page.Objects {
+			keys = append(keys, obj.Key)
+		}
+		if page.NextCursor == "" {
+			break
+		}
+		opts.Cursor = page.NextCursor
+	}
+
+	want := []string{"r1.jpg", "r2.jpg", "s1.jpg"}
+	if strings.Join(keys, ",") != strings.Join(want, ",") {
+		t.Errorf("List keys = %v, want %v", keys, want)
+	}
+
+	// Stat falls back to source
+	info, err := mixed.Stat(ctx, "s1.jpg")
+	if err != nil || info.Key != "s1.jpg" {
+		t.Errorf("Stat = %+v, %v", info, err)
+	}
+}
@@ -22,7 +22,7 @@ func (s *NoStorage) Get(ctx context.Context, key string) ([]byte, error) {
 }
 
 // Put does nothing and returns nil (simulating success)
-func (s *NoStorage) Put(ctx context.Context, key string, data []byte) error {
+func (s *NoStorage) Put(ctx context.Context, key string, data []byte, opts ...types.PutOption) error {
 	return nil
 }
 
@@ -31,6 +31,11 @@ func (s *NoStorage) Exists(ctx context.Context, key string) (bool, error) {
 	return false, nil
 }
 
+// Version always returns ErrNotFound
+func (s *NoStorage) Version(ctx context.Context, key string) (string, error) {
+	return "", types.ErrNotFound
+}
+
 // Delete does nothing and returns nil
 func (s *NoStorage) Delete(ctx context.Context, key string) error {
 	return nil
@@ -42,10 +47,20 @@ func (s *NoStorage) GetStream(ctx context.Context, key string) (io.ReadCloser, e
 }
 
 // PutStream does nothing
-func (s *NoStorage) PutStream(ctx context.Context, key string, r io.Reader) error {
+func (s *NoStorage) PutStream(ctx context.Context, key string, r io.Reader, opts ...types.PutOption) error {
 	// Consume the reader to prevent connection leaks
 	if _, err := io.Copy(io.Discard, r); err != nil {
 		return err
 	}
 	return nil
 }
+
+// Stat always returns ErrNotFound
+func (s *NoStorage) Stat(ctx context.Context, key string) (*types.ObjectInfo, error) {
+	return nil, types.ErrNotFound
+}
+
+// List always returns an empty result
+func (s *NoStorage) List(ctx context.Context, prefix string, opts types.ListOptions) (*types.ListResult, error) {
+	return &types.ListResult{}, nil
+}
@@ -59,4 +59,15 @@ func TestNoStorage(t *testing.T) {
 	case <-time.After(time.Second):
 		t.Fatal("PutStream blocked too long")
 	}
+
+	// Stat
+	info, err := s.Stat(ctx, key)
+	assert.Equal(t, types.ErrNotFound, err)
+	assert.Nil(t, info)
+
+	// List
+	list, err := s.List(ctx, "", types.ListOptions{})
+	assert.NoError(t, err)
+	assert.Empty(t, list.Objects)
+	assert.Empty(t, list.NextCursor)
 }
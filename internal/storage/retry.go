@@ -0,0 +1,144 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+
+	"github.com/vincent119/images-filters/internal/metrics"
+	"github.com/vincent119/images-filters/internal/resilience"
+	"github.com/vincent119/images-filters/internal/storage/types"
+)
+
+// RetryStorage 為讀取操作加上重試的儲存裝飾器
+// 寫入與刪除不重試，避免非冪等操作重複執行
+type RetryStorage struct {
+	inner   Storage
+	backend string
+	policy  resilience.RetryPolicy
+	metrics metrics.Metrics
+}
+
+// NewRetryStorage 建立讀取重試裝飾器（m 可為 nil）
+func NewRetryStorage(inner Storage, backend string, policy resilience.RetryPolicy, m metrics.Metrics) *RetryStorage {
+	return &RetryStorage{
+		inner:   inner,
+		backend: backend,
+		policy:  policy,
+		metrics: m,
+	}
+}
+
+// Get 取得圖片資料（失敗時重試）
+func (s *RetryStorage) Get(ctx context.Context, key string) ([]byte, error) {
+	var data []byte
+	err := s.retry(ctx, func(ctx context.Context) error {
+		var err error
+		data, err = s.inner.Get(ctx, key)
+		return err
+	})
+	return data, unwrapPermanent(err)
+}
+
+// Put 儲存圖片資料
+func (s *RetryStorage) Put(ctx context.Context, key string, data []byte, opts ...types.PutOption) error {
+	return s.inner.Put(ctx, key, data, opts...)
+}
+
+// Exists 檢查圖片是否存在（失敗時重試）
+func (s *RetryStorage) Exists(ctx context.Context, key string) (bool, error) {
+	var exists bool
+	err := s.retry(ctx, func(ctx context.Context) error {
+		var err error
+		exists, err = s.inner.Exists(ctx, key)
+		return err
+	})
+	return exists, unwrapPermanent(err)
+}
+
+// Version 取得物件版本（失敗時重試；內層未實作 Versioner 時回傳空字串）
+func (s *RetryStorage) Version(ctx context.Context, key string) (string, error) {
+	var version string
+	err := s.retry(ctx, func(ctx context.Context) error {
+		var err error
+		version, err = VersionOf(ctx, s.inner, key)
+		return err
+	})
+	return version, unwrapPermanent(err)
+}
+
+// Delete 刪除圖片
+func (s *RetryStorage) Delete(ctx context.Context, key string) error {
+	return s.inner.Delete(ctx, key)
+}
+
+// GetStream 取得圖片資料串流（僅重試開啟串流的動作）
+func (s *RetryStorage) GetStream(ctx context.Context, key string) (io.ReadCloser, error) {
+	var rc io.ReadCloser
+	err := s.retry(ctx, func(ctx context.Context) error {
+		var err error
+		rc, err = s.inner.GetStream(ctx, key)
+		return err
+	})
+	return rc, unwrapPermanent(err)
+}
+
+// PutStream 儲存圖片資料串流
+func (s *RetryStorage) PutStream(ctx context.Context, key string, r io.Reader, opts ...types.PutOption) error {
+	return s.inner.PutStream(ctx, key, r, opts...)
+}
+
+// Stat 取得物件資訊
+func (s *RetryStorage) Stat(ctx context.Context, key string) (*types.ObjectInfo, error) {
+	var info *types.ObjectInfo
+	err := s.retry(ctx, func(ctx context.Context) error {
+		var err error
+		info, err = s.inner.Stat(ctx, key)
+		return err
+	})
+	return info, unwrapPermanent(err)
+}
+
+// List 依前綴列出物件
+func (s *RetryStorage) List(ctx context.Context, prefix string, opts types.ListOptions) (*types.ListResult, error) {
+	var result *types.ListResult
+	err := s.retry(ctx, func(ctx context.Context) error {
+		var err error
+		result, err = s.inner.List(ctx, prefix, opts)
+		return err
+	})
+	return result, unwrapPermanent(err)
+}
+
+// retry 執行重試，找不到檔案視為不可重試
+func (s *RetryStorage) retry(ctx context.Context, fn func(ctx context.Context) error) error {
+	return resilience.Retry(ctx, s.policy, func(ctx context.Context) error {
+		err := fn(ctx)
+		if err != nil && isNotFound(err) {
+			return resilience.Permanent(err)
+		}
+		return err
+	}, func(int, error) {
+		if s.metrics != nil {
+			s.metrics.RecordStorageRetry(s.backend)
+		}
+	})
+}
+
+// isNotFound 判斷是否為檔案不存在錯誤
+func isNotFound(err error) bool {
+	if errors.Is(err, types.ErrNotFound) {
+		return true
+	}
+	msg := strings.ToLower(err.Error())
+	return strings.Contains(msg, "not found") || strings.Contains(msg, "404")
+}
+
+// unwrapPermanent 還原被標記為不可重試的原始錯誤，讓呼叫端的判斷維持不變
+func unwrapPermanent(err error) error {
+	if resilience.IsPermanent(err) {
+		return errors.Unwrap(err)
+	}
+	return err
+}
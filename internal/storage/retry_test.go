@@ -0,0 +1,62 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/vincent119/images-filters/internal/resilience"
+)
+
+// flakyStorage 前 failures 次讀取回傳暫時性錯誤
+type flakyStorage struct {
+	*MockStorage
+	failures int
+	calls    int
+}
+
+func (s *flakyStorage) Get(ctx context.Context, key string) ([]byte, error) {
+	s.calls++
+	if s.calls <= s.failures {
+		return nil, errors.New("connection reset")
+	}
+	return s.MockStorage.Get(ctx, key)
+}
+
+func TestRetryStorage(t *testing.T) {
+	ctx := context.Background()
+	policy := resilience.RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond}
+
+	t.Run("retries transient read errors", func(t *testing.T) {
+		inner := &flakyStorage{MockStorage: NewMockStorage(), failures: 2}
+		inner.data["a.jpg"] = []byte("data")
+
+		s := NewRetryStorage(inner, "mock", policy, nil)
+		data, err := s.Get(ctx, "a.jpg")
+		assert.NoError(t, err)
+		assert.Equal(t, []byte("data"), data)
+		assert.Equal(t, 3, inner.calls)
+	})
+
+	t.Run("does not retry not found", func(t *testing.T) {
+		inner := &flakyStorage{MockStorage: NewMockStorage()}
+
+		s := NewRetryStorage(inner, "mock", policy, nil)
+		_, err := s.Get(ctx, "missing.jpg")
+		assert.EqualError(t, err, "not found")
+		assert.False(t, resilience.IsPermanent(err))
+		assert.Equal(t, 1, inner.calls)
+	})
+
+	t.Run("passes writes through", func(t *testing.T) {
+		inner := NewMockStorage()
+		s := NewRetryStorage(inner, "mock", policy, nil)
+		assert.NoError(t, s.Put(ctx, "b.jpg", []byte("b")))
+		exists, err := s.Exists(ctx, "b.jpg")
+		assert.NoError(t, err)
+		assert.True(t, exists)
+	})
+}
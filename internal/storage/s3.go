@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"strings"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	awsconfig "github.com/aws/aws-sdk-go-v2/config"
@@ -14,6 +15,7 @@ import (
 	"github.com/aws/aws-sdk-go-v2/service/s3"
 	"github.com/aws/aws-sdk-go-v2/service/s3/types"
 	"github.com/vincent119/images-filters/internal/config"
+	stypes "github.com/vincent119/images-filters/internal/storage/types"
 )
 
 // S3API defines the interface for S3 client operations to allow mocking
@@ -22,6 +24,7 @@ type S3API interface {
 	PutObject(ctx context.Context, params *s3.PutObjectInput, optFns ...func(*s3.Options)) (*s3.PutObjectOutput, error)
 	HeadObject(ctx context.Context, params *s3.HeadObjectInput, optFns ...func(*s3.Options)) (*s3.HeadObjectOutput, error)
 	DeleteObject(ctx context.Context, params *s3.DeleteObjectInput, optFns ...func(*s3.Options)) (*s3.DeleteObjectOutput, error)
+	ListObjectsV2(ctx context.Context, params *s3.ListObjectsV2Input, optFns ...func(*s3.Options)) (*s3.ListObjectsV2Output, error)
 }
 
 // S3Storage implement Storage interface using AWS S3
@@ -83,7 +86,7 @@ func (s *S3Storage) Get(ctx context.Context, key string) ([]byte, error) {
 	if err != nil {
 		var noKey *types.NoSuchKey
 		if errors.As(err, &noKey) {
-			return nil, fmt.Errorf("file not found: %s", key)
+			return nil, fmt.Errorf("%w: %s", stypes.ErrNotFound, key)
 		}
 		// S3 SDK v2 might return different error types for "Not Found" depending on service behavior
 		// checking 404 status code helper might be useful but errors.As is standard.
@@ -100,15 +103,14 @@ func (s *S3Storage) Get(ctx context.Context, key string) ([]byte, error) {
 }
 
 // Put saves image data to S3
-func (s *S3Storage) Put(ctx context.Context, key string, data []byte) error {
-	contentType := http.DetectContentType(data)
+// Content-Type is detected from the data unless set via options
+func (s *S3Storage) Put(ctx context.Context, key string, data []byte, opts ...stypes.PutOption) error {
+	o := stypes.ApplyPutOptions(opts...)
+	if o.ContentType == "" {
+		o.ContentType = http.DetectContentType(data)
+	}
 
-	_, err := s.client.PutObject(ctx, &s3.PutObjectInput{
-		Bucket:      aws.String(s.bucket),
-		Key:         aws.String(key),
-		Body:        bytes.NewReader(data),
-		ContentType: aws.String(contentType),
-	})
+	_, err := s.client.PutObject(ctx, s.putInput(key, bytes.NewReader(data), o))
 	if err != nil {
 		return fmt.Errorf("failed to put object to s3: %w", err)
 	}
@@ -147,6 +149,34 @@ func (s *S3Storage) Exists(ctx context.Context, key string) (bool, error) {
 	return true, nil
 }
 
+// Version returns the object's VersionId (versioned buckets) or ETag via HeadObject
+func (s *S3Storage) Version(ctx context.Context, key string) (string, error) {
+	output, err := s.client.HeadObject(ctx, &s3.HeadObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		if isS3NotFound(err) {
+			return "", fmt.Errorf("%w: %s", stypes.ErrNotFound, key)
+		}
+		return "", fmt.Errorf("failed to head object: %w", err)
+	}
+
+	switch {
+	case aws.ToString(output.VersionId) != "":
+		return aws.ToString(output.VersionId), nil
+	case aws.ToString(output.ETag) != "":
+		return strings.Trim(aws.ToString(output.ETag), `"`), nil
+	default:
+		// Fallback for S3-compatible backends without ETag
+		version := fmt.Sprintf("%x", aws.ToInt64(output.ContentLength))
+		if output.LastModified != nil {
+			version = fmt.Sprintf("%x-%s", output.LastModified.UnixNano(), version)
+		}
+		return version, nil
+	}
+}
+
 // Delete removes image from S3
 func (s *S3Storage) Delete(ctx context.Context, key string) error {
 	_, err := s.client.DeleteObject(ctx, &s3.DeleteObjectInput{
@@ -169,7 +199,7 @@ func (s *S3Storage) GetStream(ctx context.Context, key string) (io.ReadCloser, e
 	if err != nil {
 		var noKey *types.NoSuchKey
 		if errors.As(err, &noKey) {
-			return nil, fmt.Errorf("file not found: %s", key)
+			return nil, fmt.Errorf("%w: %s", stypes.ErrNotFound, key)
 		}
 		// S3 SDK v2 error handling for 404
 		var responseError interface {
@@ -177,7 +207,7 @@ func (s *S3Storage) GetStream(ctx context.Context, key string) (io.ReadCloser, e
 		}
 		if errors.As(err, &responseError) {
 			if responseError.HTTPStatusCode() == 404 {
-				return nil, fmt.Errorf("file not found: %s", key)
+				return nil, fmt.Errorf("%w: %s", stypes.ErrNotFound, key)
 			}
 		}
 		return nil, fmt.Errorf("failed to get object stream from s3: %w", err)
@@ -187,7 +217,7 @@ func (s *S3Storage) GetStream(ctx context.Context, key string) (io.ReadCloser, e
 }
 
 // PutStream saves image data stream to S3
-func (s *S3Storage) PutStream(ctx context.Context, key string, r io.Reader) error {
+func (s *S3Storage) PutStream(ctx context.Context, key string, r io.Reader, opts ...stypes.PutOption) error {
 	// For S3 PutObject with io.Reader, we might need to know the length or let SDK handle it (it might buffer if Seek is not supported)
 	// But let's rely on SDK defaults. DetectContentType might not work with stream unless we peek.
 	// For efficiency, we might skip detection or use default.
@@ -200,18 +230,106 @@ func (s *S3Storage) PutStream(ctx context.Context, key string, r io.Reader) erro
 	// But this consumes the reader. We need to rebuild it.
 	// io.MultiReader(bytes.NewReader(buf[:n]), r)
 
-	// Simple version: application/octet-stream if unknown.
-	contentType := "application/octet-stream"
+	// Simple version: application/octet-stream unless set via options.
+	o := stypes.ApplyPutOptions(opts...)
+	if o.ContentType == "" {
+		o.ContentType = "application/octet-stream"
+	}
+
+	_, err := s.client.PutObject(ctx, s.putInput(key, r, o))
+	if err != nil {
+		return fmt.Errorf("failed to put object stream to s3: %w", err)
+	}
 
-	_, err := s.client.PutObject(ctx, &s3.PutObjectInput{
+	return nil
+}
+
+// putInput builds a PutObjectInput with the given options
+func (s *S3Storage) putInput(key string, body io.Reader, o stypes.PutOptions) *s3.PutObjectInput {
+	input := &s3.PutObjectInput{
 		Bucket:      aws.String(s.bucket),
 		Key:         aws.String(key),
-		Body:        r,
-		ContentType: aws.String(contentType),
+		Body:        body,
+		ContentType: aws.String(o.ContentType),
+		Metadata:    o.Metadata,
+	}
+	if o.CacheControl != "" {
+		input.CacheControl = aws.String(o.CacheControl)
+	}
+	return input
+}
+
+// Stat retrieves object metadata via HeadObject
+func (s *S3Storage) Stat(ctx context.Context, key string) (*stypes.ObjectInfo, error) {
+	output, err := s.client.HeadObject(ctx, &s3.HeadObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(key),
 	})
 	if err != nil {
-		return fmt.Errorf("failed to put object stream to s3: %w", err)
+		if isS3NotFound(err) {
+			return nil, fmt.Errorf("%w: %s", stypes.ErrNotFound, key)
+		}
+		return nil, fmt.Errorf("failed to head object: %w", err)
 	}
 
-	return nil
+	return &stypes.ObjectInfo{
+		Key:          key,
+		Size:         aws.ToInt64(output.ContentLength),
+		ModTime:      aws.ToTime(output.LastModified),
+		ETag:         strings.Trim(aws.ToString(output.ETag), `"`),
+		ContentType:  aws.ToString(output.ContentType),
+		CacheControl: aws.ToString(output.CacheControl),
+		Metadata:     output.Metadata,
+	}, nil
+}
+
+// List lists objects by prefix using ListObjectsV2
+// The cursor is the S3 continuation token
+func (s *S3Storage) List(ctx context.Context, prefix string, opts stypes.ListOptions) (*stypes.ListResult, error) {
+	limit := opts.Limit
+	if limit <= 0 {
+		limit = stypes.DefaultListLimit
+	}
+
+	input := &s3.ListObjectsV2Input{
+		Bucket:  aws.String(s.bucket),
+		Prefix:  aws.String(prefix),
+		MaxKeys: aws.Int32(int32(limit)),
+	}
+	if opts.Cursor != "" {
+		input.ContinuationToken = aws.String(opts.Cursor)
+	}
+
+	output, err := s.client.ListObjectsV2(ctx, input)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list objects: %w", err)
+	}
+
+	result := &stypes.ListResult{
+		Objects: make([]stypes.ObjectInfo, 0, len(output.Contents)),
+	}
+	for _, obj := range output.Contents {
+		result.Objects = append(result.Objects, stypes.ObjectInfo{
+			Key:     aws.ToString(obj.Key),
+			Size:    aws.ToInt64(obj.Size),
+			ModTime: aws.ToTime(obj.LastModified),
+			ETag:    strings.Trim(aws.ToString(obj.ETag), `"`),
+		})
+	}
+	if aws.ToBool(output.IsTruncated) {
+		result.NextCursor = aws.ToString(output.NextContinuationToken)
+	}
+	return result, nil
+}
+
+// isS3NotFound checks for a 404 from HeadObject/GetObject
+func isS3NotFound(err error) bool {
+	var notFound *types.NotFound
+	if errors.As(err, &notFound) {
+		return true
+	}
+	var responseError interface {
+		HTTPStatusCode() int
+	}
+	return errors.As(err, &responseError) && responseError.HTTPStatusCode() == 404
 }
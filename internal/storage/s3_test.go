@@ -5,6 +5,8 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"sort"
+	"strings"
 	"sync"
 	"testing"
 
@@ -12,17 +14,22 @@ import (
 	"github.com/aws/aws-sdk-go-v2/service/s3"
 	"github.com/aws/aws-sdk-go-v2/service/s3/types"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	stypes "github.com/vincent119/images-filters/internal/storage/types"
 )
 
 // MockS3API mocks S3API interface for testing
 type MockS3API struct {
 	mu      sync.RWMutex
 	objects map[string][]byte
+	inputs  map[string]*s3.PutObjectInput
 }
 
 func NewMockS3API() *MockS3API {
 	return &MockS3API{
 		objects: make(map[string][]byte),
+		inputs:  make(map[string]*s3.PutObjectInput),
 	}
 }
 
@@ -53,6 +60,7 @@ func (m *MockS3API) PutObject(ctx context.Context, params *s3.PutObjectInput, op
 		return nil, err
 	}
 	m.objects[key] = data
+	m.inputs[key] = params
 
 	return &s3.PutObjectOutput{}, nil
 }
@@ -68,9 +76,15 @@ func (m *MockS3API) HeadObject(ctx context.Context, params *s3.HeadObjectInput,
 		return nil, &httpResponseError{statusCode: 404}
 	}
 
-	return &s3.HeadObjectOutput{
+	output := &s3.HeadObjectOutput{
 		ContentLength: aws.Int64(int64(len(data))),
-	}, nil
+	}
+	if input := m.inputs[key]; input != nil {
+		output.ContentType = input.ContentType
+		output.CacheControl = input.CacheControl
+		output.Metadata = input.Metadata
+	}
+	return output, nil
 }
 
 type httpResponseError struct {
@@ -91,10 +105,40 @@ func (m *MockS3API) DeleteObject(ctx context.Context, params *s3.DeleteObjectInp
 
 	key := *params.Key
 	delete(m.objects, key)
+	delete(m.inputs, key)
 
 	return &s3.DeleteObjectOutput{}, nil
 }
 
+// ListObjectsV2 lists sorted keys; the continuation token is the last returned key
+func (m *MockS3API) ListObjectsV2(ctx context.Context, params *s3.ListObjectsV2Input, optFns ...func(*s3.Options)) (*s3.ListObjectsV2Output, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	after := aws.ToString(params.ContinuationToken)
+	keys := make([]string, 0, len(m.objects))
+	for k := range m.objects {
+		if strings.HasPrefix(k, aws.ToString(params.Prefix)) && k > after {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	output := &s3.ListObjectsV2Output{IsTruncated: aws.Bool(false)}
+	if maxKeys := int(aws.ToInt32(params.MaxKeys)); maxKeys > 0 && len(keys) > maxKeys {
+		keys = keys[:maxKeys]
+		output.IsTruncated = aws.Bool(true)
+		output.NextContinuationToken = aws.String(keys[len(keys)-1])
+	}
+	for _, k := range keys {
+		output.Contents = append(output.Contents, types.Object{
+			Key:  aws.String(k),
+			Size: aws.Int64(int64(len(m.objects[k]))),
+		})
+	}
+	return output, nil
+}
+
 func setupTestStorage() (*S3Storage, *MockS3API) {
 	mockAPI := NewMockS3API()
 	storage := &S3Storage{
@@ -204,3 +248,65 @@ func TestS3Storage_Stream(t *testing.T) {
 	_, err = storage.GetStream(ctx, "nonexistent-stream")
 	assert.Error(t, err)
 }
+
+func TestS3Storage_Version(t *testing.T) {
+	storage, _ := setupTestStorage()
+	ctx := context.Background()
+
+	_, err := storage.Version(ctx, "nonexistent.jpg")
+	assert.ErrorContains(t, err, "not found")
+
+	assert.NoError(t, storage.Put(ctx, "a.jpg", []byte("v1")))
+	v1, err := storage.Version(ctx, "a.jpg")
+	assert.NoError(t, err)
+	assert.NotEmpty(t, v1)
+
+	// Mock has no ETag; falls back to content length
+	assert.NoError(t, storage.Put(ctx, "a.jpg", []byte("version 2")))
+	v2, err := storage.Version(ctx, "a.jpg")
+	assert.NoError(t, err)
+	assert.NotEqual(t, v1, v2)
+}
+
+func TestS3Storage_StatAndPutOptions(t *testing.T) {
+	storage, _ := setupTestStorage()
+	ctx := context.Background()
+
+	_, err := storage.Stat(ctx, "missing.jpg")
+	assert.ErrorIs(t, err, stypes.ErrNotFound)
+
+	require.NoError(t, storage.Put(ctx, "a.jpg", []byte("data"),
+		stypes.WithContentType("image/jpeg"),
+		stypes.WithCacheControl("public, max-age=60"),
+		stypes.WithMetadata(map[string]string{"owner": "alice"}),
+	))
+
+	info, err := storage.Stat(ctx, "a.jpg")
+	require.NoError(t, err)
+	assert.Equal(t, int64(4), info.Size)
+	assert.Equal(t, "image/jpeg", info.ContentType)
+	assert.Equal(t, "public, max-age=60", info.CacheControl)
+	assert.Equal(t, "alice", info.Metadata["owner"])
+}
+
+func TestS3Storage_List(t *testing.T) {
+	storage, _ := setupTestStorage()
+	ctx := context.Background()
+
+	for _, key := range []string{"img/c.jpg", "img/a.jpg", "img/b.jpg", "other/d.jpg"} {
+		require.NoError(t, storage.Put(ctx, key, []byte(key)))
+	}
+
+	page, err := storage.List(ctx, "img/", stypes.ListOptions{Limit: 2})
+	require.NoError(t, err)
+	require.Len(t, page.Objects, 2)
+	assert.Equal(t, "img/a.jpg", page.Objects[0].Key)
+	assert.Equal(t, "img/b.jpg", page.Objects[1].Key)
+	require.NotEmpty(t, page.NextCursor)
+
+	page, err = storage.List(ctx, "img/", stypes.ListOptions{Limit: 2, Cursor: page.NextCursor})
+	require.NoError(t, err)
+	require.Len(t, page.Objects, 1)
+	assert.Equal(t, "img/c.jpg", page.Objects[0].Key)
+	assert.Empty(t, page.NextCursor)
+}
@@ -0,0 +1,82 @@
+package types
+
+import "time"
+
+// ObjectInfo records metadata of a stored object
+type ObjectInfo struct {
+	Key          string            `json:"key"`
+	Size         int64             `json:"size"`
+	ModTime      time.Time         `json:"mod_time"`
+	ETag         string            `json:"etag,omitempty"`
+	ContentType  string            `json:"content_type,omitempty"`
+	CacheControl string            `json:"cache_control,omitempty"`
+	Metadata     map[string]string `json:"metadata,omitempty"`
+}
+
+// ListOptions controls pagination of List
+type ListOptions struct {
+	// Cursor continues a previous listing (ListResult.NextCursor); empty starts from the beginning
+	Cursor string
+	// Limit is the maximum number of objects per page (0 uses the backend default)
+	Limit int
+}
+
+// DefaultListLimit is used when ListOptions.Limit is not set
+const DefaultListLimit = 1000
+
+// ListResult is one page of a listing
+type ListResult struct {
+	Objects []ObjectInfo
+	// NextCursor is empty when there are no more objects
+	NextCursor string
+}
+
+// PutOptions records optional attributes for Put/PutStream
+type PutOptions struct {
+	ContentType  string
+	CacheControl string
+	Metadata     map[string]string
+}
+
+// PutOption configures PutOptions
+type PutOption func(*PutOptions)
+
+// WithContentType sets the object's Content-Type (detected from the data when not set)
+func WithContentType(contentType string) PutOption {
+	return func(o *PutOptions) {
+		o.ContentType = contentType
+	}
+}
+
+// WithCacheControl sets the object's Cache-Control
+func WithCacheControl(cacheControl string) PutOption {
+	return func(o *PutOptions) {
+		o.CacheControl = cacheControl
+	}
+}
+
+// WithMetadata adds user metadata to the object
+func WithMetadata(metadata map[string]string) PutOption {
+	return func(o *PutOptions) {
+		if o.Metadata == nil {
+			o.Metadata = make(map[string]string, len(metadata))
+		}
+		for k, v := range metadata {
+			o.Metadata[k] = v
+		}
+	}
+}
+
+// ApplyPutOptions builds PutOptions from opts
+func ApplyPutOptions(opts ...PutOption) PutOptions {
+	var o PutOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// IsZero reports whether no option was set
+func (o PutOptions) IsZero() bool {
+	return o.ContentType == "" && o.CacheControl == "" && len(o.Metadata) == 0
+}
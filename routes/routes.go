@@ -12,17 +12,57 @@ import (
 	"github.com/vincent119/images-filters/internal/api"
 	"github.com/vincent119/images-filters/internal/config"
 	"github.com/vincent119/images-filters/internal/metrics"
+	"github.com/vincent119/images-filters/internal/security"
 	"github.com/vincent119/images-filters/internal/service"
 
 	// Swagger docs
 	_ "github.com/vincent119/images-filters/docs/swagger"
 )
 
+// Option 路由選項
+type Option func(*options)
+
+type options struct {
+	apiKeys          security.APIKeyStore
+	purgeService     service.PurgeService
+	resumableService service.ResumableUploadService
+}
+
+// WithAPIKeyStore 設定 API 金鑰儲存
+// 設定後上傳、檢測與簽名端點改用具權限範圍的 API 金鑰驗證
+func WithAPIKeyStore(store security.APIKeyStore) Option {
+	return func(o *options) {
+		o.apiKeys = store
+	}
+}
+
+// WithPurgeService 設定清除服務
+// 設定後註冊 POST /purge（與上傳端點使用相同的驗證方式，API 金鑰需具備 purge 權限）
+func WithPurgeService(svc service.PurgeService) Option {
+	return func(o *options) {
+		o.purgeService = svc
+	}
+}
+
+// WithResumableUploadService 設定可續傳上傳服務
+// 設定後註冊 /upload/resumable 端點（與上傳端點使用相同的驗證方式與 upload 權限）
+func WithResumableUploadService(svc service.ResumableUploadService) Option {
+	return func(o *options) {
+		o.resumableService = svc
+	}
+}
+
 // Setup 設定路由
-func Setup(engine *gin.Engine, imageService service.ImageService, watermarkService service.WatermarkService, cfg *config.Config, m metrics.Metrics) {
+func Setup(engine *gin.Engine, imageService service.ImageService, watermarkService service.WatermarkService, cfg *config.Config, m metrics.Metrics, opts ...Option) {
+	o := &options{}
+	for _, opt := range opts {
+		opt(o)
+	}
+
 	// 建立處理器
 	handler := api.NewHandler(imageService)
 	watermarkHandler := api.NewWatermarkHandler(watermarkService)
+	batchUploadHandler := api.NewBatchUploadHandler(imageService, cfg.Upload.Batch)
 
 	// 套用全域中介層
 	engine.Use(api.CORSMiddleware())
@@ -69,19 +109,51 @@ func Setup(engine *gin.Engine, imageService service.ImageService, watermarkServi
 	}
 
 	// 圖片上傳端點（需要 Bearer Auth）
-	if cfg.Security.Enabled && cfg.Security.SecurityKey != "" {
+	switch {
+	case o.apiKeys != nil:
+		// 使用具權限範圍的 API 金鑰
+		signHandler := api.NewSignHandler(signingKey(cfg))
+		engine.POST("/upload", api.APIKeyAuthMiddleware(o.apiKeys, security.ScopeUpload, m), handler.HandleUpload)
+		engine.POST("/upload/batch", api.APIKeyAuthMiddleware(o.apiKeys, security.ScopeUpload, m), batchUploadHandler.HandleBatchUpload)
+		engine.POST("/detect", api.APIKeyAuthMiddleware(o.apiKeys, security.ScopeDetect, m), watermarkHandler.HandleDetect)
+		engine.POST("/sign", api.APIKeyAuthMiddleware(o.apiKeys, security.ScopeSign, m), signHandler.HandleSign)
+		if o.resumableService != nil {
+			registerResumableRoutes(engine.Group("/upload"), o.resumableService, api.APIKeyAuthMiddleware(o.apiKeys, security.ScopeUpload, m))
+		}
+		if o.purgeService != nil {
+			purgeHandler := api.NewPurgeHandler(o.purgeService)
+			engine.POST("/purge", api.APIKeyAuthMiddleware(o.apiKeys, security.ScopePurge, m), purgeHandler.HandlePurge)
+		}
+	case cfg.Security.Enabled && cfg.Security.SecurityKey != "":
 		uploadGroup := engine.Group("/upload")
 		uploadGroup.Use(api.UploadAuthMiddleware(cfg.Security.SecurityKey, m))
 		uploadGroup.POST("", handler.HandleUpload)
+		uploadGroup.POST("/batch", batchUploadHandler.HandleBatchUpload)
+		if o.resumableService != nil {
+			registerResumableRoutes(uploadGroup, o.resumableService)
+		}
 
 		// 浮水印檢測端點（共用 Upload Auth）
 		detectGroup := engine.Group("/detect")
 		detectGroup.Use(api.UploadAuthMiddleware(cfg.Security.SecurityKey, m))
 		detectGroup.POST("", watermarkHandler.HandleDetect)
-	} else {
+
+		if o.purgeService != nil {
+			purgeGroup := engine.Group("/purge")
+			purgeGroup.Use(api.UploadAuthMiddleware(cfg.Security.SecurityKey, m))
+			purgeGroup.POST("", api.NewPurgeHandler(o.purgeService).HandlePurge)
+		}
+	default:
 		// 安全機制未啟用時，允許直接上傳（僅開發環境）
 		engine.POST("/upload", handler.HandleUpload)
+		engine.POST("/upload/batch", batchUploadHandler.HandleBatchUpload)
 		engine.POST("/detect", watermarkHandler.HandleDetect)
+		if o.resumableService != nil {
+			registerResumableRoutes(engine.Group("/upload"), o.resumableService)
+		}
+		if o.purgeService != nil {
+			engine.POST("/purge", api.NewPurgeHandler(o.purgeService).HandlePurge)
+		}
 	}
 
 	// 使用 NoRoute 處理所有其他請求（圖片處理）
@@ -89,6 +161,25 @@ func Setup(engine *gin.Engine, imageService service.ImageService, watermarkServi
 	engine.NoRoute(handler.HandleImage)
 }
 
+// registerResumableRoutes 註冊可續傳上傳端點（建立、查詢位移、寫入分段、完成、放棄）
+func registerResumableRoutes(r gin.IRouter, svc service.ResumableUploadService, auth ...gin.HandlerFunc) {
+	h := api.NewResumableUploadHandler(svc)
+	g := r.Group("/resumable", auth...)
+	g.POST("", h.HandleCreate)
+	g.HEAD("/:id", h.HandleStatus)
+	g.PATCH("/:id", h.HandlePatch)
+	g.DELETE("/:id", h.HandleAbort)
+	g.POST("/:id/complete", h.HandleComplete)
+}
+
+// signingKey 取得簽名用金鑰（安全機制未啟用時回傳空字串）
+func signingKey(cfg *config.Config) string {
+	if !cfg.Security.Enabled {
+		return ""
+	}
+	return cfg.Security.SecurityKey
+}
+
 // swaggerBasicAuth Swagger Basic Auth 中介層
 func swaggerBasicAuth(username, password string) gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -5,13 +5,17 @@ import (
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
 	"github.com/vincent119/images-filters/internal/config"
 	"github.com/vincent119/images-filters/internal/parser"
+	"github.com/vincent119/images-filters/internal/security"
 	"github.com/vincent119/images-filters/internal/service"
+	"github.com/vincent119/images-filters/internal/storage"
 )
 
 // MockImageService to satisfy interface
@@ -27,7 +31,7 @@ func (m *MockImageService) ProcessImage(ctx context.Context, parsedURL *parser.P
 	return nil, "", nil
 }
 
-func (m *MockImageService) UploadImage(ctx context.Context, filename string, contentType string, reader io.Reader) (*service.UploadResult, error) {
+func (m *MockImageService) UploadImage(ctx context.Context, filename string, contentType string, reader io.Reader, opts ...service.UploadOption) (*service.UploadResult, error) {
 	if m.UploadImageFunc != nil {
 		return m.UploadImageFunc(ctx, filename, contentType, reader)
 	}
@@ -112,6 +116,12 @@ func TestSetup(t *testing.T) {
 	w = httptest.NewRecorder()
 	router.ServeHTTP(w, req)
 	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	// Test Batch Upload Route (Unauthorized)
+	req, _ = http.NewRequest("POST", "/upload/batch", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
 }
 
 func TestSetup_NoAuth(t *testing.T) {
@@ -145,3 +155,121 @@ func TestSetup_NoAuth(t *testing.T) {
 	router.ServeHTTP(w, req)
 	assert.NotEqual(t, http.StatusUnauthorized, w.Code)
 }
+
+func TestSetup_APIKeys(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	cfg := &config.Config{
+		Security: config.SecurityConfig{
+			Enabled:     true,
+			SecurityKey: "secret",
+		},
+	}
+
+	ctx := context.Background()
+	ring := security.NewKeyRing(security.NewFileKeyBackend(filepath.Join(t.TempDir(), "api_keys.json")), 0)
+	signKey, _, err := ring.Create(ctx, "signer", []security.Scope{security.ScopeSign}, "")
+	assert.NoError(t, err)
+
+	router := gin.New()
+	Setup(router, &MockImageService{}, &MockWatermarkService{}, cfg, nil, WithAPIKeyStore(ring))
+
+	// Security key is no longer accepted as an upload token
+	req, _ := http.NewRequest("POST", "/upload", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	// Sign-only key cannot upload
+	req, _ = http.NewRequest("POST", "/upload", nil)
+	req.Header.Set("Authorization", "Bearer "+signKey)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	// Sign-only key can sign
+	req, _ = http.NewRequest("POST", "/sign", strings.NewReader(`{"path":"300x200/a.jpg"}`))
+	req.Header.Set("Authorization", "Bearer "+signKey)
+	req.Header.Set("Content-Type", "application/json")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+// MockPurgeService 模擬清除服務
+type MockPurgeService struct {
+	paths []string
+}
+
+func (m *MockPurgeService) Purge(ctx context.Context, path string, prefix bool) (*service.PurgeResult, error) {
+	m.paths = append(m.paths, path)
+	return &service.PurgeResult{Sources: 1, Variants: 2}, nil
+}
+
+func TestSetup_Purge(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	cfg := &config.Config{
+		Security: config.SecurityConfig{
+			Enabled:     true,
+			SecurityKey: "secret",
+		},
+	}
+
+	ctx := context.Background()
+	ring := security.NewKeyRing(security.NewFileKeyBackend(filepath.Join(t.TempDir(), "api_keys.json")), 0)
+	signKey, _, err := ring.Create(ctx, "signer", []security.Scope{security.ScopeSign}, "")
+	assert.NoError(t, err)
+	purgeKey, _, err := ring.Create(ctx, "purger", []security.Scope{security.ScopePurge}, "")
+	assert.NoError(t, err)
+
+	purgeSvc := &MockPurgeService{}
+	router := gin.New()
+	Setup(router, &MockImageService{}, &MockWatermarkService{}, cfg, nil, WithAPIKeyStore(ring), WithPurgeService(purgeSvc))
+
+	post := func(token string) int {
+		req, _ := http.NewRequest("POST", "/purge", strings.NewReader(`{"path":"uploads/a.jpg"}`))
+		req.Header.Set("Authorization", "Bearer "+token)
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	assert.Equal(t, http.StatusForbidden, post(signKey))
+	assert.Equal(t, http.StatusOK, post(purgeKey))
+	assert.Equal(t, []string{"uploads/a.jpg"}, purgeSvc.paths)
+}
+
+func TestSetup_ResumableUpload(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	cfg := &config.Config{
+		Security: config.SecurityConfig{
+			Enabled:     true,
+			SecurityKey: "secret",
+		},
+	}
+
+	store, err := storage.NewLocalStorage(t.TempDir())
+	assert.NoError(t, err)
+	svc := service.NewResumableUploadService(config.ResumableUploadConfig{}, store, &MockImageService{})
+
+	router := gin.New()
+	Setup(router, &MockImageService{}, &MockWatermarkService{}, cfg, nil, WithResumableUploadService(svc))
+
+	create := func(token string) int {
+		req, _ := http.NewRequest("POST", "/upload/resumable", strings.NewReader(`{"filename":"a.jpg","length":10}`))
+		req.Header.Set("Content-Type", "application/json")
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	assert.Equal(t, http.StatusUnauthorized, create(""))
+	assert.Equal(t, http.StatusCreated, create("secret"))
+}